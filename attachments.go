@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/blob"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/metrics"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// newBlobStore returns the instrumented store for attachment contents and a
+// function that releases it.
+func newBlobStore(ctx context.Context, cfg config.AttachmentConfig, appMetrics *metrics.Metrics) (repository.BlobStore, func(), error) {
+	if cfg.Backend == config.BlobBackendGCS {
+		store, err := blob.NewGCSStore(ctx, cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize GCS client: %w", err)
+		}
+		return metrics.InstrumentBlobStore(appMetrics, "gcs", store), func() { store.Close() }, nil
+	}
+
+	store, err := blob.NewLocalStore(cfg.LocalDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return metrics.InstrumentBlobStore(appMetrics, "local", store), func() {}, nil
+}
+
+// signingKey returns the key for attachment URLs, generating one when none is
+// configured.
+func signingKey(cfg config.AttachmentConfig) ([]byte, error) {
+	if cfg.SigningKey != "" {
+		return []byte(cfg.SigningKey), nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	log.Println("Attachments: no ATTACHMENT_SIGNING_KEY configured, download URLs are invalidated on restart")
+	return key, nil
+}
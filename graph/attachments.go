@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kuchida1981/graphql-sampleapp/internal/attachment"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+)
+
+const (
+	// MaxAttachments bounds the number of files posted with one message.
+	MaxAttachments = 10
+	// maxAttachmentNameLength is in characters.
+	maxAttachmentNameLength = 255
+)
+
+// saveAttachments stores the contents of uploaded files before the message
+// that refers to them is created. If one fails, those already stored are
+// deleted again.
+func (r *Resolver) saveAttachments(ctx context.Context, uploads []*graphql.Upload) ([]domain.Attachment, error) {
+	if len(uploads) > MaxAttachments {
+		return nil, errcode.New(errcode.BadUserInput, fmt.Sprintf("at most %d attachments can be posted", MaxAttachments))
+	}
+	names := make([]string, len(uploads))
+	for i, upload := range uploads {
+		name, err := attachmentName(upload.Filename)
+		if err != nil {
+			return nil, err
+		}
+		names[i] = name
+	}
+
+	var attachments []domain.Attachment
+	for i, upload := range uploads {
+		saved, err := r.attachments.Save(ctx, names[i], upload.ContentType, upload.File)
+		if err != nil {
+			r.deleteAttachments(ctx, attachments)
+			if errors.Is(err, attachment.ErrTooLarge) {
+				return nil, errcode.New(errcode.BadUserInput,
+					fmt.Sprintf("attachment %s exceeds %d bytes", names[i], r.attachments.MaxSize()))
+			}
+			return nil, fmt.Errorf("failed to store attachment: %w", err)
+		}
+		attachments = append(attachments, *saved)
+	}
+	return attachments, nil
+}
+
+// deleteAttachments removes the contents of attachments whose message could
+// not be created. A failure only leaves an unreferenced blob behind, so it is
+// logged.
+func (r *Resolver) deleteAttachments(ctx context.Context, attachments []domain.Attachment) {
+	for _, a := range attachments {
+		if err := r.attachments.Delete(ctx, a); err != nil {
+			log.Printf("Failed to delete attachment %s: %v", a.ID, err)
+		}
+	}
+}
+
+// attachmentName strips any directory a client sent with the filename.
+func attachmentName(filename string) (string, error) {
+	name := filename[strings.LastIndexAny(filename, `/\`)+1:]
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errcode.New(errcode.BadUserInput, "attachment filename must not be empty")
+	}
+	if utf8.RuneCountInString(name) > maxAttachmentNameLength {
+		return "", errcode.New(errcode.BadUserInput,
+			fmt.Sprintf("attachment filename must be at most %d characters", maxAttachmentNameLength))
+	}
+	return name, nil
+}
@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// authorizeChannel fails unless the current user is a member of channel or an
+// admin.
+func authorizeChannel(ctx context.Context, channel *domain.Channel) error {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return errcode.New(errcode.Unauthenticated, "authentication required")
+	}
+	if !channel.HasMember(user.ID) && !user.HasRole(domain.RoleAdmin) {
+		return errcode.New(errcode.Forbidden, "only members can access channel "+channel.ID)
+	}
+	return nil
+}
+
+// authorizeMessage applies authorizeChannel to the channel msg was posted to
+// and returns that channel, or nil for a message outside channels. A message
+// hidden by moderation is reported as not found to everyone but moderators.
+func (r *Resolver) authorizeMessage(ctx context.Context, msg *domain.Message) (*domain.Channel, error) {
+	if !visible(ctx, msg) {
+		return nil, repository.NotFoundf("message %s not found", msg.ID)
+	}
+	if msg.ChannelID == "" {
+		return nil, nil
+	}
+	channel, err := r.channelRepo.GetByID(ctx, msg.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+// checkMessageAccess looks up a message by ID and applies authorizeMessage.
+func (r *Resolver) checkMessageAccess(ctx context.Context, id string) error {
+	msg, err := r.messageRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = r.authorizeMessage(ctx, msg)
+	return err
+}
+
+// fillReads sets the current user's unread count and read position on
+// channels with a single repository call, so that listing channels costs the
+// same however many there are.
+func (r *Resolver) fillReads(ctx context.Context, channels ...*model.Channel) error {
+	user := auth.UserFromContext(ctx)
+	if user == nil || len(channels) == 0 {
+		return nil
+	}
+	ids := make([]string, len(channels))
+	for i, channel := range channels {
+		ids[i] = channel.ID
+	}
+
+	reads, err := r.channelRepo.Reads(ctx, user.ID, ids)
+	if err != nil {
+		return err
+	}
+	for _, channel := range channels {
+		if read, ok := reads[channel.ID]; ok {
+			channel.UnreadCount = int32(read.UnreadCount)
+			channel.LastReadAt = optionalTime(read.LastReadAt)
+		}
+	}
+	return nil
+}
+
+// countChannelMessage counts msg towards the unread counts of its channel and
+// marks the channel read for the author, who has seen their own message. Call
+// it within the transaction that writes msg, before the write, so that a
+// failed write also undoes the count when messages are kept in Firestore.
+func (r *Resolver) countChannelMessage(ctx context.Context, msg *domain.Message) error {
+	if err := r.channelRepo.RecordMessage(ctx, msg.ChannelID); err != nil {
+		return err
+	}
+	return r.channelRepo.MarkRead(ctx, msg.ChannelID, msg.AuthorID, msg.CreatedAt)
+}
+
+func errChannelArchived(id string) error {
+	return errcode.New(errcode.BadUserInput, "channel "+id+" is archived")
+}
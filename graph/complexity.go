@@ -0,0 +1,59 @@
+package graph
+
+// defaultListSize is the number of items assumed for list fields that do not
+// take a page-size argument. It keeps unpaginated lists from being costed as
+// if they returned a single element.
+const defaultListSize = 50
+
+// NewComplexityRoot returns the per-field cost functions used by the
+// complexity limit. Fields not listed here cost 1 plus their children.
+func NewComplexityRoot() ComplexityRoot {
+	var c ComplexityRoot
+
+	c.Query.Messages = func(childComplexity int) int {
+		return listCost(childComplexity, nil)
+	}
+	c.Query.Users = func(childComplexity int, includeDeleted bool) int {
+		return listCost(childComplexity, nil)
+	}
+	c.Query.SearchMessages = func(childComplexity int, query string, author *string, from *string, to *string, first *int32, after *string) int {
+		return listCost(childComplexity, first)
+	}
+	c.Query.Channels = func(childComplexity int, includeArchived bool) int {
+		return listCost(childComplexity, nil)
+	}
+	c.Query.MyMentions = func(childComplexity int, first *int32, after *string) int {
+		return listCost(childComplexity, first)
+	}
+	c.Query.ModerationQueue = func(childComplexity int, first *int32, after *string) int {
+		return listCost(childComplexity, first)
+	}
+	c.Query.WeatherAlerts = func(childComplexity int, region *string, issuedAfter *string, includeDeleted bool) int {
+		return listCost(childComplexity, nil)
+	}
+	c.Message.Replies = func(childComplexity int, first *int32, after *string) int {
+		return listCost(childComplexity, first)
+	}
+
+	c.Message.History = func(childComplexity int) int {
+		return listCost(childComplexity, nil)
+	}
+	c.Channel.Messages = func(childComplexity int, first *int32, after *string) int {
+		return listCost(childComplexity, first)
+	}
+	c.Channel.Members = func(childComplexity int) int {
+		return listCost(childComplexity, nil)
+	}
+
+	return c
+}
+
+// listCost weights the cost of a list field's children by the requested page
+// size, falling back to defaultListSize when no size is given.
+func listCost(childComplexity int, pageSize *int32) int {
+	size := defaultListSize
+	if pageSize != nil && *pageSize > 0 {
+		size = int(*pageSize)
+	}
+	return 1 + childComplexity*size
+}
@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListCost(t *testing.T) {
+	pageSize := int32(10)
+
+	assert.Equal(t, 1+3*defaultListSize, listCost(3, nil))
+	assert.Equal(t, 31, listCost(3, &pageSize))
+}
+
+func TestComplexityLimit(t *testing.T) {
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers:  NewResolver(&mockMessageRepository{}, nil, nil, nil, nil, nil, nil, nil, nil, nil),
+		Directives: NewDirectiveRoot(),
+		Complexity: NewComplexityRoot(),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.FixedComplexityLimit(100))
+
+	tests := []struct {
+		name        string
+		query       string
+		wantMessage string
+	}{
+		{
+			name:  "正常系: 上限以内",
+			query: `{ hello }`,
+		},
+		{
+			name:        "異常系: リストの子フィールド数に応じてコストが増える",
+			query:       `{ messages { id content author createdAt } }`,
+			wantMessage: "operation has complexity 201, which exceeds the limit of 100",
+		},
+		{
+			name:        "異常系: メンションはページサイズに応じてコストが増える",
+			query:       `{ myMentions(first: 50) { edges { node { id } } } }`,
+			wantMessage: "operation has complexity 151, which exceeds the limit of 100",
+		},
+		{
+			name:        "異常系: モデレーションキューはページサイズに応じてコストが増える",
+			query:       `{ moderationQueue(first: 50) { edges { node { id } } } }`,
+			wantMessage: "operation has complexity 151, which exceeds the limit of 100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"`+tt.query+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			if tt.wantMessage == "" {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.NotContains(t, rec.Body.String(), "errors")
+				return
+			}
+			assert.Contains(t, rec.Body.String(), tt.wantMessage)
+			assert.Contains(t, rec.Body.String(), "COMPLEXITY_LIMIT_EXCEEDED")
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+const timeFormat = "2006-01-02T15:04:05Z07:00"
+
+func toModelMessage(msg *domain.Message) *model.Message {
+	return &model.Message{
+		ID:              msg.ID,
+		Content:         msg.Content,
+		Author:          msg.Author,
+		AuthorID:        optionalString(msg.AuthorID),
+		ParentID:        optionalString(msg.ParentID),
+		ChannelID:       optionalString(msg.ChannelID),
+		ReplyCount:      int32(msg.ReplyCount),
+		CreatedAt:       msg.CreatedAt.Format(timeFormat),
+		ReactionCounts:  msg.ReactionCounts,
+		Edited:          msg.EditedAt != nil,
+		EditedAt:        optionalTime(msg.EditedAt),
+		Attachments:     toModelAttachments(msg),
+		MentionIDs:      msg.MentionIDs,
+		ModerationState: msg.Moderation,
+	}
+}
+
+func toModelAttachments(msg *domain.Message) []*model.Attachment {
+	attachments := make([]*model.Attachment, len(msg.Attachments))
+	for i, a := range msg.Attachments {
+		attachments[i] = &model.Attachment{
+			ID:          a.ID,
+			Name:        a.Name,
+			Size:        int32(a.Size),
+			ContentType: a.ContentType,
+			Checksum:    a.Checksum,
+			MessageID:   msg.ID,
+		}
+	}
+	return attachments
+}
+
+func toModelMessageEdit(edit *domain.MessageEdit) *model.MessageEdit {
+	return &model.MessageEdit{
+		Content:  edit.Content,
+		EditorID: edit.EditorID,
+		EditedAt: edit.EditedAt.Format(timeFormat),
+	}
+}
+
+func toModelChannel(channel *domain.Channel) *model.Channel {
+	return &model.Channel{
+		ID:         channel.ID,
+		Name:       channel.Name,
+		Topic:      channel.Topic,
+		MemberIds:  channel.MemberIDs,
+		CreatedBy:  optionalString(channel.CreatedBy),
+		CreatedAt:  channel.CreatedAt.Format(timeFormat),
+		ArchivedAt: optionalTime(channel.ArchivedAt),
+	}
+}
+
+func toModelUser(user *domain.User) *model.User {
+	return &model.User{
+		ID:        user.ID,
+		Name:      user.Name,
+		Handle:    optionalString(user.Handle),
+		Email:     user.Email,
+		Roles:     modelRoles(user.Roles),
+		CreatedAt: user.CreatedAt.Format(timeFormat),
+		UpdatedAt: user.UpdatedAt.Format(timeFormat),
+		UpdatedBy: optionalString(user.UpdatedBy),
+		DeletedAt: optionalTime(user.DeletedAt),
+	}
+}
+
+// toModelWeatherAlert joins an alert document with its metadata row.
+func toModelWeatherAlert(alert *domain.WeatherAlert, metadata *domain.WeatherAlertMetadata) *model.WeatherAlert {
+	rawDataJSON, err := json.Marshal(alert.RawData)
+	if err != nil {
+		log.Printf("WeatherAlerts: Warning - failed to marshal rawData for %s: %v", alert.ID, err)
+		rawDataJSON = []byte("{}")
+	}
+
+	return &model.WeatherAlert{
+		ID:              alert.ID,
+		Region:          metadata.Region,
+		Severity:        metadata.Severity,
+		IssuedAt:        metadata.IssuedAt.Format(timeFormat),
+		Title:           alert.Title,
+		Description:     alert.Description,
+		RawData:         string(rawDataJSON),
+		AffectedAreas:   alert.AffectedAreas,
+		Recommendations: alert.Recommendations,
+		UpdatedAt:       metadata.UpdatedAt.Format(timeFormat),
+		UpdatedBy:       optionalString(metadata.UpdatedBy),
+		DeletedAt:       optionalTime(metadata.DeletedAt),
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(timeFormat)
+	return &s
+}
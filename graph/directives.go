@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+)
+
+// NewDirectiveRoot returns the implementations of the schema directives.
+func NewDirectiveRoot() DirectiveRoot {
+	return DirectiveRoot{
+		HasRole: hasRole,
+	}
+}
+
+// hasRole implements @hasRole. Anonymous requests are rejected with
+// UNAUTHENTICATED and authenticated users lacking the role with FORBIDDEN.
+func hasRole(ctx context.Context, obj any, next graphql.Resolver, role model.Role) (any, error) {
+	if err := requireRole(ctx, role, graphql.GetFieldContext(ctx).Field.Name); err != nil {
+		return nil, err
+	}
+	return next(ctx)
+}
+
+// requireRole applies the @hasRole check in resolvers, for access that
+// depends on argument values. what names the field or argument in the error.
+func requireRole(ctx context.Context, role model.Role, what string) error {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return errcode.New(errcode.Unauthenticated, "authentication required")
+	}
+
+	if !user.HasRole(domainRole(role)) {
+		return errcode.New(errcode.Forbidden, fmt.Sprintf("role %s is required to access %s", role, what))
+	}
+	return nil
+}
+
+// domainRole converts a GraphQL Role enum value to the role name stored in
+// domain.User.Roles.
+func domainRole(role model.Role) string {
+	return strings.ToLower(role.String())
+}
+
+// domainRoles converts GraphQL Role values to stored role names.
+func domainRoles(roles []model.Role) []string {
+	result := make([]string, len(roles))
+	for i, r := range roles {
+		result[i] = domainRole(r)
+	}
+	return result
+}
+
+// modelRoles converts stored role names to GraphQL Role values, skipping names
+// the schema does not know about.
+func modelRoles(roles []string) []model.Role {
+	result := make([]model.Role, 0, len(roles))
+	for _, r := range roles {
+		role := model.Role(strings.ToUpper(r))
+		if role.IsValid() {
+			result = append(result, role)
+		}
+	}
+	return result
+}
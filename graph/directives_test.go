@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *domain.User
+		role     model.Role
+		wantCode string
+	}{
+		{
+			name: "正常系: ロールを保持",
+			user: &domain.User{ID: "1", Roles: []string{domain.RoleUser}},
+			role: model.RoleUser,
+		},
+		{
+			name: "正常系: ADMINはすべてのロールを満たす",
+			user: &domain.User{ID: "1", Roles: []string{domain.RoleAdmin}},
+			role: model.RoleUser,
+		},
+		{
+			name:     "異常系: 未認証",
+			user:     nil,
+			role:     model.RoleUser,
+			wantCode: "UNAUTHENTICATED",
+		},
+		{
+			name:     "異常系: ロール不足",
+			user:     &domain.User{ID: "1", Roles: []string{domain.RoleUser}},
+			role:     model.RoleAdmin,
+			wantCode: "FORBIDDEN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = auth.WithUser(ctx, tt.user)
+			}
+			ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+				Field: graphql.CollectedField{Field: &ast.Field{Name: "email"}},
+			})
+
+			got, err := hasRole(ctx, nil, func(ctx context.Context) (any, error) {
+				return "secret", nil
+			}, tt.role)
+
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "secret", got)
+				return
+			}
+
+			var gqlErr *gqlerror.Error
+			assert.ErrorAs(t, err, &gqlErr)
+			assert.Equal(t, tt.wantCode, gqlErr.Extensions["code"])
+			assert.Nil(t, got)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorPresenter maps resolver errors to client-facing GraphQL errors.
+// Repository errors get the code of their kind, GraphQL errors such as those
+// from errcode.New pass through, and anything else is logged and reported as
+// an internal error so backend details never reach the client.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var repoErr *repository.Error
+	if errors.As(err, &repoErr) {
+		code, message := repositoryErrorCode(repoErr)
+		if code == errcode.Unavailable || code == errcode.Internal {
+			log.Printf("GraphQL error at %s: %v", gqlErr.Path, err)
+		}
+		gqlErr.Message = message
+		gqlErr.Extensions = map[string]any{"code": code}
+		return gqlErr
+	}
+
+	// gqlgen wraps plain errors in a *gqlerror.Error whose Err is the
+	// original error; errors created as GraphQL errors have no Err.
+	if _, ok := gqlErr.Extensions["code"]; ok || gqlErr.Err == nil {
+		return gqlErr
+	}
+
+	log.Printf("GraphQL error at %s: %v", gqlErr.Path, err)
+	gqlErr.Message = "internal server error"
+	gqlErr.Extensions = map[string]any{"code": errcode.Internal}
+	return gqlErr
+}
+
+func repositoryErrorCode(err *repository.Error) (code, message string) {
+	switch err.Kind {
+	case repository.ErrNotFound:
+		return errcode.NotFound, err.Message
+	case repository.ErrConflict:
+		return errcode.Conflict, err.Message
+	case repository.ErrInvalid:
+		return errcode.BadUserInput, err.Message
+	case repository.ErrUnavailable:
+		return errcode.Unavailable, "service temporarily unavailable"
+	}
+	return errcode.Internal, "internal server error"
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestErrorPresenter(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "NotFoundはNOT_FOUND",
+			err:         fmt.Errorf("failed to fetch user: %w", repository.NotFoundf("user %s not found", "99")),
+			wantCode:    "NOT_FOUND",
+			wantMessage: "user 99 not found",
+		},
+		{
+			name:        "ConflictはCONFLICT",
+			err:         repository.Conflict("user already exists", errors.New("duplicate key")),
+			wantCode:    "CONFLICT",
+			wantMessage: "user already exists",
+		},
+		{
+			name:        "InvalidはBAD_USER_INPUT",
+			err:         repository.Invalid("invalid user", errors.New("null value")),
+			wantCode:    "BAD_USER_INPUT",
+			wantMessage: "invalid user",
+		},
+		{
+			name:        "Unavailableは原因を隠してUNAVAILABLE",
+			err:         fmt.Errorf("failed to fetch users: %w", repository.Unavailable("failed to query users", errors.New("dial tcp 10.0.0.1:5432"))),
+			wantCode:    "UNAVAILABLE",
+			wantMessage: "service temporarily unavailable",
+		},
+		{
+			name:        "コード付きのエラーはそのまま",
+			err:         errcode.New(errcode.Forbidden, "role ADMIN is required to access email"),
+			wantCode:    "FORBIDDEN",
+			wantMessage: "role ADMIN is required to access email",
+		},
+		{
+			name:        "未分類のエラーはINTERNAL_SERVER_ERROR",
+			err:         errors.New("pq: password authentication failed"),
+			wantCode:    "INTERNAL_SERVER_ERROR",
+			wantMessage: "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := graphql.WithFieldContext(context.Background(), &graphql.FieldContext{
+				Field: graphql.CollectedField{Field: &ast.Field{Alias: "user"}},
+			})
+
+			got := ErrorPresenter(ctx, graphql.ErrorOnPath(ctx, tt.err))
+
+			assert.Equal(t, tt.wantMessage, got.Message)
+			assert.Equal(t, tt.wantCode, got.Extensions["code"])
+			assert.Equal(t, ast.Path{ast.PathName("user")}, got.Path)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/mention"
+)
+
+// maxEmojiLength leaves room for emoji built from several code points, such as
+// skin tone modifiers and ZWJ sequences.
+const maxEmojiLength = 16
+
+var severities = []string{domain.SeverityInfo, domain.SeverityWarning, domain.SeverityCritical}
+
+// validateUser checks the fields every stored user must have.
+func validateUser(user *domain.User) error {
+	if strings.TrimSpace(user.ID) == "" {
+		return errcode.New(errcode.BadUserInput, "id must not be empty")
+	}
+	if strings.TrimSpace(user.Name) == "" {
+		return errcode.New(errcode.BadUserInput, "name must not be empty")
+	}
+	// Users stored before handles existed may have none until one is set.
+	if user.Handle != "" && !mention.IsHandle(user.Handle) {
+		return errcode.New(errcode.BadUserInput, "handle must be letters, digits, '_', '.' or '-', not ending with '.' or '-'")
+	}
+	if !strings.Contains(user.Email, "@") {
+		return errcode.New(errcode.BadUserInput, "email must be a valid address")
+	}
+	return nil
+}
+
+func validateContent(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return errcode.New(errcode.BadUserInput, "content must not be empty")
+	}
+	return nil
+}
+
+func validateChannelName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errcode.New(errcode.BadUserInput, "name must not be empty")
+	}
+	return nil
+}
+
+func validateEmoji(emoji string) error {
+	if emoji == "" || strings.ContainsFunc(emoji, unicode.IsSpace) || utf8.RuneCountInString(emoji) > maxEmojiLength {
+		return errcode.New(errcode.BadUserInput, "emoji must be a single emoji without spaces")
+	}
+	return nil
+}
+
+func validateSeverity(severity string) error {
+	if !slices.Contains(severities, severity) {
+		return errcode.New(errcode.BadUserInput, "severity must be one of info, warning or critical")
+	}
+	return nil
+}
+
+// parseTime parses an RFC 3339 argument, reporting BAD_USER_INPUT for the
+// named field on failure.
+func parseTime(field, value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errcode.New(errcode.BadUserInput, "invalid "+field+" format, expected ISO8601")
+	}
+	return t, nil
+}
+
+// parseRawData decodes the optional rawData JSON object of an alert.
+func parseRawData(rawData *string) (map[string]any, error) {
+	if rawData == nil {
+		return map[string]any{}, nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal([]byte(*rawData), &data); err != nil || data == nil {
+		return nil, errcode.New(errcode.BadUserInput, "rawData must be a JSON object")
+	}
+	return data, nil
+}
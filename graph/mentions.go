@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/mention"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// resolveMentions returns the IDs of the users mentioned by handle in
+// content, in order of first mention. In a channel, users who could not read
+// the message are left out, so that a mention never leads anyone to a message
+// they cannot open.
+func (r *Resolver) resolveMentions(ctx context.Context, content string, channel *domain.Channel) ([]string, error) {
+	handles := mention.Parse(content)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	users, err := r.userRepo.GetByHandles(ctx, handles)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, handle := range handles {
+		i := slices.IndexFunc(users, func(u *domain.User) bool { return strings.ToLower(u.Handle) == handle })
+		if i < 0 {
+			continue
+		}
+		user := users[i]
+		if channel != nil && !channel.HasMember(user.ID) && !user.HasRole(domain.RoleAdmin) {
+			continue
+		}
+		ids = append(ids, user.ID)
+	}
+	return ids, nil
+}
+
+// toMentionConnection drops the messages the current user can no longer read,
+// such as those in channels left after losing the admin role, and those hidden
+// by moderation.
+func (r *Resolver) toMentionConnection(ctx context.Context, page *repository.MessagePage) (*model.MessageConnection, error) {
+	user := auth.UserFromContext(ctx)
+	var channels []*domain.Channel
+	if !user.HasRole(domain.RoleAdmin) {
+		var err error
+		channels, err = r.channelRepo.List(ctx, repository.ChannelFilter{MemberID: user.ID, IncludeArchived: true})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return toFilteredConnection(page, func(msg *domain.Message) bool {
+		if !visible(ctx, msg) {
+			return false
+		}
+		return msg.ChannelID == "" || user.HasRole(domain.RoleAdmin) ||
+			slices.ContainsFunc(channels, func(c *domain.Channel) bool { return c.ID == msg.ChannelID })
+	}), nil
+}
@@ -2,21 +2,206 @@
 
 package model
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+type Attachment struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Size in bytes.
+	Size        int32  `json:"size"`
+	ContentType string `json:"contentType"`
+	// Hex-encoded SHA-256 digest of the contents.
+	Checksum string `json:"checksum"`
+	// Download URL relative to the server. It expires after a while; query it again for a fresh one.
+	URL string `json:"url"`
+	// Message the attachment belongs to, which its URL names.
+	MessageID string `json:"-"`
+}
+
+type Channel struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Topic     string   `json:"topic"`
+	MemberIds []string `json:"memberIds"`
+	// Members that still exist, in memberIds order.
+	Members []*User `json:"members"`
+	// ID of the user who created the channel, if known.
+	CreatedBy *string `json:"createdBy,omitempty"`
+	CreatedAt string  `json:"createdAt"`
+	// Set once the channel is archived.
+	ArchivedAt *string `json:"archivedAt,omitempty"`
+	// Top-level messages in the channel, newest first. first defaults to 20 and may be at most 100.
+	Messages *MessageConnection `json:"messages"`
+	// Top-level messages posted since the current user last marked the channel read.
+	UnreadCount int32 `json:"unreadCount"`
+	// When the current user last marked the channel read, or null if they never did.
+	LastReadAt *string `json:"lastReadAt,omitempty"`
+}
+
+type CreateChannelInput struct {
+	// Must be unique among all channels, archived ones included.
+	Name  string  `json:"name"`
+	Topic *string `json:"topic,omitempty"`
+	// IDs of the users to add besides the current user.
+	MemberIds []string `json:"memberIds,omitempty"`
+}
+
+type CreateUserInput struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Unique name to mention the user by, ignoring case: letters, digits, '_',
+	// '.' and '-', not ending with '.' or '-'. Defaults to the ID.
+	Handle *string `json:"handle,omitempty"`
+	Email  string  `json:"email"`
+	// Defaults to [USER].
+	Roles []Role `json:"roles,omitempty"`
+}
+
+type CreateWeatherAlertInput struct {
+	Region string `json:"region"`
+	// One of info, warning or critical.
+	Severity string `json:"severity"`
+	// RFC 3339 timestamp.
+	IssuedAt    string `json:"issuedAt"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// JSON object with the original alert payload.
+	RawData         *string  `json:"rawData,omitempty"`
+	AffectedAreas   []string `json:"affectedAreas,omitempty"`
+	Recommendations []string `json:"recommendations,omitempty"`
+}
+
+type HighlightSegment struct {
+	Text    string `json:"text"`
+	Matched bool   `json:"matched"`
+}
+
 type Message struct {
-	ID        string `json:"id"`
-	Content   string `json:"content"`
-	Author    string `json:"author"`
-	CreatedAt string `json:"createdAt"`
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	Author  string `json:"author"`
+	// ID of the user who wrote the message, if known.
+	AuthorID *string `json:"authorId,omitempty"`
+	// The message this one replies to, or null for a top-level message.
+	ParentID *string `json:"parentId,omitempty"`
+	// The channel the message was posted to, or null outside channels. Replies share their parent's channel.
+	ChannelID  *string `json:"channelId,omitempty"`
+	ReplyCount int32   `json:"replyCount"`
+	// Replies to this message, oldest first. first defaults to 20 and may be at most 100.
+	Replies *MessageConnection `json:"replies"`
+	// Reactions grouped by emoji, most frequent first.
+	Reactions []*ReactionSummary `json:"reactions"`
+	CreatedAt string             `json:"createdAt"`
+	Edited    bool               `json:"edited"`
+	// When the content was last edited, or null if it never was.
+	EditedAt *string `json:"editedAt,omitempty"`
+	// Earlier versions of the content, oldest first. Only the author and admins can read it.
+	History []*MessageEdit `json:"history"`
+	// Files posted with the message, in upload order.
+	Attachments []*Attachment `json:"attachments"`
+	// Users mentioned as @handle in the content, in order of first mention.
+	// Handles match case-insensitively. In a channel only users who can read it
+	// are mentioned. Edits update the mentions.
+	Mentions []*User `json:"mentions"`
+	// Set once the moderation rules flagged the message. Only moderators and the author can read it; it is null for everyone else.
+	Moderation *Moderation `json:"moderation,omitempty"`
+	// Users mentioned in the content, read by the mentions resolver.
+	MentionIDs []string `json:"-"`
+	// Moderation state of the message, read by the moderation resolver.
+	ModerationState *domain.Moderation `json:"-"`
+	// Shared by the messages of a page so that the reactions resolver reads the current user's reactions once per page.
+	ReactionBatch *ReactionBatch `json:"-"`
+	// Number of reactions per emoji, read by the reactions resolver.
+	ReactionCounts domain.ReactionCounts `json:"-"`
+}
+
+type MessageConnection struct {
+	Edges    []*MessageEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type MessageEdge struct {
+	// Pass as after to fetch the items following this one.
+	Cursor string   `json:"cursor"`
+	Node   *Message `json:"node"`
+}
+
+type MessageEdit struct {
+	// The content before the edit.
+	Content string `json:"content"`
+	// ID of the user who made the edit.
+	EditorID string `json:"editorId"`
+	EditedAt string `json:"editedAt"`
+}
+
+type Moderation struct {
+	Status ModerationStatus `json:"status"`
+	// Why the moderation rules flagged the message.
+	Reasons []string `json:"reasons"`
+	// ID of the moderator who approved or rejected the message.
+	ModeratorID *string `json:"moderatorId,omitempty"`
+	ModeratedAt *string `json:"moderatedAt,omitempty"`
+}
+
+type Mutation struct {
+}
+
+type PageInfo struct {
+	HasNextPage bool `json:"hasNextPage"`
+	// Cursor of the last edge, or null when the page is empty.
+	EndCursor *string `json:"endCursor,omitempty"`
 }
 
 type Query struct {
 }
 
+type ReactionSummary struct {
+	Emoji string `json:"emoji"`
+	Count int32  `json:"count"`
+	// Whether the current user reacted with this emoji. Always false when unauthenticated.
+	ViewerHasReacted bool `json:"viewerHasReacted"`
+}
+
+type SearchResultConnection struct {
+	Edges    []*SearchResultEdge `json:"edges"`
+	PageInfo *PageInfo           `json:"pageInfo"`
+}
+
+type SearchResultEdge struct {
+	// Pass as after to fetch the items following this one.
+	Cursor string   `json:"cursor"`
+	Node   *Message `json:"node"`
+	// The content of node split into segments, with the parts matching the query marked.
+	Highlights []*HighlightSegment `json:"highlights"`
+}
+
+type UpdateUserInput struct {
+	Name   *string `json:"name,omitempty"`
+	Handle *string `json:"handle,omitempty"`
+	Email  *string `json:"email,omitempty"`
+	Roles  []Role  `json:"roles,omitempty"`
+}
+
 type User struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	CreatedAt string `json:"createdAt"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Mentioned as @handle. Null for users stored without one, who cannot be mentioned.
+	Handle    *string `json:"handle,omitempty"`
+	Email     string  `json:"email"`
+	Roles     []Role  `json:"roles"`
+	CreatedAt string  `json:"createdAt"`
+	UpdatedAt string  `json:"updatedAt"`
+	// ID of the user who last changed this user, if any.
+	UpdatedBy *string `json:"updatedBy,omitempty"`
+	// Set while the user is deleted.
+	DeletedAt *string `json:"deletedAt,omitempty"`
 }
 
 type WeatherAlert struct {
@@ -29,4 +214,126 @@ type WeatherAlert struct {
 	RawData         string   `json:"rawData"`
 	AffectedAreas   []string `json:"affectedAreas"`
 	Recommendations []string `json:"recommendations"`
+	UpdatedAt       string   `json:"updatedAt"`
+	// ID of the user who last changed this alert, if any.
+	UpdatedBy *string `json:"updatedBy,omitempty"`
+	// Set while the alert is deleted.
+	DeletedAt *string `json:"deletedAt,omitempty"`
+}
+
+type ModerationStatus string
+
+const (
+	// Hidden until a moderator decides.
+	ModerationStatusFlagged  ModerationStatus = "FLAGGED"
+	ModerationStatusApproved ModerationStatus = "APPROVED"
+	// Hidden from everyone but moderators.
+	ModerationStatusRejected ModerationStatus = "REJECTED"
+)
+
+var AllModerationStatus = []ModerationStatus{
+	ModerationStatusFlagged,
+	ModerationStatusApproved,
+	ModerationStatusRejected,
+}
+
+func (e ModerationStatus) IsValid() bool {
+	switch e {
+	case ModerationStatusFlagged, ModerationStatusApproved, ModerationStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e ModerationStatus) String() string {
+	return string(e)
+}
+
+func (e *ModerationStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ModerationStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ModerationStatus", str)
+	}
+	return nil
+}
+
+func (e ModerationStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ModerationStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ModerationStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type Role string
+
+const (
+	RoleUser Role = "USER"
+	// Reviews the messages flagged by the moderation rules.
+	RoleModerator Role = "MODERATOR"
+	RoleAdmin     Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleUser,
+	RoleModerator,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Role) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Role) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,26 @@
+package model
+
+import "sync"
+
+// ReactionBatch loads the current user's reactions to all the messages of a
+// page once, when the reactions of the first of them are resolved.
+type ReactionBatch struct {
+	messageIDs []string
+
+	once    sync.Once
+	reacted map[string][]string
+	err     error
+}
+
+func NewReactionBatch(messageIDs []string) *ReactionBatch {
+	return &ReactionBatch{messageIDs: messageIDs}
+}
+
+// Load calls load with the IDs of the messages of the batch the first time it
+// is called and returns its result to every caller.
+func (b *ReactionBatch) Load(load func(messageIDs []string) (map[string][]string, error)) (map[string][]string, error) {
+	b.once.Do(func() {
+		b.reacted, b.err = load(b.messageIDs)
+	})
+	return b.reacted, b.err
+}
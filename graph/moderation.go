@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/moderation"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// canModerate reports whether the current user reviews flagged messages.
+func canModerate(ctx context.Context) bool {
+	user := auth.UserFromContext(ctx)
+	return user != nil && user.HasRole(domain.RoleModerator)
+}
+
+// visible reports whether the current user may see msg, which is hidden from
+// everyone but moderators while flagged or once rejected.
+func visible(ctx context.Context, msg *domain.Message) bool {
+	return !msg.Hidden() || canModerate(ctx)
+}
+
+// moderate runs the moderation rules on msg before it is written. It fails
+// with BAD_USER_INPUT when a rule rejects the message, and returns the
+// moderation state to store when one flags it.
+func (r *Resolver) moderate(ctx context.Context, msg *domain.Message) (*domain.Moderation, error) {
+	decision, err := r.moderation.Check(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to moderate message: %w", err)
+	}
+	switch decision.Action {
+	case moderation.Reject:
+		return nil, errcode.New(errcode.BadUserInput, "message rejected: "+strings.Join(decision.Reasons, "; "))
+	case moderation.Flag:
+		return &domain.Moderation{Status: domain.ModerationFlagged, Reasons: decision.Reasons}, nil
+	}
+	return nil, nil
+}
+
+// decide records the current moderator's decision on a flagged message. An
+// approved channel message is counted towards unread counts unless it was
+// counted before an edit flagged it. Only one of concurrent decisions is
+// recorded; the others fail with CONFLICT.
+func (r *Resolver) decide(ctx context.Context, id, status string) (*domain.Message, error) {
+	msg, err := r.messageRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Moderation == nil || msg.Moderation.Status != domain.ModerationFlagged {
+		return nil, errcode.New(errcode.BadUserInput, "message "+id+" is not awaiting moderation")
+	}
+
+	counted := msg.Counted()
+	now := time.Now().UTC()
+	msg.Moderation.Status = status
+	msg.Moderation.ModeratorID = auth.UserFromContext(ctx).ID
+	msg.Moderation.ModeratedAt = &now
+	err = r.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		if !counted && msg.Counted() {
+			if err := r.countChannelMessage(ctx, msg); err != nil {
+				return err
+			}
+		}
+		return r.messageRepo.SetModeration(ctx, id, msg.Moderation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func toModelModeration(m *domain.Moderation) *model.Moderation {
+	return &model.Moderation{
+		Status:      model.ModerationStatus(strings.ToUpper(m.Status)),
+		Reasons:     m.Reasons,
+		ModeratorID: optionalString(m.ModeratorID),
+		ModeratedAt: optionalTime(m.ModeratedAt),
+	}
+}
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageRequest validates the first and after arguments of a connection field.
+func pageRequest(first *int32, after *string) (repository.PageRequest, error) {
+	page := repository.PageRequest{Limit: defaultPageSize}
+	if first != nil {
+		if *first < 1 || *first > maxPageSize {
+			return page, errcode.New(errcode.BadUserInput, fmt.Sprintf("first must be between 1 and %d", maxPageSize))
+		}
+		page.Limit = int(*first)
+	}
+	if after != nil {
+		cursor, err := decodeCursor(*after)
+		if err != nil {
+			return page, errcode.New(errcode.BadUserInput, "invalid after cursor")
+		}
+		page.After = &cursor
+	}
+	return page, nil
+}
+
+// Cursors are opaque to clients. They encode the creation time and ID of an
+// item, which is the order every paginated list uses.
+func encodeCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+func decodeCursor(s string) (repository.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return repository.Cursor{}, err
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return repository.Cursor{}, fmt.Errorf("malformed cursor %q", s)
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return repository.Cursor{}, err
+	}
+	return repository.Cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// toFilteredConnection drops the messages of page that keep rejects. The
+// cursor follows the last message of page rather than the last edge, so that
+// a page of dropped messages still moves forward.
+func toFilteredConnection(page *repository.MessagePage, keep func(*domain.Message) bool) *model.MessageConnection {
+	kept := slices.DeleteFunc(slices.Clone(page.Messages), func(msg *domain.Message) bool { return !keep(msg) })
+	conn := toMessageConnection(&repository.MessagePage{Messages: kept, HasNextPage: page.HasNextPage})
+	if n := len(page.Messages); n > 0 {
+		cursor := encodeCursor(page.Messages[n-1].CreatedAt, page.Messages[n-1].ID)
+		conn.PageInfo.EndCursor = &cursor
+	}
+	return conn
+}
+
+func toMessageConnection(page *repository.MessagePage) *model.MessageConnection {
+	conn := &model.MessageConnection{
+		Edges:    make([]*model.MessageEdge, len(page.Messages)),
+		PageInfo: &model.PageInfo{HasNextPage: page.HasNextPage},
+	}
+	nodes := make([]*model.Message, len(page.Messages))
+	for i, msg := range page.Messages {
+		nodes[i] = toModelMessage(msg)
+		conn.Edges[i] = &model.MessageEdge{
+			Cursor: encodeCursor(msg.CreatedAt, msg.ID),
+			Node:   nodes[i],
+		}
+	}
+	batchReactions(nodes)
+	if n := len(conn.Edges); n > 0 {
+		conn.PageInfo.EndCursor = &conn.Edges[n-1].Cursor
+	}
+	return conn
+}
@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+// batchReactions lets messages read the current user's reactions to all of
+// them at once. Messages nobody reacted to are left out of the batch.
+func batchReactions(messages []*model.Message) {
+	var ids []string
+	for _, msg := range messages {
+		if len(msg.ReactionCounts) > 0 {
+			ids = append(ids, msg.ID)
+		}
+	}
+	batch := model.NewReactionBatch(ids)
+	for _, msg := range messages {
+		msg.ReactionBatch = batch
+	}
+}
+
+// reactionSummaries orders counts by frequency, then by emoji so that ties
+// are listed in a stable order. reacted holds the emojis the current user
+// reacted with.
+func reactionSummaries(counts domain.ReactionCounts, reacted []string) []*model.ReactionSummary {
+	summaries := make([]*model.ReactionSummary, 0, len(counts))
+	for emoji, n := range counts {
+		summaries = append(summaries, &model.ReactionSummary{
+			Emoji:            emoji,
+			Count:            int32(n),
+			ViewerHasReacted: slices.Contains(reacted, emoji),
+		})
+	}
+	slices.SortFunc(summaries, func(a, b *model.ReactionSummary) int {
+		if a.Count != b.Count {
+			return int(b.Count - a.Count)
+		}
+		return strings.Compare(a.Emoji, b.Emoji)
+	})
+	return summaries
+}
@@ -1,6 +1,10 @@
 package graph
 
-import "github.com/kuchida1981/graphql-sampleapp/internal/repository"
+import (
+	"github.com/kuchida1981/graphql-sampleapp/internal/attachment"
+	"github.com/kuchida1981/graphql-sampleapp/internal/moderation"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
 
 // This file will not be regenerated automatically.
 //
@@ -9,21 +13,40 @@ import "github.com/kuchida1981/graphql-sampleapp/internal/repository"
 
 type Resolver struct {
 	messageRepo              repository.MessageRepository
+	channelRepo              repository.ChannelRepository
+	searchRepo               repository.SearchRepository
 	userRepo                 repository.UserRepository
 	weatherAlertMetadataRepo repository.WeatherAlertMetadataRepository
 	weatherAlertRepo         repository.WeatherAlertRepository
+	tx                       repository.Transactor
+	attachments              *attachment.Store
+	attachmentURLs           *attachment.Signer
+	// moderation checks written messages. A nil pipeline allows everything.
+	moderation *moderation.Pipeline
 }
 
 func NewResolver(
 	messageRepo repository.MessageRepository,
+	channelRepo repository.ChannelRepository,
+	searchRepo repository.SearchRepository,
 	userRepo repository.UserRepository,
 	weatherAlertMetadataRepo repository.WeatherAlertMetadataRepository,
 	weatherAlertRepo repository.WeatherAlertRepository,
+	tx repository.Transactor,
+	attachments *attachment.Store,
+	attachmentURLs *attachment.Signer,
+	moderation *moderation.Pipeline,
 ) *Resolver {
 	return &Resolver{
 		messageRepo:              messageRepo,
+		channelRepo:              channelRepo,
+		searchRepo:               searchRepo,
 		userRepo:                 userRepo,
 		weatherAlertMetadataRepo: weatherAlertMetadataRepo,
 		weatherAlertRepo:         weatherAlertRepo,
+		tx:                       tx,
+		attachments:              attachments,
+		attachmentURLs:           attachmentURLs,
+		moderation:               moderation,
 	}
 }
@@ -7,16 +7,650 @@ package graph
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
 	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
 	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
 	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
 )
 
+// URL is the resolver for the url field.
+func (r *attachmentResolver) URL(ctx context.Context, obj *model.Attachment) (string, error) {
+	return r.attachmentURLs.URL(obj.MessageID, obj.ID), nil
+}
+
+// Members is the resolver for the members field.
+func (r *channelResolver) Members(ctx context.Context, obj *model.Channel) ([]*model.User, error) {
+	if len(obj.MemberIds) == 0 {
+		return []*model.User{}, nil
+	}
+	found, err := r.userRepo.GetByIDs(ctx, obj.MemberIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch members: %w", err)
+	}
+
+	return orderUsers(obj.MemberIds, found), nil
+}
+
+// Messages is the resolver for the messages field.
+func (r *channelResolver) Messages(ctx context.Context, obj *model.Channel, first *int32, after *string) (*model.MessageConnection, error) {
+	page, err := pageRequest(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := r.messageRepo.ListByChannel(ctx, obj.ID, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+
+	return toFilteredConnection(messages, func(msg *domain.Message) bool { return visible(ctx, msg) }), nil
+}
+
+// Replies is the resolver for the replies field.
+func (r *messageResolver) Replies(ctx context.Context, obj *model.Message, first *int32, after *string) (*model.MessageConnection, error) {
+	page, err := pageRequest(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := r.messageRepo.ListReplies(ctx, obj.ID, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch replies: %w", err)
+	}
+
+	return toFilteredConnection(replies, func(msg *domain.Message) bool { return visible(ctx, msg) }), nil
+}
+
+// Reactions is the resolver for the reactions field.
+func (r *messageResolver) Reactions(ctx context.Context, obj *model.Message) ([]*model.ReactionSummary, error) {
+	var reacted []string
+	if user := auth.UserFromContext(ctx); user != nil && len(obj.ReactionCounts) > 0 {
+		batch := obj.ReactionBatch
+		if batch == nil {
+			batch = model.NewReactionBatch([]string{obj.ID})
+		}
+		byMessage, err := batch.Load(func(messageIDs []string) (map[string][]string, error) {
+			return r.messageRepo.UserReactions(ctx, messageIDs, user.ID)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch reactions: %w", err)
+		}
+		reacted = byMessage[obj.ID]
+	}
+
+	return reactionSummaries(obj.ReactionCounts, reacted), nil
+}
+
+// History is the resolver for the history field.
+func (r *messageResolver) History(ctx context.Context, obj *model.Message) ([]*model.MessageEdit, error) {
+	if user := auth.UserFromContext(ctx); user == nil || obj.AuthorID == nil || *obj.AuthorID != user.ID {
+		if err := requireRole(ctx, model.RoleAdmin, "the history of messages by other users"); err != nil {
+			return nil, err
+		}
+	}
+
+	edits, err := r.messageRepo.History(ctx, obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message history: %w", err)
+	}
+
+	result := make([]*model.MessageEdit, len(edits))
+	for i, edit := range edits {
+		result[i] = toModelMessageEdit(edit)
+	}
+
+	return result, nil
+}
+
+// Mentions is the resolver for the mentions field.
+func (r *messageResolver) Mentions(ctx context.Context, obj *model.Message) ([]*model.User, error) {
+	if len(obj.MentionIDs) == 0 {
+		return []*model.User{}, nil
+	}
+	found, err := r.userRepo.GetByIDs(ctx, obj.MentionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch mentions: %w", err)
+	}
+
+	return orderUsers(obj.MentionIDs, found), nil
+}
+
+// Moderation is the resolver for the moderation field.
+func (r *messageResolver) Moderation(ctx context.Context, obj *model.Message) (*model.Moderation, error) {
+	if obj.ModerationState == nil {
+		return nil, nil
+	}
+	if user := auth.UserFromContext(ctx); !canModerate(ctx) && (user == nil || obj.AuthorID == nil || *obj.AuthorID != user.ID) {
+		return nil, nil
+	}
+
+	return toModelModeration(obj.ModerationState), nil
+}
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*model.User, error) {
+	user := &domain.User{
+		ID:        input.ID,
+		Name:      input.Name,
+		Handle:    input.ID,
+		Email:     input.Email,
+		Roles:     []string{domain.RoleUser},
+		CreatedAt: time.Now().UTC(),
+	}
+	if input.Handle != nil {
+		user.Handle = *input.Handle
+	}
+	if input.Roles != nil {
+		user.Roles = domainRoles(input.Roles)
+	}
+	if err := validateUser(user); err != nil {
+		return nil, err
+	}
+
+	err := r.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		return r.userRepo.Create(ctx, user)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	log.Printf("CreateUser: Created user %s", user.ID)
+	return toModelUser(user), nil
+}
+
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input model.UpdateUserInput) (*model.User, error) {
+	var updated *domain.User
+	err := r.tx.WithinTx(ctx, repository.TxOptions{Isolation: repository.Serializable}, func(ctx context.Context) error {
+		user, err := r.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		wasAdmin := slices.Contains(user.Roles, domain.RoleAdmin)
+
+		if input.Name != nil {
+			user.Name = *input.Name
+		}
+		if input.Handle != nil {
+			user.Handle = *input.Handle
+		}
+		if input.Email != nil {
+			user.Email = *input.Email
+		}
+		if input.Roles != nil {
+			user.Roles = domainRoles(input.Roles)
+		}
+		if err := validateUser(user); err != nil {
+			return err
+		}
+
+		if wasAdmin && !slices.Contains(user.Roles, domain.RoleAdmin) {
+			if err := r.ensureOtherAdmin(ctx, id, "remove the ADMIN role from"); err != nil {
+				return err
+			}
+		}
+
+		if err := r.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		updated = user
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	log.Printf("UpdateUser: Updated user %s", id)
+	return toModelUser(updated), nil
+}
+
+// CreateWeatherAlert is the resolver for the createWeatherAlert field.
+func (r *mutationResolver) CreateWeatherAlert(ctx context.Context, input model.CreateWeatherAlertInput) (*model.WeatherAlert, error) {
+	issuedAt, err := parseTime("issuedAt", input.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSeverity(input.Severity); err != nil {
+		return nil, err
+	}
+	rawData, err := parseRawData(input.RawData)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	metadata := &domain.WeatherAlertMetadata{
+		ID:        id,
+		Region:    input.Region,
+		Severity:  input.Severity,
+		IssuedAt:  issuedAt,
+		CreatedAt: time.Now().UTC(),
+	}
+	alert := &domain.WeatherAlert{
+		ID:              id,
+		Title:           input.Title,
+		Description:     input.Description,
+		RawData:         rawData,
+		AffectedAreas:   append([]string{}, input.AffectedAreas...),
+		Recommendations: append([]string{}, input.Recommendations...),
+	}
+
+	// The metadata row only commits once the alert document is stored, so a
+	// failed document write leaves nothing searchable behind. If the commit
+	// itself fails, the orphaned document is unreachable because alerts are
+	// always looked up through their metadata.
+	err = r.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		if err := r.weatherAlertMetadataRepo.Create(ctx, metadata); err != nil {
+			return err
+		}
+		return r.weatherAlertRepo.Put(ctx, alert)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create weather alert: %w", err)
+	}
+
+	log.Printf("CreateWeatherAlert: Created weather alert %s", id)
+	return toModelWeatherAlert(alert, metadata), nil
+}
+
+// DeleteUser is the resolver for the deleteUser field.
+func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (*model.User, error) {
+	var deleted *domain.User
+	err := r.tx.WithinTx(ctx, repository.TxOptions{Isolation: repository.Serializable}, func(ctx context.Context) error {
+		user, err := r.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(user.Roles, domain.RoleAdmin) {
+			if err := r.ensureOtherAdmin(ctx, id, "delete"); err != nil {
+				return err
+			}
+		}
+
+		deleted, err = r.userRepo.Delete(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	log.Printf("DeleteUser: Deleted user %s", id)
+	return toModelUser(deleted), nil
+}
+
+// RestoreUser is the resolver for the restoreUser field.
+func (r *mutationResolver) RestoreUser(ctx context.Context, id string) (*model.User, error) {
+	user, err := r.userRepo.Restore(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	log.Printf("RestoreUser: Restored user %s", id)
+	return toModelUser(user), nil
+}
+
+// DeleteWeatherAlert is the resolver for the deleteWeatherAlert field.
+func (r *mutationResolver) DeleteWeatherAlert(ctx context.Context, id string) (*model.WeatherAlert, error) {
+	// Only the metadata row is marked deleted. The alert document is kept so
+	// that the deletion can be undone, and is read first so that a missing
+	// document does not leave the metadata deleted with an error returned.
+	alert, err := r.weatherAlertRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete weather alert: %w", err)
+	}
+	metadata, err := r.weatherAlertMetadataRepo.Delete(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete weather alert: %w", err)
+	}
+
+	log.Printf("DeleteWeatherAlert: Deleted weather alert %s", id)
+	return toModelWeatherAlert(alert, metadata), nil
+}
+
+// RestoreWeatherAlert is the resolver for the restoreWeatherAlert field.
+func (r *mutationResolver) RestoreWeatherAlert(ctx context.Context, id string) (*model.WeatherAlert, error) {
+	alert, err := r.weatherAlertRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore weather alert: %w", err)
+	}
+	metadata, err := r.weatherAlertMetadataRepo.Restore(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore weather alert: %w", err)
+	}
+
+	log.Printf("RestoreWeatherAlert: Restored weather alert %s", id)
+	return toModelWeatherAlert(alert, metadata), nil
+}
+
+// PostReply is the resolver for the postReply field.
+func (r *mutationResolver) PostReply(ctx context.Context, parentID string, content string, attachments []*graphql.Upload) (*model.Message, error) {
+	if err := validateContent(content); err != nil {
+		return nil, err
+	}
+
+	parent, err := r.messageRepo.GetByID(ctx, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post reply: %w", err)
+	}
+	if parent.ParentID != "" {
+		return nil, errcode.New(errcode.BadUserInput, "cannot reply to a reply")
+	}
+	channel, err := r.authorizeMessage(ctx, parent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post reply: %w", err)
+	}
+	if channel != nil && channel.ArchivedAt != nil {
+		return nil, errChannelArchived(channel.ID)
+	}
+	mentions, err := r.resolveMentions(ctx, content, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post reply: %w", err)
+	}
+
+	user := auth.UserFromContext(ctx)
+	reply := &domain.Message{
+		ID:         uuid.NewString(),
+		Content:    content,
+		Author:     user.Name,
+		AuthorID:   user.ID,
+		ParentID:   parentID,
+		CreatedAt:  time.Now().UTC(),
+		MentionIDs: mentions,
+	}
+	if reply.Moderation, err = r.moderate(ctx, reply); err != nil {
+		return nil, err
+	}
+	stored, err := r.saveAttachments(ctx, attachments)
+	if err != nil {
+		return nil, err
+	}
+	reply.Attachments = stored
+	if err := r.messageRepo.Create(ctx, reply); err != nil {
+		r.deleteAttachments(ctx, stored)
+		return nil, fmt.Errorf("failed to post reply: %w", err)
+	}
+
+	r.indexMessage(ctx, reply)
+
+	log.Printf("PostReply: Created reply %s to %s", reply.ID, parentID)
+	return toModelMessage(reply), nil
+}
+
+// AddReaction is the resolver for the addReaction field.
+func (r *mutationResolver) AddReaction(ctx context.Context, messageID string, emoji string) (*model.Message, error) {
+	if err := validateEmoji(emoji); err != nil {
+		return nil, err
+	}
+
+	if err := r.checkMessageAccess(ctx, messageID); err != nil {
+		return nil, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	reaction := &domain.Reaction{
+		MessageID: messageID,
+		Emoji:     emoji,
+		UserID:    auth.UserFromContext(ctx).ID,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := r.messageRepo.AddReaction(ctx, reaction); err != nil {
+		return nil, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	msg, err := r.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message: %w", err)
+	}
+
+	log.Printf("AddReaction: %s reacted to %s with %s", reaction.UserID, messageID, emoji)
+	return toModelMessage(msg), nil
+}
+
+// RemoveReaction is the resolver for the removeReaction field.
+func (r *mutationResolver) RemoveReaction(ctx context.Context, messageID string, emoji string) (*model.Message, error) {
+	if err := validateEmoji(emoji); err != nil {
+		return nil, err
+	}
+
+	if err := r.checkMessageAccess(ctx, messageID); err != nil {
+		return nil, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	userID := auth.UserFromContext(ctx).ID
+	if err := r.messageRepo.RemoveReaction(ctx, messageID, emoji, userID); err != nil {
+		return nil, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	msg, err := r.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message: %w", err)
+	}
+
+	log.Printf("RemoveReaction: %s removed %s from %s", userID, emoji, messageID)
+	return toModelMessage(msg), nil
+}
+
+// CreateChannel is the resolver for the createChannel field.
+func (r *mutationResolver) CreateChannel(ctx context.Context, input model.CreateChannelInput) (*model.Channel, error) {
+	if err := validateChannelName(input.Name); err != nil {
+		return nil, err
+	}
+
+	user := auth.UserFromContext(ctx)
+	members := append([]string{user.ID}, input.MemberIds...)
+	slices.Sort(members)
+	channel := &domain.Channel{
+		ID:        uuid.NewString(),
+		Name:      strings.TrimSpace(input.Name),
+		MemberIDs: slices.Compact(members),
+		CreatedBy: user.ID,
+		CreatedAt: time.Now().UTC(),
+	}
+	if input.Topic != nil {
+		channel.Topic = *input.Topic
+	}
+
+	err := r.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		return r.channelRepo.Create(ctx, channel)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create channel: %w", err)
+	}
+
+	log.Printf("CreateChannel: Created channel %s", channel.ID)
+	return toModelChannel(channel), nil
+}
+
+// ArchiveChannel is the resolver for the archiveChannel field.
+func (r *mutationResolver) ArchiveChannel(ctx context.Context, id string) (*model.Channel, error) {
+	channel, err := r.channelRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive channel: %w", err)
+	}
+	if user := auth.UserFromContext(ctx); channel.CreatedBy != user.ID {
+		if err := requireRole(ctx, model.RoleAdmin, "archiveChannel on a channel created by another user"); err != nil {
+			return nil, err
+		}
+	}
+
+	channel, err = r.channelRepo.Archive(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive channel: %w", err)
+	}
+
+	log.Printf("ArchiveChannel: Archived channel %s", id)
+	result := toModelChannel(channel)
+	if err := r.fillReads(ctx, result); err != nil {
+		return nil, fmt.Errorf("failed to archive channel: %w", err)
+	}
+	return result, nil
+}
+
+// PostMessage is the resolver for the postMessage field.
+func (r *mutationResolver) PostMessage(ctx context.Context, channelID string, content string, attachments []*graphql.Upload) (*model.Message, error) {
+	if err := validateContent(content); err != nil {
+		return nil, err
+	}
+
+	channel, err := r.channelRepo.GetByID(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post message: %w", err)
+	}
+	if err := authorizeChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+	if channel.ArchivedAt != nil {
+		return nil, errChannelArchived(channelID)
+	}
+	mentions, err := r.resolveMentions(ctx, content, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post message: %w", err)
+	}
+
+	user := auth.UserFromContext(ctx)
+	msg := &domain.Message{
+		ID:         uuid.NewString(),
+		Content:    content,
+		Author:     user.Name,
+		AuthorID:   user.ID,
+		ChannelID:  channelID,
+		CreatedAt:  time.Now().UTC(),
+		MentionIDs: mentions,
+	}
+	if msg.Moderation, err = r.moderate(ctx, msg); err != nil {
+		return nil, err
+	}
+	stored, err := r.saveAttachments(ctx, attachments)
+	if err != nil {
+		return nil, err
+	}
+	msg.Attachments = stored
+	err = r.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		// A flagged message counts as unread once a moderator approves it.
+		if msg.Counted() {
+			if err := r.countChannelMessage(ctx, msg); err != nil {
+				return err
+			}
+		}
+		return r.messageRepo.Create(ctx, msg)
+	})
+	if err != nil {
+		r.deleteAttachments(ctx, stored)
+		return nil, fmt.Errorf("failed to post message: %w", err)
+	}
+
+	r.indexMessage(ctx, msg)
+
+	log.Printf("PostMessage: Created message %s in %s", msg.ID, channelID)
+	return toModelMessage(msg), nil
+}
+
+// MarkRead is the resolver for the markRead field.
+func (r *mutationResolver) MarkRead(ctx context.Context, channelID string) (*model.Channel, error) {
+	channel, err := r.channelRepo.GetByID(ctx, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark channel read: %w", err)
+	}
+	if err := authorizeChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	user := auth.UserFromContext(ctx)
+	if err := r.channelRepo.MarkRead(ctx, channelID, user.ID, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("failed to mark channel read: %w", err)
+	}
+
+	result := toModelChannel(channel)
+	if err := r.fillReads(ctx, result); err != nil {
+		return nil, fmt.Errorf("failed to mark channel read: %w", err)
+	}
+	return result, nil
+}
+
+// EditMessage is the resolver for the editMessage field.
+func (r *mutationResolver) EditMessage(ctx context.Context, id string, content string) (*model.Message, error) {
+	if err := validateContent(content); err != nil {
+		return nil, err
+	}
+
+	msg, err := r.messageRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+	user := auth.UserFromContext(ctx)
+	if msg.AuthorID == "" || msg.AuthorID != user.ID {
+		return nil, errcode.New(errcode.Forbidden, "only the author can edit message "+id)
+	}
+	channel, err := r.authorizeMessage(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+	if channel != nil && channel.ArchivedAt != nil {
+		return nil, errChannelArchived(channel.ID)
+	}
+	if content == msg.Content {
+		return toModelMessage(msg), nil
+	}
+	mentions, err := r.resolveMentions(ctx, content, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	editedAt := time.Now().UTC()
+	edited := *msg
+	edited.Content = content
+	edited.EditedAt = &editedAt
+	flagged, err := r.moderate(ctx, &edited)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.messageRepo.Edit(ctx, id, content, mentions, flagged, user.ID, editedAt); err != nil {
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	msg, err = r.messageRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message: %w", err)
+	}
+
+	r.indexMessage(ctx, msg)
+
+	log.Printf("EditMessage: %s edited %s", user.ID, id)
+	return toModelMessage(msg), nil
+}
+
+// ApproveMessage is the resolver for the approveMessage field.
+func (r *mutationResolver) ApproveMessage(ctx context.Context, id string) (*model.Message, error) {
+	msg, err := r.decide(ctx, id, domain.ModerationApproved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve message: %w", err)
+	}
+
+	log.Printf("ApproveMessage: %s approved %s", auth.UserFromContext(ctx).ID, id)
+	return toModelMessage(msg), nil
+}
+
+// RejectMessage is the resolver for the rejectMessage field.
+func (r *mutationResolver) RejectMessage(ctx context.Context, id string) (*model.Message, error) {
+	msg, err := r.decide(ctx, id, domain.ModerationRejected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject message: %w", err)
+	}
+
+	log.Printf("RejectMessage: %s rejected %s", auth.UserFromContext(ctx).ID, id)
+	return toModelMessage(msg), nil
+}
+
 // Hello is the resolver for the hello field.
 func (r *queryResolver) Hello(ctx context.Context) (string, error) {
 	return "Hello World", nil
@@ -29,15 +663,13 @@ func (r *queryResolver) Messages(ctx context.Context) ([]*model.Message, error)
 		return nil, fmt.Errorf("failed to fetch messages: %w", err)
 	}
 
-	result := make([]*model.Message, len(messages))
-	for i, msg := range messages {
-		result[i] = &model.Message{
-			ID:        msg.ID,
-			Content:   msg.Content,
-			Author:    msg.Author,
-			CreatedAt: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	result := make([]*model.Message, 0, len(messages))
+	for _, msg := range messages {
+		if visible(ctx, msg) {
+			result = append(result, toModelMessage(msg))
 		}
 	}
+	batchReactions(result)
 
 	return result, nil
 }
@@ -45,33 +677,140 @@ func (r *queryResolver) Messages(ctx context.Context) ([]*model.Message, error)
 // Message is the resolver for the message field.
 func (r *queryResolver) Message(ctx context.Context, id string) (*model.Message, error) {
 	msg, err := r.messageRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch message: %w", err)
 	}
+	_, err = r.authorizeMessage(ctx, msg)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelMessage(msg), nil
+}
+
+// SearchMessages is the resolver for the searchMessages field.
+func (r *queryResolver) SearchMessages(ctx context.Context, query string, author *string, from *string, to *string, first *int32, after *string) (*model.SearchResultConnection, error) {
+	q, err := r.searchQuery(ctx, query, author, from, to)
+	if err != nil {
+		return nil, err
+	}
+	page, err := pageRequest(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.searchRepo.Search(ctx, q, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	conn, err := r.toSearchConnection(ctx, result, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+	return conn, nil
+}
+
+// Channels is the resolver for the channels field.
+func (r *queryResolver) Channels(ctx context.Context, includeArchived bool) ([]*model.Channel, error) {
+	filter := repository.ChannelFilter{IncludeArchived: includeArchived}
+	if user := auth.UserFromContext(ctx); !user.HasRole(domain.RoleAdmin) {
+		filter.MemberID = user.ID
+	}
+
+	channels, err := r.channelRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch channels: %w", err)
+	}
+
+	result := make([]*model.Channel, len(channels))
+	for i, channel := range channels {
+		result[i] = toModelChannel(channel)
+	}
+	if err := r.fillReads(ctx, result...); err != nil {
+		return nil, fmt.Errorf("failed to fetch channels: %w", err)
+	}
+
+	return result, nil
+}
+
+// MyMentions is the resolver for the myMentions field.
+func (r *queryResolver) MyMentions(ctx context.Context, first *int32, after *string) (*model.MessageConnection, error) {
+	page, err := pageRequest(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	user := auth.UserFromContext(ctx)
+	messages, err := r.messageRepo.ListMentions(ctx, user.ID, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch mentions: %w", err)
+	}
 
-	return &model.Message{
-		ID:        msg.ID,
-		Content:   msg.Content,
-		Author:    msg.Author,
-		CreatedAt: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	conn, err := r.toMentionConnection(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch mentions: %w", err)
+	}
+	return conn, nil
+}
+
+// ModerationQueue is the resolver for the moderationQueue field.
+func (r *queryResolver) ModerationQueue(ctx context.Context, first *int32, after *string) (*model.MessageConnection, error) {
+	page, err := pageRequest(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := r.messageRepo.ListFlagged(ctx, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch moderation queue: %w", err)
+	}
+
+	return toMessageConnection(messages), nil
+}
+
+// Channel is the resolver for the channel field.
+func (r *queryResolver) Channel(ctx context.Context, id string) (*model.Channel, error) {
+	channel, err := r.channelRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch channel: %w", err)
+	}
+	if err := authorizeChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	result := toModelChannel(channel)
+	if err := r.fillReads(ctx, result); err != nil {
+		return nil, fmt.Errorf("failed to fetch channel: %w", err)
+	}
+	return result, nil
 }
 
 // Users is the resolver for the users field.
-func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
-	users, err := r.userRepo.List(ctx)
+func (r *queryResolver) Users(ctx context.Context, includeDeleted bool) ([]*model.User, error) {
+	if includeDeleted {
+		if err := requireRole(ctx, model.RoleAdmin, "includeDeleted"); err != nil {
+			return nil, err
+		}
+	}
+
+	users, err := r.userRepo.List(ctx, repository.UserFilter{IncludeDeleted: includeDeleted})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch users: %w", err)
 	}
 
 	result := make([]*model.User, len(users))
 	for i, user := range users {
-		result[i] = &model.User{
-			ID:        user.ID,
-			Name:      user.Name,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
+		result[i] = toModelUser(user)
 	}
 
 	return result, nil
@@ -80,31 +819,46 @@ func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
 // User is the resolver for the user field.
 func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
 	user, err := r.userRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch user: %w", err)
 	}
 
-	return &model.User{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	return toModelUser(user), nil
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
+	user := auth.UserFromContext(ctx)
+	if user == nil {
+		return nil, nil
+	}
+
+	return toModelUser(user), nil
 }
 
 // WeatherAlerts is the resolver for the weatherAlerts field.
-func (r *queryResolver) WeatherAlerts(ctx context.Context, region *string, issuedAfter *string) ([]*model.WeatherAlert, error) {
-	log.Printf("WeatherAlerts resolver called with region=%v, issuedAfter=%v", region, issuedAfter)
+func (r *queryResolver) WeatherAlerts(ctx context.Context, region *string, issuedAfter *string, includeDeleted bool) ([]*model.WeatherAlert, error) {
+	log.Printf("WeatherAlerts resolver called with region=%v, issuedAfter=%v, includeDeleted=%v", region, issuedAfter, includeDeleted)
+
+	if includeDeleted {
+		if err := requireRole(ctx, model.RoleAdmin, "includeDeleted"); err != nil {
+			return nil, err
+		}
+	}
 
 	filter := repository.MetadataFilter{
-		Region: region,
+		Region:         region,
+		IncludeDeleted: includeDeleted,
 	}
 
 	if issuedAfter != nil {
-		parsedTime, err := time.Parse(time.RFC3339, *issuedAfter)
+		parsedTime, err := parseTime("issuedAfter", *issuedAfter)
 		if err != nil {
 			log.Printf("WeatherAlerts: Failed to parse issuedAfter: %v", err)
-			return nil, fmt.Errorf("invalid issuedAfter format, expected ISO8601: %w", err)
+			return nil, err
 		}
 		filter.IssuedAfter = &parsedTime
 	}
@@ -144,30 +898,30 @@ func (r *queryResolver) WeatherAlerts(ctx context.Context, region *string, issue
 			continue
 		}
 
-		rawDataJSON, err := json.Marshal(alert.RawData)
-		if err != nil {
-			log.Printf("WeatherAlerts: Warning - failed to marshal rawData for %s: %v", alert.ID, err)
-			rawDataJSON = []byte("{}")
-		}
-
-		result = append(result, &model.WeatherAlert{
-			ID:              alert.ID,
-			Region:          metadata.Region,
-			Severity:        metadata.Severity,
-			IssuedAt:        metadata.IssuedAt.Format("2006-01-02T15:04:05Z07:00"),
-			Title:           alert.Title,
-			Description:     alert.Description,
-			RawData:         string(rawDataJSON),
-			AffectedAreas:   alert.AffectedAreas,
-			Recommendations: alert.Recommendations,
-		})
+		result = append(result, toModelWeatherAlert(alert, metadata))
 	}
 
 	log.Printf("WeatherAlerts: Returning %d weather alerts", len(result))
 	return result, nil
 }
 
+// Attachment returns AttachmentResolver implementation.
+func (r *Resolver) Attachment() AttachmentResolver { return &attachmentResolver{r} }
+
+// Channel returns ChannelResolver implementation.
+func (r *Resolver) Channel() ChannelResolver { return &channelResolver{r} }
+
+// Message returns MessageResolver implementation.
+func (r *Resolver) Message() MessageResolver { return &messageResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+type attachmentResolver struct{ *Resolver }
+type channelResolver struct{ *Resolver }
+type messageResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -3,13 +3,27 @@ package graph
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/attachment"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/blob"
 	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/moderation"
 	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository/memory"
+	"github.com/kuchida1981/graphql-sampleapp/internal/search"
 	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 // --- Mock Implementations ---
@@ -22,7 +36,7 @@ type mockUserRepository struct {
 	getErr  error
 }
 
-func (m *mockUserRepository) List(ctx context.Context) ([]*domain.User, error) {
+func (m *mockUserRepository) List(ctx context.Context, filter repository.UserFilter) ([]*domain.User, error) {
 	if m.listErr != nil {
 		return nil, m.listErr
 	}
@@ -49,7 +63,62 @@ func (m *mockUserRepository) GetByID(ctx context.Context, id string) (*domain.Us
 			return u, nil
 		}
 	}
-	return nil, errors.New("user not found")
+	return nil, repository.NotFoundf("user %s not found", id)
+}
+
+func (m *mockUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var users []*domain.User
+	for _, u := range m.users {
+		if slices.Contains(ids, u.ID) {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+func (m *mockUserRepository) GetByHandles(ctx context.Context, handles []string) ([]*domain.User, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var users []*domain.User
+	for _, u := range m.users {
+		if u.Handle != "" && slices.Contains(handles, strings.ToLower(u.Handle)) {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+func (m *mockUserRepository) Create(ctx context.Context, user *domain.User) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.users = append(m.users, user)
+	return nil
+}
+
+func (m *mockUserRepository) Update(ctx context.Context, user *domain.User) error {
+	if m.err != nil {
+		return m.err
+	}
+	for i, u := range m.users {
+		if u.ID == user.ID {
+			m.users[i] = user
+			return nil
+		}
+	}
+	return repository.NotFoundf("user %s not found", user.ID)
+}
+
+func (m *mockUserRepository) Delete(ctx context.Context, id string) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserRepository) Restore(ctx context.Context, id string) (*domain.User, error) {
+	return nil, errors.New("not implemented")
 }
 
 type mockMessageRepository struct {
@@ -65,6 +134,33 @@ func (m *mockMessageRepository) List(ctx context.Context) ([]*domain.Message, er
 	return m.messages, nil
 }
 
+func (m *mockMessageRepository) ListByChannel(ctx context.Context, channelID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &repository.MessagePage{}, nil
+}
+
+func (m *mockMessageRepository) ListMentions(ctx context.Context, userID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &repository.MessagePage{}, nil
+}
+
+func (m *mockMessageRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Message, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var messages []*domain.Message
+	for _, msg := range m.messages {
+		if slices.Contains(ids, msg.ID) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
 func (m *mockMessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -77,7 +173,58 @@ func (m *mockMessageRepository) GetByID(ctx context.Context, id string) (*domain
 			return msg, nil
 		}
 	}
-	return nil, errors.New("message not found")
+	return nil, repository.NotFoundf("message %s not found", id)
+}
+
+func (m *mockMessageRepository) Create(ctx context.Context, msg *domain.Message) error {
+	return m.err
+}
+
+func (m *mockMessageRepository) ListReplies(ctx context.Context, parentID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &repository.MessagePage{}, nil
+}
+
+func (m *mockMessageRepository) AddReaction(ctx context.Context, reaction *domain.Reaction) error {
+	return m.err
+}
+
+func (m *mockMessageRepository) RemoveReaction(ctx context.Context, messageID, emoji, userID string) error {
+	return m.err
+}
+
+func (m *mockMessageRepository) UserReactions(ctx context.Context, messageIDs []string, userID string) (map[string][]string, error) {
+	return nil, m.err
+}
+
+func (m *mockMessageRepository) Scan(ctx context.Context, page repository.PageRequest) (*repository.MessagePage, error) {
+	return nil, m.err
+}
+
+func (m *mockMessageRepository) Edit(ctx context.Context, id, content string, mentionIDs []string, moderation *domain.Moderation, editorID string, editedAt time.Time) error {
+	return m.err
+}
+
+func (m *mockMessageRepository) ListFlagged(ctx context.Context, page repository.PageRequest) (*repository.MessagePage, error) {
+	return nil, m.err
+}
+
+func (m *mockMessageRepository) SetModeration(ctx context.Context, id string, moderation *domain.Moderation) error {
+	return m.err
+}
+
+func (m *mockMessageRepository) ListExpired(ctx context.Context, filter repository.ExpiryFilter, page repository.PageRequest) (*repository.MessagePage, error) {
+	return nil, m.err
+}
+
+func (m *mockMessageRepository) Purge(ctx context.Context, ids []string) ([]*domain.Message, error) {
+	return nil, m.err
+}
+
+func (m *mockMessageRepository) History(ctx context.Context, messageID string) ([]*domain.MessageEdit, error) {
+	return nil, m.err
 }
 
 type mockWeatherAlertMetadataRepository struct {
@@ -105,6 +252,22 @@ func (m *mockWeatherAlertMetadataRepository) Search(ctx context.Context, filter
 	return result, nil
 }
 
+func (m *mockWeatherAlertMetadataRepository) Create(ctx context.Context, metadata *domain.WeatherAlertMetadata) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.metadata = append(m.metadata, metadata)
+	return nil
+}
+
+func (m *mockWeatherAlertMetadataRepository) Delete(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockWeatherAlertMetadataRepository) Restore(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockWeatherAlertMetadataRepository) SearchIDs(ctx context.Context, filter repository.MetadataFilter) ([]string, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -116,6 +279,7 @@ type mockWeatherAlertRepository struct {
 	alerts []*domain.WeatherAlert
 	alert  *domain.WeatherAlert
 	err    error
+	putErr error
 }
 
 func (m *mockWeatherAlertRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.WeatherAlert, error) {
@@ -144,6 +308,14 @@ func (m *mockWeatherAlertRepository) GetByID(ctx context.Context, id string) (*d
 	return nil, errors.New("not found")
 }
 
+func (m *mockWeatherAlertRepository) Put(ctx context.Context, alert *domain.WeatherAlert) error {
+	if m.putErr != nil {
+		return m.putErr
+	}
+	m.alerts = append(m.alerts, alert)
+	return nil
+}
+
 // --- Tests ---
 
 func TestQueryResolver_Hello(t *testing.T) {
@@ -186,9 +358,9 @@ func TestQueryResolver_Users(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resolver := NewResolver(nil, tt.mock, nil, nil)
+			resolver := NewResolver(nil, nil, nil, tt.mock, nil, nil, nil, nil, nil, nil)
 			q := resolver.Query()
-			got, err := q.Users(context.Background())
+			got, err := q.Users(context.Background(), false)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -227,24 +399,36 @@ func TestQueryResolver_User(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "異常系: ユーザーが見つからない",
+			name: "正常系: ユーザーが見つからない場合はnull",
 			id:   "99",
 			mock: &mockUserRepository{
 				users: []*domain.User{},
 			},
 			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "異常系: Repositoryエラー",
+			id:   "1",
+			mock: &mockUserRepository{
+				getErr: repository.Unavailable("failed to get", errors.New("connection refused")),
+			},
+			want:    nil,
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resolver := NewResolver(nil, tt.mock, nil, nil)
+			resolver := NewResolver(nil, nil, nil, tt.mock, nil, nil, nil, nil, nil, nil)
 			q := resolver.Query()
 			got, err := q.User(context.Background(), tt.id)
 
 			if tt.wantErr {
 				assert.Error(t, err)
+			} else if tt.want == nil {
+				assert.NoError(t, err)
+				assert.Nil(t, got)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.want.ID, got.ID)
@@ -286,7 +470,7 @@ func TestQueryResolver_Messages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resolver := NewResolver(tt.mock, nil, nil, nil)
+			resolver := NewResolver(tt.mock, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 			q := resolver.Query()
 			got, err := q.Messages(context.Background())
 
@@ -324,24 +508,36 @@ func TestQueryResolver_Message(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "異常系: メッセージが見つからない",
+			name: "正常系: メッセージが見つからない場合はnull",
 			id:   "99",
 			mock: &mockMessageRepository{
 				messages: []*domain.Message{},
 			},
 			want:    nil,
+			wantErr: false,
+		},
+		{
+			name: "異常系: Repositoryエラー",
+			id:   "1",
+			mock: &mockMessageRepository{
+				err: repository.Unavailable("failed to get", errors.New("connection refused")),
+			},
+			want:    nil,
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resolver := NewResolver(tt.mock, nil, nil, nil)
+			resolver := NewResolver(tt.mock, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 			q := resolver.Query()
 			got, err := q.Message(context.Background(), tt.id)
 
 			if tt.wantErr {
 				assert.Error(t, err)
+			} else if tt.want == nil {
+				assert.NoError(t, err)
+				assert.Nil(t, got)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.want.ID, got.ID)
@@ -456,9 +652,9 @@ func TestQueryResolver_WeatherAlerts(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resolver := NewResolver(nil, nil, tt.mockMeta, tt.mockAlert)
+			resolver := NewResolver(nil, nil, nil, nil, tt.mockMeta, tt.mockAlert, nil, nil, nil, nil)
 			q := resolver.Query()
-			got, err := q.WeatherAlerts(context.Background(), tt.region, tt.issuedAfter)
+			got, err := q.WeatherAlerts(context.Background(), tt.region, tt.issuedAfter, false)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -474,3 +670,1563 @@ func TestQueryResolver_WeatherAlerts(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryResolver_Me(t *testing.T) {
+	fixedTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := &domain.User{ID: "1", Name: "User 1", Email: "u1@example.com", Roles: []string{"user"}, CreatedAt: fixedTime, UpdatedAt: fixedTime}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want *model.User
+	}{
+		{
+			name: "正常系: 認証済みユーザー",
+			ctx:  auth.WithUser(context.Background(), user),
+			want: &model.User{ID: "1", Name: "User 1", Email: "u1@example.com", Roles: []model.Role{model.RoleUser}, CreatedAt: fixedTime.Format(time.RFC3339), UpdatedAt: fixedTime.Format(time.RFC3339)},
+		},
+		{
+			name: "正常系: 未認証はnull",
+			ctx:  context.Background(),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := NewResolver(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+			q := resolver.Query()
+			got, err := q.Me(tt.ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// errorCode returns the "code" extension of a GraphQL error in err's chain.
+func errorCode(err error) string {
+	var gqlErr *gqlerror.Error
+	if !errors.As(err, &gqlErr) {
+		return ""
+	}
+	code, _ := gqlErr.Extensions["code"].(string)
+	return code
+}
+
+func TestMutationResolver_CreateUser(t *testing.T) {
+	existing := &domain.User{ID: "admin1", Name: "Admin", Handle: "admin1", Email: "admin@example.com", Roles: []string{"admin"}}
+	handle := func(s string) *string { return &s }
+
+	tests := []struct {
+		name       string
+		input      model.CreateUserInput
+		wantRoles  []model.Role
+		wantHandle string
+		wantKind   error
+		wantCode   string
+	}{
+		{
+			name:       "正常系: ロール省略時はUSER、ハンドル省略時はID",
+			input:      model.CreateUserInput{ID: "user1", Name: "Alice", Email: "alice@example.com"},
+			wantRoles:  []model.Role{model.RoleUser},
+			wantHandle: "user1",
+		},
+		{
+			name:       "正常系: ハンドル指定",
+			input:      model.CreateUserInput{ID: "user1", Name: "Alice Smith", Handle: handle("Alice.Smith"), Email: "alice@example.com"},
+			wantRoles:  []model.Role{model.RoleUser},
+			wantHandle: "Alice.Smith",
+		},
+		{
+			name:     "異常系: 空白を含むハンドル",
+			input:    model.CreateUserInput{ID: "user1", Name: "Alice", Handle: handle("alice smith"), Email: "alice@example.com"},
+			wantCode: errcode.BadUserInput,
+		},
+		{
+			name:     "異常系: ハンドルにできないIDでハンドル省略",
+			input:    model.CreateUserInput{ID: "alice@example.com", Name: "Alice", Email: "alice@example.com"},
+			wantCode: errcode.BadUserInput,
+		},
+		{
+			name:     "異常系: 大文字小文字だけが異なるハンドルの重複",
+			input:    model.CreateUserInput{ID: "user1", Name: "Alice", Handle: handle("ADMIN1"), Email: "alice@example.com"},
+			wantKind: repository.ErrConflict,
+		},
+		{
+			name:       "正常系: ロール指定",
+			input:      model.CreateUserInput{ID: "user1", Name: "Alice", Email: "alice@example.com", Roles: []model.Role{model.RoleAdmin}},
+			wantRoles:  []model.Role{model.RoleAdmin},
+			wantHandle: "user1",
+		},
+		{
+			name:     "異常系: メールアドレスが不正",
+			input:    model.CreateUserInput{ID: "user1", Name: "Alice", Email: "alice"},
+			wantCode: errcode.BadUserInput,
+		},
+		{
+			name:     "異常系: メールアドレスの重複",
+			input:    model.CreateUserInput{ID: "user1", Name: "Alice", Email: "admin@example.com"},
+			wantKind: repository.ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := memory.NewMemoryUserRepository([]*domain.User{existing})
+			resolver := NewResolver(nil, nil, nil, users, nil, nil, memory.NewMemoryTxManager(), nil, nil, nil)
+
+			got, err := resolver.Mutation().CreateUser(context.Background(), tt.input)
+
+			if tt.wantKind != nil || tt.wantCode != "" {
+				assert.Error(t, err)
+				if tt.wantKind != nil {
+					assert.ErrorIs(t, err, tt.wantKind)
+				}
+				if tt.wantCode != "" {
+					assert.Equal(t, tt.wantCode, errorCode(err))
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRoles, got.Roles)
+			assert.Equal(t, &tt.wantHandle, got.Handle)
+
+			stored, err := users.GetByID(context.Background(), tt.input.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input.Email, stored.Email)
+			assert.Equal(t, tt.wantHandle, stored.Handle)
+		})
+	}
+}
+
+func TestMutationResolver_UpdateUser(t *testing.T) {
+	name := "Alicia"
+	userOnly := []model.Role{model.RoleUser}
+
+	tests := []struct {
+		name     string
+		users    []*domain.User
+		id       string
+		input    model.UpdateUserInput
+		wantName string
+		wantKind error
+		wantCode string
+	}{
+		{
+			name: "正常系: 指定したフィールドのみ更新",
+			users: []*domain.User{
+				{ID: "user1", Name: "Alice", Email: "alice@example.com", Roles: []string{"user"}},
+			},
+			id:       "user1",
+			input:    model.UpdateUserInput{Name: &name},
+			wantName: "Alicia",
+		},
+		{
+			name: "正常系: 他に管理者がいれば管理者ロールを外せる",
+			users: []*domain.User{
+				{ID: "admin1", Name: "Alice", Email: "alice@example.com", Roles: []string{"admin"}},
+				{ID: "admin2", Name: "Bob", Email: "bob@example.com", Roles: []string{"admin"}},
+			},
+			id:       "admin1",
+			input:    model.UpdateUserInput{Roles: userOnly},
+			wantName: "Alice",
+		},
+		{
+			name: "異常系: 最後の管理者から管理者ロールは外せない",
+			users: []*domain.User{
+				{ID: "admin1", Name: "Alice", Email: "alice@example.com", Roles: []string{"admin"}},
+				{ID: "user2", Name: "Bob", Email: "bob@example.com", Roles: []string{"user"}},
+			},
+			id:       "admin1",
+			input:    model.UpdateUserInput{Roles: userOnly},
+			wantCode: errcode.BadUserInput,
+		},
+		{
+			name:     "異常系: ユーザーが見つからない",
+			users:    []*domain.User{},
+			id:       "nonexistent",
+			input:    model.UpdateUserInput{Name: &name},
+			wantKind: repository.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := memory.NewMemoryUserRepository(tt.users)
+			resolver := NewResolver(nil, nil, nil, users, nil, nil, memory.NewMemoryTxManager(), nil, nil, nil)
+
+			got, err := resolver.Mutation().UpdateUser(context.Background(), tt.id, tt.input)
+
+			if tt.wantKind != nil || tt.wantCode != "" {
+				assert.Error(t, err)
+				if tt.wantKind != nil {
+					assert.ErrorIs(t, err, tt.wantKind)
+				}
+				if tt.wantCode != "" {
+					assert.Equal(t, tt.wantCode, errorCode(err))
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantName, got.Name)
+
+			stored, err := users.GetByID(context.Background(), tt.id)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantName, stored.Name)
+		})
+	}
+}
+
+func TestMutationResolver_CreateWeatherAlert(t *testing.T) {
+	rawData := `{"pressure": 950}`
+	invalidRawData := `[1, 2]`
+	valid := model.CreateWeatherAlertInput{
+		Region:        "Tokyo",
+		Severity:      "warning",
+		IssuedAt:      "2024-01-15T12:00:00+09:00",
+		Title:         "Typhoon",
+		Description:   "Typhoon approaching",
+		RawData:       &rawData,
+		AffectedAreas: []string{"Chiyoda"},
+	}
+	withInput := func(modify func(*model.CreateWeatherAlertInput)) model.CreateWeatherAlertInput {
+		input := valid
+		modify(&input)
+		return input
+	}
+
+	tests := []struct {
+		name     string
+		input    model.CreateWeatherAlertInput
+		putErr   error
+		wantErr  bool
+		wantCode string
+	}{
+		{
+			name:  "正常系: メタデータとアラートを保存",
+			input: valid,
+		},
+		{
+			name:     "異常系: 不正な重要度",
+			input:    withInput(func(in *model.CreateWeatherAlertInput) { in.Severity = "severe" }),
+			wantErr:  true,
+			wantCode: errcode.BadUserInput,
+		},
+		{
+			name:     "異常系: 不正な発表日時",
+			input:    withInput(func(in *model.CreateWeatherAlertInput) { in.IssuedAt = "yesterday" }),
+			wantErr:  true,
+			wantCode: errcode.BadUserInput,
+		},
+		{
+			name:     "異常系: rawDataがJSONオブジェクトではない",
+			input:    withInput(func(in *model.CreateWeatherAlertInput) { in.RawData = &invalidRawData }),
+			wantErr:  true,
+			wantCode: errcode.BadUserInput,
+		},
+		{
+			name:    "異常系: アラート本体の保存に失敗",
+			input:   valid,
+			putErr:  repository.Unavailable("failed to save weather alert", errors.New("firestore down")),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := &mockWeatherAlertMetadataRepository{}
+			alerts := &mockWeatherAlertRepository{putErr: tt.putErr}
+			resolver := NewResolver(nil, nil, nil, nil, metadata, alerts, memory.NewMemoryTxManager(), nil, nil, nil)
+
+			got, err := resolver.Mutation().CreateWeatherAlert(context.Background(), tt.input)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.wantCode != "" {
+					assert.Equal(t, tt.wantCode, errorCode(err))
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, got.ID)
+			assert.Equal(t, "2024-01-15T12:00:00+09:00", got.IssuedAt)
+			assert.JSONEq(t, rawData, got.RawData)
+			assert.Equal(t, []string{}, got.Recommendations)
+
+			if assert.Len(t, metadata.metadata, 1) && assert.Len(t, alerts.alerts, 1) {
+				assert.Equal(t, got.ID, metadata.metadata[0].ID)
+				assert.Equal(t, got.ID, alerts.alerts[0].ID)
+			}
+		})
+	}
+}
+
+func TestQueryResolver_Users_IncludeDeleted(t *testing.T) {
+	admin := &domain.User{ID: "admin1", Name: "Alice", Email: "alice@example.com", Roles: []string{"admin"}}
+	member := &domain.User{ID: "user1", Name: "Bob", Email: "bob@example.com", Roles: []string{"user"}}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantIDs  []string
+		wantCode string
+	}{
+		{
+			name:    "正常系: 管理者は削除済みユーザーも取得",
+			ctx:     auth.WithUser(context.Background(), admin),
+			wantIDs: []string{"admin1", "user1"},
+		},
+		{
+			name:     "異常系: 一般ユーザーは指定できない",
+			ctx:      auth.WithUser(context.Background(), member),
+			wantCode: errcode.Forbidden,
+		},
+		{
+			name:     "異常系: 未認証は指定できない",
+			ctx:      context.Background(),
+			wantCode: errcode.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := memory.NewMemoryUserRepository([]*domain.User{admin, member})
+			_, err := users.Delete(context.Background(), "user1")
+			assert.NoError(t, err)
+			resolver := NewResolver(nil, nil, nil, users, nil, nil, nil, nil, nil, nil)
+
+			got, err := resolver.Query().Users(tt.ctx, true)
+
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, errorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			ids := make([]string, len(got))
+			for i, u := range got {
+				ids[i] = u.ID
+			}
+			assert.ElementsMatch(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestMutationResolver_DeleteUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		users    []*domain.User
+		id       string
+		wantKind error
+		wantCode string
+	}{
+		{
+			name: "正常系: ユーザーを論理削除",
+			users: []*domain.User{
+				{ID: "admin1", Name: "Alice", Email: "alice@example.com", Roles: []string{"admin"}},
+				{ID: "user1", Name: "Bob", Email: "bob@example.com", Roles: []string{"user"}},
+			},
+			id: "user1",
+		},
+		{
+			name: "正常系: 他に管理者がいれば管理者を削除できる",
+			users: []*domain.User{
+				{ID: "admin1", Name: "Alice", Email: "alice@example.com", Roles: []string{"admin"}},
+				{ID: "admin2", Name: "Bob", Email: "bob@example.com", Roles: []string{"admin"}},
+			},
+			id: "admin2",
+		},
+		{
+			name: "異常系: 最後の管理者は削除できない",
+			users: []*domain.User{
+				{ID: "admin1", Name: "Alice", Email: "alice@example.com", Roles: []string{"admin"}},
+				{ID: "user1", Name: "Bob", Email: "bob@example.com", Roles: []string{"user"}},
+			},
+			id:       "admin1",
+			wantCode: errcode.BadUserInput,
+		},
+		{
+			name:     "異常系: ユーザーが見つからない",
+			users:    []*domain.User{},
+			id:       "nonexistent",
+			wantKind: repository.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := memory.NewMemoryUserRepository(tt.users)
+			resolver := NewResolver(nil, nil, nil, users, nil, nil, memory.NewMemoryTxManager(), nil, nil, nil)
+			ctx := auth.WithUser(context.Background(), &domain.User{ID: "admin1", Roles: []string{"admin"}})
+
+			got, err := resolver.Mutation().DeleteUser(ctx, tt.id)
+
+			if tt.wantKind != nil || tt.wantCode != "" {
+				assert.Error(t, err)
+				if tt.wantKind != nil {
+					assert.ErrorIs(t, err, tt.wantKind)
+				}
+				if tt.wantCode != "" {
+					assert.Equal(t, tt.wantCode, errorCode(err))
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, got.DeletedAt)
+			if assert.NotNil(t, got.UpdatedBy) {
+				assert.Equal(t, "admin1", *got.UpdatedBy)
+			}
+
+			_, err = users.GetByID(context.Background(), tt.id)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+		})
+	}
+}
+
+func TestMutationResolver_RestoreUser(t *testing.T) {
+	users := memory.NewMemoryUserRepository([]*domain.User{
+		{ID: "user1", Name: "Bob", Email: "bob@example.com", Roles: []string{"user"}},
+	})
+	resolver := NewResolver(nil, nil, nil, users, nil, nil, memory.NewMemoryTxManager(), nil, nil, nil)
+	ctx := context.Background()
+
+	_, err := resolver.Mutation().RestoreUser(ctx, "user1")
+	assert.ErrorIs(t, err, repository.ErrNotFound, "restoring a user that is not deleted")
+
+	_, err = users.Delete(ctx, "user1")
+	assert.NoError(t, err)
+
+	got, err := resolver.Mutation().RestoreUser(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Nil(t, got.DeletedAt)
+
+	_, err = users.GetByID(ctx, "user1")
+	assert.NoError(t, err)
+}
+
+func TestMutationResolver_DeleteAndRestoreWeatherAlert(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	metadata := memory.NewMemoryWeatherAlertMetadataRepository([]*domain.WeatherAlertMetadata{
+		{ID: "alert1", Region: "Tokyo", Severity: domain.SeverityWarning, IssuedAt: issuedAt, CreatedAt: issuedAt},
+	})
+	alerts := memory.NewMemoryWeatherAlertRepository([]*domain.WeatherAlert{
+		{ID: "alert1", Title: "Typhoon"},
+	})
+	resolver := NewResolver(nil, nil, nil, nil, metadata, alerts, memory.NewMemoryTxManager(), nil, nil, nil)
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: "admin1", Roles: []string{"admin"}})
+
+	deleted, err := resolver.Mutation().DeleteWeatherAlert(ctx, "alert1")
+	assert.NoError(t, err)
+	assert.NotNil(t, deleted.DeletedAt)
+	assert.Equal(t, "Typhoon", deleted.Title)
+
+	got, err := resolver.Query().WeatherAlerts(ctx, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	got, err = resolver.Query().WeatherAlerts(ctx, nil, nil, true)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	_, err = resolver.Mutation().DeleteWeatherAlert(ctx, "alert1")
+	assert.ErrorIs(t, err, repository.ErrNotFound, "deleting twice")
+
+	restored, err := resolver.Mutation().RestoreWeatherAlert(ctx, "alert1")
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+
+	got, err = resolver.Query().WeatherAlerts(ctx, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	_, err = resolver.Mutation().DeleteWeatherAlert(ctx, "nonexistent")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestMutationResolver_PostReply(t *testing.T) {
+	fixedTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		parentID string
+		content  string
+		wantKind error
+		wantCode string
+	}{
+		{name: "正常系: 返信を投稿", parentID: "msg1", content: "Hi"},
+		{name: "異常系: 本文が空", parentID: "msg1", content: "  ", wantCode: errcode.BadUserInput},
+		{name: "異常系: 返信への返信", parentID: "reply1", content: "Hi", wantCode: errcode.BadUserInput},
+		{name: "異常系: 親メッセージが見つからない", parentID: "nonexistent", content: "Hi", wantKind: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := memory.NewMemoryMessageRepository([]*domain.Message{
+				{ID: "msg1", Content: "Hello", Author: "Alice", CreatedAt: fixedTime},
+			})
+			if err := messages.Create(context.Background(), &domain.Message{ID: "reply1", Content: "Re", Author: "Alice", ParentID: "msg1", CreatedAt: fixedTime}); err != nil {
+				t.Fatal(err)
+			}
+			resolver := NewResolver(messages, nil, memory.NewMemorySearchRepository(), nil, nil, nil, memory.NewMemoryTxManager(), nil, nil, nil)
+			ctx := auth.WithUser(context.Background(), &domain.User{ID: "user2", Name: "Bob", Roles: []string{"user"}})
+
+			got, err := resolver.Mutation().PostReply(ctx, tt.parentID, tt.content, nil)
+
+			if tt.wantKind != nil || tt.wantCode != "" {
+				assert.Error(t, err)
+				if tt.wantKind != nil {
+					assert.ErrorIs(t, err, tt.wantKind)
+				}
+				if tt.wantCode != "" {
+					assert.Equal(t, tt.wantCode, errorCode(err))
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "Bob", got.Author)
+			assert.Equal(t, "user2", *got.AuthorID)
+			assert.Equal(t, "msg1", *got.ParentID)
+
+			parent, err := messages.GetByID(context.Background(), "msg1")
+			assert.NoError(t, err)
+			assert.Equal(t, 2, parent.ReplyCount)
+		})
+	}
+}
+
+func TestMessageResolver_Replies(t *testing.T) {
+	fixedTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	messages := memory.NewMemoryMessageRepository([]*domain.Message{
+		{ID: "msg1", Content: "Hello", Author: "Alice", CreatedAt: fixedTime},
+	})
+	for i, id := range []string{"reply1", "reply2", "reply3"} {
+		reply := &domain.Message{ID: id, Content: id, Author: "Bob", ParentID: "msg1", CreatedAt: fixedTime.Add(time.Duration(i) * time.Minute)}
+		if err := messages.Create(context.Background(), reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resolver := NewResolver(messages, nil, nil, nil, nil, nil, nil, nil, nil, nil).Message()
+	parent := &model.Message{ID: "msg1"}
+	first := int32(2)
+
+	t.Run("正常系: カーソルで次のページを取得", func(t *testing.T) {
+		page1, err := resolver.Replies(context.Background(), parent, &first, nil)
+		assert.NoError(t, err)
+		assert.Len(t, page1.Edges, 2)
+		assert.Equal(t, "reply1", page1.Edges[0].Node.ID)
+		assert.True(t, page1.PageInfo.HasNextPage)
+
+		page2, err := resolver.Replies(context.Background(), parent, &first, page1.PageInfo.EndCursor)
+		assert.NoError(t, err)
+		assert.Len(t, page2.Edges, 1)
+		assert.Equal(t, "reply3", page2.Edges[0].Node.ID)
+		assert.False(t, page2.PageInfo.HasNextPage)
+	})
+
+	t.Run("正常系: 返信がなければ空", func(t *testing.T) {
+		got, err := resolver.Replies(context.Background(), &model.Message{ID: "reply1"}, nil, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, got.Edges)
+		assert.Nil(t, got.PageInfo.EndCursor)
+	})
+
+	t.Run("異常系: firstが上限を超える", func(t *testing.T) {
+		tooMany := int32(maxPageSize + 1)
+		_, err := resolver.Replies(context.Background(), parent, &tooMany, nil)
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+	})
+
+	t.Run("異常系: 不正なカーソル", func(t *testing.T) {
+		cursor := "not-a-cursor"
+		_, err := resolver.Replies(context.Background(), parent, &first, &cursor)
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+	})
+}
+
+func TestMutationResolver_AddAndRemoveReaction(t *testing.T) {
+	fixedTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	messages := memory.NewMemoryMessageRepository([]*domain.Message{
+		{ID: "msg1", Content: "Hello", Author: "Alice", CreatedAt: fixedTime},
+	})
+	m := NewResolver(messages, nil, nil, nil, nil, nil, nil, nil, nil, nil).Mutation()
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: "user1", Roles: []string{"user"}})
+
+	t.Run("正常系: リアクションを追加", func(t *testing.T) {
+		got, err := m.AddReaction(ctx, "msg1", "👍")
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ReactionCounts{"👍": 1}, got.ReactionCounts)
+	})
+
+	t.Run("異常系: 同じ絵文字を重ねて追加", func(t *testing.T) {
+		_, err := m.AddReaction(ctx, "msg1", "👍")
+		assert.ErrorIs(t, err, repository.ErrConflict)
+	})
+
+	t.Run("異常系: 空白を含む絵文字", func(t *testing.T) {
+		_, err := m.AddReaction(ctx, "msg1", "👍 👍")
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+	})
+
+	t.Run("異常系: 存在しないメッセージ", func(t *testing.T) {
+		_, err := m.AddReaction(ctx, "nonexistent", "👍")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("正常系: リアクションを取り消し", func(t *testing.T) {
+		got, err := m.RemoveReaction(ctx, "msg1", "👍")
+		assert.NoError(t, err)
+		assert.Empty(t, got.ReactionCounts)
+	})
+
+	t.Run("異常系: リアクションしていない絵文字を取り消し", func(t *testing.T) {
+		_, err := m.RemoveReaction(ctx, "msg1", "👍")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("異常系: 空白を含む絵文字を取り消し", func(t *testing.T) {
+		_, err := m.RemoveReaction(ctx, "msg1", "👍 👍")
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+	})
+}
+
+func TestMessageResolver_Reactions(t *testing.T) {
+	fixedTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	messages := memory.NewMemoryMessageRepository([]*domain.Message{
+		{ID: "msg1", Content: "Hello", Author: "Alice", CreatedAt: fixedTime},
+	})
+	for _, r := range []*domain.Reaction{
+		{MessageID: "msg1", Emoji: "🎉", UserID: "user1"},
+		{MessageID: "msg1", Emoji: "👍", UserID: "user1"},
+		{MessageID: "msg1", Emoji: "👍", UserID: "user2"},
+	} {
+		if err := messages.AddReaction(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	msg, err := messages.GetByID(context.Background(), "msg1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewResolver(messages, nil, nil, nil, nil, nil, nil, nil, nil, nil).Message()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []*model.ReactionSummary
+	}{
+		{
+			name: "正常系: 件数の降順で自分のリアクションを示す",
+			ctx:  auth.WithUser(context.Background(), &domain.User{ID: "user2", Roles: []string{"user"}}),
+			want: []*model.ReactionSummary{
+				{Emoji: "👍", Count: 2, ViewerHasReacted: true},
+				{Emoji: "🎉", Count: 1, ViewerHasReacted: false},
+			},
+		},
+		{
+			name: "正常系: 未認証ではリアクション済みにならない",
+			ctx:  context.Background(),
+			want: []*model.ReactionSummary{
+				{Emoji: "👍", Count: 2, ViewerHasReacted: false},
+				{Emoji: "🎉", Count: 1, ViewerHasReacted: false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Reactions(tt.ctx, toModelMessage(msg))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("正常系: ページのメッセージのリアクションを1回で取得", func(t *testing.T) {
+		if err := messages.Create(context.Background(), &domain.Message{ID: "msg2", Content: "World", Author: "Bob", CreatedAt: fixedTime}); err != nil {
+			t.Fatal(err)
+		}
+		if err := messages.AddReaction(context.Background(), &domain.Reaction{MessageID: "msg2", Emoji: "👀", UserID: "user2"}); err != nil {
+			t.Fatal(err)
+		}
+		page, err := messages.Scan(context.Background(), repository.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		counting := &countingMessageRepository{MessageRepository: messages}
+		resolver := NewResolver(counting, nil, nil, nil, nil, nil, nil, nil, nil, nil).Message()
+
+		ctx := auth.WithUser(context.Background(), &domain.User{ID: "user2", Roles: []string{"user"}})
+		var reacted []string
+		for _, edge := range toMessageConnection(page).Edges {
+			got, err := resolver.Reactions(ctx, edge.Node)
+			assert.NoError(t, err)
+			for _, summary := range got {
+				if summary.ViewerHasReacted {
+					reacted = append(reacted, edge.Node.ID+summary.Emoji)
+				}
+			}
+		}
+		assert.Equal(t, []string{"msg1👍", "msg2👀"}, reacted)
+		assert.Equal(t, 1, counting.userReactions)
+	})
+}
+
+// countingMessageRepository counts the calls to UserReactions.
+type countingMessageRepository struct {
+	repository.MessageRepository
+	userReactions int
+}
+
+func (r *countingMessageRepository) UserReactions(ctx context.Context, messageIDs []string, userID string) (map[string][]string, error) {
+	r.userReactions++
+	return r.MessageRepository.UserReactions(ctx, messageIDs, userID)
+}
+
+// newChannelResolver returns a resolver backed by in-memory repositories with
+// users alice (admin), bob and carol, and channel ch1 created by alice with
+// alice and bob as members.
+func newChannelResolver(t *testing.T) (*Resolver, *memory.MemoryMessageRepository) {
+	t.Helper()
+	fixedTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	users := memory.NewMemoryUserRepository([]*domain.User{
+		{ID: "alice", Name: "Alice", Handle: "alice", Roles: []string{"admin"}, CreatedAt: fixedTime},
+		{ID: "bob", Name: "Bob", Handle: "bob", Roles: []string{"user"}, CreatedAt: fixedTime},
+		{ID: "carol", Name: "Carol", Handle: "carol", Roles: []string{"user"}, CreatedAt: fixedTime},
+	})
+	channels := memory.NewMemoryChannelRepository(users)
+	if err := channels.Create(context.Background(), &domain.Channel{
+		ID: "ch1", Name: "general", MemberIDs: []string{"alice", "bob"}, CreatedBy: "alice", CreatedAt: fixedTime,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	messages := memory.NewMemoryMessageRepository([]*domain.Message{
+		{ID: "msg1", Content: "Hello", Author: "Alice", ChannelID: "ch1", CreatedAt: fixedTime},
+	})
+	tx := memory.NewMemoryTxManager(users, channels, messages)
+	return NewResolver(messages, channels, memory.NewMemorySearchRepository(), users, nil, nil, tx, nil, nil, nil), messages
+}
+
+// failingMarkReadChannelRepository fails every MarkRead.
+type failingMarkReadChannelRepository struct {
+	repository.ChannelRepository
+}
+
+func (r failingMarkReadChannelRepository) MarkRead(ctx context.Context, channelID, userID string, readAt time.Time) error {
+	return repository.Unavailable("failed to mark channel read", errors.New("connection reset"))
+}
+
+func channelUser(id string, roles ...string) context.Context {
+	return auth.WithUser(context.Background(), &domain.User{ID: id, Name: id, Roles: roles})
+}
+
+func TestQueryResolver_Channels(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []string
+	}{
+		{name: "正常系: メンバーのチャンネルのみ", ctx: channelUser("bob", "user"), want: []string{"ch1"}},
+		{name: "正常系: メンバーでなければ空", ctx: channelUser("carol", "user"), want: []string{}},
+		{name: "正常系: 管理者はすべてのチャンネル", ctx: channelUser("dave", "admin"), want: []string{"ch1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, _ := newChannelResolver(t)
+			got, err := resolver.Query().Channels(tt.ctx, false)
+			assert.NoError(t, err)
+			ids := make([]string, len(got))
+			for i, c := range got {
+				ids[i] = c.ID
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestQueryResolver_ChannelAccess(t *testing.T) {
+	resolver, _ := newChannelResolver(t)
+
+	t.Run("正常系: メンバーはチャンネルとメッセージを取得", func(t *testing.T) {
+		ctx := channelUser("bob", "user")
+		channel, err := resolver.Query().Channel(ctx, "ch1")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob"}, channel.MemberIds)
+
+		msg, err := resolver.Query().Message(ctx, "msg1")
+		assert.NoError(t, err)
+		assert.Equal(t, "ch1", *msg.ChannelID)
+	})
+
+	t.Run("正常系: 存在しないチャンネルはnull", func(t *testing.T) {
+		got, err := resolver.Query().Channel(channelUser("bob", "user"), "nonexistent")
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("異常系: メンバー以外はアクセスできない", func(t *testing.T) {
+		ctx := channelUser("carol", "user")
+		_, err := resolver.Query().Channel(ctx, "ch1")
+		assert.Equal(t, errcode.Forbidden, errorCode(err))
+		_, err = resolver.Query().Message(ctx, "msg1")
+		assert.Equal(t, errcode.Forbidden, errorCode(err))
+		_, err = resolver.Mutation().AddReaction(ctx, "msg1", "👍")
+		assert.Equal(t, errcode.Forbidden, errorCode(err))
+		_, err = resolver.Mutation().PostReply(ctx, "msg1", "Hi", nil)
+		assert.Equal(t, errcode.Forbidden, errorCode(err))
+	})
+
+	t.Run("異常系: 未認証ではメッセージを取得できない", func(t *testing.T) {
+		_, err := resolver.Query().Message(context.Background(), "msg1")
+		assert.Equal(t, errcode.Unauthenticated, errorCode(err))
+	})
+}
+
+func TestMutationResolver_CreateChannel(t *testing.T) {
+	topic := "雑談"
+
+	tests := []struct {
+		name        string
+		input       model.CreateChannelInput
+		wantMembers []string
+		wantKind    error
+		wantCode    string
+	}{
+		{
+			name:        "正常系: 作成者をメンバーに加える",
+			input:       model.CreateChannelInput{Name: " random ", Topic: &topic, MemberIds: []string{"carol", "bob", "carol"}},
+			wantMembers: []string{"bob", "carol"},
+		},
+		{name: "異常系: 名前が空", input: model.CreateChannelInput{Name: " "}, wantCode: errcode.BadUserInput},
+		{name: "異常系: 名前が重複", input: model.CreateChannelInput{Name: "general"}, wantKind: repository.ErrConflict},
+		{name: "異常系: 存在しないユーザー", input: model.CreateChannelInput{Name: "random", MemberIds: []string{"nonexistent"}}, wantKind: repository.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, _ := newChannelResolver(t)
+
+			got, err := resolver.Mutation().CreateChannel(channelUser("bob", "user"), tt.input)
+
+			if tt.wantKind != nil {
+				assert.ErrorIs(t, err, tt.wantKind)
+				return
+			}
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, errorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "random", got.Name)
+			assert.Equal(t, "雑談", got.Topic)
+			assert.Equal(t, tt.wantMembers, got.MemberIds)
+			assert.Equal(t, "bob", *got.CreatedBy)
+		})
+	}
+}
+
+func TestMutationResolver_ArchiveChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantCode string
+	}{
+		{name: "正常系: 作成者がアーカイブ", ctx: channelUser("alice", "admin")},
+		{name: "正常系: 管理者がアーカイブ", ctx: channelUser("dave", "admin")},
+		{name: "異常系: 作成者以外のメンバー", ctx: channelUser("bob", "user"), wantCode: errcode.Forbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, _ := newChannelResolver(t)
+
+			got, err := resolver.Mutation().ArchiveChannel(tt.ctx, "ch1")
+
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, errorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, got.ArchivedAt)
+
+			_, err = resolver.Mutation().PostMessage(channelUser("bob", "user"), "ch1", "Hi", nil)
+			assert.Equal(t, errcode.BadUserInput, errorCode(err))
+			_, err = resolver.Mutation().PostReply(channelUser("bob", "user"), "msg1", "Hi", nil)
+			assert.Equal(t, errcode.BadUserInput, errorCode(err))
+		})
+	}
+}
+
+func TestMutationResolver_PostMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		channelID string
+		content   string
+		wantKind  error
+		wantCode  string
+	}{
+		{name: "正常系: メンバーが投稿", ctx: channelUser("bob", "user"), channelID: "ch1", content: "Hi"},
+		{name: "異常系: 本文が空", ctx: channelUser("bob", "user"), channelID: "ch1", content: " ", wantCode: errcode.BadUserInput},
+		{name: "異常系: メンバー以外", ctx: channelUser("carol", "user"), channelID: "ch1", content: "Hi", wantCode: errcode.Forbidden},
+		{name: "異常系: チャンネルが見つからない", ctx: channelUser("bob", "user"), channelID: "nonexistent", content: "Hi", wantKind: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, _ := newChannelResolver(t)
+
+			got, err := resolver.Mutation().PostMessage(tt.ctx, tt.channelID, tt.content, nil)
+
+			if tt.wantKind != nil || tt.wantCode != "" {
+				assert.Error(t, err)
+				if tt.wantKind != nil {
+					assert.ErrorIs(t, err, tt.wantKind)
+				}
+				if tt.wantCode != "" {
+					assert.Equal(t, tt.wantCode, errorCode(err))
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "ch1", *got.ChannelID)
+
+			page, err := resolver.Channel().Messages(tt.ctx, &model.Channel{ID: "ch1"}, nil, nil)
+			assert.NoError(t, err)
+			assert.Len(t, page.Edges, 2)
+			assert.Equal(t, got.ID, page.Edges[0].Node.ID)
+
+			top, err := resolver.Query().Messages(tt.ctx)
+			assert.NoError(t, err)
+			assert.Empty(t, top)
+		})
+	}
+}
+
+func TestChannelResolver_Members(t *testing.T) {
+	resolver, _ := newChannelResolver(t)
+
+	got, err := resolver.Channel().Members(context.Background(), &model.Channel{ID: "ch1", MemberIds: []string{"alice", "nonexistent"}})
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "Alice", got[0].Name)
+
+	t.Run("正常系: メンバーをまとめて取得し、メンバーの順に返す", func(t *testing.T) {
+		users := &mockUserRepository{
+			users:  []*domain.User{{ID: "alice", Name: "Alice"}, {ID: "bob", Name: "Bob"}},
+			getErr: errors.New("GetByID must not be called"),
+		}
+		resolver := NewResolver(nil, nil, nil, users, nil, nil, nil, nil, nil, nil)
+
+		got, err := resolver.Channel().Members(context.Background(), &model.Channel{ID: "ch1", MemberIds: []string{"bob", "alice"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Bob", "Alice"}, []string{got[0].Name, got[1].Name})
+	})
+}
+
+func TestMutationResolver_EditMessage(t *testing.T) {
+	fixedTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		id         string
+		content    string
+		wantEdited bool
+		wantKind   error
+		wantCode   string
+	}{
+		{name: "正常系: 作成者が編集", ctx: channelUser("user1", "user"), id: "msg1", content: "Edited", wantEdited: true},
+		{name: "正常系: 本文が同じなら編集しない", ctx: channelUser("user1", "user"), id: "msg1", content: "Hello"},
+		{name: "異常系: 作成者以外", ctx: channelUser("user2", "admin"), id: "msg1", content: "Edited", wantCode: errcode.Forbidden},
+		{name: "異常系: 作成者が不明", ctx: channelUser("user1", "user"), id: "legacy", content: "Edited", wantCode: errcode.Forbidden},
+		{name: "異常系: 本文が空", ctx: channelUser("user1", "user"), id: "msg1", content: " ", wantCode: errcode.BadUserInput},
+		{name: "異常系: メッセージが見つからない", ctx: channelUser("user1", "user"), id: "nonexistent", content: "Edited", wantKind: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := memory.NewMemoryMessageRepository([]*domain.Message{
+				{ID: "msg1", Content: "Hello", Author: "Alice", AuthorID: "user1", CreatedAt: fixedTime},
+				{ID: "legacy", Content: "Hello", Author: "Alice", CreatedAt: fixedTime},
+			})
+			resolver := NewResolver(messages, nil, memory.NewMemorySearchRepository(), nil, nil, nil, memory.NewMemoryTxManager(), nil, nil, nil)
+
+			got, err := resolver.Mutation().EditMessage(tt.ctx, tt.id, tt.content)
+
+			if tt.wantKind != nil || tt.wantCode != "" {
+				assert.Error(t, err)
+				if tt.wantKind != nil {
+					assert.ErrorIs(t, err, tt.wantKind)
+				}
+				if tt.wantCode != "" {
+					assert.Equal(t, tt.wantCode, errorCode(err))
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.content, got.Content)
+			assert.Equal(t, tt.wantEdited, got.Edited)
+
+			history, err := messages.History(context.Background(), tt.id)
+			assert.NoError(t, err)
+			if tt.wantEdited {
+				assert.Len(t, history, 1)
+				assert.Equal(t, "Hello", history[0].Content)
+				assert.Equal(t, "user1", history[0].EditorID)
+			} else {
+				assert.Empty(t, history)
+			}
+		})
+	}
+}
+
+func TestMessageResolver_History(t *testing.T) {
+	fixedTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	messages := memory.NewMemoryMessageRepository([]*domain.Message{
+		{ID: "msg1", Content: "Hello", Author: "Alice", AuthorID: "user1", CreatedAt: fixedTime},
+	})
+	if err := messages.Edit(context.Background(), "msg1", "Edited", nil, nil, "user1", fixedTime.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := messages.GetByID(context.Background(), "msg1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewResolver(messages, nil, nil, nil, nil, nil, nil, nil, nil, nil).Message()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantCode string
+	}{
+		{name: "正常系: 作成者は履歴を取得", ctx: channelUser("user1", "user")},
+		{name: "正常系: 管理者は履歴を取得", ctx: channelUser("user2", "admin")},
+		{name: "異常系: 作成者以外", ctx: channelUser("user2", "user"), wantCode: errcode.Forbidden},
+		{name: "異常系: 未認証", ctx: context.Background(), wantCode: errcode.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.History(tt.ctx, toModelMessage(msg))
+
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, errorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []*model.MessageEdit{
+				{Content: "Hello", EditorID: "user1", EditedAt: "2023-01-01T01:00:00Z"},
+			}, got)
+		})
+	}
+}
+
+func TestQueryResolver_SearchMessages(t *testing.T) {
+	fixedTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	resolver, messages := newChannelResolver(t)
+	ctx := context.Background()
+	for _, msg := range []*domain.Message{
+		{ID: "top1", Content: "東京タワーに行きました", Author: "Carol", CreatedAt: fixedTime.Add(time.Hour)},
+		{ID: "ch-msg", Content: "明日は東京で会議", Author: "Bob", ChannelID: "ch1", CreatedAt: fixedTime.Add(2 * time.Hour)},
+		{ID: "reply1", Content: "Tokyo is great", Author: "Alice", ParentID: "top1", CreatedAt: fixedTime.Add(3 * time.Hour)},
+	} {
+		if err := messages.Create(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := search.Reindex(ctx, messages, resolver.searchRepo, resolver.tx); err != nil {
+		t.Fatal(err)
+	}
+	q := resolver.Query()
+	nodeIDs := func(conn *model.SearchResultConnection) []string {
+		ids := make([]string, len(conn.Edges))
+		for i, e := range conn.Edges {
+			ids[i] = e.Node.ID
+		}
+		return ids
+	}
+
+	t.Run("正常系: メンバーはチャンネルのメッセージも検索できる", func(t *testing.T) {
+		got, err := q.SearchMessages(channelUser("bob", "user"), "東京", nil, nil, nil, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ch-msg", "top1"}, nodeIDs(got))
+		assert.Equal(t, []*model.HighlightSegment{
+			{Text: "明日は"}, {Text: "東京", Matched: true}, {Text: "で会議"},
+		}, got.Edges[0].Highlights)
+	})
+
+	t.Run("正常系: メンバー以外と未認証はチャンネル外のみ", func(t *testing.T) {
+		for _, ctx := range []context.Context{channelUser("carol", "user"), context.Background()} {
+			got, err := q.SearchMessages(ctx, "東京", nil, nil, nil, nil, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"top1"}, nodeIDs(got))
+		}
+	})
+
+	t.Run("正常系: 返信を大文字小文字を区別せず検索", func(t *testing.T) {
+		got, err := q.SearchMessages(ctx, "TOKYO", nil, nil, nil, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"reply1"}, nodeIDs(got))
+	})
+
+	t.Run("正常系: 投稿者と期間で絞り込み", func(t *testing.T) {
+		author, from := "Bob", "2023-01-01T02:00:00Z"
+		got, err := q.SearchMessages(channelUser("dave", "admin"), "東京", &author, &from, nil, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ch-msg"}, nodeIDs(got))
+	})
+
+	t.Run("正常系: 投稿後に索引を更新", func(t *testing.T) {
+		bob := channelUser("bob", "user")
+		posted, err := resolver.Mutation().PostMessage(bob, "ch1", "大阪に出張", nil)
+		assert.NoError(t, err)
+
+		got, err := q.SearchMessages(bob, "大阪", nil, nil, nil, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{posted.ID}, nodeIDs(got))
+	})
+
+	t.Run("正常系: カーソルで次のページを取得", func(t *testing.T) {
+		first := int32(1)
+		bob := channelUser("bob", "user")
+		page1, err := q.SearchMessages(bob, "東京", nil, nil, nil, &first, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ch-msg"}, nodeIDs(page1))
+		assert.True(t, page1.PageInfo.HasNextPage)
+
+		page2, err := q.SearchMessages(bob, "東京", nil, nil, nil, &first, page1.PageInfo.EndCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"top1"}, nodeIDs(page2))
+		assert.False(t, page2.PageInfo.HasNextPage)
+	})
+
+	t.Run("異常系: 検索語がない", func(t *testing.T) {
+		_, err := q.SearchMessages(ctx, " !? ", nil, nil, nil, nil, nil)
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+	})
+
+	t.Run("異常系: 不正な日時", func(t *testing.T) {
+		to := "yesterday"
+		_, err := q.SearchMessages(ctx, "東京", nil, nil, &to, nil, nil)
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+	})
+}
+
+func TestMutationResolver_PostMessageAttachments(t *testing.T) {
+	upload := func(name, content string) *graphql.Upload {
+		return &graphql.Upload{File: strings.NewReader(content), Filename: name, Size: int64(len(content)), ContentType: "text/plain"}
+	}
+	newResolver := func(t *testing.T) (*Resolver, string) {
+		resolver, _ := newChannelResolver(t)
+		dir := t.TempDir()
+		blobs, err := blob.NewLocalStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resolver.attachments = attachment.NewStore(blobs, 5)
+		resolver.attachmentURLs = attachment.NewSigner([]byte("secret"), time.Minute)
+		return resolver, dir
+	}
+	storedFiles := func(t *testing.T, dir string) int {
+		entries, err := os.ReadDir(filepath.Join(dir, "attachments"))
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+	bob := channelUser("bob", "user")
+
+	t.Run("正常系: 添付ファイルを投稿順に保存", func(t *testing.T) {
+		resolver, dir := newResolver(t)
+
+		got, err := resolver.Mutation().PostMessage(bob, "ch1", "files", []*graphql.Upload{
+			upload(`C:\Users\bob\memo.txt`, "hello"), upload("b.txt", ""),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got.Attachments, 2)
+		assert.Equal(t, "memo.txt", got.Attachments[0].Name)
+		assert.Equal(t, int32(5), got.Attachments[0].Size)
+		assert.Equal(t, "text/plain", got.Attachments[0].ContentType)
+		assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", got.Attachments[0].Checksum)
+		assert.Equal(t, "b.txt", got.Attachments[1].Name)
+		assert.Equal(t, 2, storedFiles(t, dir))
+
+		url, err := resolver.Attachment().URL(bob, got.Attachments[0])
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(url, "/attachments/"+got.ID+"/"+got.Attachments[0].ID+"?"), url)
+
+		msg, err := resolver.Query().Message(bob, got.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, got.Attachments, msg.Attachments)
+	})
+
+	t.Run("正常系: 返信に添付", func(t *testing.T) {
+		resolver, dir := newResolver(t)
+
+		got, err := resolver.Mutation().PostReply(bob, "msg1", "reply", []*graphql.Upload{upload("a.txt", "hi")})
+		assert.NoError(t, err)
+		assert.Len(t, got.Attachments, 1)
+		assert.Equal(t, 1, storedFiles(t, dir))
+	})
+
+	t.Run("異常系: 上限を超えるファイルがあれば何も保存しない", func(t *testing.T) {
+		resolver, dir := newResolver(t)
+
+		_, err := resolver.Mutation().PostMessage(bob, "ch1", "files", []*graphql.Upload{
+			upload("a.txt", "hello"), upload("big.txt", "hello!"),
+		})
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+		assert.Equal(t, 0, storedFiles(t, dir))
+	})
+
+	t.Run("異常系: ファイル名が空", func(t *testing.T) {
+		resolver, dir := newResolver(t)
+
+		_, err := resolver.Mutation().PostMessage(bob, "ch1", "files", []*graphql.Upload{upload("dir/", "x")})
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+		assert.Equal(t, 0, storedFiles(t, dir))
+	})
+
+	t.Run("異常系: ファイル数が多すぎる", func(t *testing.T) {
+		resolver, _ := newResolver(t)
+		uploads := make([]*graphql.Upload, MaxAttachments+1)
+		for i := range uploads {
+			uploads[i] = upload(fmt.Sprintf("%d.txt", i), "x")
+		}
+
+		_, err := resolver.Mutation().PostMessage(bob, "ch1", "files", uploads)
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+	})
+
+	t.Run("異常系: メンバー以外は保存前に拒否", func(t *testing.T) {
+		resolver, dir := newResolver(t)
+
+		_, err := resolver.Mutation().PostMessage(channelUser("carol", "user"), "ch1", "files", []*graphql.Upload{upload("a.txt", "x")})
+		assert.Equal(t, errcode.Forbidden, errorCode(err))
+		assert.Equal(t, 0, storedFiles(t, dir))
+	})
+}
+
+func TestMutationResolver_MarkRead(t *testing.T) {
+	resolver, _ := newChannelResolver(t)
+	alice, bob := channelUser("alice", "admin"), channelUser("bob", "user")
+	unread := func(t *testing.T, ctx context.Context) *model.Channel {
+		t.Helper()
+		channels, err := resolver.Query().Channels(ctx, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, channels, 1)
+		return channels[0]
+	}
+	for _, content := range []string{"one", "two"} {
+		if _, err := resolver.Mutation().PostMessage(bob, "ch1", content, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("正常系: 他人の投稿だけが未読になる", func(t *testing.T) {
+		got := unread(t, alice)
+		assert.Equal(t, int32(2), got.UnreadCount)
+		assert.Nil(t, got.LastReadAt)
+
+		got = unread(t, bob)
+		assert.Equal(t, int32(0), got.UnreadCount)
+		assert.NotNil(t, got.LastReadAt)
+	})
+
+	t.Run("正常系: 既読にした後の投稿を数える", func(t *testing.T) {
+		got, err := resolver.Mutation().MarkRead(alice, "ch1")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(0), got.UnreadCount)
+		assert.NotNil(t, got.LastReadAt)
+
+		_, err = resolver.Mutation().PostMessage(bob, "ch1", "three", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), unread(t, alice).UnreadCount)
+
+		channel, err := resolver.Query().Channel(alice, "ch1")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), channel.UnreadCount)
+	})
+
+	t.Run("異常系: 未読数を更新できなければ投稿も保存しない", func(t *testing.T) {
+		channels := resolver.channelRepo
+		resolver.channelRepo = failingMarkReadChannelRepository{channels}
+		_, err := resolver.Mutation().PostMessage(bob, "ch1", "lost", nil)
+		resolver.channelRepo = channels
+		assert.ErrorIs(t, err, repository.ErrUnavailable)
+
+		assert.Equal(t, int32(1), unread(t, alice).UnreadCount)
+		page, err := resolver.Channel().Messages(alice, &model.Channel{ID: "ch1"}, nil, nil)
+		assert.NoError(t, err)
+		assert.Len(t, page.Edges, 4)
+	})
+
+	t.Run("異常系: メンバー以外", func(t *testing.T) {
+		_, err := resolver.Mutation().MarkRead(channelUser("carol", "user"), "ch1")
+		assert.Equal(t, errcode.Forbidden, errorCode(err))
+	})
+
+	t.Run("異常系: チャンネルが見つからない", func(t *testing.T) {
+		_, err := resolver.Mutation().MarkRead(bob, "nonexistent")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestMutationResolver_Mentions(t *testing.T) {
+	resolver, _ := newChannelResolver(t)
+	alice, bob := channelUser("alice", "admin"), channelUser("bob", "user")
+	mentionIDs := func(t *testing.T, ctx context.Context) []string {
+		t.Helper()
+		conn, err := resolver.Query().MyMentions(ctx, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, len(conn.Edges))
+		for i, edge := range conn.Edges {
+			ids[i] = edge.Node.ID
+		}
+		return ids
+	}
+	mentionedUsers := func(t *testing.T, msg *model.Message) []string {
+		t.Helper()
+		users, err := resolver.Message().Mentions(alice, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, len(users))
+		for i, u := range users {
+			ids[i] = u.ID
+		}
+		return ids
+	}
+
+	t.Run("正常系: 投稿と返信のメンションを解決", func(t *testing.T) {
+		msg, err := resolver.Mutation().PostMessage(bob, "ch1", "@BOB と @alice、@nobody も", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bob", "alice"}, mentionedUsers(t, msg))
+
+		reply, err := resolver.Mutation().PostReply(bob, msg.ID, "@alice 確認お願いします", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice"}, mentionedUsers(t, reply))
+
+		assert.Equal(t, []string{reply.ID, msg.ID}, mentionIDs(t, alice))
+		assert.Equal(t, []string{msg.ID}, mentionIDs(t, bob))
+	})
+
+	t.Run("正常系: 表示名ではなくハンドルで一意に解決", func(t *testing.T) {
+		if err := resolver.userRepo.Create(context.Background(), &domain.User{
+			ID: "dave", Name: "Alice", Handle: "dave.k", Email: "dave@example.com", Roles: []string{"admin"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		msg, err := resolver.Mutation().PostMessage(bob, "ch1", "@alice と @Dave.K", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice", "dave"}, mentionedUsers(t, msg))
+	})
+
+	t.Run("正常系: チャンネルを読めないユーザーはメンションしない", func(t *testing.T) {
+		msg, err := resolver.Mutation().PostMessage(bob, "ch1", "@carol 見えますか", nil)
+		assert.NoError(t, err)
+		assert.Empty(t, mentionedUsers(t, msg))
+		assert.Empty(t, mentionIDs(t, channelUser("carol", "user")))
+	})
+
+	t.Run("正常系: 編集でメンションを置き換える", func(t *testing.T) {
+		msg, err := resolver.Mutation().PostMessage(bob, "ch1", "@alice", nil)
+		assert.NoError(t, err)
+		edited, err := resolver.Mutation().EditMessage(bob, msg.ID, "@bob")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bob"}, mentionedUsers(t, edited))
+		assert.NotContains(t, mentionIDs(t, alice), msg.ID)
+		assert.Contains(t, mentionIDs(t, bob), msg.ID)
+	})
+
+	t.Run("正常系: 読めなくなったチャンネルのメンションは除外", func(t *testing.T) {
+		if err := resolver.channelRepo.Create(context.Background(), &domain.Channel{
+			ID: "ch2", Name: "random", MemberIDs: []string{"bob"}, CreatedBy: "bob", CreatedAt: time.Now().UTC(),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		msg, err := resolver.Mutation().PostMessage(bob, "ch2", "@alice", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice"}, mentionedUsers(t, msg))
+		assert.Contains(t, mentionIDs(t, alice), msg.ID)
+		assert.NotContains(t, mentionIDs(t, channelUser("alice", "user")), msg.ID)
+	})
+
+	t.Run("正常系: 削除されたユーザーは除外してメンション順を保つ", func(t *testing.T) {
+		msg := &model.Message{ID: "m", MentionIDs: []string{"carol", "bob", "alice"}}
+		if _, err := resolver.userRepo.Delete(context.Background(), "carol"); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []string{"bob", "alice"}, mentionedUsers(t, msg))
+	})
+}
+
+func TestMutationResolver_Moderation(t *testing.T) {
+	resolver, _ := newChannelResolver(t)
+	resolver.moderation = moderation.NewPipeline(
+		moderation.WordList([]string{"spam"}, moderation.Flag),
+		moderation.WordList([]string{"ng"}, moderation.Reject),
+	)
+	alice, bob, mod := channelUser("alice", "admin"), channelUser("bob", "user"), channelUser("mod", "moderator")
+	channelMessageIDs := func(t *testing.T, ctx context.Context) []string {
+		t.Helper()
+		conn, err := resolver.Channel().Messages(ctx, &model.Channel{ID: "ch1"}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, len(conn.Edges))
+		for i, edge := range conn.Edges {
+			ids[i] = edge.Node.ID
+		}
+		return ids
+	}
+	// unreadCount is the unread count of ch1 for a user who never read it.
+	unreadCount := func(t *testing.T) int {
+		t.Helper()
+		reads, err := resolver.channelRepo.Reads(context.Background(), "nobody", []string{"ch1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reads["ch1"].UnreadCount
+	}
+	queueIDs := func(t *testing.T) []string {
+		t.Helper()
+		conn, err := resolver.Query().ModerationQueue(mod, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, len(conn.Edges))
+		for i, edge := range conn.Edges {
+			ids[i] = edge.Node.ID
+		}
+		return ids
+	}
+
+	t.Run("異常系: 拒否された投稿は保存しない", func(t *testing.T) {
+		_, err := resolver.Mutation().PostMessage(bob, "ch1", "NG ワード", nil)
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+		assert.Equal(t, []string{"msg1"}, channelMessageIDs(t, alice))
+	})
+
+	t.Run("正常系: 警告された投稿はモデレーター以外から隠す", func(t *testing.T) {
+		msg, err := resolver.Mutation().PostMessage(bob, "ch1", "spam です", nil)
+		assert.NoError(t, err)
+
+		state, err := resolver.Message().Moderation(bob, msg)
+		assert.NoError(t, err)
+		assert.Equal(t, model.ModerationStatusFlagged, state.Status)
+		assert.Len(t, state.Reasons, 1)
+		state, err = resolver.Message().Moderation(channelUser("carol", "user"), msg)
+		assert.NoError(t, err)
+		assert.Nil(t, state)
+
+		assert.Equal(t, []string{"msg1"}, channelMessageIDs(t, bob))
+		assert.Equal(t, []string{msg.ID, "msg1"}, channelMessageIDs(t, alice))
+		got, err := resolver.Query().Message(bob, msg.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+		_, err = resolver.Mutation().PostReply(bob, msg.ID, "返信", nil)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.Equal(t, []string{msg.ID}, queueIDs(t))
+
+		approved, err := resolver.Mutation().ApproveMessage(mod, msg.ID)
+		assert.NoError(t, err)
+		state, err = resolver.Message().Moderation(mod, approved)
+		assert.NoError(t, err)
+		assert.Equal(t, model.ModerationStatusApproved, state.Status)
+		assert.Equal(t, "mod", *state.ModeratorID)
+		assert.NotNil(t, state.ModeratedAt)
+		assert.Empty(t, queueIDs(t))
+		assert.Equal(t, []string{msg.ID, "msg1"}, channelMessageIDs(t, bob))
+
+		_, err = resolver.Mutation().RejectMessage(mod, msg.ID)
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+	})
+
+	t.Run("正常系: 却下された投稿は隠したまま", func(t *testing.T) {
+		msg, err := resolver.Mutation().PostMessage(bob, "ch1", "spam", nil)
+		assert.NoError(t, err)
+		_, err = resolver.Mutation().RejectMessage(mod, msg.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, queueIDs(t))
+		assert.NotContains(t, channelMessageIDs(t, bob), msg.ID)
+	})
+
+	t.Run("正常系: 編集で警告されるとキューに入る", func(t *testing.T) {
+		msg, err := resolver.Mutation().PostMessage(bob, "ch1", "こんにちは", nil)
+		assert.NoError(t, err)
+
+		_, err = resolver.Mutation().EditMessage(bob, msg.ID, "ng")
+		assert.Equal(t, errcode.BadUserInput, errorCode(err))
+
+		edited, err := resolver.Mutation().EditMessage(bob, msg.ID, "spam")
+		assert.NoError(t, err)
+		assert.Equal(t, "spam", edited.Content)
+		assert.Equal(t, []string{msg.ID}, queueIDs(t))
+		assert.NotContains(t, channelMessageIDs(t, bob), msg.ID)
+
+		// The message was counted when posted, so approving it does not count it again.
+		before := unreadCount(t)
+		_, err = resolver.Mutation().ApproveMessage(mod, msg.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, before, unreadCount(t))
+		assert.Contains(t, channelMessageIDs(t, bob), msg.ID)
+	})
+
+	t.Run("異常系: 同時に承認されると後の承認は記録しない", func(t *testing.T) {
+		msg, err := resolver.Mutation().PostMessage(bob, "ch1", "spam", nil)
+		assert.NoError(t, err)
+		before := unreadCount(t)
+		stale, err := resolver.messageRepo.GetByID(context.Background(), msg.ID)
+		assert.NoError(t, err)
+
+		_, err = resolver.Mutation().ApproveMessage(mod, msg.ID)
+		assert.NoError(t, err)
+		messages := resolver.messageRepo
+		resolver.messageRepo = &staleGetRepository{MessageRepository: messages, msg: stale}
+		defer func() { resolver.messageRepo = messages }()
+
+		_, err = resolver.Mutation().ApproveMessage(channelUser("mod2", "moderator"), msg.ID)
+		assert.ErrorIs(t, err, repository.ErrConflict)
+		assert.Equal(t, before+1, unreadCount(t))
+	})
+
+	t.Run("異常系: 編集に失敗すると警告もしない", func(t *testing.T) {
+		msg, err := resolver.Mutation().PostMessage(bob, "ch1", "おはよう", nil)
+		assert.NoError(t, err)
+		messages := resolver.messageRepo
+		resolver.messageRepo = &failingEditRepository{MessageRepository: messages}
+		defer func() { resolver.messageRepo = messages }()
+
+		_, err = resolver.Mutation().EditMessage(bob, msg.ID, "spam spam")
+		assert.Error(t, err)
+		assert.NotContains(t, queueIDs(t), msg.ID)
+		assert.Contains(t, channelMessageIDs(t, bob), msg.ID)
+	})
+}
+
+// staleGetRepository returns msg as it was read before another write.
+type staleGetRepository struct {
+	repository.MessageRepository
+	msg *domain.Message
+}
+
+func (r *staleGetRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	msg := *r.msg
+	moderation := *msg.Moderation
+	msg.Moderation = &moderation
+	return &msg, nil
+}
+
+// failingEditRepository fails every edit.
+type failingEditRepository struct {
+	repository.MessageRepository
+}
+
+func (r *failingEditRepository) Edit(ctx context.Context, id, content string, mentionIDs []string, moderation *domain.Moderation, editorID string, editedAt time.Time) error {
+	return repository.Unavailable("edit failed", nil)
+}
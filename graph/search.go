@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"context"
+	"log"
+
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/kuchida1981/graphql-sampleapp/internal/search"
+)
+
+// indexMessage updates the search index after msg was written. The message is
+// already stored at this point, so a failure is only logged; the reindex
+// command repairs the index.
+func (r *Resolver) indexMessage(ctx context.Context, msg *domain.Message) {
+	err := r.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+		return r.searchRepo.Index(ctx, search.NewDocument(msg))
+	})
+	if err != nil {
+		log.Printf("Failed to index message %s: %v", msg.ID, err)
+	}
+}
+
+// searchQuery builds the index query for the searchMessages arguments,
+// limited to the channels the current user can read.
+func (r *Resolver) searchQuery(ctx context.Context, query string, author, from, to *string) (repository.SearchQuery, error) {
+	q := repository.SearchQuery{Tokens: search.QueryTokens(query)}
+	if len(q.Tokens) == 0 {
+		return q, errcode.New(errcode.BadUserInput, "query must contain a word")
+	}
+	if author != nil {
+		q.Author = *author
+	}
+	if from != nil {
+		t, err := parseTime("from", *from)
+		if err != nil {
+			return q, err
+		}
+		q.From = &t
+	}
+	if to != nil {
+		t, err := parseTime("to", *to)
+		if err != nil {
+			return q, err
+		}
+		q.To = &t
+	}
+
+	user := auth.UserFromContext(ctx)
+	switch {
+	case user == nil:
+	case user.HasRole(domain.RoleAdmin):
+		q.AllChannels = true
+	default:
+		channels, err := r.channelRepo.List(ctx, repository.ChannelFilter{MemberID: user.ID, IncludeArchived: true})
+		if err != nil {
+			return q, err
+		}
+		for _, c := range channels {
+			q.ChannelIDs = append(q.ChannelIDs, c.ID)
+		}
+	}
+	return q, nil
+}
+
+// toSearchConnection loads the messages of a page of search results in one
+// call and highlights query in their content.
+func (r *Resolver) toSearchConnection(ctx context.Context, page *repository.SearchPage, query string) (*model.SearchResultConnection, error) {
+	conn := &model.SearchResultConnection{
+		Edges:    make([]*model.SearchResultEdge, 0, len(page.Documents)),
+		PageInfo: &model.PageInfo{HasNextPage: page.HasNextPage},
+	}
+	ids := make([]string, len(page.Documents))
+	for i, doc := range page.Documents {
+		ids[i] = doc.MessageID
+	}
+	var messages []*domain.Message
+	if len(ids) > 0 {
+		var err error
+		if messages, err = r.messageRepo.GetByIDs(ctx, ids); err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[string]*domain.Message, len(messages))
+	for _, msg := range messages {
+		byID[msg.ID] = msg
+	}
+	for _, doc := range page.Documents {
+		msg, ok := byID[doc.MessageID]
+		if !ok {
+			// The index is a projection and may still hold a message
+			// that has since been removed.
+			continue
+		}
+		if !visible(ctx, msg) {
+			continue
+		}
+		conn.Edges = append(conn.Edges, &model.SearchResultEdge{
+			Cursor:     encodeCursor(doc.CreatedAt, doc.MessageID),
+			Node:       toModelMessage(msg),
+			Highlights: toModelHighlights(search.Highlight(msg.Content, query)),
+		})
+	}
+	nodes := make([]*model.Message, len(conn.Edges))
+	for i, edge := range conn.Edges {
+		nodes[i] = edge.Node
+	}
+	batchReactions(nodes)
+	// The cursor follows the last document rather than the last edge, so
+	// that a page of skipped documents still moves forward.
+	if n := len(page.Documents); n > 0 {
+		cursor := encodeCursor(page.Documents[n-1].CreatedAt, page.Documents[n-1].MessageID)
+		conn.PageInfo.EndCursor = &cursor
+	}
+	return conn, nil
+}
+
+func toModelHighlights(segments []search.Segment) []*model.HighlightSegment {
+	result := make([]*model.HighlightSegment, len(segments))
+	for i, s := range segments {
+		result[i] = &model.HighlightSegment{Text: s.Text, Matched: s.Matched}
+	}
+	return result
+}
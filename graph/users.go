@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"context"
+	"slices"
+
+	"github.com/kuchida1981/graphql-sampleapp/graph/model"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// orderUsers orders found, the result of GetByIDs, as in ids. Users that no
+// longer exist are left out.
+func orderUsers(ids []string, found []*domain.User) []*model.User {
+	byID := make(map[string]*domain.User, len(found))
+	for _, user := range found {
+		byID[user.ID] = user
+	}
+	users := make([]*model.User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := byID[id]; ok {
+			users = append(users, toModelUser(user))
+		}
+	}
+	return users
+}
+
+// ensureOtherAdmin fails unless an active admin other than id exists. Run it
+// in a serializable transaction so that two concurrent changes cannot both
+// pass the check and leave no admin behind.
+func (r *Resolver) ensureOtherAdmin(ctx context.Context, id, action string) error {
+	users, err := r.userRepo.List(ctx, repository.UserFilter{})
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(users, func(u *domain.User) bool {
+		return u.ID != id && slices.Contains(u.Roles, domain.RoleAdmin)
+	}) {
+		return errcode.New(errcode.BadUserInput, "cannot "+action+" the last admin")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package attachment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/blob"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Save(t *testing.T) {
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	store := NewStore(blobs, 5)
+	ctx := context.Background()
+
+	t.Run("正常系: サイズとチェックサムを記録", func(t *testing.T) {
+		got, err := store.Save(ctx, "memo.txt", "", strings.NewReader("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, "memo.txt", got.Name)
+		assert.Equal(t, int64(5), got.Size)
+		assert.Equal(t, "application/octet-stream", got.ContentType)
+		assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", got.Checksum)
+		assert.Equal(t, "attachments/"+got.ID, got.BlobKey)
+
+		r, err := blobs.Open(ctx, got.BlobKey)
+		require.NoError(t, err)
+		r.Close()
+	})
+
+	t.Run("異常系: 上限を超えるファイル", func(t *testing.T) {
+		_, err := store.Save(ctx, "big.txt", "text/plain", strings.NewReader("hello!"))
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	file, err := NewStore(blobs, 1024).Save(ctx, "週報.txt", "text/plain; charset=utf-8", strings.NewReader("hello"))
+	require.NoError(t, err)
+	missing := domain.Attachment{ID: "gone", Name: "gone.txt", BlobKey: "attachments/gone"}
+	messages := memory.NewMemoryMessageRepository([]*domain.Message{
+		{ID: "msg1", Content: "files", Attachments: []domain.Attachment{*file, missing}},
+	})
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	signer := NewSigner([]byte("secret"), 15*time.Minute)
+	signer.now = func() time.Time { return now }
+	handler := NewHandler(signer, messages, blobs)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	t.Run("正常系: 署名付きURLから取得", func(t *testing.T) {
+		rec := serve(http.MethodGet, signer.URL("msg1", file.ID))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "hello", rec.Body.String())
+		assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "5", rec.Header().Get("Content-Length"))
+		assert.Equal(t, "attachment; filename*=utf-8''%E9%80%B1%E5%A0%B1.txt", rec.Header().Get("Content-Disposition"))
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	})
+
+	t.Run("正常系: HEADは本文を返さない", func(t *testing.T) {
+		rec := serve(http.MethodHead, signer.URL("msg1", file.ID))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Body.String())
+	})
+
+	t.Run("異常系: 期限切れ", func(t *testing.T) {
+		url := signer.URL("msg1", file.ID)
+		now = now.Add(15 * time.Minute)
+		defer func() { now = now.Add(-15 * time.Minute) }()
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, url).Code)
+	})
+
+	t.Run("異常系: 署名の改ざん", func(t *testing.T) {
+		other := NewSigner([]byte("other"), 15*time.Minute)
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, other.URL("msg1", file.ID)).Code)
+
+		url := strings.Replace(signer.URL("msg1", file.ID), "expires=", "expires=9", 1)
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, url).Code)
+	})
+
+	t.Run("異常系: 存在しない添付ファイル", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, signer.URL("msg1", "nope")).Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, signer.URL("nope", file.ID)).Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, signer.URL("msg1", "gone")).Code)
+	})
+
+	t.Run("異常系: GET以外のメソッド", func(t *testing.T) {
+		assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, signer.URL("msg1", file.ID)).Code)
+	})
+}
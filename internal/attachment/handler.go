@@ -0,0 +1,100 @@
+package attachment
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// Handler serves attachments at the URLs issued by a Signer. The signature
+// stands in for authorization, so requests need no bearer token.
+type Handler struct {
+	signer   *Signer
+	messages repository.MessageRepository
+	blobs    repository.BlobStore
+}
+
+func NewHandler(signer *Signer, messages repository.MessageRepository, blobs repository.BlobStore) *Handler {
+	return &Handler{signer: signer, messages: messages, blobs: blobs}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	messageID, attachmentID, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, PathPrefix), "/")
+	if !ok || messageID == "" || attachmentID == "" || strings.Contains(attachmentID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	if err := h.signer.verify(messageID, attachmentID, query.Get("expires"), query.Get("signature")); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	msg, err := h.messages.GetByID(r.Context(), messageID)
+	if err != nil {
+		writeError(w, r, "failed to get message", err)
+		return
+	}
+	attachment := findAttachment(msg, attachmentID)
+	if attachment == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := h.blobs.Open(r.Context(), attachment.BlobKey)
+	if err != nil {
+		writeError(w, r, "failed to open attachment", err)
+		return
+	}
+	defer body.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", attachment.ContentType)
+	header.Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	header.Set("ETag", `"`+attachment.Checksum+`"`)
+	header.Set("Cache-Control", "private")
+	// Uploaded files are untrusted; browsers must not render them as another
+	// type, such as HTML.
+	header.Set("X-Content-Type-Options", "nosniff")
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Attachments: Failed to send %s: %v", attachmentID, err)
+	}
+}
+
+func findAttachment(msg *domain.Message, id string) *domain.Attachment {
+	for i := range msg.Attachments {
+		if msg.Attachments[i].ID == id {
+			return &msg.Attachments[i]
+		}
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.NotFound(w, r)
+	case errors.Is(err, repository.ErrUnavailable):
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+	default:
+		log.Printf("Attachments: %s: %v", message, err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,66 @@
+package attachment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PathPrefix is where Handler serves attachments.
+const PathPrefix = "/attachments/"
+
+var (
+	errInvalidSignature = errors.New("invalid signature")
+	errExpired          = errors.New("URL has expired")
+)
+
+// Signer issues download URLs that expire after a fixed time. A URL grants
+// access to one attachment to whoever holds it, so it is only handed out to
+// users allowed to read the message.
+type Signer struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+func NewSigner(key []byte, ttl time.Duration) *Signer {
+	return &Signer{key: key, ttl: ttl, now: time.Now}
+}
+
+// URL returns the path under which the attachment can be downloaded until the
+// URL expires. It is relative to the server's address.
+func (s *Signer) URL(messageID, attachmentID string) string {
+	expires := strconv.FormatInt(s.now().Add(s.ttl).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.sign(messageID, attachmentID, expires)},
+	}
+	return PathPrefix + url.PathEscape(messageID) + "/" + url.PathEscape(attachmentID) + "?" + query.Encode()
+}
+
+// verify checks a signature issued by URL.
+func (s *Signer) verify(messageID, attachmentID, expires, signature string) error {
+	if !hmac.Equal([]byte(signature), []byte(s.sign(messageID, attachmentID, expires))) {
+		return errInvalidSignature
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expiry: %w", err)
+	}
+	if !s.now().Before(time.Unix(unix, 0)) {
+		return errExpired
+	}
+	return nil
+}
+
+func (s *Signer) sign(messageID, attachmentID, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	// The IDs never contain a slash, so the fields cannot run into each other.
+	mac.Write([]byte(messageID + "/" + attachmentID + "/" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,83 @@
+package attachment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+const defaultContentType = "application/octet-stream"
+
+// ErrTooLarge is returned by Save for a file larger than the store's limit.
+var ErrTooLarge = errors.New("attachment is too large")
+
+// Store saves the contents of attachments in a blob store.
+type Store struct {
+	blobs   repository.BlobStore
+	maxSize int64
+}
+
+// NewStore returns a Store that accepts files of up to maxSize bytes.
+func NewStore(blobs repository.BlobStore, maxSize int64) *Store {
+	return &Store{blobs: blobs, maxSize: maxSize}
+}
+
+func (s *Store) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Save writes the contents read from r and returns the attachment describing
+// them. The size and checksum are those of the bytes read, not what the
+// client claimed. Nothing is stored when r fails or exceeds the limit.
+func (s *Store) Save(ctx context.Context, name, contentType string, r io.Reader) (*domain.Attachment, error) {
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	id := uuid.NewString()
+	key := "attachments/" + id
+
+	hash := sha256.New()
+	body := &limitedReader{r: io.TeeReader(r, hash), remaining: s.maxSize}
+	if err := s.blobs.Put(ctx, key, body, contentType); err != nil {
+		return nil, err
+	}
+
+	return &domain.Attachment{
+		ID:          id,
+		Name:        name,
+		Size:        s.maxSize - body.remaining,
+		ContentType: contentType,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		BlobKey:     key,
+	}, nil
+}
+
+// Delete removes the contents of attachment.
+func (s *Store) Delete(ctx context.Context, attachment domain.Attachment) error {
+	return s.blobs.Delete(ctx, attachment.BlobKey)
+}
+
+// limitedReader fails with ErrTooLarge once more than remaining bytes have
+// been read, so that the blob store aborts the write.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		return 0, ErrTooLarge
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
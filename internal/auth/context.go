@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user. The user
+// is also recorded as the actor of any repository writes made with ctx.
+func WithUser(ctx context.Context, user *domain.User) context.Context {
+	ctx = repository.WithActor(ctx, user.ID)
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext returns the authenticated user, or nil for anonymous requests.
+func UserFromContext(ctx context.Context) *domain.User {
+	user, _ := ctx.Value(contextKey{}).(*domain.User)
+	return user
+}
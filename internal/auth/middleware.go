@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// Middleware authenticates requests carrying an "Authorization: Bearer" header
+// and stores the matching domain.User in the request context. Requests without
+// the header pass through anonymously; requests with an invalid token or an
+// unknown subject are rejected with an UNAUTHENTICATED GraphQL error, and a
+// failed user lookup is reported as UNAVAILABLE or INTERNAL_SERVER_ERROR.
+func Middleware(verifier *Verifier, users repository.UserRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scheme, token, ok := strings.Cut(header, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				reject(w, "authorization header must use the Bearer scheme")
+				return
+			}
+
+			subject, err := verifier.Verify(strings.TrimSpace(token))
+			if err != nil {
+				log.Printf("Auth: rejecting request: %v", err)
+				reject(w, "invalid or expired token")
+				return
+			}
+
+			user, err := users.GetByID(r.Context(), subject)
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				log.Printf("Auth: rejecting request: unknown subject %s", subject)
+				reject(w, "token subject is not a known user")
+				return
+			case errors.Is(err, repository.ErrUnavailable):
+				log.Printf("Auth: failed to resolve subject %s: %v", subject, err)
+				errcode.WriteHTTP(w, http.StatusServiceUnavailable, errcode.New(errcode.Unavailable, "service temporarily unavailable"))
+				return
+			case err != nil:
+				log.Printf("Auth: failed to resolve subject %s: %v", subject, err)
+				errcode.WriteHTTP(w, http.StatusInternalServerError, errcode.New(errcode.Internal, "internal server error"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+		})
+	}
+}
+
+func reject(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	errcode.WriteHTTP(w, http.StatusUnauthorized, errcode.New(errcode.Unauthenticated, message))
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeyID = "test-key"
+
+type mockUserRepository struct {
+	users map[string]*domain.User
+	errs  map[string]error
+}
+
+func (m *mockUserRepository) List(ctx context.Context, filter repository.UserFilter) ([]*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	if err, ok := m.errs[id]; ok {
+		return nil, err
+	}
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
+	return nil, repository.NotFoundf("user %s not found", id)
+}
+
+func (m *mockUserRepository) Create(ctx context.Context, user *domain.User) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockUserRepository) Update(ctx context.Context, user *domain.User) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockUserRepository) Delete(ctx context.Context, id string) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserRepository) GetByHandles(ctx context.Context, handles []string) ([]*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockUserRepository) Restore(ctx context.Context, id string) (*domain.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+// newTestVerifier writes a JWKS containing key's public half to a temp file
+// and loads it the same way the server does.
+func newTestVerifier(t *testing.T, key *rsa.PrivateKey, cfg config.AuthConfig) *Verifier {
+	t.Helper()
+
+	jwks := map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	raw, err := json.Marshal(jwks)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	cfg.JWKSFile = path
+	verifier, err := NewVerifier(context.Background(), cfg)
+	require.NoError(t, err)
+	return verifier
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestMiddleware(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	verifier := newTestVerifier(t, key, config.AuthConfig{Issuer: "https://issuer.example.com"})
+	users := &mockUserRepository{
+		users: map[string]*domain.User{
+			"user1": {ID: "user1", Name: "Alice"},
+		},
+		errs: map[string]error{
+			"down":   repository.Unavailable("failed to get user", errors.New("connection refused")),
+			"broken": errors.New("unexpected"),
+		},
+	}
+
+	validClaims := func(sub string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": sub,
+			"iss": "https://issuer.example.com",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantCode   string
+		wantUserID string
+	}{
+		{
+			name:       "正常系: ヘッダーなしは匿名で通過",
+			header:     "",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "正常系: 有効なトークン",
+			header:     "Bearer " + signToken(t, key, validClaims("user1")),
+			wantStatus: http.StatusOK,
+			wantUserID: "user1",
+		},
+		{
+			name:       "異常系: Bearer以外のスキーム",
+			header:     "Basic dXNlcjpwYXNz",
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "UNAUTHENTICATED",
+		},
+		{
+			name:       "異常系: 署名鍵が異なる",
+			header:     "Bearer " + signToken(t, otherKey, validClaims("user1")),
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "UNAUTHENTICATED",
+		},
+		{
+			name: "異常系: 期限切れ",
+			header: "Bearer " + signToken(t, key, jwt.MapClaims{
+				"sub": "user1",
+				"iss": "https://issuer.example.com",
+				"exp": time.Now().Add(-time.Hour).Unix(),
+			}),
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "UNAUTHENTICATED",
+		},
+		{
+			name: "異常系: 発行者が異なる",
+			header: "Bearer " + signToken(t, key, jwt.MapClaims{
+				"sub": "user1",
+				"iss": "https://evil.example.com",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}),
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "UNAUTHENTICATED",
+		},
+		{
+			name:       "異常系: 存在しないユーザー",
+			header:     "Bearer " + signToken(t, key, validClaims("ghost")),
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "UNAUTHENTICATED",
+		},
+		{
+			name:       "異常系: ユーザーリポジトリが利用不可",
+			header:     "Bearer " + signToken(t, key, validClaims("down")),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "UNAVAILABLE",
+		},
+		{
+			name:       "異常系: ユーザー取得で予期しないエラー",
+			header:     "Bearer " + signToken(t, key, validClaims("broken")),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "INTERNAL_SERVER_ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser *domain.User
+			handler := Middleware(verifier, users)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser = UserFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/query", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				var body struct {
+					Errors []struct {
+						Message    string         `json:"message"`
+						Extensions map[string]any `json:"extensions"`
+					} `json:"errors"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				require.Len(t, body.Errors, 1)
+				assert.Equal(t, tt.wantCode, body.Errors[0].Extensions["code"])
+				return
+			}
+
+			if tt.wantUserID == "" {
+				assert.Nil(t, gotUser)
+			} else {
+				require.NotNil(t, gotUser)
+				assert.Equal(t, tt.wantUserID, gotUser.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Verifier validates bearer JWTs against a JWKS and returns their subject.
+type Verifier struct {
+	keyfunc jwt.Keyfunc
+	parser  *jwt.Parser
+}
+
+// NewVerifier loads the JWKS from cfg.JWKSFile, or from cfg.JWKSURL in which
+// case the key set is refreshed in the background until ctx is cancelled.
+func NewVerifier(ctx context.Context, cfg config.AuthConfig) (*Verifier, error) {
+	var (
+		kf  keyfunc.Keyfunc
+		err error
+	)
+
+	switch {
+	case cfg.JWKSFile != "":
+		raw, readErr := os.ReadFile(cfg.JWKSFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", readErr)
+		}
+		kf, err = keyfunc.NewJWKSetJSON(raw)
+		log.Printf("Auth: loaded JWKS from file: %s", cfg.JWKSFile)
+	case cfg.JWKSURL != "":
+		kf, err = keyfunc.NewDefaultCtx(ctx, []string{cfg.JWKSURL})
+		log.Printf("Auth: using JWKS from URL: %s", cfg.JWKSURL)
+	default:
+		return nil, fmt.Errorf("no JWKS source configured")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWKS: %w", err)
+	}
+
+	return newVerifier(kf.Keyfunc, cfg), nil
+}
+
+func newVerifier(kf jwt.Keyfunc, cfg config.AuthConfig) *Verifier {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &Verifier{keyfunc: kf, parser: jwt.NewParser(opts...)}
+}
+
+// Verify checks the signature and standard claims of tokenString and returns
+// its "sub" claim.
+func (v *Verifier) Verify(tokenString string) (string, error) {
+	token, err := v.parser.Parse(tokenString, v.keyfunc)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, err := token.Claims.GetSubject()
+	if err != nil || subject == "" {
+		return "", fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return subject, nil
+}
@@ -0,0 +1,99 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	testBlobStore(t, store)
+
+	t.Run("異常系: ディレクトリ外を指すキー", func(t *testing.T) {
+		for _, key := range []string{"../escape", "/abs", "a/../../b", ""} {
+			err := store.Put(context.Background(), key, strings.NewReader("x"), "text/plain")
+			assert.ErrorIs(t, err, repository.ErrInvalid, key)
+		}
+	})
+}
+
+func TestEmulatorEndpoint(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "正常系: スキームなしはHTTP", host: "localhost:4443", want: "http://localhost:4443/storage/v1/"},
+		{name: "正常系: スキームを指定", host: "https://gcs.example.com/", want: "https://gcs.example.com/storage/v1/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, emulatorEndpoint(tt.host))
+		})
+	}
+}
+
+// TestGCSStore runs against the emulator at ATTACHMENT_GCS_EMULATOR_HOST,
+// e.g. fake-gcs-server.
+func TestGCSStore(t *testing.T) {
+	host := os.Getenv("ATTACHMENT_GCS_EMULATOR_HOST")
+	if host == "" {
+		t.Skip("ATTACHMENT_GCS_EMULATOR_HOST is not set")
+	}
+	ctx := context.Background()
+	bucket := fmt.Sprintf("contract-test-%d", time.Now().UnixNano())
+	store, err := NewGCSStore(ctx, config.AttachmentConfig{GCSBucket: bucket, GCSEmulatorHost: host})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	require.NoError(t, store.bucket.Create(ctx, "contract-test", nil))
+
+	testBlobStore(t, store)
+}
+
+func testBlobStore(t *testing.T, store repository.BlobStore) {
+	ctx := context.Background()
+
+	t.Run("正常系: 保存した内容を読み出す", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "attachments/a1", strings.NewReader("hello"), "text/plain"))
+
+		r, err := store.Open(ctx, "attachments/a1")
+		require.NoError(t, err)
+		defer r.Close()
+		got, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(got))
+	})
+
+	t.Run("正常系: 削除後は見つからない", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "attachments/a2", strings.NewReader("bye"), "text/plain"))
+		require.NoError(t, store.Delete(ctx, "attachments/a2"))
+		require.NoError(t, store.Delete(ctx, "attachments/a2"))
+
+		_, err := store.Open(ctx, "attachments/a2")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("異常系: 読み込みに失敗した内容は保存しない", func(t *testing.T) {
+		r := io.MultiReader(strings.NewReader("partial"), errReader{})
+		assert.Error(t, store.Put(ctx, "attachments/a3", r, "text/plain"))
+
+		_, err := store.Open(ctx, "attachments/a3")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
@@ -0,0 +1,86 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"google.golang.org/api/option"
+)
+
+// GCSStore keeps blobs as objects in a Cloud Storage bucket, or in a
+// GCS-compatible emulator.
+type GCSStore struct {
+	client *storage.Client
+	bucket *storage.BucketHandle
+}
+
+func NewGCSStore(ctx context.Context, cfg config.AttachmentConfig) (*GCSStore, error) {
+	var opts []option.ClientOption
+	if cfg.GCSEmulatorHost != "" {
+		opts = append(opts, option.WithEndpoint(emulatorEndpoint(cfg.GCSEmulatorHost)), option.WithoutAuthentication())
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Storing attachments in GCS bucket: %s", cfg.GCSBucket)
+	return &GCSStore{client: client, bucket: client.Bucket(cfg.GCSBucket)}, nil
+}
+
+// emulatorEndpoint returns the JSON API endpoint of an emulator at host, which
+// is served over plain HTTP unless host names another scheme.
+func emulatorEndpoint(host string) string {
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return strings.TrimSuffix(host, "/") + "/storage/v1/"
+}
+
+func (s *GCSStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *GCSStore) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	// Cancelling the context aborts the upload, so an object is only created
+	// when the writer is closed after a complete copy.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+func (s *GCSStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := s.bucket.Object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, repository.NotFoundf("blob %s not found", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return r, nil
+}
+
+func (s *GCSStore) Delete(ctx context.Context, key string) error {
+	err := s.bucket.Object(key).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
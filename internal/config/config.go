@@ -0,0 +1,602 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Storage backends selectable with STORAGE_BACKEND, SQL_BACKEND and MESSAGE_BACKEND.
+const (
+	BackendExternal  = "external"
+	BackendMemory    = "memory"
+	BackendFirestore = "firestore"
+	BackendPostgres  = "postgres"
+	BackendSQLite    = "sqlite"
+)
+
+// Attachment storage backends selectable with ATTACHMENT_BACKEND.
+const (
+	BlobBackendLocal = "local"
+	BlobBackendGCS   = "gcs"
+)
+
+// Actions a moderation rule takes on a message that breaks it.
+const (
+	ModerationFlag   = "flag"
+	ModerationReject = "reject"
+)
+
+const (
+	defaultPort          = "8080"
+	defaultSQLitePath    = "graphql-sampleapp.db"
+	defaultProjectID     = "demo-project"
+	defaultMaxDepth      = 10
+	defaultMaxComplexity = 1000
+	defaultAttachmentDir = "attachments"
+)
+
+// maxFirestoreRetentionChannels is the most channels that can have a retention
+// of their own with Firestore, which leaves them out of the default retention
+// with a not-in filter of at most 10 values.
+const maxFirestoreRetentionChannels = 10
+
+// Config holds the server settings read from environment variables.
+type Config struct {
+	Port        string
+	ProjectID   string
+	DatabaseURL string
+	// StorageBackend is BackendExternal (PostgreSQL and Firestore) or
+	// BackendMemory, which needs no services and loses data on restart.
+	StorageBackend string
+	// SQLBackend stores users and weather alert metadata in BackendPostgres
+	// or BackendSQLite when StorageBackend is BackendExternal.
+	SQLBackend string
+	SQLitePath string
+	// MessageBackend stores messages in BackendFirestore, BackendPostgres or
+	// BackendMemory. It defaults to Firestore, or memory when StorageBackend is.
+	MessageBackend string
+	// MemorySeedFile is an optional JSON fixture loaded into the memory backend.
+	MemorySeedFile string
+	// MigrateOnStart applies pending database migrations before serving.
+	MigrateOnStart bool
+	Firestore      FirestoreConfig
+	Postgres       PostgresPoolConfig
+	Tx             TxConfig
+	Auth           AuthConfig
+	QueryLimits    QueryLimitsConfig
+	RateLimit      RateLimitConfig
+	Attachments    AttachmentConfig
+	Moderation     ModerationConfig
+	Retention      RetentionConfig
+	Metrics        MetricsConfig
+}
+
+// FirestoreConfig configures the Firestore client and repositories.
+type FirestoreConfig struct {
+	// CredentialsFile is a service account key file. When empty, Application
+	// Default Credentials are used. It is ignored when EmulatorHost is set.
+	CredentialsFile string
+	EmulatorHost    string
+	// DatabaseID selects a named database; empty means "(default)".
+	DatabaseID string
+	// CollectionPrefix is prepended to every collection name, e.g. "staging_".
+	CollectionPrefix string
+	Retry            FirestoreRetryConfig
+}
+
+// FirestoreRetryConfig is the exponential backoff applied to transient gRPC
+// errors such as UNAVAILABLE. MaxAttempts of one or less disables retries.
+type FirestoreRetryConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+// PostgresPoolConfig tunes the pgx connection pool.
+type PostgresPoolConfig struct {
+	MaxConns               int32
+	MinConns               int32
+	MaxConnLifetime        time.Duration
+	MaxConnIdleTime        time.Duration
+	StatementCacheCapacity int
+}
+
+// TxConfig controls how transactions that fail with a serialization failure
+// or deadlock are retried. MaxAttempts of one or less disables retries.
+type TxConfig struct {
+	MaxAttempts  int
+	RetryBackoff time.Duration
+}
+
+// AuthConfig configures bearer JWT verification. Authentication is disabled
+// when neither JWKSURL nor JWKSFile is set.
+type AuthConfig struct {
+	JWKSURL  string
+	JWKSFile string
+	Issuer   string
+	Audience string
+}
+
+func (c AuthConfig) Enabled() bool {
+	return c.JWKSURL != "" || c.JWKSFile != ""
+}
+
+// QueryLimitsConfig bounds the cost of a single GraphQL operation. A value of
+// zero disables the corresponding limit.
+type QueryLimitsConfig struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// AttachmentConfig configures where the contents of attachments are stored and
+// how their download URLs are signed.
+type AttachmentConfig struct {
+	// Backend is BlobBackendLocal, which stores files under LocalDir, or
+	// BlobBackendGCS, which stores objects in GCSBucket.
+	Backend  string
+	LocalDir string
+	// GCSBucket must exist beforehand. GCSEmulatorHost points the client at an
+	// emulator such as fake-gcs-server instead of Cloud Storage.
+	GCSBucket       string
+	GCSEmulatorHost string
+	// SigningKey signs download URLs. When empty, a random key is used and
+	// URLs handed out before a restart stop working.
+	SigningKey string
+	URLTTL     time.Duration
+	// MaxSize bounds the size of a single file in bytes.
+	MaxSize int64
+}
+
+// RateLimitConfig configures per-client token buckets for each operation type.
+type RateLimitConfig struct {
+	Enabled bool
+	// TrustProxy makes the last X-Forwarded-For address, which the proxy in
+	// front of the server appends, the client IP.
+	TrustProxy bool
+	// APIKeys are the X-API-Key values given a bucket of their own. Any
+	// other value is ignored so that clients cannot rotate it to get fresh
+	// buckets.
+	APIKeys      []string
+	Query        RateLimitRule
+	Mutation     RateLimitRule
+	Subscription RateLimitRule
+}
+
+// RateLimitRule is a token bucket refilled at RequestsPerSecond holding at
+// most Burst tokens. A non-positive RequestsPerSecond disables the limit.
+type RateLimitRule struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// ModerationConfig configures the rules that check messages before they are
+// stored. Each *Action is ModerationFlag, which holds the message for a
+// moderator, or ModerationReject. A limit of zero disables its rule.
+type ModerationConfig struct {
+	// Words are matched case-insensitively anywhere in the content.
+	Words       []string
+	WordsAction string
+	// MaxLinks bounds the number of http and https URLs in the content.
+	MaxLinks    int
+	LinksAction string
+	// MaxLength bounds the length of the content in characters.
+	MaxLength    int
+	LengthAction string
+	// FloodMessages bounds how many messages an author may post within
+	// FloodWindow.
+	FloodMessages int
+	FloodWindow   time.Duration
+	FloodAction   string
+}
+
+// MetricsConfig configures the Prometheus metrics.
+type MetricsConfig struct {
+	// Operations are the operation names recorded as they are. Other and
+	// anonymous operations share the "other" label, so that clients cannot
+	// create series without bound.
+	Operations []string
+}
+
+// RetentionConfig configures how long messages are kept and the job that
+// purges the expired ones. A retention of zero keeps messages forever.
+type RetentionConfig struct {
+	// Default applies to messages outside channels and in channels without
+	// an entry in Channels.
+	Default time.Duration
+	// Channels overrides Default by channel ID.
+	Channels map[string]time.Duration
+	// Interval is the time between purges run by the server. Zero disables
+	// the background job, leaving the purge subcommand.
+	Interval time.Duration
+	// BatchSize is the number of threads deleted at a time.
+	BatchSize int
+	// DryRun counts the expired messages without deleting them.
+	DryRun bool
+}
+
+func Load() (*Config, error) {
+	maxDepth, err := getEnvInt("MAX_QUERY_DEPTH", defaultMaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	maxComplexity, err := getEnvInt("MAX_QUERY_COMPLEXITY", defaultMaxComplexity)
+	if err != nil {
+		return nil, err
+	}
+
+	migrateOnStart, err := getEnvBool("MIGRATE_ON_START", false)
+	if err != nil {
+		return nil, err
+	}
+	firestore, err := loadFirestore()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := loadPostgresPool()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := loadTx()
+	if err != nil {
+		return nil, err
+	}
+	rateLimit, err := loadRateLimit()
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := loadAttachments()
+	if err != nil {
+		return nil, err
+	}
+	moderation, err := loadModeration()
+	if err != nil {
+		return nil, err
+	}
+	retention, err := loadRetention()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Port:           getEnv("PORT", defaultPort),
+		ProjectID:      getEnv("GCP_PROJECT_ID", defaultProjectID),
+		DatabaseURL:    os.Getenv("DATABASE_URL"),
+		StorageBackend: getEnv("STORAGE_BACKEND", BackendExternal),
+		MemorySeedFile: os.Getenv("MEMORY_SEED_FILE"),
+		SQLBackend:     getEnv("SQL_BACKEND", BackendPostgres),
+		SQLitePath:     getEnv("SQLITE_PATH", defaultSQLitePath),
+		MessageBackend: os.Getenv("MESSAGE_BACKEND"),
+		MigrateOnStart: migrateOnStart,
+		Firestore:      firestore,
+		Postgres:       pool,
+		Tx:             tx,
+		Auth: AuthConfig{
+			JWKSURL:  os.Getenv("AUTH_JWKS_URL"),
+			JWKSFile: os.Getenv("AUTH_JWKS_FILE"),
+			Issuer:   os.Getenv("AUTH_ISSUER"),
+			Audience: os.Getenv("AUTH_AUDIENCE"),
+		},
+		QueryLimits: QueryLimitsConfig{
+			MaxDepth:      maxDepth,
+			MaxComplexity: maxComplexity,
+		},
+		RateLimit:   rateLimit,
+		Attachments: attachments,
+		Moderation:  moderation,
+		Retention:   retention,
+		Metrics:     MetricsConfig{Operations: getEnvList("METRICS_OPERATIONS")},
+	}
+
+	if cfg.StorageBackend != BackendExternal && cfg.StorageBackend != BackendMemory {
+		return nil, fmt.Errorf("invalid STORAGE_BACKEND %q: must be %q or %q", cfg.StorageBackend, BackendExternal, BackendMemory)
+	}
+	if cfg.SQLBackend != BackendPostgres && cfg.SQLBackend != BackendSQLite {
+		return nil, fmt.Errorf("invalid SQL_BACKEND %q: must be %q or %q", cfg.SQLBackend, BackendPostgres, BackendSQLite)
+	}
+	switch {
+	case cfg.MessageBackend == "" && cfg.StorageBackend == BackendMemory:
+		cfg.MessageBackend = BackendMemory
+	case cfg.MessageBackend == "":
+		cfg.MessageBackend = BackendFirestore
+	case cfg.StorageBackend == BackendMemory && cfg.MessageBackend != BackendMemory:
+		return nil, fmt.Errorf("MESSAGE_BACKEND=%s requires STORAGE_BACKEND=%s", cfg.MessageBackend, BackendExternal)
+	case cfg.MessageBackend != BackendFirestore && cfg.MessageBackend != BackendPostgres && cfg.MessageBackend != BackendMemory:
+		return nil, fmt.Errorf("invalid MESSAGE_BACKEND %q: must be %q, %q or %q",
+			cfg.MessageBackend, BackendFirestore, BackendPostgres, BackendMemory)
+	case cfg.MessageBackend == BackendPostgres && cfg.SQLBackend != BackendPostgres:
+		return nil, fmt.Errorf("MESSAGE_BACKEND=%s requires SQL_BACKEND=%s", BackendPostgres, BackendPostgres)
+	}
+	if cfg.MessageBackend == BackendFirestore && cfg.Retention.Default > 0 && len(cfg.Retention.Channels) > maxFirestoreRetentionChannels {
+		return nil, fmt.Errorf("RETENTION_CHANNELS accepts at most %d channels with RETENTION_DEFAULT and MESSAGE_BACKEND=%s",
+			maxFirestoreRetentionChannels, BackendFirestore)
+	}
+	if cfg.Attachments.Backend != BlobBackendLocal && cfg.Attachments.Backend != BlobBackendGCS {
+		return nil, fmt.Errorf("invalid ATTACHMENT_BACKEND %q: must be %q or %q", cfg.Attachments.Backend, BlobBackendLocal, BlobBackendGCS)
+	}
+	if cfg.Attachments.Backend == BlobBackendGCS && cfg.Attachments.GCSBucket == "" {
+		return nil, fmt.Errorf("ATTACHMENT_BACKEND=%s requires ATTACHMENT_GCS_BUCKET", BlobBackendGCS)
+	}
+	if cfg.Auth.JWKSURL != "" && cfg.Auth.JWKSFile != "" {
+		return nil, fmt.Errorf("AUTH_JWKS_URL and AUTH_JWKS_FILE are mutually exclusive")
+	}
+
+	return cfg, nil
+}
+
+func loadFirestore() (FirestoreConfig, error) {
+	cfg := FirestoreConfig{
+		CredentialsFile:  os.Getenv("FIRESTORE_CREDENTIALS_FILE"),
+		EmulatorHost:     os.Getenv("FIRESTORE_EMULATOR_HOST"),
+		DatabaseID:       os.Getenv("FIRESTORE_DATABASE_ID"),
+		CollectionPrefix: os.Getenv("FIRESTORE_COLLECTION_PREFIX"),
+	}
+
+	var err error
+	if cfg.Retry.MaxAttempts, err = getEnvInt("FIRESTORE_RETRY_MAX_ATTEMPTS", 3); err != nil {
+		return cfg, err
+	}
+	if cfg.Retry.InitialBackoff, err = getEnvDuration("FIRESTORE_RETRY_INITIAL_BACKOFF", 100*time.Millisecond); err != nil {
+		return cfg, err
+	}
+	if cfg.Retry.MaxBackoff, err = getEnvDuration("FIRESTORE_RETRY_MAX_BACKOFF", 2*time.Second); err != nil {
+		return cfg, err
+	}
+	if cfg.Retry.Multiplier, err = getEnvFloat("FIRESTORE_RETRY_MULTIPLIER", 2); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func loadAttachments() (AttachmentConfig, error) {
+	cfg := AttachmentConfig{
+		Backend:         getEnv("ATTACHMENT_BACKEND", BlobBackendLocal),
+		LocalDir:        getEnv("ATTACHMENT_DIR", defaultAttachmentDir),
+		GCSBucket:       os.Getenv("ATTACHMENT_GCS_BUCKET"),
+		GCSEmulatorHost: os.Getenv("ATTACHMENT_GCS_EMULATOR_HOST"),
+		SigningKey:      os.Getenv("ATTACHMENT_SIGNING_KEY"),
+	}
+
+	var err error
+	if cfg.URLTTL, err = getEnvDuration("ATTACHMENT_URL_TTL", 15*time.Minute); err != nil {
+		return cfg, err
+	}
+	maxSize, err := getEnvInt("ATTACHMENT_MAX_SIZE", 10<<20)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.MaxSize = int64(maxSize)
+
+	return cfg, nil
+}
+
+func loadModeration() (ModerationConfig, error) {
+	cfg := ModerationConfig{
+		WordsAction:  getEnv("MODERATION_WORDS_ACTION", ModerationFlag),
+		LinksAction:  getEnv("MODERATION_LINKS_ACTION", ModerationFlag),
+		LengthAction: getEnv("MODERATION_LENGTH_ACTION", ModerationReject),
+		FloodAction:  getEnv("MODERATION_FLOOD_ACTION", ModerationReject),
+	}
+	for _, word := range strings.Split(os.Getenv("MODERATION_WORDS"), ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			cfg.Words = append(cfg.Words, word)
+		}
+	}
+
+	var err error
+	if cfg.MaxLinks, err = getEnvInt("MODERATION_MAX_LINKS", 5); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxLength, err = getEnvInt("MODERATION_MAX_LENGTH", 4000); err != nil {
+		return cfg, err
+	}
+	if cfg.FloodMessages, err = getEnvInt("MODERATION_FLOOD_MESSAGES", 20); err != nil {
+		return cfg, err
+	}
+	if cfg.FloodWindow, err = getEnvDuration("MODERATION_FLOOD_WINDOW", time.Minute); err != nil {
+		return cfg, err
+	}
+
+	for key, action := range map[string]string{
+		"MODERATION_WORDS_ACTION":  cfg.WordsAction,
+		"MODERATION_LINKS_ACTION":  cfg.LinksAction,
+		"MODERATION_LENGTH_ACTION": cfg.LengthAction,
+		"MODERATION_FLOOD_ACTION":  cfg.FloodAction,
+	} {
+		if action != ModerationFlag && action != ModerationReject {
+			return cfg, fmt.Errorf("invalid %s %q: must be %q or %q", key, action, ModerationFlag, ModerationReject)
+		}
+	}
+	return cfg, nil
+}
+
+func loadRetention() (RetentionConfig, error) {
+	var cfg RetentionConfig
+	var err error
+	if cfg.Default, err = getEnvDuration("RETENTION_DEFAULT", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.Interval, err = getEnvDuration("RETENTION_INTERVAL", time.Hour); err != nil {
+		return cfg, err
+	}
+	if cfg.BatchSize, err = getEnvInt("RETENTION_BATCH_SIZE", 100); err != nil {
+		return cfg, err
+	}
+	if cfg.DryRun, err = getEnvBool("RETENTION_DRY_RUN", false); err != nil {
+		return cfg, err
+	}
+
+	// RETENTION_CHANNELS is a comma-separated list of <channel ID>=<duration>.
+	for _, entry := range strings.Split(os.Getenv("RETENTION_CHANNELS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		id, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(id) == "" {
+			return cfg, fmt.Errorf("invalid RETENTION_CHANNELS entry %q: must be <channel ID>=<duration>", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return cfg, fmt.Errorf("invalid RETENTION_CHANNELS entry %q: %w", entry, err)
+		}
+		if cfg.Channels == nil {
+			cfg.Channels = make(map[string]time.Duration)
+		}
+		cfg.Channels[strings.TrimSpace(id)] = d
+	}
+
+	if cfg.Default < 0 {
+		return cfg, fmt.Errorf("invalid RETENTION_DEFAULT %s: must not be negative", cfg.Default)
+	}
+	for id, d := range cfg.Channels {
+		if d < 0 {
+			return cfg, fmt.Errorf("invalid RETENTION_CHANNELS retention %s for %s: must not be negative", d, id)
+		}
+	}
+	if cfg.BatchSize <= 0 {
+		return cfg, fmt.Errorf("invalid RETENTION_BATCH_SIZE %d: must be positive", cfg.BatchSize)
+	}
+	return cfg, nil
+}
+
+func loadPostgresPool() (PostgresPoolConfig, error) {
+	var cfg PostgresPoolConfig
+
+	maxConns, err := getEnvInt("PG_MAX_CONNS", 0)
+	if err != nil {
+		return cfg, err
+	}
+	minConns, err := getEnvInt("PG_MIN_CONNS", 0)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.MaxConns = int32(maxConns)
+	cfg.MinConns = int32(minConns)
+
+	if cfg.MaxConnLifetime, err = getEnvDuration("PG_MAX_CONN_LIFETIME", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxConnIdleTime, err = getEnvDuration("PG_MAX_CONN_IDLE_TIME", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.StatementCacheCapacity, err = getEnvInt("PG_STATEMENT_CACHE_CAPACITY", 512); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func loadTx() (TxConfig, error) {
+	var cfg TxConfig
+	var err error
+	if cfg.MaxAttempts, err = getEnvInt("TX_MAX_ATTEMPTS", 3); err != nil {
+		return cfg, err
+	}
+	if cfg.RetryBackoff, err = getEnvDuration("TX_RETRY_BACKOFF", 20*time.Millisecond); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func loadRateLimit() (RateLimitConfig, error) {
+	enabled, err := getEnvBool("RATE_LIMIT_ENABLED", true)
+	if err != nil {
+		return RateLimitConfig{}, err
+	}
+	trustProxy, err := getEnvBool("RATE_LIMIT_TRUST_PROXY", false)
+	if err != nil {
+		return RateLimitConfig{}, err
+	}
+
+	cfg := RateLimitConfig{Enabled: enabled, TrustProxy: trustProxy, APIKeys: getEnvList("RATE_LIMIT_API_KEYS")}
+	rules := []struct {
+		prefix string
+		rule   *RateLimitRule
+		rps    float64
+		burst  int
+	}{
+		{"RATE_LIMIT_QUERY", &cfg.Query, 10, 20},
+		{"RATE_LIMIT_MUTATION", &cfg.Mutation, 2, 5},
+		{"RATE_LIMIT_SUBSCRIPTION", &cfg.Subscription, 1, 3},
+	}
+	for _, r := range rules {
+		if r.rule.RequestsPerSecond, err = getEnvFloat(r.prefix+"_RPS", r.rps); err != nil {
+			return RateLimitConfig{}, err
+		}
+		if r.rule.Burst, err = getEnvInt(r.prefix+"_BURST", r.burst); err != nil {
+			return RateLimitConfig{}, err
+		}
+	}
+
+	return cfg, nil
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
+
+func getEnvBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
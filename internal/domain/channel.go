@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Channel groups messages. Only its members can read or post to it.
+type Channel struct {
+	ID    string
+	Name  string
+	Topic string
+	// MemberIDs are the users.id of the members, sorted.
+	MemberIDs []string
+	// CreatedBy is the ID of the user who created the channel.
+	CreatedBy string
+	CreatedAt time.Time
+	// ArchivedAt is set once the channel is archived. Archived channels can
+	// still be read but accept no new messages.
+	ArchivedAt *time.Time
+}
+
+// HasMember reports whether userID belongs to the channel.
+func (c *Channel) HasMember(userID string) bool {
+	return slices.Contains(c.MemberIDs, userID)
+}
+
+// ChannelRead is a user's read position in a channel.
+type ChannelRead struct {
+	ChannelID string
+	// LastReadAt is when the user last marked the channel read, or nil if
+	// they never did.
+	LastReadAt *time.Time
+	// UnreadCount is the number of top-level messages posted to the channel
+	// since then.
+	UnreadCount int
+}
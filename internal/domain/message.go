@@ -3,8 +3,85 @@ package domain
 import "time"
 
 type Message struct {
-	ID        string    `firestore:"id"`
+	ID      string `firestore:"id"`
+	Content string `firestore:"content"`
+	Author  string `firestore:"author"`
+	// AuthorID is the users.id of the author. Messages written before
+	// authentication was added have none.
+	AuthorID string `firestore:"authorId"`
+	// ChannelID is the channel the message was posted to, or empty for a
+	// message outside any channel. Replies share the channel of their parent.
+	ChannelID string `firestore:"channelId"`
+	// ParentID is the message this one replies to, or empty for a top-level
+	// message. Replies cannot be nested.
+	ParentID       string         `firestore:"parentId"`
+	ReplyCount     int            `firestore:"replyCount"`
+	ReactionCounts ReactionCounts `firestore:"reactionCounts,omitempty"`
+	CreatedAt      time.Time      `firestore:"createdAt"`
+	// EditedAt is set once the content has been edited.
+	EditedAt *time.Time `firestore:"editedAt,omitempty"`
+	// Attachments are the files posted with the message, in upload order.
+	Attachments []Attachment `firestore:"attachments,omitempty"`
+	// MentionIDs are the users mentioned in the content, resolved when the
+	// message was written or last edited.
+	MentionIDs []string `firestore:"mentionIds,omitempty"`
+	// Moderation is set once the moderation rules flagged the message.
+	Moderation *Moderation `firestore:"moderation,omitempty"`
+}
+
+// Hidden reports whether the message awaits a moderator or was rejected by
+// one. Hidden messages are only shown to moderators.
+func (m *Message) Hidden() bool {
+	return m.Moderation != nil && m.Moderation.Status != ModerationApproved
+}
+
+// Counted reports whether the message counts towards the unread counts of its
+// channel. A top-level channel message is counted when it is posted unless it
+// is flagged, and otherwise when it is first approved. Flagging it on an edit
+// leaves it counted.
+func (m *Message) Counted() bool {
+	if m.ParentID != "" || m.ChannelID == "" {
+		return false
+	}
+	return m.Moderation == nil || m.Moderation.Status == ModerationApproved || m.Moderation.Counted
+}
+
+// Moderation statuses of a flagged message.
+const (
+	ModerationFlagged  = "flagged"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// Moderation records why the moderation rules flagged a message and what a
+// moderator decided. ModeratorID and ModeratedAt are set by the decision.
+// Counted is set by the repository when an edit flags a message that was
+// visible, and so already counted towards unread counts.
+type Moderation struct {
+	Status      string     `firestore:"status" json:"status"`
+	Reasons     []string   `firestore:"reasons" json:"reasons"`
+	ModeratorID string     `firestore:"moderatorId,omitempty" json:"moderatorId,omitempty"`
+	ModeratedAt *time.Time `firestore:"moderatedAt,omitempty" json:"moderatedAt,omitempty"`
+	Counted     bool       `firestore:"counted,omitempty" json:"counted,omitempty"`
+}
+
+// Attachment describes a file posted with a message. Its contents are kept
+// in a blob store under BlobKey. Checksum is the hex-encoded SHA-256 digest
+// of the contents.
+type Attachment struct {
+	ID          string `firestore:"id" json:"id"`
+	Name        string `firestore:"name" json:"name"`
+	Size        int64  `firestore:"size" json:"size"`
+	ContentType string `firestore:"contentType" json:"contentType"`
+	Checksum    string `firestore:"checksum" json:"checksum"`
+	BlobKey     string `firestore:"blobKey" json:"blobKey"`
+}
+
+// MessageEdit records an earlier version of a message's content. EditorID and
+// EditedAt describe the edit that replaced it.
+type MessageEdit struct {
+	MessageID string    `firestore:"messageId"`
 	Content   string    `firestore:"content"`
-	Author    string    `firestore:"author"`
-	CreatedAt time.Time `firestore:"createdAt"`
+	EditorID  string    `firestore:"editorId"`
+	EditedAt  time.Time `firestore:"editedAt"`
 }
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Reaction is one user's emoji reaction to a message. A user can react to a
+// message with several emojis, but with each emoji only once.
+type Reaction struct {
+	MessageID string    `firestore:"messageId"`
+	Emoji     string    `firestore:"emoji"`
+	UserID    string    `firestore:"userId"`
+	CreatedAt time.Time `firestore:"createdAt"`
+}
+
+// ReactionCounts maps each emoji to the number of users who reacted to a
+// message with it. Emojis nobody reacted with are absent.
+type ReactionCounts map[string]int
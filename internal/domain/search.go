@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// SearchDocument is the projection of a message kept in the search index.
+// Tokens are the terms the message content is found under.
+type SearchDocument struct {
+	MessageID string
+	ChannelID string
+	Author    string
+	CreatedAt time.Time
+	Tokens    []string
+}
@@ -1,10 +1,35 @@
 package domain
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+	// RoleModerator reviews the messages flagged by the moderation rules.
+	RoleModerator = "moderator"
+)
 
 type User struct {
-	ID        string
-	Name      string
+	ID   string
+	Name string
+	// Handle is the unique name the user is mentioned by, compared ignoring
+	// case. Users stored without one cannot be mentioned.
+	Handle    string
 	Email     string
+	Roles     []string
 	CreatedAt time.Time
+	UpdatedAt time.Time
+	// UpdatedBy is the ID of the user who last changed the record, or empty
+	// when the change was not made on behalf of a user.
+	UpdatedBy string
+	// DeletedAt is set while the user is soft-deleted.
+	DeletedAt *time.Time
+}
+
+// HasRole reports whether the user holds role. Admins implicitly hold every role.
+func (u *User) HasRole(role string) bool {
+	return slices.Contains(u.Roles, RoleAdmin) || slices.Contains(u.Roles, role)
 }
@@ -14,4 +14,8 @@ type WeatherAlertMetadata struct {
 	Severity  string
 	IssuedAt  time.Time
 	CreatedAt time.Time
+	UpdatedAt time.Time
+	UpdatedBy string
+	// DeletedAt is set while the alert is soft-deleted.
+	DeletedAt *time.Time
 }
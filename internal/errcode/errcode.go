@@ -0,0 +1,41 @@
+package errcode
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes reported to clients in the "code" extension of GraphQL errors.
+const (
+	Unauthenticated = "UNAUTHENTICATED"
+	Forbidden       = "FORBIDDEN"
+	RateLimited     = "RATE_LIMITED"
+	BadUserInput    = "BAD_USER_INPUT"
+	NotFound        = "NOT_FOUND"
+	Conflict        = "CONFLICT"
+	Unavailable     = "UNAVAILABLE"
+	Internal        = "INTERNAL_SERVER_ERROR"
+)
+
+// New returns a GraphQL error carrying the given code in its extensions.
+func New(code, message string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    message,
+		Extensions: map[string]any{"code": code},
+	}
+}
+
+// WriteHTTP writes err as a GraphQL response body. It is used by HTTP
+// middleware that rejects a request before it reaches the GraphQL handler.
+func WriteHTTP(w http.ResponseWriter, status int, err *gqlerror.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	body := map[string]any{"errors": gqlerror.List{err}}
+	if encErr := json.NewEncoder(w).Encode(body); encErr != nil {
+		log.Printf("errcode: failed to write error response: %v", encErr)
+	}
+}
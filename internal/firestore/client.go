@@ -5,25 +5,36 @@ import (
 	"log"
 
 	"cloud.google.com/go/firestore"
-	firebase "firebase.google.com/go/v4"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
-func NewClient(ctx context.Context, projectID string) (*firestore.Client, error) {
-	conf := &firebase.Config{ProjectID: projectID}
-
+func NewClient(ctx context.Context, projectID string, cfg config.FirestoreConfig) (*firestore.Client, error) {
 	var opts []option.ClientOption
 
-	app, err := firebase.NewApp(ctx, conf, opts...)
-	if err != nil {
-		return nil, err
+	if cfg.EmulatorHost != "" {
+		// The emulator serves plaintext gRPC and needs no credentials.
+		opts = append(opts,
+			option.WithEndpoint(cfg.EmulatorHost),
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		)
+	} else if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+
+	databaseID := cfg.DatabaseID
+	if databaseID == "" {
+		databaseID = firestore.DefaultDatabaseID
 	}
 
-	client, err := app.Firestore(ctx)
+	client, err := firestore.NewClientWithDatabase(ctx, projectID, databaseID, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("Firestore client initialized for project: %s", projectID)
+	log.Printf("Firestore client initialized for project: %s (database: %s)", projectID, databaseID)
 	return client, nil
 }
@@ -0,0 +1,48 @@
+package firestore
+
+import (
+	"context"
+	"log"
+
+	"github.com/googleapis/gax-go/v2"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sleep is replaced in tests to avoid waiting for real backoff delays.
+var sleep = gax.Sleep
+
+// Retry calls fn until it succeeds, returns a non-transient error, or
+// cfg.MaxAttempts attempts have been made, backing off exponentially between
+// attempts. A MaxAttempts of one or less calls fn exactly once.
+func Retry(ctx context.Context, cfg config.FirestoreRetryConfig, fn func() error) error {
+	backoff := gax.Backoff{
+		Initial:    cfg.InitialBackoff,
+		Max:        cfg.MaxBackoff,
+		Multiplier: cfg.Multiplier,
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= cfg.MaxAttempts || !IsTransient(err) {
+			return err
+		}
+
+		delay := backoff.Pause()
+		log.Printf("Firestore: transient error (attempt %d/%d), retrying in %s: %v", attempt, cfg.MaxAttempts, delay, err)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// IsTransient reports whether err is a gRPC error worth retrying.
+func IsTransient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Internal:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,131 @@
+package firestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/googleapis/gax-go/v2"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetry(t *testing.T) {
+	var delays []time.Duration
+	sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { sleep = gax.Sleep })
+
+	cfg := config.FirestoreRetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	tests := []struct {
+		name         string
+		cfg          config.FirestoreRetryConfig
+		errs         []error
+		wantCalls    int
+		wantErr      error
+		cancelledCtx bool
+	}{
+		{
+			name:      "正常系: 初回で成功",
+			cfg:       cfg,
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "正常系: 一時的なエラーの後に成功",
+			cfg:       cfg,
+			errs:      []error{unavailable, unavailable, nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "異常系: 最大試行回数を超える",
+			cfg:       cfg,
+			errs:      []error{unavailable, unavailable, unavailable, nil},
+			wantCalls: 3,
+			wantErr:   unavailable,
+		},
+		{
+			name:      "異常系: 一時的でないエラーはリトライしない",
+			cfg:       cfg,
+			errs:      []error{status.Error(codes.NotFound, "not found"), nil},
+			wantCalls: 1,
+			wantErr:   status.Error(codes.NotFound, "not found"),
+		},
+		{
+			name:      "異常系: リトライ無効",
+			cfg:       config.FirestoreRetryConfig{},
+			errs:      []error{unavailable, nil},
+			wantCalls: 1,
+			wantErr:   unavailable,
+		},
+		{
+			name:         "異常系: コンテキストがキャンセル済み",
+			cfg:          cfg,
+			errs:         []error{unavailable, nil},
+			wantCalls:    1,
+			wantErr:      unavailable,
+			cancelledCtx: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelledCtx {
+				cancel()
+			}
+
+			calls := 0
+			err := Retry(ctx, tt.cfg, func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("Retry() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Retry() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && (err == nil || err.Error() != tt.wantErr.Error()) {
+				t.Errorf("Retry() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"UNAVAILABLE", status.Error(codes.Unavailable, ""), true},
+		{"DEADLINE_EXCEEDED", status.Error(codes.DeadlineExceeded, ""), true},
+		{"RESOURCE_EXHAUSTED", status.Error(codes.ResourceExhausted, ""), true},
+		{"NOT_FOUND", status.Error(codes.NotFound, ""), false},
+		{"PERMISSION_DENIED", status.Error(codes.PermissionDenied, ""), false},
+		{"非gRPCエラー", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Package mention finds @name mentions of users in message content.
+package mention
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxNames caps the number of distinct names Parse returns, so that a single
+// message cannot make the server look up an unbounded number of users.
+const MaxNames = 20
+
+// Parse returns the distinct names mentioned in content, lowercased, in order
+// of first appearance. A mention is an @ followed by letters, digits, '_',
+// '.' or '-', and must not follow a letter or digit, so that email addresses
+// are not taken for mentions. Trailing '.' and '-' are left out, as in
+// "thanks @alice.".
+func Parse(content string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(content); i++ {
+		if content[i] != '@' || (i > 0 && isNameRune(lastRune(content[:i]))) {
+			continue
+		}
+		end := i + 1
+		for end < len(content) {
+			r, size := utf8.DecodeRuneInString(content[end:])
+			if !isNameRune(r) && r != '.' && r != '-' {
+				break
+			}
+			end += size
+		}
+		name := strings.ToLower(strings.TrimRight(content[i+1:end], ".-"))
+		i = end - 1
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == MaxNames {
+			break
+		}
+	}
+	return names
+}
+
+// IsHandle reports whether handle can be mentioned as a whole, that is whether
+// Parse reads "@"+handle as handle.
+func IsHandle(handle string) bool {
+	names := Parse("@" + handle)
+	return len(names) == 1 && names[0] == strings.ToLower(handle)
+}
+
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
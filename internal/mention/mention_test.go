@@ -0,0 +1,62 @@
+package mention
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "正常系: 複数のメンション", content: "@Alice と @bob に確認", want: []string{"alice", "bob"}},
+		{name: "正常系: 重複は大文字小文字を区別せず1件", content: "@alice @ALICE @Alice", want: []string{"alice"}},
+		{name: "正常系: 末尾の記号は含めない", content: "thanks @alice. and @bob-", want: []string{"alice", "bob"}},
+		{name: "正常系: 名前中の記号", content: "cc @john.doe @jane_doe @a-b", want: []string{"john.doe", "jane_doe", "a-b"}},
+		{name: "正常系: 日本語の名前", content: "（@太郎）さん", want: []string{"太郎"}},
+		{name: "正常系: メールアドレスは対象外", content: "alice@example.com に送信", want: nil},
+		{name: "正常系: @のみ", content: "@ @. @@", want: nil},
+		{name: "正常系: メンションなし", content: "こんにちは", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.content))
+		})
+	}
+
+	t.Run("正常系: 件数の上限", func(t *testing.T) {
+		var b strings.Builder
+		for i := range MaxNames + 5 {
+			fmt.Fprintf(&b, "@user%d ", i)
+		}
+		assert.Len(t, Parse(b.String()), MaxNames)
+	})
+}
+
+func TestIsHandle(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle string
+		want   bool
+	}{
+		{name: "正常系: 英数字", handle: "alice01", want: true},
+		{name: "正常系: 大文字と記号", handle: "John.Doe_2", want: true},
+		{name: "正常系: 日本語", handle: "太郎", want: true},
+		{name: "異常系: 空", handle: "", want: false},
+		{name: "異常系: 空白を含む", handle: "alice smith", want: false},
+		{name: "異常系: 末尾の記号", handle: "alice.", want: false},
+		{name: "異常系: @を含む", handle: "alice@example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsHandle(tt.handle))
+		})
+	}
+}
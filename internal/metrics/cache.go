@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Cache names used for the "cache" label of graphql_cache_requests_total.
+const (
+	CacheAPQ   = "apq"
+	CacheQuery = "query"
+)
+
+type instrumentedCache[T any] struct {
+	name    string
+	next    graphql.Cache[T]
+	metrics *Metrics
+}
+
+// InstrumentCache wraps a gqlgen cache so that every lookup is counted as a
+// hit or a miss. The hit rate can be derived from graphql_cache_requests_total.
+func InstrumentCache[T any](m *Metrics, name string, next graphql.Cache[T]) graphql.Cache[T] {
+	return &instrumentedCache[T]{name: name, next: next, metrics: m}
+}
+
+func (c *instrumentedCache[T]) Get(ctx context.Context, key string) (T, bool) {
+	value, ok := c.next.Get(ctx, key)
+	result := "miss"
+	if ok {
+		result = "hit"
+	}
+	c.metrics.cacheRequests.WithLabelValues(c.name, result).Inc()
+	return value, ok
+}
+
+func (c *instrumentedCache[T]) Add(ctx context.Context, key string, value T) {
+	c.next.Add(ctx, key, value)
+}
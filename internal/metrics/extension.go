@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const (
+	otherOperation   = "other"
+	unknownErrorCode = "INTERNAL"
+)
+
+// Extension is a gqlgen handler extension that records per-operation request
+// counts, latency and error codes.
+type Extension struct {
+	metrics    *Metrics
+	operations map[string]bool
+}
+
+var (
+	_ graphql.HandlerExtension    = Extension{}
+	_ graphql.ResponseInterceptor = Extension{}
+)
+
+// Extension returns the gqlgen extension to register with srv.Use. Only the
+// operation names in operations get a label of their own; the names come
+// from clients, so any others would create series without bound.
+func (m *Metrics) Extension(operations []string) Extension {
+	known := make(map[string]bool, len(operations))
+	for _, name := range operations {
+		known[name] = true
+	}
+	return Extension{metrics: m, operations: known}
+}
+
+func (e Extension) ExtensionName() string {
+	return "PrometheusMetrics"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	name := opCtx.OperationName
+	if !e.operations[name] {
+		name = otherOperation
+	}
+	opType := "unknown"
+	if opCtx.Operation != nil {
+		opType = string(opCtx.Operation.Operation)
+	}
+
+	status := "success"
+	if resp != nil && len(resp.Errors) > 0 {
+		status = "error"
+		for _, err := range resp.Errors {
+			code, _ := err.Extensions["code"].(string)
+			if code == "" {
+				code = unknownErrorCode
+			}
+			e.metrics.resolverErrors.WithLabelValues(code).Inc()
+		}
+	}
+
+	e.metrics.operationsTotal.WithLabelValues(name, opType, status).Inc()
+	e.metrics.operationDuration.WithLabelValues(name, opType).Observe(time.Since(opCtx.Stats.OperationStart).Seconds())
+
+	return resp
+}
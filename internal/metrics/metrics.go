@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "graphql_sampleapp"
+
+// Metrics owns the Prometheus registry and every collector exposed on /metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	operationsTotal   *prometheus.CounterVec
+	operationDuration *prometheus.HistogramVec
+	resolverErrors    *prometheus.CounterVec
+	repositoryCalls   *prometheus.HistogramVec
+	cacheRequests     *prometheus.CounterVec
+	purgeRuns         *prometheus.CounterVec
+	purgedMessages    *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		operationsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "graphql_operations_total",
+			Help:      "Number of GraphQL operations processed, by operation name, type and status.",
+		}, []string{"operation", "type", "status"}),
+		operationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "graphql_operation_duration_seconds",
+			Help:      "Latency of GraphQL operations, by operation name and type.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "type"}),
+		resolverErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "graphql_resolver_errors_total",
+			Help:      "Number of GraphQL errors returned to clients, by error code.",
+		}, []string{"code"}),
+		repositoryCalls: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_call_duration_seconds",
+			Help:      "Latency of repository calls, by backend, repository, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "repository", "method", "status"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "graphql_cache_requests_total",
+			Help:      "Number of APQ and query cache lookups, by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+		purgeRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retention_purge_runs_total",
+			Help:      "Number of message retention purges, by mode (delete or dry_run) and status.",
+		}, []string{"mode", "status"}),
+		purgedMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retention_purged_messages_total",
+			Help:      "Number of expired messages and replies deleted, or found by dry runs, by mode (delete or dry_run).",
+		}, []string{"mode"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.operationsTotal,
+		m.operationDuration,
+		m.resolverErrors,
+		m.repositoryCalls,
+		m.cacheRequests,
+		m.purgeRuns,
+		m.purgedMessages,
+	)
+
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRepositoryCall records the latency of a single repository method call.
+func (m *Metrics) ObserveRepositoryCall(backend, repo, method string, start time.Time, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.repositoryCalls.WithLabelValues(backend, repo, method, status).Observe(time.Since(start).Seconds())
+}
+
+// ObservePurge records a message retention purge and the number of messages
+// it deleted, or found in a dry run, before it finished or failed.
+func (m *Metrics) ObservePurge(dryRun bool, messages int, err error) {
+	mode := "delete"
+	if dryRun {
+		mode = "dry_run"
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.purgeRuns.WithLabelValues(mode, status).Inc()
+	m.purgedMessages.WithLabelValues(mode).Add(float64(messages))
+}
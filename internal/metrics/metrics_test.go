@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type stubUserRepository struct {
+	err error
+}
+
+func (s *stubUserRepository) List(ctx context.Context, filter repository.UserFilter) ([]*domain.User, error) {
+	return nil, s.err
+}
+
+func (s *stubUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	return &domain.User{ID: id}, s.err
+}
+
+func (s *stubUserRepository) Create(ctx context.Context, user *domain.User) error {
+	return s.err
+}
+
+func (s *stubUserRepository) Update(ctx context.Context, user *domain.User) error {
+	return s.err
+}
+
+func (s *stubUserRepository) Delete(ctx context.Context, id string) (*domain.User, error) {
+	return &domain.User{ID: id}, s.err
+}
+
+func (s *stubUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	return nil, s.err
+}
+
+func (s *stubUserRepository) GetByHandles(ctx context.Context, handles []string) ([]*domain.User, error) {
+	return nil, s.err
+}
+
+func (s *stubUserRepository) Restore(ctx context.Context, id string) (*domain.User, error) {
+	return &domain.User{ID: id}, s.err
+}
+
+func TestInstrumentCache_HitAndMiss(t *testing.T) {
+	m := New()
+	cache := InstrumentCache[string](m, CacheAPQ, graphql.MapCache[string]{})
+	ctx := context.Background()
+
+	_, ok := cache.Get(ctx, "hash")
+	assert.False(t, ok)
+
+	cache.Add(ctx, "hash", "{ hello }")
+	got, ok := cache.Get(ctx, "hash")
+	assert.True(t, ok)
+	assert.Equal(t, "{ hello }", got)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.cacheRequests.WithLabelValues(CacheAPQ, "hit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.cacheRequests.WithLabelValues(CacheAPQ, "miss")))
+}
+
+func TestExtension_InterceptResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		opName     string
+		resp       *graphql.Response
+		wantLabel  string
+		wantStatus string
+		wantCode   string
+	}{
+		{
+			name:       "正常系: 名前付きオペレーション",
+			opName:     "ListUsers",
+			resp:       &graphql.Response{},
+			wantLabel:  "ListUsers",
+			wantStatus: "success",
+		},
+		{
+			name:       "異常系: コード付きエラー",
+			opName:     "",
+			resp:       &graphql.Response{Errors: gqlerror.List{{Message: "denied", Extensions: map[string]any{"code": "FORBIDDEN"}}}},
+			wantLabel:  otherOperation,
+			wantStatus: "error",
+			wantCode:   "FORBIDDEN",
+		},
+		{
+			name:       "異常系: コードなしエラー",
+			opName:     "Broken",
+			resp:       &graphql.Response{Errors: gqlerror.List{{Message: "boom"}}},
+			wantLabel:  "Broken",
+			wantStatus: "error",
+			wantCode:   unknownErrorCode,
+		},
+		{
+			name:       "正常系: 未登録のオペレーション名はotherにまとめる",
+			opName:     "Random123",
+			resp:       &graphql.Response{},
+			wantLabel:  otherOperation,
+			wantStatus: "success",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+				OperationName: tt.opName,
+				Operation:     &ast.OperationDefinition{Operation: ast.Query},
+				Stats:         graphql.Stats{OperationStart: time.Now()},
+			})
+
+			m.Extension([]string{"ListUsers", "Broken"}).InterceptResponse(ctx, func(ctx context.Context) *graphql.Response { return tt.resp })
+
+			assert.Equal(t, 1.0, testutil.ToFloat64(m.operationsTotal.WithLabelValues(tt.wantLabel, "query", tt.wantStatus)))
+			if tt.wantCode != "" {
+				assert.Equal(t, 1.0, testutil.ToFloat64(m.resolverErrors.WithLabelValues(tt.wantCode)))
+			}
+		})
+	}
+}
+
+func TestInstrumentUserRepository(t *testing.T) {
+	m := New()
+	repo := InstrumentUserRepository(m, "postgres", &stubUserRepository{})
+	failing := InstrumentUserRepository(m, "postgres", &stubUserRepository{err: errors.New("db down")})
+
+	_, err := repo.GetByID(context.Background(), "user1")
+	assert.NoError(t, err)
+	_, err = failing.List(context.Background(), repository.UserFilter{})
+	assert.Error(t, err)
+
+	assert.Equal(t, uint64(1), sampleCount(t, m, "postgres", "user", "GetByID", "ok"))
+	assert.Equal(t, uint64(1), sampleCount(t, m, "postgres", "user", "List", "error"))
+}
+
+func sampleCount(t *testing.T, m *Metrics, labels ...string) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := m.repositoryCalls.WithLabelValues(labels...).(prometheus.Histogram).Write(&metric); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestMetrics_ObservePurge(t *testing.T) {
+	m := New()
+	m.ObservePurge(false, 3, nil)
+	m.ObservePurge(false, 2, errors.New("unavailable"))
+	m.ObservePurge(true, 5, nil)
+
+	assert.Equal(t, 5.0, testutil.ToFloat64(m.purgedMessages.WithLabelValues("delete")))
+	assert.Equal(t, 5.0, testutil.ToFloat64(m.purgedMessages.WithLabelValues("dry_run")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.purgeRuns.WithLabelValues("delete", "ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.purgeRuns.WithLabelValues("delete", "error")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.purgeRuns.WithLabelValues("dry_run", "ok")))
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.cacheRequests.WithLabelValues(CacheQuery, "hit").Inc()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.Contains(rec.Body.String(), "graphql_sampleapp_graphql_cache_requests_total"))
+}
@@ -0,0 +1,348 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// The wrappers below decorate repository implementations so that each call is
+// recorded in repository_call_duration_seconds, labelled with the backend
+// (e.g. "postgres", "firestore") that serves it.
+
+type instrumentedMessageRepository struct {
+	backend string
+	next    repository.MessageRepository
+	metrics *Metrics
+}
+
+func InstrumentMessageRepository(m *Metrics, backend string, next repository.MessageRepository) repository.MessageRepository {
+	return &instrumentedMessageRepository{backend: backend, next: next, metrics: m}
+}
+
+func (r *instrumentedMessageRepository) List(ctx context.Context) (messages []*domain.Message, err error) {
+	defer r.observe("List", time.Now(), &err)
+	return r.next.List(ctx)
+}
+
+func (r *instrumentedMessageRepository) ListByChannel(ctx context.Context, channelID string, page repository.PageRequest) (messages *repository.MessagePage, err error) {
+	defer r.observe("ListByChannel", time.Now(), &err)
+	return r.next.ListByChannel(ctx, channelID, page)
+}
+
+func (r *instrumentedMessageRepository) ListMentions(ctx context.Context, userID string, page repository.PageRequest) (messages *repository.MessagePage, err error) {
+	defer r.observe("ListMentions", time.Now(), &err)
+	return r.next.ListMentions(ctx, userID, page)
+}
+
+func (r *instrumentedMessageRepository) GetByID(ctx context.Context, id string) (msg *domain.Message, err error) {
+	defer r.observe("GetByID", time.Now(), &err)
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedMessageRepository) GetByIDs(ctx context.Context, ids []string) (messages []*domain.Message, err error) {
+	defer r.observe("GetByIDs", time.Now(), &err)
+	return r.next.GetByIDs(ctx, ids)
+}
+
+func (r *instrumentedMessageRepository) Create(ctx context.Context, msg *domain.Message) (err error) {
+	defer r.observe("Create", time.Now(), &err)
+	return r.next.Create(ctx, msg)
+}
+
+func (r *instrumentedMessageRepository) ListReplies(ctx context.Context, parentID string, page repository.PageRequest) (replies *repository.MessagePage, err error) {
+	defer r.observe("ListReplies", time.Now(), &err)
+	return r.next.ListReplies(ctx, parentID, page)
+}
+
+func (r *instrumentedMessageRepository) AddReaction(ctx context.Context, reaction *domain.Reaction) (err error) {
+	defer r.observe("AddReaction", time.Now(), &err)
+	return r.next.AddReaction(ctx, reaction)
+}
+
+func (r *instrumentedMessageRepository) RemoveReaction(ctx context.Context, messageID, emoji, userID string) (err error) {
+	defer r.observe("RemoveReaction", time.Now(), &err)
+	return r.next.RemoveReaction(ctx, messageID, emoji, userID)
+}
+
+func (r *instrumentedMessageRepository) UserReactions(ctx context.Context, messageIDs []string, userID string) (emojis map[string][]string, err error) {
+	defer r.observe("UserReactions", time.Now(), &err)
+	return r.next.UserReactions(ctx, messageIDs, userID)
+}
+
+func (r *instrumentedMessageRepository) Scan(ctx context.Context, page repository.PageRequest) (messages *repository.MessagePage, err error) {
+	defer r.observe("Scan", time.Now(), &err)
+	return r.next.Scan(ctx, page)
+}
+
+func (r *instrumentedMessageRepository) Edit(ctx context.Context, id, content string, mentionIDs []string, moderation *domain.Moderation, editorID string, editedAt time.Time) (err error) {
+	defer r.observe("Edit", time.Now(), &err)
+	return r.next.Edit(ctx, id, content, mentionIDs, moderation, editorID, editedAt)
+}
+
+func (r *instrumentedMessageRepository) ListFlagged(ctx context.Context, page repository.PageRequest) (messages *repository.MessagePage, err error) {
+	defer r.observe("ListFlagged", time.Now(), &err)
+	return r.next.ListFlagged(ctx, page)
+}
+
+func (r *instrumentedMessageRepository) SetModeration(ctx context.Context, id string, moderation *domain.Moderation) (err error) {
+	defer r.observe("SetModeration", time.Now(), &err)
+	return r.next.SetModeration(ctx, id, moderation)
+}
+
+func (r *instrumentedMessageRepository) ListExpired(ctx context.Context, filter repository.ExpiryFilter, page repository.PageRequest) (messages *repository.MessagePage, err error) {
+	defer r.observe("ListExpired", time.Now(), &err)
+	return r.next.ListExpired(ctx, filter, page)
+}
+
+func (r *instrumentedMessageRepository) Purge(ctx context.Context, ids []string) (deleted []*domain.Message, err error) {
+	defer r.observe("Purge", time.Now(), &err)
+	return r.next.Purge(ctx, ids)
+}
+
+func (r *instrumentedMessageRepository) History(ctx context.Context, messageID string) (edits []*domain.MessageEdit, err error) {
+	defer r.observe("History", time.Now(), &err)
+	return r.next.History(ctx, messageID)
+}
+
+func (r *instrumentedMessageRepository) observe(method string, start time.Time, err *error) {
+	r.metrics.ObserveRepositoryCall(r.backend, "message", method, start, *err)
+}
+
+type instrumentedUserRepository struct {
+	backend string
+	next    repository.UserRepository
+	metrics *Metrics
+}
+
+func InstrumentUserRepository(m *Metrics, backend string, next repository.UserRepository) repository.UserRepository {
+	return &instrumentedUserRepository{backend: backend, next: next, metrics: m}
+}
+
+func (r *instrumentedUserRepository) List(ctx context.Context, filter repository.UserFilter) (users []*domain.User, err error) {
+	defer r.observe("List", time.Now(), &err)
+	return r.next.List(ctx, filter)
+}
+
+func (r *instrumentedUserRepository) GetByID(ctx context.Context, id string) (user *domain.User, err error) {
+	defer r.observe("GetByID", time.Now(), &err)
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedUserRepository) GetByIDs(ctx context.Context, ids []string) (users []*domain.User, err error) {
+	defer r.observe("GetByIDs", time.Now(), &err)
+	return r.next.GetByIDs(ctx, ids)
+}
+
+func (r *instrumentedUserRepository) GetByHandles(ctx context.Context, handles []string) (users []*domain.User, err error) {
+	defer r.observe("GetByHandles", time.Now(), &err)
+	return r.next.GetByHandles(ctx, handles)
+}
+
+func (r *instrumentedUserRepository) Create(ctx context.Context, user *domain.User) (err error) {
+	defer r.observe("Create", time.Now(), &err)
+	return r.next.Create(ctx, user)
+}
+
+func (r *instrumentedUserRepository) Update(ctx context.Context, user *domain.User) (err error) {
+	defer r.observe("Update", time.Now(), &err)
+	return r.next.Update(ctx, user)
+}
+
+func (r *instrumentedUserRepository) Delete(ctx context.Context, id string) (user *domain.User, err error) {
+	defer r.observe("Delete", time.Now(), &err)
+	return r.next.Delete(ctx, id)
+}
+
+func (r *instrumentedUserRepository) Restore(ctx context.Context, id string) (user *domain.User, err error) {
+	defer r.observe("Restore", time.Now(), &err)
+	return r.next.Restore(ctx, id)
+}
+
+func (r *instrumentedUserRepository) observe(method string, start time.Time, err *error) {
+	r.metrics.ObserveRepositoryCall(r.backend, "user", method, start, *err)
+}
+
+type instrumentedWeatherAlertRepository struct {
+	backend string
+	next    repository.WeatherAlertRepository
+	metrics *Metrics
+}
+
+func InstrumentWeatherAlertRepository(m *Metrics, backend string, next repository.WeatherAlertRepository) repository.WeatherAlertRepository {
+	return &instrumentedWeatherAlertRepository{backend: backend, next: next, metrics: m}
+}
+
+func (r *instrumentedWeatherAlertRepository) GetByID(ctx context.Context, id string) (alert *domain.WeatherAlert, err error) {
+	defer r.observe("GetByID", time.Now(), &err)
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedWeatherAlertRepository) GetByIDs(ctx context.Context, ids []string) (alerts []*domain.WeatherAlert, err error) {
+	defer r.observe("GetByIDs", time.Now(), &err)
+	return r.next.GetByIDs(ctx, ids)
+}
+
+func (r *instrumentedWeatherAlertRepository) Put(ctx context.Context, alert *domain.WeatherAlert) (err error) {
+	defer r.observe("Put", time.Now(), &err)
+	return r.next.Put(ctx, alert)
+}
+
+func (r *instrumentedWeatherAlertRepository) observe(method string, start time.Time, err *error) {
+	r.metrics.ObserveRepositoryCall(r.backend, "weather_alert", method, start, *err)
+}
+
+type instrumentedWeatherAlertMetadataRepository struct {
+	backend string
+	next    repository.WeatherAlertMetadataRepository
+	metrics *Metrics
+}
+
+func InstrumentWeatherAlertMetadataRepository(m *Metrics, backend string, next repository.WeatherAlertMetadataRepository) repository.WeatherAlertMetadataRepository {
+	return &instrumentedWeatherAlertMetadataRepository{backend: backend, next: next, metrics: m}
+}
+
+func (r *instrumentedWeatherAlertMetadataRepository) SearchIDs(ctx context.Context, filter repository.MetadataFilter) (ids []string, err error) {
+	defer r.observe("SearchIDs", time.Now(), &err)
+	return r.next.SearchIDs(ctx, filter)
+}
+
+func (r *instrumentedWeatherAlertMetadataRepository) Search(ctx context.Context, filter repository.MetadataFilter) (metadata []*domain.WeatherAlertMetadata, err error) {
+	defer r.observe("Search", time.Now(), &err)
+	return r.next.Search(ctx, filter)
+}
+
+func (r *instrumentedWeatherAlertMetadataRepository) Create(ctx context.Context, metadata *domain.WeatherAlertMetadata) (err error) {
+	defer r.observe("Create", time.Now(), &err)
+	return r.next.Create(ctx, metadata)
+}
+
+func (r *instrumentedWeatherAlertMetadataRepository) Delete(ctx context.Context, id string) (metadata *domain.WeatherAlertMetadata, err error) {
+	defer r.observe("Delete", time.Now(), &err)
+	return r.next.Delete(ctx, id)
+}
+
+func (r *instrumentedWeatherAlertMetadataRepository) Restore(ctx context.Context, id string) (metadata *domain.WeatherAlertMetadata, err error) {
+	defer r.observe("Restore", time.Now(), &err)
+	return r.next.Restore(ctx, id)
+}
+
+func (r *instrumentedWeatherAlertMetadataRepository) observe(method string, start time.Time, err *error) {
+	r.metrics.ObserveRepositoryCall(r.backend, "weather_alert_metadata", method, start, *err)
+}
+
+type instrumentedChannelRepository struct {
+	backend string
+	next    repository.ChannelRepository
+	metrics *Metrics
+}
+
+func InstrumentChannelRepository(m *Metrics, backend string, next repository.ChannelRepository) repository.ChannelRepository {
+	return &instrumentedChannelRepository{backend: backend, next: next, metrics: m}
+}
+
+func (r *instrumentedChannelRepository) List(ctx context.Context, filter repository.ChannelFilter) (channels []*domain.Channel, err error) {
+	defer r.observe("List", time.Now(), &err)
+	return r.next.List(ctx, filter)
+}
+
+func (r *instrumentedChannelRepository) GetByID(ctx context.Context, id string) (channel *domain.Channel, err error) {
+	defer r.observe("GetByID", time.Now(), &err)
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedChannelRepository) Create(ctx context.Context, channel *domain.Channel) (err error) {
+	defer r.observe("Create", time.Now(), &err)
+	return r.next.Create(ctx, channel)
+}
+
+func (r *instrumentedChannelRepository) Archive(ctx context.Context, id string) (channel *domain.Channel, err error) {
+	defer r.observe("Archive", time.Now(), &err)
+	return r.next.Archive(ctx, id)
+}
+
+func (r *instrumentedChannelRepository) RecordMessage(ctx context.Context, channelID string) (err error) {
+	defer r.observe("RecordMessage", time.Now(), &err)
+	return r.next.RecordMessage(ctx, channelID)
+}
+
+func (r *instrumentedChannelRepository) RemoveMessages(ctx context.Context, channelID string, n int) (err error) {
+	defer r.observe("RemoveMessages", time.Now(), &err)
+	return r.next.RemoveMessages(ctx, channelID, n)
+}
+
+func (r *instrumentedChannelRepository) MarkRead(ctx context.Context, channelID, userID string, readAt time.Time) (err error) {
+	defer r.observe("MarkRead", time.Now(), &err)
+	return r.next.MarkRead(ctx, channelID, userID, readAt)
+}
+
+func (r *instrumentedChannelRepository) Reads(ctx context.Context, userID string, channelIDs []string) (reads map[string]*domain.ChannelRead, err error) {
+	defer r.observe("Reads", time.Now(), &err)
+	return r.next.Reads(ctx, userID, channelIDs)
+}
+
+func (r *instrumentedChannelRepository) observe(method string, start time.Time, err *error) {
+	r.metrics.ObserveRepositoryCall(r.backend, "channel", method, start, *err)
+}
+
+type instrumentedSearchRepository struct {
+	backend string
+	next    repository.SearchRepository
+	metrics *Metrics
+}
+
+func InstrumentSearchRepository(m *Metrics, backend string, next repository.SearchRepository) repository.SearchRepository {
+	return &instrumentedSearchRepository{backend: backend, next: next, metrics: m}
+}
+
+func (r *instrumentedSearchRepository) Index(ctx context.Context, doc *domain.SearchDocument) (err error) {
+	defer r.observe("Index", time.Now(), &err)
+	return r.next.Index(ctx, doc)
+}
+
+func (r *instrumentedSearchRepository) Delete(ctx context.Context, messageIDs []string) (err error) {
+	defer r.observe("Delete", time.Now(), &err)
+	return r.next.Delete(ctx, messageIDs)
+}
+
+func (r *instrumentedSearchRepository) Search(ctx context.Context, query repository.SearchQuery, page repository.PageRequest) (result *repository.SearchPage, err error) {
+	defer r.observe("Search", time.Now(), &err)
+	return r.next.Search(ctx, query, page)
+}
+
+func (r *instrumentedSearchRepository) observe(method string, start time.Time, err *error) {
+	r.metrics.ObserveRepositoryCall(r.backend, "search", method, start, *err)
+}
+
+type instrumentedBlobStore struct {
+	backend string
+	next    repository.BlobStore
+	metrics *Metrics
+}
+
+func InstrumentBlobStore(m *Metrics, backend string, next repository.BlobStore) repository.BlobStore {
+	return &instrumentedBlobStore{backend: backend, next: next, metrics: m}
+}
+
+func (s *instrumentedBlobStore) Put(ctx context.Context, key string, r io.Reader, contentType string) (err error) {
+	defer s.observe("Put", time.Now(), &err)
+	return s.next.Put(ctx, key, r, contentType)
+}
+
+// Open is observed until the contents start to arrive, not until they are read.
+func (s *instrumentedBlobStore) Open(ctx context.Context, key string) (body io.ReadCloser, err error) {
+	defer s.observe("Open", time.Now(), &err)
+	return s.next.Open(ctx, key)
+}
+
+func (s *instrumentedBlobStore) Delete(ctx context.Context, key string) (err error) {
+	defer s.observe("Delete", time.Now(), &err)
+	return s.next.Delete(ctx, key)
+}
+
+func (s *instrumentedBlobStore) observe(method string, start time.Time, err *error) {
+	s.metrics.ObserveRepositoryCall(s.backend, "blob", method, start, *err)
+}
@@ -0,0 +1,34 @@
+package migrate
+
+// advisoryLockID serializes migrations when several server instances start
+// at the same time with MIGRATE_ON_START enabled.
+const advisoryLockID = 4_720_031
+
+// Dialect holds the database-specific statements issued by the Migrator.
+type Dialect struct {
+	// Lock and Unlock serialize concurrent migrators and receive the lock ID
+	// as $1. Both are skipped when empty.
+	Lock        string
+	Unlock      string
+	CreateTable string
+}
+
+var Postgres = Dialect{
+	Lock:   "SELECT pg_advisory_lock($1)",
+	Unlock: "SELECT pg_advisory_unlock($1)",
+	CreateTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+)`,
+}
+
+// SQLite needs no lock: the database allows a single writer and every
+// migration runs in its own transaction.
+var SQLite = Dialect{
+	CreateTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`,
+}
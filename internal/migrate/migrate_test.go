@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []Migration
+		wantErr bool
+	}{
+		{
+			name: "正常系: バージョン順に並ぶ",
+			files: fstest.MapFS{
+				"m/0002_add_column.up.sql":   {Data: []byte("ALTER 2")},
+				"m/0002_add_column.down.sql": {Data: []byte("REVERT 2")},
+				"m/0001_init.up.sql":         {Data: []byte("CREATE 1")},
+				"m/0001_init.down.sql":       {Data: []byte("DROP 1")},
+			},
+			want: []Migration{
+				{Version: 1, Name: "init", Up: "CREATE 1", Down: "DROP 1"},
+				{Version: 2, Name: "add_column", Up: "ALTER 2", Down: "REVERT 2"},
+			},
+		},
+		{
+			name: "正常系: downファイルは省略可能",
+			files: fstest.MapFS{
+				"m/0001_init.up.sql": {Data: []byte("CREATE 1")},
+			},
+			want: []Migration{{Version: 1, Name: "init", Up: "CREATE 1"}},
+		},
+		{
+			name: "異常系: upファイルがない",
+			files: fstest.MapFS{
+				"m/0001_init.down.sql": {Data: []byte("DROP 1")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "異常系: 不正なファイル名",
+			files: fstest.MapFS{
+				"m/init.sql": {Data: []byte("CREATE 1")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "異常系: 同じバージョンで名前が異なる",
+			files: fstest.MapFS{
+				"m/0001_init.up.sql":  {Data: []byte("CREATE 1")},
+				"m/0001_other.up.sql": {Data: []byte("CREATE 1")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.files, "m")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	migrations, err := PostgresMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "init", migrations[0].Name)
+	for _, m := range migrations {
+		assert.NotEmpty(t, m.Down, "migration %04d_%s should be reversible", m.Version, m.Name)
+	}
+}
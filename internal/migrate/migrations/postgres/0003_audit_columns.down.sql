@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_weather_alert_metadata_active_issued_at;
+DROP INDEX IF EXISTS idx_users_active_created_at;
+
+ALTER TABLE weather_alert_metadata
+    DROP COLUMN IF EXISTS updated_by,
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS updated_by,
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS updated_at;
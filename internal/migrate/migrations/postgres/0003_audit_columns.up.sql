@@ -0,0 +1,19 @@
+-- Audit columns and soft delete for users and weather alert metadata
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS updated_by VARCHAR(255);
+
+UPDATE users SET updated_at = created_at;
+
+ALTER TABLE weather_alert_metadata
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS updated_by VARCHAR(255);
+
+UPDATE weather_alert_metadata SET updated_at = created_at;
+
+-- Most queries only look at rows that have not been deleted
+CREATE INDEX IF NOT EXISTS idx_users_active_created_at ON users(created_at) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_weather_alert_metadata_active_issued_at ON weather_alert_metadata(issued_at) WHERE deleted_at IS NULL;
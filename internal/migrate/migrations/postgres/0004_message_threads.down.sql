@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_messages_parent_id_created_at;
+
+DELETE FROM messages WHERE parent_id IS NOT NULL;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS reply_count,
+    DROP COLUMN IF EXISTS parent_id,
+    DROP COLUMN IF EXISTS author_id;
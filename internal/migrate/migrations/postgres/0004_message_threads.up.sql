@@ -0,0 +1,9 @@
+-- Threaded replies: a reply points at its top-level message, which keeps a
+-- count of its replies
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS author_id VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS parent_id VARCHAR(255) REFERENCES messages(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS reply_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_messages_parent_id_created_at ON messages(parent_id, created_at, id);
@@ -0,0 +1,12 @@
+-- Emoji reactions: one row per user and emoji. Counts per message are
+-- aggregated from this table when messages are read, so they cannot drift.
+
+CREATE TABLE IF NOT EXISTS message_reactions (
+    message_id VARCHAR(255) NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    emoji VARCHAR(64) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, emoji, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_reactions_message_id_user_id ON message_reactions(message_id, user_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_messages_channel_id_created_at;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS channel_id;
+
+DROP TABLE IF EXISTS channel_members;
+DROP TABLE IF EXISTS channels;
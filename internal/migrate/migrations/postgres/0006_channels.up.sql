@@ -0,0 +1,24 @@
+-- Channels group messages. Members reference users so that a channel cannot
+-- name a user that does not exist.
+
+CREATE TABLE IF NOT EXISTS channels (
+    id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    topic TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS channel_members (
+    channel_id VARCHAR(255) NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (channel_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_members_user_id ON channel_members(user_id);
+
+-- messages may live in Firestore instead, so channel_id is not a foreign key.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS channel_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_messages_channel_id_created_at ON messages(channel_id, created_at DESC, id DESC) WHERE parent_id IS NULL;
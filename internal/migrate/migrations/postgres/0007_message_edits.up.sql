@@ -0,0 +1,14 @@
+-- Message edit history: each row keeps a replaced version of a message's
+-- content together with the edit that replaced it
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS message_edits (
+    id BIGSERIAL PRIMARY KEY,
+    message_id VARCHAR(255) NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    editor_id VARCHAR(255) NOT NULL,
+    edited_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_edits_message_id_edited_at ON message_edits(message_id, edited_at, id);
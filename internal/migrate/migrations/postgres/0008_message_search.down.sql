@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS message_search_tokens;
+DROP TABLE IF EXISTS message_search_documents;
@@ -0,0 +1,20 @@
+-- Search index over message content. Messages may live in Firestore, so the
+-- index is a projection written next to them: one document per message and
+-- one row per token it contains.
+
+CREATE TABLE IF NOT EXISTS message_search_documents (
+    message_id VARCHAR(255) PRIMARY KEY,
+    channel_id VARCHAR(255) NOT NULL DEFAULT '',
+    author VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_search_documents_created_at ON message_search_documents(created_at DESC, message_id DESC);
+
+CREATE TABLE IF NOT EXISTS message_search_tokens (
+    token VARCHAR(255) NOT NULL,
+    message_id VARCHAR(255) NOT NULL REFERENCES message_search_documents(message_id) ON DELETE CASCADE,
+    PRIMARY KEY (token, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_search_tokens_message_id ON message_search_tokens(message_id);
@@ -0,0 +1,4 @@
+-- Metadata of the files posted with a message, as a JSON array in upload
+-- order. The contents are kept in the attachment blob store
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS attachments JSONB NOT NULL DEFAULT '[]';
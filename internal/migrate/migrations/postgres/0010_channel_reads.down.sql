@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS channel_reads;
+
+ALTER TABLE channels DROP COLUMN IF EXISTS message_count;
@@ -0,0 +1,19 @@
+-- Read positions: channels count their top-level messages and each user's
+-- position is the count at the time they last read the channel, so unread
+-- counts never require reading the messages
+
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS message_count INTEGER NOT NULL DEFAULT 0;
+
+-- Count messages already stored in PostgreSQL. Messages kept in Firestore
+-- are counted from the time they are posted.
+UPDATE channels SET message_count = (
+    SELECT COUNT(*) FROM messages WHERE messages.channel_id = channels.id AND messages.parent_id IS NULL
+);
+
+CREATE TABLE IF NOT EXISTS channel_reads (
+    channel_id VARCHAR(255) NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    read_count INTEGER NOT NULL,
+    last_read_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, channel_id)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_messages_mention_ids;
+ALTER TABLE messages DROP COLUMN IF EXISTS mention_ids;
@@ -0,0 +1,6 @@
+-- IDs of the users mentioned in a message, resolved from @name mentions when
+-- the message is written or edited. The GIN index serves the mentions feed
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS mention_ids TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_messages_mention_ids ON messages USING GIN (mention_ids);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_messages_flagged;
+ALTER TABLE messages DROP COLUMN IF EXISTS moderation;
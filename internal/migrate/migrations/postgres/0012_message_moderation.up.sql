@@ -0,0 +1,8 @@
+-- Moderation state of a message flagged by the moderation rules, as a JSON
+-- object with its status, the reasons and the moderator's decision. Null for
+-- messages that were never flagged. The partial index serves the moderation
+-- queue
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS moderation JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_messages_flagged ON messages(created_at, id) WHERE moderation->>'status' = 'flagged';
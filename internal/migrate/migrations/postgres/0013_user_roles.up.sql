@@ -0,0 +1,5 @@
+-- Roles of each user. Migration 0001 creates users only if the table does not
+-- exist yet, so databases created by the former init script still lack the
+-- column at this point.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS roles TEXT[] NOT NULL DEFAULT '{user}';
@@ -0,0 +1,13 @@
+ALTER TABLE channel_reads ALTER COLUMN last_read_at TYPE TIMESTAMP WITH TIME ZONE USING last_read_at AT TIME ZONE 'UTC';
+
+ALTER TABLE message_search_documents ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE message_edits ALTER COLUMN edited_at TYPE TIMESTAMP WITH TIME ZONE USING edited_at AT TIME ZONE 'UTC';
+
+ALTER TABLE messages ALTER COLUMN edited_at TYPE TIMESTAMP WITH TIME ZONE USING edited_at AT TIME ZONE 'UTC';
+
+ALTER TABLE channels
+    ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN archived_at TYPE TIMESTAMP WITH TIME ZONE USING archived_at AT TIME ZONE 'UTC';
+
+ALTER TABLE message_reactions ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC';
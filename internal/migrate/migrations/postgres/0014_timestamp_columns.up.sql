@@ -0,0 +1,17 @@
+-- Store every timestamp as TIMESTAMP holding UTC, as users and messages always
+-- have. Columns added since then used TIMESTAMP WITH TIME ZONE; their values
+-- are converted to the same UTC wall clock.
+
+ALTER TABLE message_reactions ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE channels
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN archived_at TYPE TIMESTAMP USING archived_at AT TIME ZONE 'UTC';
+
+ALTER TABLE messages ALTER COLUMN edited_at TYPE TIMESTAMP USING edited_at AT TIME ZONE 'UTC';
+
+ALTER TABLE message_edits ALTER COLUMN edited_at TYPE TIMESTAMP USING edited_at AT TIME ZONE 'UTC';
+
+ALTER TABLE message_search_documents ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE channel_reads ALTER COLUMN last_read_at TYPE TIMESTAMP USING last_read_at AT TIME ZONE 'UTC';
@@ -0,0 +1,13 @@
+-- Handles: the unique names users are mentioned by. Display names are not
+-- unique and may contain spaces, so mentions no longer match them. Existing
+-- users get their ID as handle if it can be mentioned and does not differ from
+-- another ID only in case.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS handle VARCHAR(255);
+
+UPDATE users SET handle = id
+WHERE handle IS NULL
+  AND id ~ '^[[:alnum:]_.-]*[[:alnum:]_]$'
+  AND LOWER(id) IN (SELECT LOWER(id) FROM users GROUP BY LOWER(id) HAVING COUNT(*) = 1);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users(LOWER(handle));
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS weather_alert_metadata;
+DROP TABLE IF EXISTS users;
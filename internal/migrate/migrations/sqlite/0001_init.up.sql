@@ -0,0 +1,25 @@
+-- Initial schema: users and weather alert metadata
+-- Timestamps are stored as UTC text so that they compare in chronological order.
+
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    -- JSON array of role names
+    roles TEXT NOT NULL DEFAULT '["user"]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);
+
+CREATE TABLE IF NOT EXISTS weather_alert_metadata (
+    id TEXT PRIMARY KEY,
+    region TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    issued_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_weather_alert_metadata_region ON weather_alert_metadata(region);
+CREATE INDEX IF NOT EXISTS idx_weather_alert_metadata_issued_at ON weather_alert_metadata(issued_at);
+CREATE INDEX IF NOT EXISTS idx_weather_alert_metadata_severity ON weather_alert_metadata(severity);
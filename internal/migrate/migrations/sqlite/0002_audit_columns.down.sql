@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_weather_alert_metadata_active_issued_at;
+DROP INDEX IF EXISTS idx_users_active_created_at;
+
+ALTER TABLE weather_alert_metadata DROP COLUMN updated_by;
+ALTER TABLE weather_alert_metadata DROP COLUMN deleted_at;
+ALTER TABLE weather_alert_metadata DROP COLUMN updated_at;
+
+ALTER TABLE users DROP COLUMN updated_by;
+ALTER TABLE users DROP COLUMN deleted_at;
+ALTER TABLE users DROP COLUMN updated_at;
@@ -0,0 +1,16 @@
+-- Audit columns and soft delete for users and weather alert metadata
+-- SQLite only accepts constant defaults in ADD COLUMN, so updated_at is
+-- backfilled from created_at afterwards.
+
+ALTER TABLE users ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN updated_by TEXT;
+UPDATE users SET updated_at = created_at;
+
+ALTER TABLE weather_alert_metadata ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+ALTER TABLE weather_alert_metadata ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE weather_alert_metadata ADD COLUMN updated_by TEXT;
+UPDATE weather_alert_metadata SET updated_at = created_at;
+
+CREATE INDEX IF NOT EXISTS idx_users_active_created_at ON users(created_at) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_weather_alert_metadata_active_issued_at ON weather_alert_metadata(issued_at) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS channel_members;
+DROP TABLE IF EXISTS channels;
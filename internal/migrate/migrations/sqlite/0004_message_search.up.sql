@@ -0,0 +1,20 @@
+-- Search index over message content, a projection of the messages stored in
+-- Firestore or PostgreSQL: one document per message and one row per token it
+-- contains.
+
+CREATE TABLE IF NOT EXISTS message_search_documents (
+    message_id TEXT PRIMARY KEY,
+    channel_id TEXT NOT NULL DEFAULT '',
+    author TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_search_documents_created_at ON message_search_documents(created_at DESC, message_id DESC);
+
+CREATE TABLE IF NOT EXISTS message_search_tokens (
+    token TEXT NOT NULL,
+    message_id TEXT NOT NULL REFERENCES message_search_documents(message_id) ON DELETE CASCADE,
+    PRIMARY KEY (token, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_search_tokens_message_id ON message_search_tokens(message_id);
@@ -0,0 +1,13 @@
+-- Read positions: channels count their top-level messages and each user's
+-- position is the count at the time they last read the channel, so unread
+-- counts never require reading the messages
+
+ALTER TABLE channels ADD COLUMN message_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS channel_reads (
+    channel_id TEXT NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    read_count INTEGER NOT NULL,
+    last_read_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, channel_id)
+);
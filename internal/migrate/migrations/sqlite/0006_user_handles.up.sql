@@ -0,0 +1,15 @@
+-- Handles: the unique names users are mentioned by. Existing users get their
+-- ID as handle if it is made of ASCII letters, digits, '_', '.' and '-', does
+-- not end with '.' or '-' and does not differ from another ID only in case.
+-- SQLite's LOWER only folds ASCII, so handles differing in the case of other
+-- letters are told apart.
+
+ALTER TABLE users ADD COLUMN handle TEXT;
+
+UPDATE users SET handle = id
+WHERE handle IS NULL
+  AND id NOT GLOB '*[^A-Za-z0-9_.-]*'
+  AND id GLOB '*[A-Za-z0-9_]'
+  AND LOWER(id) IN (SELECT LOWER(id) FROM users GROUP BY LOWER(id) HAVING COUNT(*) = 1);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users(LOWER(handle));
@@ -0,0 +1,181 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kuchida1981/graphql-sampleapp/internal/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "init", Up: "CREATE TABLE a (id INT)", Down: "DROP TABLE a"},
+	{Version: 2, Name: "add_b", Up: "CREATE TABLE b (id INT)", Down: "DROP TABLE b"},
+}
+
+func expectLockAndTable(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(advisoryLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(Postgres.CreateTable)).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(advisoryLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectApplied(mock sqlmock.Sqlmock, versions ...int64) {
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, v := range versions {
+		rows.AddRow(v, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, applied_at FROM schema_migrations ORDER BY version")).
+		WillReturnRows(rows)
+}
+
+func TestMigrator_Up(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockFn      func(mock sqlmock.Sqlmock)
+		wantApplied []int64
+		wantErr     bool
+	}{
+		{
+			name: "正常系: 未適用のマイグレーションのみ適用",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				expectLockAndTable(mock)
+				expectApplied(mock, 1)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id INT)")).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).
+					WithArgs(int64(2), "add_b").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				expectUnlock(mock)
+			},
+			wantApplied: []int64{2},
+		},
+		{
+			name: "正常系: すべて適用済み",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				expectLockAndTable(mock)
+				expectApplied(mock, 1, 2)
+				expectUnlock(mock)
+			},
+			wantApplied: nil,
+		},
+		{
+			name: "異常系: SQLエラーでロールバック",
+			mockFn: func(mock sqlmock.Sqlmock) {
+				expectLockAndTable(mock)
+				expectApplied(mock)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE a (id INT)")).WillReturnError(errors.New("syntax error"))
+				mock.ExpectRollback()
+				expectUnlock(mock)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.mockFn(mock)
+
+			applied, err := New(db, Postgres, testMigrations).Up(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				var versions []int64
+				for _, m := range applied {
+					versions = append(versions, m.Version)
+				}
+				assert.Equal(t, tt.wantApplied, versions)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLockAndTable(mock)
+	expectApplied(mock, 1, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE b")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = $1")).
+		WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	reverted, err := New(db, Postgres, testMigrations).Down(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, int64(2), reverted[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLockAndTable(mock)
+	expectApplied(mock, 1)
+	expectUnlock(mock)
+
+	statuses, err := New(db, Postgres, testMigrations).Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_SQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.NewClient(ctx, filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrations, err := SQLiteMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	migrator := New(db, SQLite, migrations)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "Up() must be idempotent")
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, "migration %04d_%s", s.Version, s.Name)
+	}
+
+	reverted, err := migrator.Down(ctx, len(migrations))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(migrations))
+
+	var tables int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name != 'schema_migrations'").Scan(&tables))
+	assert.Zero(t, tables)
+}
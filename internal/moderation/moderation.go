@@ -0,0 +1,118 @@
+// Package moderation checks messages against a chain of rules before they are
+// stored.
+package moderation
+
+import (
+	"context"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+// Action is what a rule does with a message. Later actions are stronger.
+type Action int
+
+const (
+	Allow Action = iota
+	// Flag stores the message hidden until a moderator approves it.
+	Flag
+	// Reject refuses to store the message.
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Flag:
+		return config.ModerationFlag
+	case Reject:
+		return config.ModerationReject
+	default:
+		return "allow"
+	}
+}
+
+// Result is a rule's verdict on a message. Reason explains a Flag or Reject
+// to the author and to moderators.
+type Result struct {
+	Action Action
+	Reason string
+}
+
+// Rule checks a single aspect of a message. Edited messages are checked
+// again with EditedAt set.
+type Rule interface {
+	Check(ctx context.Context, msg *domain.Message) (Result, error)
+}
+
+// RuleFunc adapts a function to Rule.
+type RuleFunc func(ctx context.Context, msg *domain.Message) (Result, error)
+
+func (f RuleFunc) Check(ctx context.Context, msg *domain.Message) (Result, error) {
+	return f(ctx, msg)
+}
+
+// Decision is the combined verdict of a Pipeline: the strongest action of its
+// rules and the reasons of every rule that did not allow the message.
+type Decision struct {
+	Action  Action
+	Reasons []string
+}
+
+// Pipeline runs rules in order. It stops at the first rule that rejects the
+// message, so later rules such as a flood limit do not count it.
+type Pipeline struct {
+	rules []Rule
+}
+
+func NewPipeline(rules ...Rule) *Pipeline {
+	return &Pipeline{rules: rules}
+}
+
+// FromConfig builds the pipeline of the built-in rules enabled in cfg: the
+// length limit, the word list, the link limit and the flood limit.
+func FromConfig(cfg config.ModerationConfig) *Pipeline {
+	var rules []Rule
+	if cfg.MaxLength > 0 {
+		rules = append(rules, LengthLimit(cfg.MaxLength, parseAction(cfg.LengthAction)))
+	}
+	if len(cfg.Words) > 0 {
+		rules = append(rules, WordList(cfg.Words, parseAction(cfg.WordsAction)))
+	}
+	if cfg.MaxLinks > 0 {
+		rules = append(rules, LinkLimit(cfg.MaxLinks, parseAction(cfg.LinksAction)))
+	}
+	if cfg.FloodMessages > 0 && cfg.FloodWindow > 0 {
+		rules = append(rules, NewFloodLimit(cfg.FloodMessages, cfg.FloodWindow, parseAction(cfg.FloodAction)))
+	}
+	return NewPipeline(rules...)
+}
+
+func parseAction(s string) Action {
+	if s == config.ModerationReject {
+		return Reject
+	}
+	return Flag
+}
+
+// Check runs the rules on msg. A nil Pipeline allows every message.
+func (p *Pipeline) Check(ctx context.Context, msg *domain.Message) (Decision, error) {
+	var decision Decision
+	if p == nil {
+		return decision, nil
+	}
+	for _, rule := range p.rules {
+		result, err := rule.Check(ctx, msg)
+		if err != nil {
+			return Decision{}, err
+		}
+		if result.Action == Allow {
+			continue
+		}
+		decision.Action = max(decision.Action, result.Action)
+		decision.Reasons = append(decision.Reasons, result.Reason)
+		if result.Action == Reject {
+			break
+		}
+	}
+	return decision, nil
+}
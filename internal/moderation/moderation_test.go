@@ -0,0 +1,130 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		content string
+		want    Action
+	}{
+		{name: "正常系: 文字数以内", rule: LengthLimit(5, Reject), content: "こんにちは", want: Allow},
+		{name: "異常系: 文字数超過", rule: LengthLimit(5, Reject), content: "こんにちは!", want: Reject},
+		{name: "正常系: 禁止語なし", rule: WordList([]string{"spam"}, Flag), content: "hello", want: Allow},
+		{name: "異常系: 禁止語を大文字小文字を区別せず検出", rule: WordList([]string{"spam"}, Flag), content: "Buy SPAMMY goods", want: Flag},
+		{name: "異常系: 日本語の禁止語", rule: WordList([]string{"禁止語"}, Reject), content: "これは禁止語です", want: Reject},
+		{name: "正常系: リンク数以内", rule: LinkLimit(1, Flag), content: "see https://example.com", want: Allow},
+		{name: "異常系: リンク数超過", rule: LinkLimit(1, Flag), content: "http://a.example HTTPS://b.example", want: Flag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rule.Check(context.Background(), &domain.Message{Content: tt.content})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.Action)
+			if tt.want != Allow {
+				assert.NotEmpty(t, got.Reason)
+			}
+		})
+	}
+}
+
+func TestFloodLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	flood := NewFloodLimit(2, time.Minute, Reject)
+	flood.now = func() time.Time { return now }
+	check := func(authorID string) Action {
+		t.Helper()
+		got, err := flood.Check(context.Background(), &domain.Message{AuthorID: authorID})
+		assert.NoError(t, err)
+		return got.Action
+	}
+
+	t.Run("異常系: 期間内の上限を超えた投稿", func(t *testing.T) {
+		assert.Equal(t, Allow, check("alice"))
+		assert.Equal(t, Allow, check("alice"))
+		assert.Equal(t, Reject, check("alice"))
+		assert.Equal(t, Allow, check("bob"))
+	})
+
+	t.Run("正常系: 編集は数えない", func(t *testing.T) {
+		edited := now
+		got, err := flood.Check(context.Background(), &domain.Message{AuthorID: "alice", EditedAt: &edited})
+		assert.NoError(t, err)
+		assert.Equal(t, Allow, got.Action)
+	})
+
+	t.Run("正常系: 期間が過ぎれば投稿できる", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		assert.Equal(t, Allow, check("alice"))
+		assert.NotContains(t, flood.sent, "bob")
+	})
+}
+
+func TestPipeline(t *testing.T) {
+	counted := 0
+	counter := RuleFunc(func(ctx context.Context, msg *domain.Message) (Result, error) {
+		counted++
+		return Result{}, nil
+	})
+	pipeline := NewPipeline(LinkLimit(0, Flag), WordList([]string{"ng"}, Reject), counter)
+
+	t.Run("正常系: 警告の理由をまとめる", func(t *testing.T) {
+		got, err := pipeline.Check(context.Background(), &domain.Message{Content: "https://example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, Flag, got.Action)
+		assert.Len(t, got.Reasons, 1)
+		assert.Equal(t, 1, counted)
+	})
+
+	t.Run("異常系: 拒否した時点で後続のルールを実行しない", func(t *testing.T) {
+		got, err := pipeline.Check(context.Background(), &domain.Message{Content: "https://example.com ng"})
+		assert.NoError(t, err)
+		assert.Equal(t, Reject, got.Action)
+		assert.Len(t, got.Reasons, 2)
+		assert.Equal(t, 1, counted)
+	})
+
+	t.Run("異常系: ルールのエラー", func(t *testing.T) {
+		failing := RuleFunc(func(ctx context.Context, msg *domain.Message) (Result, error) {
+			return Result{}, errors.New("classifier unavailable")
+		})
+		_, err := NewPipeline(failing).Check(context.Background(), &domain.Message{})
+		assert.Error(t, err)
+	})
+
+	t.Run("正常系: nilはすべて許可", func(t *testing.T) {
+		var nilPipeline *Pipeline
+		got, err := nilPipeline.Check(context.Background(), &domain.Message{Content: "ng"})
+		assert.NoError(t, err)
+		assert.Equal(t, Allow, got.Action)
+	})
+}
+
+func TestFromConfig(t *testing.T) {
+	pipeline := FromConfig(config.ModerationConfig{
+		Words:        []string{"ng"},
+		WordsAction:  config.ModerationReject,
+		MaxLength:    10,
+		LengthAction: config.ModerationFlag,
+	})
+
+	got, err := pipeline.Check(context.Background(), &domain.Message{Content: strings.Repeat("a", 11)})
+	assert.NoError(t, err)
+	assert.Equal(t, Flag, got.Action)
+
+	got, err = pipeline.Check(context.Background(), &domain.Message{Content: "ng"})
+	assert.NoError(t, err)
+	assert.Equal(t, Reject, got.Action)
+}
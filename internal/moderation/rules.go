@@ -0,0 +1,126 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+// LengthLimit applies action to content longer than max characters.
+func LengthLimit(max int, action Action) Rule {
+	return RuleFunc(func(ctx context.Context, msg *domain.Message) (Result, error) {
+		if n := utf8.RuneCountInString(msg.Content); n > max {
+			return Result{Action: action, Reason: fmt.Sprintf("content is %d characters long, more than %d", n, max)}, nil
+		}
+		return Result{}, nil
+	})
+}
+
+// WordList applies action to content containing one of words, ignoring case.
+// Words are matched anywhere in the content, since Japanese text has no word
+// boundaries to match at.
+func WordList(words []string, action Action) Rule {
+	lower := make([]string, len(words))
+	for i, word := range words {
+		lower[i] = strings.ToLower(word)
+	}
+	return RuleFunc(func(ctx context.Context, msg *domain.Message) (Result, error) {
+		content := strings.ToLower(msg.Content)
+		for _, word := range lower {
+			if strings.Contains(content, word) {
+				// The word itself is left out of the reason, which is
+				// shown to the author.
+				return Result{Action: action, Reason: "content contains a blocked word"}, nil
+			}
+		}
+		return Result{}, nil
+	})
+}
+
+var linkPattern = regexp.MustCompile(`(?i)https?://`)
+
+// LinkLimit applies action to content with more than max http or https URLs.
+func LinkLimit(max int, action Action) Rule {
+	return RuleFunc(func(ctx context.Context, msg *domain.Message) (Result, error) {
+		if n := len(linkPattern.FindAllStringIndex(msg.Content, -1)); n > max {
+			return Result{Action: action, Reason: fmt.Sprintf("content has %d links, more than %d", n, max)}, nil
+		}
+		return Result{}, nil
+	})
+}
+
+// FloodLimit applies its action to an author's messages beyond a number
+// within a sliding window. It keeps the times of recent messages in memory,
+// so each server instance counts separately. Only messages it lets through
+// are counted, and edits are neither counted nor limited.
+type FloodLimit struct {
+	messages int
+	window   time.Duration
+	action   Action
+
+	mu          sync.Mutex
+	sent        map[string][]time.Time
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+func NewFloodLimit(messages int, window time.Duration, action Action) *FloodLimit {
+	return &FloodLimit{
+		messages: messages,
+		window:   window,
+		action:   action,
+		sent:     make(map[string][]time.Time),
+		now:      time.Now,
+	}
+}
+
+func (f *FloodLimit) Check(ctx context.Context, msg *domain.Message) (Result, error) {
+	if msg.EditedAt != nil || msg.AuthorID == "" {
+		return Result{}, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	f.cleanup(now)
+	sent := f.recent(msg.AuthorID, now)
+	if len(sent) >= f.messages {
+		return Result{Action: f.action, Reason: fmt.Sprintf("more than %d messages within %s", f.messages, f.window)}, nil
+	}
+	f.sent[msg.AuthorID] = append(sent, now)
+	return Result{}, nil
+}
+
+// recent returns the times of author's messages within the window ending at
+// now. The caller must hold f.mu.
+func (f *FloodLimit) recent(author string, now time.Time) []time.Time {
+	times := f.sent[author]
+	i := 0
+	for i < len(times) && now.Sub(times[i]) >= f.window {
+		i++
+	}
+	return times[i:]
+}
+
+// cleanup forgets the authors without messages in the window, at most once
+// per window, so that the map only holds recent authors. The caller must
+// hold f.mu.
+func (f *FloodLimit) cleanup(now time.Time) {
+	if now.Sub(f.lastCleanup) < f.window {
+		return
+	}
+	f.lastCleanup = now
+
+	for author := range f.sent {
+		if len(f.recent(author, now)) == 0 {
+			delete(f.sent, author)
+		}
+	}
+}
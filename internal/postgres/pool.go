@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+)
+
+// NewPool opens a pgx connection pool. Zero values in cfg keep the pgx
+// defaults, except StatementCacheCapacity where zero disables prepared
+// statement caching (useful behind PgBouncer in transaction mode).
+func NewPool(ctx context.Context, connStr string, cfg config.PostgresPoolConfig) (*pgxpool.Pool, error) {
+	if connStr == "" {
+		connStr = os.Getenv("DATABASE_URL")
+		if connStr == "" {
+			return nil, fmt.Errorf("DATABASE_URL environment variable is not set")
+		}
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.StatementCacheCapacity > 0 {
+		poolConfig.ConnConfig.StatementCacheCapacity = cfg.StatementCacheCapacity
+	} else {
+		poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+	}
+
+	log.Printf("Connecting to PostgreSQL pool: %s (max=%d, min=%d)",
+		maskPassword(connStr), poolConfig.MaxConns, poolConfig.MinConns)
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	log.Println("Successfully connected to PostgreSQL")
+	return pool, nil
+}
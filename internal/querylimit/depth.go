@@ -0,0 +1,75 @@
+package querylimit
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errDepthLimit = "DEPTH_LIMIT_EXCEEDED"
+
+// DepthLimit is a gqlgen extension that rejects operations whose selection
+// sets are nested deeper than Max. Fragments are expanded and do not count as a
+// level of their own; introspection fields (__schema, __type, ...) are ignored
+// so that GraphQL tooling keeps working.
+type DepthLimit struct {
+	Max int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = DepthLimit{}
+
+func (d DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d DepthLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if d.Max <= 0 || opCtx.Operation == nil {
+		return nil
+	}
+
+	depth := Depth(opCtx.Operation.SelectionSet)
+	if depth > d.Max {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.Max)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+
+	return nil
+}
+
+// Depth returns the maximum field nesting of a selection set. A selection set
+// containing only scalar fields has depth 1.
+func Depth(selectionSet ast.SelectionSet) int {
+	maxDepth := 0
+	for _, selection := range selectionSet {
+		var depth int
+		switch sel := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			depth = 1 + Depth(sel.SelectionSet)
+		case *ast.InlineFragment:
+			depth = Depth(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				depth = Depth(sel.Definition.SelectionSet)
+			}
+		}
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+	return maxDepth
+}
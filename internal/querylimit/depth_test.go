@@ -0,0 +1,72 @@
+package querylimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var testSchema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+type Query { node: Node }
+type Node { id: ID! child: Node }
+`})
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{
+			name:  "正常系: フラットなクエリ",
+			query: `{ node { id } }`,
+			want:  2,
+		},
+		{
+			name:  "正常系: ネストしたクエリ",
+			query: `{ node { child { child { id } } } }`,
+			want:  4,
+		},
+		{
+			name:  "正常系: フラグメントは展開して数える",
+			query: `query { node { ...F } } fragment F on Node { child { id } }`,
+			want:  3,
+		},
+		{
+			name:  "正常系: インラインフラグメント",
+			query: `{ node { ... on Node { child { id } } } }`,
+			want:  3,
+		},
+		{
+			name:  "正常系: イントロスペクションは無視",
+			query: `{ __schema { types { fields { type { ofType { name } } } } } node { id } }`,
+			want:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, errs := gqlparser.LoadQuery(testSchema, tt.query)
+			require.Empty(t, errs)
+			assert.Equal(t, tt.want, Depth(doc.Operations[0].SelectionSet))
+		})
+	}
+}
+
+func TestDepthLimit_MutateOperationContext(t *testing.T) {
+	doc, errs := gqlparser.LoadQuery(testSchema, `{ node { child { child { id } } } }`)
+	require.Empty(t, errs)
+	opCtx := &graphql.OperationContext{Doc: doc, Operation: doc.Operations[0]}
+
+	assert.Nil(t, DepthLimit{Max: 4}.MutateOperationContext(context.Background(), opCtx))
+
+	err := DepthLimit{Max: 3}.MutateOperationContext(context.Background(), opCtx)
+	require.NotNil(t, err)
+	assert.Equal(t, "operation has depth 4, which exceeds the limit of 3", err.Message)
+	assert.Equal(t, errDepthLimit, err.Extensions["code"])
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kuchida1981/graphql-sampleapp/internal/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Extension is a gqlgen extension that applies the Limiter once the operation
+// type is known. Throttled operations are rejected with a RATE_LIMITED error
+// and a Retry-After header.
+type Extension struct {
+	Limiter *Limiter
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = Extension{}
+
+func (e Extension) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	state := stateFromContext(ctx)
+	if state == nil || opCtx.Operation == nil {
+		return nil
+	}
+
+	allowed, retryAfter := e.Limiter.Allow(state.key, opCtx.Operation.Operation)
+	if allowed {
+		return nil
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	state.throttled = true
+	state.header.Set("Retry-After", strconv.Itoa(seconds))
+
+	err := errcode.New(errcode.RateLimited, "rate limit exceeded, retry after "+strconv.Itoa(seconds)+"s")
+	err.Extensions["retryAfter"] = seconds
+	return err
+}
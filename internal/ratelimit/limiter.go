@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/time/rate"
+)
+
+const (
+	idleTimeout     = 10 * time.Minute
+	cleanupInterval = time.Minute
+)
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter keeps one token bucket per client key and operation type.
+// Buckets that have been idle for longer than idleTimeout are discarded.
+type Limiter struct {
+	rules map[ast.Operation]config.RateLimitRule
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+func NewLimiter(cfg config.RateLimitConfig) *Limiter {
+	return &Limiter{
+		rules: map[ast.Operation]config.RateLimitRule{
+			ast.Query:        cfg.Query,
+			ast.Mutation:     cfg.Mutation,
+			ast.Subscription: cfg.Subscription,
+		},
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow consumes a token for key's bucket of the given operation type. When
+// the bucket is empty it returns false and how long the client should wait.
+func (l *Limiter) Allow(key string, op ast.Operation) (bool, time.Duration) {
+	rule, ok := l.rules[op]
+	if !ok || rule.RequestsPerSecond <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	bucketKey := string(op) + "|" + key
+	b, ok := l.buckets[bucketKey]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(rule.RequestsPerSecond), max(rule.Burst, 1))}
+		l.buckets[bucketKey] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
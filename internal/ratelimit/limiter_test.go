@@ -0,0 +1,241 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func newTestLimiter(now *time.Time) *Limiter {
+	l := NewLimiter(config.RateLimitConfig{
+		Query:    config.RateLimitRule{RequestsPerSecond: 1, Burst: 2},
+		Mutation: config.RateLimitRule{RequestsPerSecond: 0.5, Burst: 1},
+	})
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+
+	// バースト分は即時に許可される
+	for i := 0; i < 2; i++ {
+		ok, _ := l.Allow("ip:1.2.3.4", ast.Query)
+		assert.True(t, ok)
+	}
+
+	ok, retryAfter := l.Allow("ip:1.2.3.4", ast.Query)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	// 別のクライアントは影響を受けない
+	ok, _ = l.Allow("ip:5.6.7.8", ast.Query)
+	assert.True(t, ok)
+
+	// オペレーション種別ごとに独立したバケット
+	ok, _ = l.Allow("ip:1.2.3.4", ast.Mutation)
+	assert.True(t, ok)
+	ok, retryAfter = l.Allow("ip:1.2.3.4", ast.Mutation)
+	assert.False(t, ok)
+	assert.Equal(t, 2*time.Second, retryAfter)
+
+	// 制限が設定されていない種別は常に許可
+	ok, _ = l.Allow("ip:1.2.3.4", ast.Subscription)
+	assert.True(t, ok)
+
+	// トークンが補充されると再び許可される
+	now = now.Add(time.Second)
+	ok, _ = l.Allow("ip:1.2.3.4", ast.Query)
+	assert.True(t, ok)
+}
+
+func TestLimiter_CleanupIdleBuckets(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+
+	l.Allow("ip:1.2.3.4", ast.Query)
+	require.Len(t, l.buckets, 1)
+
+	now = now.Add(idleTimeout + cleanupInterval)
+	l.Allow("ip:5.6.7.8", ast.Query)
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "query|ip:5.6.7.8")
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(r *http.Request) *http.Request
+		trustProxy bool
+		want       string
+	}{
+		{
+			name: "正常系: APIキーが最優先",
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set(APIKeyHeader, "key-123")
+				return r.WithContext(auth.WithUser(r.Context(), &domain.User{ID: "user1"}))
+			},
+			want: "apikey:key-123",
+		},
+		{
+			name: "正常系: 未登録のAPIキーは無視して認証済みユーザー",
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set(APIKeyHeader, "unknown")
+				return r.WithContext(auth.WithUser(r.Context(), &domain.User{ID: "user1"}))
+			},
+			want: "user:user1",
+		},
+		{
+			name: "正常系: 未登録のAPIキーは無視してクライアントIP",
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set(APIKeyHeader, "unknown")
+				return r
+			},
+			want: "ip:192.0.2.1",
+		},
+		{
+			name: "正常系: 認証済みユーザー",
+			setup: func(r *http.Request) *http.Request {
+				return r.WithContext(auth.WithUser(r.Context(), &domain.User{ID: "user1"}))
+			},
+			want: "user:user1",
+		},
+		{
+			name:  "正常系: クライアントIP",
+			setup: func(r *http.Request) *http.Request { return r },
+			want:  "ip:192.0.2.1",
+		},
+		{
+			name: "正常系: プロキシを信頼しない場合はX-Forwarded-Forを無視",
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Forwarded-For", "203.0.113.7")
+				return r
+			},
+			want: "ip:192.0.2.1",
+		},
+		{
+			name: "正常系: プロキシを信頼する場合はX-Forwarded-Forの末尾",
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Forwarded-For", "198.51.100.9, 203.0.113.7")
+				return r
+			},
+			trustProxy: true,
+			want:       "ip:203.0.113.7",
+		},
+		{
+			name: "正常系: X-Forwarded-Forが複数ある場合は最後のヘッダーの末尾",
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Add("X-Forwarded-For", "198.51.100.9")
+				r.Header.Add("X-Forwarded-For", "203.0.113.7")
+				return r
+			},
+			trustProxy: true,
+			want:       "ip:203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/query", nil)
+			r.RemoteAddr = "192.0.2.1:54321"
+			assert.Equal(t, tt.want, clientKey(tt.setup(r), map[string]bool{"key-123": true}, tt.trustProxy))
+		})
+	}
+}
+
+func TestExtension_Throttled(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ext := Extension{Limiter: newTestLimiter(&now)}
+	opCtx := &graphql.OperationContext{Operation: &ast.OperationDefinition{Operation: ast.Mutation}}
+
+	var errs []any
+	handler := Middleware(config.RateLimitConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := ext.MutateOperationContext(r.Context(), opCtx); err != nil {
+			errs = append(errs, err.Extensions["code"])
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, httptest.NewRequest(http.MethodPost, "/query", nil))
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get("Retry-After"))
+
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, httptest.NewRequest(http.MethodPost, "/query", nil))
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	assert.Equal(t, "2", second.Header().Get("Retry-After"))
+	assert.Equal(t, []any{"RATE_LIMITED"}, errs)
+}
+
+func TestExtension_RotatedAPIKey(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ext := Extension{Limiter: newTestLimiter(&now)}
+	opCtx := &graphql.OperationContext{Operation: &ast.OperationDefinition{Operation: ast.Mutation}}
+	handler := Middleware(config.RateLimitConfig{APIKeys: []string{"key-123"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = ext.MutateOperationContext(r.Context(), opCtx)
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(apiKey string) int {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		r.Header.Set(APIKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("異常系: 未登録のAPIキーを変えても制限は解除されない", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("random-1"))
+		assert.Equal(t, http.StatusTooManyRequests, serve("random-2"))
+		assert.Equal(t, http.StatusTooManyRequests, serve("random-3"))
+	})
+
+	t.Run("正常系: 登録済みのAPIキーは別のバケット", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("key-123"))
+		assert.Equal(t, http.StatusTooManyRequests, serve("key-123"))
+	})
+
+	assert.Len(t, ext.Limiter.buckets, 2)
+}
+
+func TestExtension_SpoofedForwardedFor(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ext := Extension{Limiter: newTestLimiter(&now)}
+	opCtx := &graphql.OperationContext{Operation: &ast.OperationDefinition{Operation: ast.Mutation}}
+	handler := Middleware(config.RateLimitConfig{TrustProxy: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = ext.MutateOperationContext(r.Context(), opCtx)
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(spoofed string) int {
+		r := httptest.NewRequest(http.MethodPost, "/query", nil)
+		// The proxy appends the address it received the request from.
+		r.Header.Set("X-Forwarded-For", spoofed+", 203.0.113.7")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("198.51.100.3"))
+	assert.Len(t, ext.Limiter.buckets, 1)
+}
+
+func TestExtension_WithoutMiddleware(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ext := Extension{Limiter: newTestLimiter(&now)}
+	opCtx := &graphql.OperationContext{Operation: &ast.OperationDefinition{Operation: ast.Query}}
+
+	assert.Nil(t, ext.MutateOperationContext(context.Background(), opCtx))
+}
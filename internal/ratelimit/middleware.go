@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+)
+
+// APIKeyHeader identifies API clients. A configured key takes precedence over
+// the authenticated user and the client IP when choosing a rate limit bucket.
+const APIKeyHeader = "X-API-Key"
+
+type contextKey struct{}
+
+// requestState carries the client key into the gqlgen extension and lets the
+// extension mark the request as throttled so that the response status can be
+// rewritten to 429.
+type requestState struct {
+	key       string
+	header    http.Header
+	throttled bool
+}
+
+// Middleware resolves the rate limit key for each request. It must wrap the
+// GraphQL handler inside auth.Middleware so that the authenticated user is
+// already in the request context.
+func Middleware(cfg config.RateLimitConfig) func(http.Handler) http.Handler {
+	apiKeys := make(map[string]bool, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		apiKeys[key] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			state := &requestState{key: clientKey(r, apiKeys, cfg.TrustProxy), header: w.Header()}
+			ctx := context.WithValue(r.Context(), contextKey{}, state)
+			next.ServeHTTP(&statusWriter{ResponseWriter: w, state: state}, r.WithContext(ctx))
+		})
+	}
+}
+
+// clientKey ignores API keys missing from apiKeys, which would otherwise let
+// a client bypass the limit by sending a new key with each request.
+func clientKey(r *http.Request, apiKeys map[string]bool, trustProxy bool) string {
+	if apiKey := r.Header.Get(APIKeyHeader); apiKeys[apiKey] {
+		return "apikey:" + apiKey
+	}
+	if user := auth.UserFromContext(r.Context()); user != nil {
+		return "user:" + user.ID
+	}
+	return "ip:" + clientIP(r, trustProxy)
+}
+
+// clientIP takes the rightmost X-Forwarded-For entry, the one appended by the
+// trusted proxy. The entries before it come from the client, which could
+// otherwise get a new bucket with each request by changing them.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if values := r.Header.Values("X-Forwarded-For"); trustProxy && len(values) > 0 {
+		forwarded := values[len(values)-1]
+		if i := strings.LastIndex(forwarded, ","); i >= 0 {
+			forwarded = forwarded[i+1:]
+		}
+		if ip := strings.TrimSpace(forwarded); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func stateFromContext(ctx context.Context) *requestState {
+	state, _ := ctx.Value(contextKey{}).(*requestState)
+	return state
+}
+
+// statusWriter replaces the status code of throttled responses with 429.
+type statusWriter struct {
+	http.ResponseWriter
+	state       *requestState
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.state.throttled {
+		code = http.StatusTooManyRequests
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx recording the ID of the user on whose
+// behalf repository writes are made. Repositories store it as updated_by.
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// ActorFromContext returns the ID recorded by WithActor, or "" if none.
+func ActorFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(actorKey{}).(string)
+	return id
+}
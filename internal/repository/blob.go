@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore keeps the contents of attachments under opaque keys. Contents are
+// written once and never modified.
+type BlobStore interface {
+	// Put stores the contents read from r under key.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Open returns the contents stored under key, or a NotFound error. The
+	// caller must close the reader.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+type ChannelFilter struct {
+	// MemberID limits the result to channels the user belongs to. Empty
+	// lists every channel.
+	MemberID        string
+	IncludeArchived bool
+}
+
+// ChannelRepository stores channels and their members next to the users they
+// reference.
+type ChannelRepository interface {
+	// List returns the channels matching filter, ordered by name.
+	List(ctx context.Context, filter ChannelFilter) ([]*domain.Channel, error)
+	// GetByID returns a channel whether or not it is archived.
+	GetByID(ctx context.Context, id string) (*domain.Channel, error)
+	// Create stores channel together with its members. It fails with
+	// ErrConflict if the ID or name is taken and with ErrInvalid if a member
+	// does not exist. Call it within a transaction so that a failure leaves
+	// no partial channel behind.
+	Create(ctx context.Context, channel *domain.Channel) error
+	// Archive marks the channel archived and returns it, failing with
+	// ErrNotFound if it does not exist or is already archived.
+	Archive(ctx context.Context, id string) (*domain.Channel, error)
+	// RecordMessage counts a top-level message posted to the channel. Unread
+	// counts are derived from this counter, so that they can be computed
+	// without reading the messages, which may be stored elsewhere. It fails
+	// with ErrNotFound if the channel does not exist.
+	RecordMessage(ctx context.Context, channelID string) error
+	// RemoveMessages uncounts n top-level messages deleted from the channel,
+	// which must be its oldest ones, as retention deletes them. Read
+	// positions move back as well, so that unread counts only lose the
+	// deleted messages that were unread. Call it within a transaction so that
+	// a failure leaves no count half updated. It fails with ErrNotFound if
+	// the channel does not exist.
+	RemoveMessages(ctx context.Context, channelID string, n int) error
+	// MarkRead records that userID has read every message counted so far.
+	// The read position never moves back, even if readAt is older than the
+	// stored one. It fails with ErrNotFound if the channel does not exist.
+	MarkRead(ctx context.Context, channelID, userID string, readAt time.Time) error
+	// Reads returns userID's read position in each of channelIDs, keyed by
+	// channel ID, with a single query. Channels that do not exist are left
+	// out.
+	Reads(ctx context.Context, userID string, channelIDs []string) (map[string]*domain.ChannelRead, error)
+}
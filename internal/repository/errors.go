@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds returned by every repository implementation. Match them with
+// errors.Is; the concrete error is an *Error carrying a client-safe message.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrUnavailable = errors.New("unavailable")
+	ErrInvalid     = errors.New("invalid")
+)
+
+// Error is a classified repository failure. Message describes the failure
+// without backend details and may be shown to clients; Err is the underlying
+// cause, if any, and is only logged.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool { return target == e.Kind }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// NotFoundf reports that the requested record does not exist.
+func NotFoundf(format string, args ...any) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflict reports that a write collided with existing data, such as a
+// duplicate key.
+func Conflict(message string, err error) error {
+	return &Error{Kind: ErrConflict, Message: message, Err: err}
+}
+
+// Invalid reports that the backend rejected the input.
+func Invalid(message string, err error) error {
+	return &Error{Kind: ErrInvalid, Message: message, Err: err}
+}
+
+// Unavailable reports that the backend could not be reached or is
+// temporarily overloaded; retrying later may succeed.
+func Unavailable(message string, err error) error {
+	return &Error{Kind: ErrUnavailable, Message: message, Err: err}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantKind    error
+		wantMessage string
+		wantCause   bool
+	}{
+		{
+			name:        "正常系: NotFoundf",
+			err:         NotFoundf("user %s not found", "u1"),
+			wantKind:    ErrNotFound,
+			wantMessage: "user u1 not found",
+		},
+		{
+			name:        "正常系: Conflict",
+			err:         Conflict("user already exists", cause),
+			wantKind:    ErrConflict,
+			wantMessage: "user already exists",
+			wantCause:   true,
+		},
+		{
+			name:        "正常系: Invalid",
+			err:         Invalid("invalid user", cause),
+			wantKind:    ErrInvalid,
+			wantMessage: "invalid user",
+			wantCause:   true,
+		},
+		{
+			name:        "正常系: ラップされたUnavailable",
+			err:         fmt.Errorf("failed to fetch user: %w", Unavailable("database unavailable", cause)),
+			wantKind:    ErrUnavailable,
+			wantMessage: "database unavailable",
+			wantCause:   true,
+		},
+	}
+
+	kinds := []error{ErrNotFound, ErrConflict, ErrUnavailable, ErrInvalid}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, kind := range kinds {
+				if got := errors.Is(tt.err, kind); got != (kind == tt.wantKind) {
+					t.Errorf("errors.Is(%v) = %v", kind, got)
+				}
+			}
+
+			var repoErr *Error
+			if !errors.As(tt.err, &repoErr) {
+				t.Fatalf("errors.As(*Error) = false")
+			}
+			if repoErr.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", repoErr.Message, tt.wantMessage)
+			}
+			if got := errors.Is(tt.err, cause); got != tt.wantCause {
+				t.Errorf("errors.Is(cause) = %v, want %v", got, tt.wantCause)
+			}
+		})
+	}
+}
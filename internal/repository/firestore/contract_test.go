@@ -0,0 +1,77 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	firestoreClient "github.com/kuchida1981/graphql-sampleapp/internal/firestore"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository/repositorytest"
+)
+
+// newContractClient connects to the emulator at FIRESTORE_EMULATOR_HOST.
+func newContractClient(t *testing.T) *firestore.Client {
+	t.Helper()
+	host := os.Getenv("FIRESTORE_EMULATOR_HOST")
+	if host == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set")
+	}
+
+	client, err := firestoreClient.NewClient(context.Background(), "contract-test", config.FirestoreConfig{EmulatorHost: host})
+	if err != nil {
+		t.Fatalf("failed to create Firestore client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// seedCollection writes docs under a collection prefix unique to the calling
+// test, so subtests never see each other's documents.
+func seedCollection[T any](t *testing.T, client *firestore.Client, collection string, docs map[string]T) string {
+	t.Helper()
+	ctx := context.Background()
+	prefix := fmt.Sprintf("contract_%d_", time.Now().UnixNano())
+
+	for id, doc := range docs {
+		if _, err := client.Collection(prefix+collection).Doc(id).Set(ctx, doc); err != nil {
+			t.Fatalf("failed to seed %s/%s: %v", collection, id, err)
+		}
+	}
+	t.Cleanup(func() {
+		for id := range docs {
+			client.Collection(prefix + collection).Doc(id).Delete(context.Background())
+		}
+	})
+	return prefix
+}
+
+func TestContract(t *testing.T) {
+	client := newContractClient(t)
+
+	t.Run("MessageRepository", func(t *testing.T) {
+		repositorytest.TestMessageRepository(t, func(t *testing.T, messages []*domain.Message) repository.MessageRepository {
+			docs := make(map[string]*domain.Message, len(messages))
+			for _, m := range messages {
+				docs[m.ID] = m
+			}
+			prefix := seedCollection(t, client, messagesCollection, docs)
+			return NewFirestoreMessageRepository(client, WithCollectionPrefix(prefix))
+		})
+	})
+	t.Run("WeatherAlertRepository", func(t *testing.T) {
+		repositorytest.TestWeatherAlertRepository(t, func(t *testing.T, alerts []*domain.WeatherAlert) repository.WeatherAlertRepository {
+			docs := make(map[string]*domain.WeatherAlert, len(alerts))
+			for _, a := range alerts {
+				docs[a.ID] = a
+			}
+			prefix := seedCollection(t, client, weatherAlertsCollection, docs)
+			return NewFirestoreWeatherAlertRepository(client, WithCollectionPrefix(prefix))
+		})
+	})
+}
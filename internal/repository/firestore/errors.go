@@ -0,0 +1,27 @@
+package firestore
+
+import (
+	"fmt"
+
+	firestoreClient "github.com/kuchida1981/graphql-sampleapp/internal/firestore"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// classifyError wraps err in the matching repository error kind, or with a
+// plain message when it is not one the caller can act on. FailedPrecondition
+// is left unclassified: Firestore mostly returns it for a query missing its
+// composite index, which is a server misconfiguration rather than bad input.
+func classifyError(message string, err error) error {
+	switch status.Code(err) {
+	case codes.AlreadyExists:
+		return repository.Conflict(message, err)
+	case codes.InvalidArgument, codes.OutOfRange:
+		return repository.Invalid(message, err)
+	}
+	if firestoreClient.IsTransient(err) {
+		return repository.Unavailable(message, err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
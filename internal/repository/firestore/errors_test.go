@@ -0,0 +1,41 @@
+package firestore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error
+	}{
+		{"AlreadyExistsはConflict", status.Error(codes.AlreadyExists, "exists"), repository.ErrConflict},
+		{"InvalidArgumentはInvalid", status.Error(codes.InvalidArgument, "bad"), repository.ErrInvalid},
+		{"UnavailableはUnavailable", status.Error(codes.Unavailable, "down"), repository.ErrUnavailable},
+		{"DeadlineExceededはUnavailable", status.Error(codes.DeadlineExceeded, "slow"), repository.ErrUnavailable},
+		{"PermissionDeniedは分類しない", status.Error(codes.PermissionDenied, "denied"), nil},
+		{"FailedPrecondition（インデックス未作成）は分類しない", status.Error(codes.FailedPrecondition, "The query requires an index"), nil},
+		{"gRPC以外のエラーは分類しない", errors.New("boom"), nil},
+	}
+
+	kinds := []error{repository.ErrNotFound, repository.ErrConflict, repository.ErrUnavailable, repository.ErrInvalid}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError("failed to fetch message", tt.err)
+			if !errors.Is(got, tt.err) {
+				t.Errorf("classifyError() = %v, does not wrap the cause", got)
+			}
+			for _, kind := range kinds {
+				if is := errors.Is(got, kind); is != (kind == tt.wantKind) {
+					t.Errorf("errors.Is(%v) = %v", kind, is)
+				}
+			}
+		})
+	}
+}
@@ -2,69 +2,781 @@ package firestore
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FirestoreMessageRepository struct {
 	client *firestore.Client
+	opts   options
 }
 
-func NewFirestoreMessageRepository(client *firestore.Client) *FirestoreMessageRepository {
+func NewFirestoreMessageRepository(client *firestore.Client, opts ...Option) *FirestoreMessageRepository {
 	return &FirestoreMessageRepository{
 		client: client,
+		opts:   newOptions(opts),
 	}
 }
 
+// List reads the messages outside channels, which needs the composite index on
+// channelId, createdAt and __name__ that ListByChannel uses. Messages written
+// before channels existed have no channelId field and are left out until
+// scripts/backfill-firestore-channel-ids.go has been run.
 func (r *FirestoreMessageRepository) List(ctx context.Context) ([]*domain.Message, error) {
 	log.Println("Fetching all messages from Firestore")
 
-	iter := r.client.Collection("messages").OrderBy("createdAt", firestore.Desc).Documents(ctx)
-	defer iter.Stop()
-
 	var messages []*domain.Message
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			log.Printf("Error iterating messages: %v", err)
-			return nil, err
-		}
+	err := r.opts.do(ctx, func() error {
+		messages = nil
+		iter := r.opts.collection(r.client, messagesCollection).
+			Where("channelId", "==", "").
+			OrderBy("createdAt", firestore.Desc).
+			Documents(ctx)
+		defer iter.Stop()
 
-		var msg domain.Message
-		if err := doc.DataTo(&msg); err != nil {
-			log.Printf("Error converting document to Message: %v", err)
-			return nil, err
-		}
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
 
-		messages = append(messages, &msg)
+			var msg domain.Message
+			if err := doc.DataTo(&msg); err != nil {
+				log.Printf("Error converting document to Message: %v", err)
+				return err
+			}
+			messages = append(messages, &msg)
+		}
+	})
+	if err != nil {
+		log.Printf("Error iterating messages: %v", err)
+		return nil, classifyError("failed to list messages", err)
 	}
 
 	log.Printf("Successfully fetched %d messages", len(messages))
 	return messages, nil
 }
 
+// get reads a top-level message or a reply. Replies live under their parent,
+// so they are looked up by their stored id with a collection group query,
+// which needs the collection group index on id in firestore.indexes.json.
+func (r *FirestoreMessageRepository) get(ctx context.Context, id string) (*firestore.DocumentSnapshot, error) {
+	var doc *firestore.DocumentSnapshot
+	err := r.opts.do(ctx, func() (err error) {
+		doc, err = r.opts.collection(r.client, messagesCollection).Doc(id).Get(ctx)
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+		docs, err := r.client.CollectionGroup(r.opts.collectionPrefix+repliesCollection).
+			Where("id", "==", id).Limit(1).Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return status.Errorf(codes.NotFound, "message %s not found", id)
+		}
+		doc = docs[0]
+		return nil
+	})
+	return doc, err
+}
+
 func (r *FirestoreMessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
 	log.Printf("Fetching message with ID: %s", id)
 
-	doc, err := r.client.Collection("messages").Doc(id).Get(ctx)
+	doc, err := r.get(ctx, id)
 	if err != nil {
 		log.Printf("Error fetching message %s: %v", id, err)
-		return nil, fmt.Errorf("message not found: %w", err)
+		if status.Code(err) == codes.NotFound {
+			return nil, repository.NotFoundf("message %s not found", id)
+		}
+		return nil, classifyError("failed to get message", err)
 	}
 
 	var msg domain.Message
 	if err := doc.DataTo(&msg); err != nil {
 		log.Printf("Error converting document to Message: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to decode message %s: %w", id, err)
 	}
 
 	log.Printf("Successfully fetched message: %s", id)
 	return &msg, nil
 }
+
+// GetByIDs reads the top-level messages in one batch and looks up the rest as
+// replies with one collection group query per inQueryLimit IDs, rather than
+// one query per reply as GetByID does.
+func (r *FirestoreMessageRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Message, error) {
+	log.Printf("Fetching %d messages", len(ids))
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = r.opts.collection(r.client, messagesCollection).Doc(id)
+	}
+
+	var docs []*firestore.DocumentSnapshot
+	err := r.opts.do(ctx, func() error {
+		found, err := r.client.GetAll(ctx, refs)
+		if err != nil {
+			return err
+		}
+		docs = docs[:0]
+		var missing []string
+		for i, doc := range found {
+			if doc.Exists() {
+				docs = append(docs, doc)
+			} else {
+				missing = append(missing, ids[i])
+			}
+		}
+		for chunk := range slices.Chunk(missing, inQueryLimit) {
+			replies, err := r.client.CollectionGroup(r.opts.collectionPrefix+repliesCollection).
+				Where("id", "in", chunk).Documents(ctx).GetAll()
+			if err != nil {
+				return err
+			}
+			docs = append(docs, replies...)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error fetching messages: %v", err)
+		return nil, classifyError("failed to get messages", err)
+	}
+
+	messages := make([]*domain.Message, 0, len(docs))
+	for _, doc := range docs {
+		var msg domain.Message
+		if err := doc.DataTo(&msg); err != nil {
+			log.Printf("Error converting document to Message: %v", err)
+			return nil, fmt.Errorf("failed to decode message %s: %w", doc.Ref.ID, err)
+		}
+		messages = append(messages, &msg)
+	}
+	slices.SortFunc(messages, func(a, b *domain.Message) int { return strings.Compare(a.ID, b.ID) })
+
+	log.Printf("Successfully fetched %d of %d messages", len(messages), len(ids))
+	return messages, nil
+}
+
+// Create stores a top-level message in the messages collection and a reply in
+// the replies subcollection of its parent, so that a thread can be read with a
+// single query. Writes are not retried since a retried create would conflict
+// with itself.
+func (r *FirestoreMessageRepository) Create(ctx context.Context, msg *domain.Message) error {
+	log.Printf("Creating message with ID: %s", msg.ID)
+
+	if msg.ParentID == "" {
+		if _, err := r.opts.collection(r.client, messagesCollection).Doc(msg.ID).Create(ctx, msg); err != nil {
+			log.Printf("Error creating message %s: %v", msg.ID, err)
+			return classifyError("failed to create message", err)
+		}
+		return nil
+	}
+
+	parentRef := r.opts.collection(r.client, messagesCollection).Doc(msg.ParentID)
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(parentRef)
+		if err != nil {
+			return err
+		}
+		// Replies share the channel of their parent.
+		channelID, err := doc.DataAt("channelId")
+		if err == nil {
+			msg.ChannelID, _ = channelID.(string)
+		}
+		if err := tx.Create(r.opts.replies(r.client, msg.ParentID).Doc(msg.ID), msg); err != nil {
+			return err
+		}
+		return tx.Update(parentRef, []firestore.Update{{Path: "replyCount", Value: firestore.Increment(1)}})
+	})
+	if err != nil {
+		log.Printf("Error creating reply %s: %v", msg.ID, err)
+		if status.Code(err) == codes.NotFound {
+			return repository.NotFoundf("message %s not found", msg.ParentID)
+		}
+		return classifyError("failed to create reply", err)
+	}
+
+	log.Printf("Successfully created reply %s to %s", msg.ID, msg.ParentID)
+	return nil
+}
+
+// ListByChannel needs a composite index on channelId (ascending), createdAt
+// (descending) and __name__ (descending).
+func (r *FirestoreMessageRepository) ListByChannel(ctx context.Context, channelID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Printf("Fetching messages in channel %s", channelID)
+
+	query := r.opts.collection(r.client, messagesCollection).
+		Where("channelId", "==", channelID).
+		OrderBy("createdAt", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc)
+	if page.After != nil {
+		query = query.StartAfter(page.After.CreatedAt, page.After.ID)
+	}
+
+	result, err := r.queryPage(ctx, query, page.Limit)
+	if err != nil {
+		log.Printf("Error fetching messages in channel %s: %v", channelID, err)
+		return nil, classifyError("failed to list channel messages", err)
+	}
+	return result, nil
+}
+
+// ListMentions needs a composite index on mentionIds (array-contains),
+// createdAt (descending) and id (descending) for the messages collection and
+// the replies collection group.
+func (r *FirestoreMessageRepository) ListMentions(ctx context.Context, userID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Printf("Fetching messages mentioning %s", userID)
+
+	result, err := r.queryAll(ctx, func(q firestore.Query) firestore.Query {
+		return q.Where("mentionIds", "array-contains", userID)
+	}, firestore.Desc, page)
+	if err != nil {
+		log.Printf("Error fetching messages mentioning %s: %v", userID, err)
+		return nil, classifyError("failed to list mentions", err)
+	}
+	return result, nil
+}
+
+// ListFlagged needs a composite index on moderation.status (ascending),
+// createdAt (ascending) and id (ascending) for the messages collection and
+// the replies collection group.
+func (r *FirestoreMessageRepository) ListFlagged(ctx context.Context, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Println("Fetching flagged messages")
+
+	result, err := r.queryAll(ctx, func(q firestore.Query) firestore.Query {
+		return q.Where("moderation.status", "==", domain.ModerationFlagged)
+	}, firestore.Asc, page)
+	if err != nil {
+		log.Printf("Error fetching flagged messages: %v", err)
+		return nil, classifyError("failed to list flagged messages", err)
+	}
+	return result, nil
+}
+
+// queryAll applies filter to top-level messages and to the replies collection
+// group separately and merges the two pages. Both order by the stored id
+// rather than the document path, which differs between the two.
+func (r *FirestoreMessageRepository) queryAll(ctx context.Context, filter func(firestore.Query) firestore.Query, dir firestore.Direction, page repository.PageRequest) (*repository.MessagePage, error) {
+	queries := []firestore.Query{
+		r.opts.collection(r.client, messagesCollection).Query,
+		r.client.CollectionGroup(r.opts.collectionPrefix + repliesCollection).Query,
+	}
+	pages := make([]*repository.MessagePage, len(queries))
+	for i, query := range queries {
+		query = filter(query).OrderBy("createdAt", dir).OrderBy("id", dir)
+		if page.After != nil {
+			query = query.StartAfter(page.After.CreatedAt, page.After.ID)
+		}
+		result, err := r.queryPage(ctx, query, page.Limit)
+		if err != nil {
+			return nil, err
+		}
+		pages[i] = result
+	}
+	return mergePages(pages, dir, page.Limit), nil
+}
+
+// mergePages combines pages sorted in direction dir into one page of at most
+// limit messages. A page with more to come already holds limit messages, so
+// the combined page is full and followed by another one.
+func mergePages(pages []*repository.MessagePage, dir firestore.Direction, limit int) *repository.MessagePage {
+	result := &repository.MessagePage{}
+	for _, page := range pages {
+		result.Messages = append(result.Messages, page.Messages...)
+		result.HasNextPage = result.HasNextPage || page.HasNextPage
+	}
+	slices.SortFunc(result.Messages, func(a, b *domain.Message) int {
+		c := a.CreatedAt.Compare(b.CreatedAt)
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if dir == firestore.Desc {
+			return -c
+		}
+		return c
+	})
+	if len(result.Messages) > limit {
+		result.Messages = result.Messages[:limit]
+		result.HasNextPage = true
+	}
+	return result
+}
+
+func (r *FirestoreMessageRepository) ListReplies(ctx context.Context, parentID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Printf("Fetching replies to message %s", parentID)
+
+	query := r.opts.replies(r.client, parentID).
+		OrderBy("createdAt", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc)
+	if page.After != nil {
+		query = query.StartAfter(page.After.CreatedAt, page.After.ID)
+	}
+
+	result, err := r.queryPage(ctx, query, page.Limit)
+	if err != nil {
+		log.Printf("Error fetching replies to %s: %v", parentID, err)
+		return nil, classifyError("failed to list replies", err)
+	}
+	return result, nil
+}
+
+func (r *FirestoreMessageRepository) Scan(ctx context.Context, page repository.PageRequest) (*repository.MessagePage, error) {
+	query := r.opts.collection(r.client, messagesCollection).
+		OrderBy("createdAt", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc)
+	if page.After != nil {
+		query = query.StartAfter(page.After.CreatedAt, page.After.ID)
+	}
+
+	result, err := r.queryPage(ctx, query, page.Limit)
+	if err != nil {
+		log.Printf("Error scanning messages: %v", err)
+		return nil, classifyError("failed to scan messages", err)
+	}
+	return result, nil
+}
+
+// queryPage fetches one document more than limit, so that the extra document
+// tells whether another page follows.
+func (r *FirestoreMessageRepository) queryPage(ctx context.Context, query firestore.Query, limit int) (*repository.MessagePage, error) {
+	var messages []*domain.Message
+	err := r.opts.do(ctx, func() error {
+		docs, err := query.Limit(limit + 1).Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		messages = make([]*domain.Message, 0, len(docs))
+		for _, doc := range docs {
+			var msg domain.Message
+			if err := doc.DataTo(&msg); err != nil {
+				return fmt.Errorf("failed to decode message %s: %w", doc.Ref.ID, err)
+			}
+			messages = append(messages, &msg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &repository.MessagePage{Messages: messages}
+	if len(messages) > limit {
+		result.Messages = messages[:limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
+
+// AddReaction stores the reaction in the reactions subcollection of the
+// message and increments reactionCounts.<emoji> on the message in the same
+// transaction, so the counts always match the stored reactions.
+func (r *FirestoreMessageRepository) AddReaction(ctx context.Context, reaction *domain.Reaction) error {
+	log.Printf("Adding reaction %s by %s to message %s", reaction.Emoji, reaction.UserID, reaction.MessageID)
+
+	msgRef, err := r.ref(ctx, reaction.MessageID)
+	if err != nil {
+		return err
+	}
+	reactionRef := r.reactionRef(msgRef, reaction.Emoji, reaction.UserID)
+
+	err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		_, err := tx.Get(reactionRef)
+		if err == nil {
+			return repository.Conflict(fmt.Sprintf("user %s already reacted with %s", reaction.UserID, reaction.Emoji), nil)
+		}
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err := tx.Create(reactionRef, reaction); err != nil {
+			return err
+		}
+		return tx.Update(msgRef, []firestore.Update{{
+			FieldPath: firestore.FieldPath{"reactionCounts", reaction.Emoji},
+			Value:     firestore.Increment(1),
+		}})
+	})
+	if err != nil {
+		log.Printf("Error adding reaction to message %s: %v", reaction.MessageID, err)
+		return r.classifyTxError("failed to add reaction", reaction.MessageID, err)
+	}
+	return nil
+}
+
+// RemoveReaction deletes the reaction and decrements its count in one
+// transaction. The count is removed from the message once it reaches zero.
+func (r *FirestoreMessageRepository) RemoveReaction(ctx context.Context, messageID, emoji, userID string) error {
+	log.Printf("Removing reaction %s by %s from message %s", emoji, userID, messageID)
+
+	msgRef, err := r.ref(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	reactionRef := r.reactionRef(msgRef, emoji, userID)
+
+	err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(reactionRef); err != nil {
+			if status.Code(err) == codes.NotFound {
+				return repository.NotFoundf("reaction %s by %s to message %s not found", emoji, userID, messageID)
+			}
+			return err
+		}
+		doc, err := tx.Get(msgRef)
+		if err != nil {
+			return err
+		}
+		var msg domain.Message
+		if err := doc.DataTo(&msg); err != nil {
+			return fmt.Errorf("failed to decode message %s: %w", messageID, err)
+		}
+
+		var count any = firestore.Increment(-1)
+		if msg.ReactionCounts[emoji] <= 1 {
+			count = firestore.Delete
+		}
+		if err := tx.Delete(reactionRef); err != nil {
+			return err
+		}
+		return tx.Update(msgRef, []firestore.Update{{FieldPath: firestore.FieldPath{"reactionCounts", emoji}, Value: count}})
+	})
+	if err != nil {
+		log.Printf("Error removing reaction from message %s: %v", messageID, err)
+		return r.classifyTxError("failed to remove reaction", messageID, err)
+	}
+	return nil
+}
+
+// inQueryLimit is the most values an "in" filter may compare against.
+const inQueryLimit = 30
+
+// UserReactions reads the reactions of every message with a collection group
+// query per inQueryLimit messages, which needs a composite index on userId
+// (ascending) and messageId (ascending) for the reactions collection group.
+func (r *FirestoreMessageRepository) UserReactions(ctx context.Context, messageIDs []string, userID string) (map[string][]string, error) {
+	var emojis map[string][]string
+	err := r.opts.do(ctx, func() error {
+		emojis = make(map[string][]string)
+		for ids := range slices.Chunk(messageIDs, inQueryLimit) {
+			docs, err := r.client.CollectionGroup(r.opts.collectionPrefix+reactionsCollection).
+				Where("userId", "==", userID).Where("messageId", "in", ids).Documents(ctx).GetAll()
+			if err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				var reaction domain.Reaction
+				if err := doc.DataTo(&reaction); err != nil {
+					return fmt.Errorf("failed to decode reaction %s: %w", doc.Ref.ID, err)
+				}
+				emojis[reaction.MessageID] = append(emojis[reaction.MessageID], reaction.Emoji)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error fetching reactions of %s to %d message(s): %v", userID, len(messageIDs), err)
+		return nil, classifyError("failed to list reactions", err)
+	}
+	return emojis, nil
+}
+
+// Edit stores the replaced content in the history subcollection of the
+// message and updates the message in one transaction, so that concurrent edits
+// each record the content the previous one wrote.
+func (r *FirestoreMessageRepository) Edit(ctx context.Context, id, content string, mentionIDs []string, moderation *domain.Moderation, editorID string, editedAt time.Time) error {
+	log.Printf("Editing message %s by %s", id, editorID)
+
+	msgRef, err := r.ref(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(msgRef)
+		if err != nil {
+			return err
+		}
+		var msg domain.Message
+		if err := doc.DataTo(&msg); err != nil {
+			return fmt.Errorf("failed to decode message %s: %w", id, err)
+		}
+
+		edit := &domain.MessageEdit{MessageID: id, Content: msg.Content, EditorID: editorID, EditedAt: editedAt}
+		if err := tx.Create(msgRef.Collection(r.opts.collectionPrefix+historyCollection).NewDoc(), edit); err != nil {
+			return err
+		}
+		updates := []firestore.Update{
+			{Path: "content", Value: content},
+			{Path: "mentionIds", Value: mentionIDs},
+			{Path: "editedAt", Value: editedAt},
+		}
+		if moderation != nil {
+			flagged := *moderation
+			flagged.Counted = !msg.Hidden() || msg.Moderation.Counted
+			updates = append(updates, firestore.Update{Path: "moderation", Value: &flagged})
+		}
+		return tx.Update(msgRef, updates)
+	})
+	if err != nil {
+		log.Printf("Error editing message %s: %v", id, err)
+		return r.classifyTxError("failed to edit message", id, err)
+	}
+	return nil
+}
+
+func (r *FirestoreMessageRepository) SetModeration(ctx context.Context, id string, moderation *domain.Moderation) error {
+	log.Printf("Setting moderation of message %s", id)
+
+	msgRef, err := r.ref(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Reading the message in the transaction makes a concurrent decision
+	// retry and then see the message no longer flagged.
+	err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(msgRef)
+		if err != nil {
+			return err
+		}
+		var msg domain.Message
+		if err := doc.DataTo(&msg); err != nil {
+			return fmt.Errorf("failed to decode message %s: %w", id, err)
+		}
+		if msg.Moderation == nil || msg.Moderation.Status != domain.ModerationFlagged {
+			return repository.Conflict(fmt.Sprintf("message %s is not awaiting moderation", id), nil)
+		}
+		return tx.Update(msgRef, []firestore.Update{{Path: "moderation", Value: moderation}})
+	})
+	if err != nil {
+		log.Printf("Error setting moderation of message %s: %v", id, err)
+		return r.classifyTxError("failed to set moderation", id, err)
+	}
+	return nil
+}
+
+// ListExpired needs a composite index on channelId (ascending), createdAt
+// (ascending) and __name__ (ascending) when filter.ChannelID is set, and on
+// createdAt (ascending), channelId (ascending) and __name__ (ascending) when
+// filter.ExcludeChannelIDs is. A not-in filter takes at most 10 values, which
+// the config checks.
+func (r *FirestoreMessageRepository) ListExpired(ctx context.Context, filter repository.ExpiryFilter, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Printf("Fetching messages created before %s", filter.Before.Format(time.RFC3339))
+
+	query := r.opts.collection(r.client, messagesCollection).Where("createdAt", "<", filter.Before)
+	if filter.ChannelID != "" {
+		query = query.Where("channelId", "==", filter.ChannelID)
+	}
+	if len(filter.ExcludeChannelIDs) > 0 {
+		query = query.Where("channelId", "not-in", filter.ExcludeChannelIDs)
+	}
+	query = query.OrderBy("createdAt", firestore.Asc).OrderBy(firestore.DocumentID, firestore.Asc)
+	if page.After != nil {
+		query = query.StartAfter(page.After.CreatedAt, page.After.ID)
+	}
+
+	result, err := r.queryPage(ctx, query, page.Limit)
+	if err != nil {
+		log.Printf("Error fetching expired messages: %v", err)
+		return nil, classifyError("failed to list expired messages", err)
+	}
+	return result, nil
+}
+
+// Purge deletes the documents of the threads with a BulkWriter. Subcollections
+// are not deleted with their parent document in Firestore, and a BulkWriter
+// applies its writes in no particular order, so the reactions, history and
+// replies of every thread are flushed before the top-level messages are
+// deleted. A top-level message is deleted only if it still exists, and its
+// thread is returned only then, so that of purges reading the same thread at
+// the same time only one returns it. A purge that fails part way leaves the
+// messages for the next one to find.
+func (r *FirestoreMessageRepository) Purge(ctx context.Context, ids []string) ([]*domain.Message, error) {
+	log.Printf("Purging %d message(s)", len(ids))
+
+	var threadMessages [][]*domain.Message
+	var children, threads []*firestore.DocumentRef
+	for _, id := range ids {
+		messages, refs, err := r.threadDocuments(ctx, id)
+		if err != nil {
+			log.Printf("Error reading thread %s: %v", id, err)
+			return nil, classifyError("failed to purge messages", err)
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		threadMessages = append(threadMessages, messages)
+		children = append(children, refs[:len(refs)-1]...)
+		threads = append(threads, refs[len(refs)-1])
+	}
+
+	writer := r.client.BulkWriter(ctx)
+	defer writer.End()
+	if err := bulkDelete(writer, children); err != nil {
+		log.Printf("Error purging messages: %v", err)
+		return nil, classifyError("failed to purge messages", err)
+	}
+	jobs := make([]*firestore.BulkWriterJob, 0, len(threads))
+	for _, ref := range threads {
+		job, err := writer.Delete(ref, firestore.Exists)
+		if err != nil {
+			log.Printf("Error purging messages: %v", err)
+			return nil, classifyError("failed to purge messages", err)
+		}
+		jobs = append(jobs, job)
+	}
+	writer.Flush()
+
+	var deleted []*domain.Message
+	for i, job := range jobs {
+		_, err := job.Results()
+		if status.Code(err) == codes.NotFound {
+			// Another purge deleted the thread first.
+			continue
+		}
+		if err != nil {
+			log.Printf("Error purging messages: %v", err)
+			return nil, classifyError("failed to purge messages", err)
+		}
+		deleted = append(deleted, threadMessages[i]...)
+	}
+	return deleted, nil
+}
+
+// bulkDelete deletes refs with writer and waits for the deletes to finish.
+func bulkDelete(writer *firestore.BulkWriter, refs []*firestore.DocumentRef) error {
+	jobs := make([]*firestore.BulkWriterJob, 0, len(refs))
+	for _, ref := range refs {
+		job, err := writer.Delete(ref)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+	writer.Flush()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// threadDocuments returns the messages of the thread started by id and the
+// documents to delete for it, in deletion order with the top-level message
+// last. It returns nothing when id is not a top-level message.
+func (r *FirestoreMessageRepository) threadDocuments(ctx context.Context, id string) ([]*domain.Message, []*firestore.DocumentRef, error) {
+	var messages []*domain.Message
+	var refs []*firestore.DocumentRef
+	err := r.opts.do(ctx, func() error {
+		messages, refs = nil, nil
+		msgRef := r.opts.collection(r.client, messagesCollection).Doc(id)
+		doc, err := msgRef.Get(ctx)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		replies, err := r.opts.replies(r.client, id).Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range append(replies, doc) {
+			var msg domain.Message
+			if err := doc.DataTo(&msg); err != nil {
+				return fmt.Errorf("failed to decode message %s: %w", doc.Ref.ID, err)
+			}
+			for _, name := range []string{reactionsCollection, historyCollection} {
+				children, err := doc.Ref.Collection(r.opts.collectionPrefix + name).DocumentRefs(ctx).GetAll()
+				if err != nil {
+					return err
+				}
+				refs = append(refs, children...)
+			}
+			refs = append(refs, doc.Ref)
+			messages = append(messages, &msg)
+		}
+		return nil
+	})
+	return messages, refs, err
+}
+
+func (r *FirestoreMessageRepository) History(ctx context.Context, messageID string) ([]*domain.MessageEdit, error) {
+	msgRef, err := r.ref(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []*domain.MessageEdit
+	err = r.opts.do(ctx, func() error {
+		docs, err := msgRef.Collection(r.opts.collectionPrefix+historyCollection).
+			OrderBy("editedAt", firestore.Asc).Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		edits = make([]*domain.MessageEdit, 0, len(docs))
+		for _, doc := range docs {
+			var edit domain.MessageEdit
+			if err := doc.DataTo(&edit); err != nil {
+				return fmt.Errorf("failed to decode message edit %s: %w", doc.Ref.ID, err)
+			}
+			edits = append(edits, &edit)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error fetching history of message %s: %v", messageID, err)
+		return nil, classifyError("failed to list message history", err)
+	}
+	return edits, nil
+}
+
+// ref resolves the document of a top-level message or a reply.
+func (r *FirestoreMessageRepository) ref(ctx context.Context, id string) (*firestore.DocumentRef, error) {
+	doc, err := r.get(ctx, id)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, repository.NotFoundf("message %s not found", id)
+		}
+		return nil, classifyError("failed to get message", err)
+	}
+	return doc.Ref, nil
+}
+
+// reactionRef names reaction documents after the user and the hex-encoded
+// emoji, so that a user can hold at most one reaction per emoji.
+func (r *FirestoreMessageRepository) reactionRef(msgRef *firestore.DocumentRef, emoji, userID string) *firestore.DocumentRef {
+	return msgRef.Collection(r.opts.collectionPrefix + reactionsCollection).Doc(fmt.Sprintf("%s_%x", userID, emoji))
+}
+
+// classifyTxError passes through the repository errors returned from inside a
+// transaction and classifies the rest.
+func (r *FirestoreMessageRepository) classifyTxError(message, messageID string, err error) error {
+	var repoErr *repository.Error
+	if errors.As(err, &repoErr) {
+		return err
+	}
+	if status.Code(err) == codes.NotFound {
+		return repository.NotFoundf("message %s not found", messageID)
+	}
+	return classifyError(message, err)
+}
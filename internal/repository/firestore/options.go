@@ -0,0 +1,64 @@
+package firestore
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	firestoreClient "github.com/kuchida1981/graphql-sampleapp/internal/firestore"
+)
+
+const (
+	messagesCollection      = "messages"
+	repliesCollection       = "replies"
+	reactionsCollection     = "reactions"
+	historyCollection       = "history"
+	weatherAlertsCollection = "weatherAlerts"
+)
+
+// Option customizes a Firestore repository.
+type Option func(*options)
+
+type options struct {
+	collectionPrefix string
+	retry            config.FirestoreRetryConfig
+}
+
+// WithCollectionPrefix prepends prefix to the repository's collection name so
+// that several environments can share one database.
+func WithCollectionPrefix(prefix string) Option {
+	return func(o *options) {
+		o.collectionPrefix = prefix
+	}
+}
+
+// WithRetry retries reads and idempotent writes that fail with a transient
+// gRPC error.
+func WithRetry(cfg config.FirestoreRetryConfig) Option {
+	return func(o *options) {
+		o.retry = cfg
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) collection(client *firestore.Client, name string) *firestore.CollectionRef {
+	return client.Collection(o.collectionPrefix + name)
+}
+
+// replies returns the subcollection holding the replies to parentID. It is
+// prefixed as well so that collection group queries stay within one
+// environment.
+func (o options) replies(client *firestore.Client, parentID string) *firestore.CollectionRef {
+	return o.collection(client, messagesCollection).Doc(parentID).Collection(o.collectionPrefix + repliesCollection)
+}
+
+func (o options) do(ctx context.Context, fn func() error) error {
+	return firestoreClient.Retry(ctx, o.retry, fn)
+}
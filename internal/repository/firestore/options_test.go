@@ -0,0 +1,41 @@
+package firestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+)
+
+func TestNewOptions(t *testing.T) {
+	retry := config.FirestoreRetryConfig{MaxAttempts: 5, InitialBackoff: time.Millisecond}
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		wantPrefix string
+		wantRetry  config.FirestoreRetryConfig
+	}{
+		{
+			name: "正常系: オプションなし",
+		},
+		{
+			name:       "正常系: プレフィックスとリトライを指定",
+			opts:       []Option{WithCollectionPrefix("staging_"), WithRetry(retry)},
+			wantPrefix: "staging_",
+			wantRetry:  retry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newOptions(tt.opts)
+			if got.collectionPrefix != tt.wantPrefix {
+				t.Errorf("collectionPrefix = %q, want %q", got.collectionPrefix, tt.wantPrefix)
+			}
+			if got.retry != tt.wantRetry {
+				t.Errorf("retry = %+v, want %+v", got.retry, tt.wantRetry)
+			}
+		})
+	}
+}
@@ -7,31 +7,48 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FirestoreWeatherAlertRepository struct {
 	client *firestore.Client
+	opts   options
 }
 
-func NewFirestoreWeatherAlertRepository(client *firestore.Client) *FirestoreWeatherAlertRepository {
+func NewFirestoreWeatherAlertRepository(client *firestore.Client, opts ...Option) *FirestoreWeatherAlertRepository {
 	return &FirestoreWeatherAlertRepository{
 		client: client,
+		opts:   newOptions(opts),
 	}
 }
 
+func (r *FirestoreWeatherAlertRepository) get(ctx context.Context, id string) (*firestore.DocumentSnapshot, error) {
+	var doc *firestore.DocumentSnapshot
+	err := r.opts.do(ctx, func() (err error) {
+		doc, err = r.opts.collection(r.client, weatherAlertsCollection).Doc(id).Get(ctx)
+		return err
+	})
+	return doc, err
+}
+
 func (r *FirestoreWeatherAlertRepository) GetByID(ctx context.Context, id string) (*domain.WeatherAlert, error) {
 	log.Printf("FirestoreWeatherAlertRepository: Fetching weather alert with ID: %s", id)
 
-	doc, err := r.client.Collection("weatherAlerts").Doc(id).Get(ctx)
+	doc, err := r.get(ctx, id)
 	if err != nil {
 		log.Printf("FirestoreWeatherAlertRepository: Error fetching weather alert %s: %v", id, err)
-		return nil, fmt.Errorf("weather alert not found: %w", err)
+		if status.Code(err) == codes.NotFound {
+			return nil, repository.NotFoundf("weather alert %s not found", id)
+		}
+		return nil, classifyError("failed to get weather alert", err)
 	}
 
 	var alert domain.WeatherAlert
 	if err := doc.DataTo(&alert); err != nil {
 		log.Printf("FirestoreWeatherAlertRepository: Error converting document to WeatherAlert: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to decode weather alert %s: %w", id, err)
 	}
 
 	log.Printf("FirestoreWeatherAlertRepository: Successfully fetched weather alert: %s", id)
@@ -47,11 +64,15 @@ func (r *FirestoreWeatherAlertRepository) GetByIDs(ctx context.Context, ids []st
 
 	var alerts []*domain.WeatherAlert
 	for _, id := range ids {
-		doc, err := r.client.Collection("weatherAlerts").Doc(id).Get(ctx)
-		if err != nil {
-			log.Printf("FirestoreWeatherAlertRepository: Warning - failed to fetch weather alert %s: %v (skipping)", id, err)
+		doc, err := r.get(ctx, id)
+		if status.Code(err) == codes.NotFound {
+			log.Printf("FirestoreWeatherAlertRepository: Warning - weather alert %s not found (skipping)", id)
 			continue
 		}
+		if err != nil {
+			log.Printf("FirestoreWeatherAlertRepository: Failed to fetch weather alert %s: %v", id, err)
+			return nil, classifyError("failed to get weather alerts", err)
+		}
 
 		var alert domain.WeatherAlert
 		if err := doc.DataTo(&alert); err != nil {
@@ -65,3 +86,17 @@ func (r *FirestoreWeatherAlertRepository) GetByIDs(ctx context.Context, ids []st
 	log.Printf("FirestoreWeatherAlertRepository: Successfully fetched %d out of %d weather alerts", len(alerts), len(ids))
 	return alerts, nil
 }
+
+func (r *FirestoreWeatherAlertRepository) Put(ctx context.Context, alert *domain.WeatherAlert) error {
+	log.Printf("FirestoreWeatherAlertRepository: Putting weather alert: %s", alert.ID)
+
+	err := r.opts.do(ctx, func() error {
+		_, err := r.opts.collection(r.client, weatherAlertsCollection).Doc(alert.ID).Set(ctx, alert)
+		return err
+	})
+	if err != nil {
+		log.Printf("FirestoreWeatherAlertRepository: Failed to put weather alert %s: %v", alert.ID, err)
+		return classifyError("failed to save weather alert", err)
+	}
+	return nil
+}
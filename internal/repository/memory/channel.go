@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// MemoryChannelRepository checks members against users, as the foreign key
+// on channel_members does in the SQL backends.
+type MemoryChannelRepository struct {
+	mu       sync.RWMutex
+	channels map[string]*domain.Channel
+	users    *MemoryUserRepository
+	// messageCounts and reads mirror channels.message_count and
+	// channel_reads. reads is keyed by user ID, then channel ID.
+	messageCounts map[string]int
+	reads         map[string]map[string]*channelRead
+}
+
+type channelRead struct {
+	count      int
+	lastReadAt time.Time
+}
+
+func NewMemoryChannelRepository(users *MemoryUserRepository) *MemoryChannelRepository {
+	return &MemoryChannelRepository{
+		channels:      make(map[string]*domain.Channel),
+		users:         users,
+		messageCounts: make(map[string]int),
+		reads:         make(map[string]map[string]*channelRead),
+	}
+}
+
+func (r *MemoryChannelRepository) List(ctx context.Context, filter repository.ChannelFilter) ([]*domain.Channel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	channels := make([]*domain.Channel, 0, len(r.channels))
+	for _, channel := range r.channels {
+		if channel.ArchivedAt != nil && !filter.IncludeArchived {
+			continue
+		}
+		if filter.MemberID != "" && !channel.HasMember(filter.MemberID) {
+			continue
+		}
+		channels = append(channels, cloneChannel(channel))
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+
+	return channels, nil
+}
+
+func (r *MemoryChannelRepository) GetByID(ctx context.Context, id string) (*domain.Channel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	channel, ok := r.channels[id]
+	if !ok {
+		return nil, repository.NotFoundf("channel %s not found", id)
+	}
+	return cloneChannel(channel), nil
+}
+
+func (r *MemoryChannelRepository) Create(ctx context.Context, channel *domain.Channel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.channels {
+		if c.ID == channel.ID || c.Name == channel.Name {
+			return repository.Conflict(fmt.Sprintf("channel %s already exists", channel.Name), nil)
+		}
+	}
+	for _, userID := range channel.MemberIDs {
+		if !r.users.exists(userID) {
+			return repository.Invalid(fmt.Sprintf("user %s does not exist", userID), nil)
+		}
+	}
+
+	c := cloneChannel(channel)
+	slices.Sort(c.MemberIDs)
+	c.MemberIDs = slices.Compact(c.MemberIDs)
+	r.channels[channel.ID] = c
+	return nil
+}
+
+func (r *MemoryChannelRepository) Archive(ctx context.Context, id string) (*domain.Channel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	channel, ok := r.channels[id]
+	if !ok || channel.ArchivedAt != nil {
+		return nil, repository.NotFoundf("channel %s not found", id)
+	}
+	now := time.Now().UTC()
+	channel.ArchivedAt = &now
+	return cloneChannel(channel), nil
+}
+
+func (r *MemoryChannelRepository) RecordMessage(ctx context.Context, channelID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.channels[channelID]; !ok {
+		return repository.NotFoundf("channel %s not found", channelID)
+	}
+	r.messageCounts[channelID]++
+	return nil
+}
+
+func (r *MemoryChannelRepository) RemoveMessages(ctx context.Context, channelID string, n int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.channels[channelID]; !ok {
+		return repository.NotFoundf("channel %s not found", channelID)
+	}
+	r.messageCounts[channelID] = max(r.messageCounts[channelID]-n, 0)
+	for _, reads := range r.reads {
+		if read, ok := reads[channelID]; ok {
+			read.count = max(read.count-n, 0)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryChannelRepository) MarkRead(ctx context.Context, channelID, userID string, readAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.channels[channelID]; !ok {
+		return repository.NotFoundf("channel %s not found", channelID)
+	}
+	if !r.users.exists(userID) {
+		return repository.Invalid(fmt.Sprintf("user %s does not exist", userID), nil)
+	}
+	if r.reads[userID] == nil {
+		r.reads[userID] = make(map[string]*channelRead)
+	}
+	read, ok := r.reads[userID][channelID]
+	if !ok {
+		read = &channelRead{}
+		r.reads[userID][channelID] = read
+	}
+	read.count = max(read.count, r.messageCounts[channelID])
+	if readAt.After(read.lastReadAt) {
+		read.lastReadAt = readAt.UTC()
+	}
+	return nil
+}
+
+func (r *MemoryChannelRepository) Reads(ctx context.Context, userID string, channelIDs []string) (map[string]*domain.ChannelRead, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reads := make(map[string]*domain.ChannelRead, len(channelIDs))
+	for _, id := range channelIDs {
+		if _, ok := r.channels[id]; !ok {
+			continue
+		}
+		read := &domain.ChannelRead{ChannelID: id, UnreadCount: r.messageCounts[id]}
+		if stored, ok := r.reads[userID][id]; ok {
+			lastReadAt := stored.lastReadAt
+			read.LastReadAt = &lastReadAt
+			read.UnreadCount -= stored.count
+		}
+		reads[id] = read
+	}
+	return reads, nil
+}
+
+func (r *MemoryChannelRepository) snapshot() func() {
+	r.mu.RLock()
+	channels := cloneMap(r.channels, cloneChannel)
+	messageCounts := maps.Clone(r.messageCounts)
+	reads := cloneMap(r.reads, func(reads map[string]*channelRead) map[string]*channelRead {
+		return cloneMap(reads, func(read *channelRead) *channelRead {
+			c := *read
+			return &c
+		})
+	})
+	r.mu.RUnlock()
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.channels, r.messageCounts, r.reads = channels, messageCounts, reads
+	}
+}
+
+func cloneChannel(channel *domain.Channel) *domain.Channel {
+	c := *channel
+	c.MemberIDs = slices.Clone(channel.MemberIDs)
+	if channel.ArchivedAt != nil {
+		archivedAt := *channel.ArchivedAt
+		c.ArchivedAt = &archivedAt
+	}
+	return &c
+}
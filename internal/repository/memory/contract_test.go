@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository/repositorytest"
+)
+
+func TestContract(t *testing.T) {
+	t.Run("MessageRepository", func(t *testing.T) {
+		repositorytest.TestMessageRepository(t, func(t *testing.T, messages []*domain.Message) repository.MessageRepository {
+			return NewMemoryMessageRepository(messages)
+		})
+	})
+	t.Run("ChannelRepository", func(t *testing.T) {
+		repositorytest.TestChannelRepository(t, func(t *testing.T, users []*domain.User) repository.ChannelRepository {
+			return NewMemoryChannelRepository(NewMemoryUserRepository(users))
+		})
+	})
+	t.Run("SearchRepository", func(t *testing.T) {
+		repositorytest.TestSearchRepository(t, func(t *testing.T) repository.SearchRepository {
+			return NewMemorySearchRepository()
+		})
+	})
+	t.Run("UserRepository", func(t *testing.T) {
+		repositorytest.TestUserRepository(t, func(t *testing.T, users []*domain.User) repository.UserRepository {
+			return NewMemoryUserRepository(users)
+		})
+	})
+	t.Run("WeatherAlertRepository", func(t *testing.T) {
+		repositorytest.TestWeatherAlertRepository(t, func(t *testing.T, alerts []*domain.WeatherAlert) repository.WeatherAlertRepository {
+			return NewMemoryWeatherAlertRepository(alerts)
+		})
+	})
+	t.Run("WeatherAlertMetadataRepository", func(t *testing.T) {
+		repositorytest.TestWeatherAlertMetadataRepository(t, func(t *testing.T, metadata []*domain.WeatherAlertMetadata) repository.WeatherAlertMetadataRepository {
+			return NewMemoryWeatherAlertMetadataRepository(metadata)
+		})
+	})
+}
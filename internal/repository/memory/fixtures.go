@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+// Fixtures is the seed data loaded into the in-memory repositories. See
+// fixtures/dev.json for the file format.
+type Fixtures struct {
+	Users                []*domain.User
+	Messages             []*domain.Message
+	WeatherAlerts        []*domain.WeatherAlert
+	WeatherAlertMetadata []*domain.WeatherAlertMetadata
+}
+
+type fixturesFile struct {
+	Users []struct {
+		ID        string    `json:"id"`
+		Name      string    `json:"name"`
+		Handle    string    `json:"handle"`
+		Email     string    `json:"email"`
+		Roles     []string  `json:"roles"`
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"users"`
+	Messages []struct {
+		ID        string    `json:"id"`
+		Content   string    `json:"content"`
+		Author    string    `json:"author"`
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"messages"`
+	WeatherAlerts []struct {
+		ID              string                 `json:"id"`
+		Title           string                 `json:"title"`
+		Description     string                 `json:"description"`
+		RawData         map[string]interface{} `json:"rawData"`
+		AffectedAreas   []string               `json:"affectedAreas"`
+		Recommendations []string               `json:"recommendations"`
+	} `json:"weatherAlerts"`
+	WeatherAlertMetadata []struct {
+		ID        string    `json:"id"`
+		Region    string    `json:"region"`
+		Severity  string    `json:"severity"`
+		IssuedAt  time.Time `json:"issuedAt"`
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"weatherAlertMetadata"`
+}
+
+// LoadFixtures reads seed data from a JSON file.
+func LoadFixtures(path string) (*Fixtures, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var file fixturesFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures %s: %w", path, err)
+	}
+
+	f := &Fixtures{}
+	for _, u := range file.Users {
+		roles := u.Roles
+		if len(roles) == 0 {
+			roles = []string{domain.RoleUser}
+		}
+		f.Users = append(f.Users, &domain.User{ID: u.ID, Name: u.Name, Handle: u.Handle, Email: u.Email, Roles: roles, CreatedAt: u.CreatedAt, UpdatedAt: u.CreatedAt})
+	}
+	for _, m := range file.Messages {
+		f.Messages = append(f.Messages, &domain.Message{ID: m.ID, Content: m.Content, Author: m.Author, CreatedAt: m.CreatedAt})
+	}
+	for _, a := range file.WeatherAlerts {
+		f.WeatherAlerts = append(f.WeatherAlerts, &domain.WeatherAlert{
+			ID:              a.ID,
+			Title:           a.Title,
+			Description:     a.Description,
+			RawData:         a.RawData,
+			AffectedAreas:   a.AffectedAreas,
+			Recommendations: a.Recommendations,
+		})
+	}
+	for _, m := range file.WeatherAlertMetadata {
+		f.WeatherAlertMetadata = append(f.WeatherAlertMetadata, &domain.WeatherAlertMetadata{
+			ID:        m.ID,
+			Region:    m.Region,
+			Severity:  m.Severity,
+			IssuedAt:  m.IssuedAt,
+			CreatedAt: m.CreatedAt,
+			UpdatedAt: m.CreatedAt,
+		})
+	}
+
+	return f, nil
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	t.Run("正常系: 同梱のdev.jsonを読み込み", func(t *testing.T) {
+		f, err := LoadFixtures(filepath.Join("..", "..", "..", "fixtures", "dev.json"))
+		if err != nil {
+			t.Fatalf("LoadFixtures() error = %v", err)
+		}
+		if len(f.Users) == 0 || len(f.Messages) == 0 || len(f.WeatherAlerts) == 0 || len(f.WeatherAlertMetadata) == 0 {
+			t.Errorf("LoadFixtures() = %d users, %d messages, %d alerts, %d metadata; want all non-empty",
+				len(f.Users), len(f.Messages), len(f.WeatherAlerts), len(f.WeatherAlertMetadata))
+		}
+		if f.Users[0].CreatedAt.IsZero() {
+			t.Error("LoadFixtures() did not parse createdAt")
+		}
+	})
+
+	t.Run("正常系: ロール未指定はuser", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "seed.json")
+		if err := os.WriteFile(path, []byte(`{"users":[{"id":"u1","name":"A","email":"a@example.com"}]}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		f, err := LoadFixtures(path)
+		if err != nil {
+			t.Fatalf("LoadFixtures() error = %v", err)
+		}
+		if len(f.Users) != 1 || len(f.Users[0].Roles) != 1 || f.Users[0].Roles[0] != "user" {
+			t.Errorf("LoadFixtures() users = %+v", f.Users)
+		}
+	})
+
+	t.Run("異常系: ファイルが存在しない", func(t *testing.T) {
+		if _, err := LoadFixtures(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+			t.Error("LoadFixtures() error = nil, want error")
+		}
+	})
+
+	t.Run("異常系: 不正なJSON", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broken.json")
+		if err := os.WriteFile(path, []byte(`{"users":`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFixtures(path); err == nil {
+			t.Error("LoadFixtures() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,347 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type MemoryMessageRepository struct {
+	mu       sync.RWMutex
+	messages map[string]*domain.Message
+	// reactions holds the reactions to each message, keyed by message ID.
+	reactions map[string][]*domain.Reaction
+	// history holds the replaced versions of each message, keyed by message
+	// ID.
+	history map[string][]*domain.MessageEdit
+}
+
+func NewMemoryMessageRepository(messages []*domain.Message) *MemoryMessageRepository {
+	r := &MemoryMessageRepository{
+		messages:  make(map[string]*domain.Message, len(messages)),
+		reactions: make(map[string][]*domain.Reaction),
+		history:   make(map[string][]*domain.MessageEdit),
+	}
+	for _, msg := range messages {
+		r.messages[msg.ID] = cloneMessage(msg)
+	}
+	return r
+}
+
+func (r *MemoryMessageRepository) List(ctx context.Context) ([]*domain.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	messages := make([]*domain.Message, 0, len(r.messages))
+	for _, msg := range r.messages {
+		if msg.ParentID != "" || msg.ChannelID != "" {
+			continue
+		}
+		messages = append(messages, cloneMessage(msg))
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].CreatedAt.After(messages[j].CreatedAt) })
+
+	return messages, nil
+}
+
+func (r *MemoryMessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	msg, ok := r.messages[id]
+	if !ok {
+		return nil, repository.NotFoundf("message %s not found", id)
+	}
+	return cloneMessage(msg), nil
+}
+
+func (r *MemoryMessageRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var messages []*domain.Message
+	for _, msg := range r.messages {
+		if slices.Contains(ids, msg.ID) {
+			messages = append(messages, cloneMessage(msg))
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+
+	return messages, nil
+}
+
+func (r *MemoryMessageRepository) Create(ctx context.Context, msg *domain.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.messages[msg.ID]; ok {
+		return repository.Conflict(fmt.Sprintf("message %s already exists", msg.ID), nil)
+	}
+	if msg.ParentID != "" {
+		parent, ok := r.messages[msg.ParentID]
+		if !ok || parent.ParentID != "" {
+			return repository.NotFoundf("message %s not found", msg.ParentID)
+		}
+		parent.ReplyCount++
+		msg.ChannelID = parent.ChannelID
+	}
+	r.messages[msg.ID] = cloneMessage(msg)
+	return nil
+}
+
+func (r *MemoryMessageRepository) ListReplies(ctx context.Context, parentID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.oldestFirst(page, func(msg *domain.Message) bool { return msg.ParentID == parentID }), nil
+}
+
+func (r *MemoryMessageRepository) Scan(ctx context.Context, page repository.PageRequest) (*repository.MessagePage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.oldestFirst(page, func(msg *domain.Message) bool { return msg.ParentID == "" }), nil
+}
+
+// oldestFirst returns a page of the messages matching keep in ascending
+// creation order. The caller must hold r.mu.
+func (r *MemoryMessageRepository) oldestFirst(page repository.PageRequest, keep func(*domain.Message) bool) *repository.MessagePage {
+	var messages []*domain.Message
+	for _, msg := range r.messages {
+		if keep(msg) && (page.After == nil || after(msg.CreatedAt, msg.ID, *page.After)) {
+			messages = append(messages, cloneMessage(msg))
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return after(messages[j].CreatedAt, messages[j].ID, repository.Cursor{CreatedAt: messages[i].CreatedAt, ID: messages[i].ID})
+	})
+
+	return paginate(messages, page.Limit)
+}
+
+func (r *MemoryMessageRepository) ListByChannel(ctx context.Context, channelID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.newestFirst(page, func(msg *domain.Message) bool { return msg.ChannelID == channelID && msg.ParentID == "" }), nil
+}
+
+func (r *MemoryMessageRepository) ListMentions(ctx context.Context, userID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.newestFirst(page, func(msg *domain.Message) bool { return slices.Contains(msg.MentionIDs, userID) }), nil
+}
+
+// newestFirst returns a page of the messages matching keep in descending
+// creation order. The caller must hold r.mu.
+func (r *MemoryMessageRepository) newestFirst(page repository.PageRequest, keep func(*domain.Message) bool) *repository.MessagePage {
+	var messages []*domain.Message
+	for _, msg := range r.messages {
+		if keep(msg) && (page.After == nil || before(msg.CreatedAt, msg.ID, *page.After)) {
+			messages = append(messages, cloneMessage(msg))
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return before(messages[j].CreatedAt, messages[j].ID, repository.Cursor{CreatedAt: messages[i].CreatedAt, ID: messages[i].ID})
+	})
+
+	return paginate(messages, page.Limit)
+}
+
+func (r *MemoryMessageRepository) AddReaction(ctx context.Context, reaction *domain.Reaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg, ok := r.messages[reaction.MessageID]
+	if !ok {
+		return repository.NotFoundf("message %s not found", reaction.MessageID)
+	}
+	if r.findReaction(reaction.MessageID, reaction.Emoji, reaction.UserID) >= 0 {
+		return repository.Conflict(fmt.Sprintf("user %s already reacted with %s", reaction.UserID, reaction.Emoji), nil)
+	}
+
+	c := *reaction
+	r.reactions[reaction.MessageID] = append(r.reactions[reaction.MessageID], &c)
+	if msg.ReactionCounts == nil {
+		msg.ReactionCounts = make(domain.ReactionCounts)
+	}
+	msg.ReactionCounts[reaction.Emoji]++
+	return nil
+}
+
+func (r *MemoryMessageRepository) RemoveReaction(ctx context.Context, messageID, emoji, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.findReaction(messageID, emoji, userID)
+	if i < 0 {
+		return repository.NotFoundf("reaction %s by %s to message %s not found", emoji, userID, messageID)
+	}
+
+	r.reactions[messageID] = slices.Delete(r.reactions[messageID], i, i+1)
+	msg := r.messages[messageID]
+	if msg.ReactionCounts[emoji]--; msg.ReactionCounts[emoji] <= 0 {
+		delete(msg.ReactionCounts, emoji)
+	}
+	return nil
+}
+
+func (r *MemoryMessageRepository) UserReactions(ctx context.Context, messageIDs []string, userID string) (map[string][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	emojis := make(map[string][]string)
+	for _, id := range messageIDs {
+		for _, reaction := range r.reactions[id] {
+			if reaction.UserID == userID {
+				emojis[id] = append(emojis[id], reaction.Emoji)
+			}
+		}
+	}
+	return emojis, nil
+}
+
+func (r *MemoryMessageRepository) Edit(ctx context.Context, id, content string, mentionIDs []string, moderation *domain.Moderation, editorID string, editedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg, ok := r.messages[id]
+	if !ok {
+		return repository.NotFoundf("message %s not found", id)
+	}
+
+	r.history[id] = append(r.history[id], &domain.MessageEdit{
+		MessageID: id,
+		Content:   msg.Content,
+		EditorID:  editorID,
+		EditedAt:  editedAt,
+	})
+	msg.Content = content
+	msg.MentionIDs = slices.Clone(mentionIDs)
+	msg.EditedAt = &editedAt
+	if moderation != nil {
+		counted := !msg.Hidden() || msg.Moderation.Counted
+		msg.Moderation = cloneModeration(moderation)
+		msg.Moderation.Counted = counted
+	}
+	return nil
+}
+
+func (r *MemoryMessageRepository) ListFlagged(ctx context.Context, page repository.PageRequest) (*repository.MessagePage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.oldestFirst(page, func(msg *domain.Message) bool {
+		return msg.Moderation != nil && msg.Moderation.Status == domain.ModerationFlagged
+	}), nil
+}
+
+func (r *MemoryMessageRepository) SetModeration(ctx context.Context, id string, moderation *domain.Moderation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg, ok := r.messages[id]
+	if !ok {
+		return repository.NotFoundf("message %s not found", id)
+	}
+	if msg.Moderation == nil || msg.Moderation.Status != domain.ModerationFlagged {
+		return repository.Conflict(fmt.Sprintf("message %s is not awaiting moderation", id), nil)
+	}
+	msg.Moderation = cloneModeration(moderation)
+	return nil
+}
+
+func (r *MemoryMessageRepository) ListExpired(ctx context.Context, filter repository.ExpiryFilter, page repository.PageRequest) (*repository.MessagePage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.oldestFirst(page, func(msg *domain.Message) bool {
+		return msg.ParentID == "" && msg.CreatedAt.Before(filter.Before) &&
+			(filter.ChannelID == "" || msg.ChannelID == filter.ChannelID) &&
+			(msg.ChannelID == "" || !slices.Contains(filter.ExcludeChannelIDs, msg.ChannelID))
+	}), nil
+}
+
+func (r *MemoryMessageRepository) Purge(ctx context.Context, ids []string) ([]*domain.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted []*domain.Message
+	for _, id := range ids {
+		msg, ok := r.messages[id]
+		if !ok || msg.ParentID != "" {
+			continue
+		}
+		thread := []*domain.Message{msg}
+		replies := r.oldestFirst(repository.PageRequest{Limit: len(r.messages)}, func(m *domain.Message) bool { return m.ParentID == id })
+		thread = append(thread, replies.Messages...)
+		for _, m := range thread {
+			delete(r.messages, m.ID)
+			delete(r.reactions, m.ID)
+			delete(r.history, m.ID)
+		}
+		deleted = append(deleted, thread...)
+	}
+	return deleted, nil
+}
+
+func (r *MemoryMessageRepository) History(ctx context.Context, messageID string) ([]*domain.MessageEdit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	edits := make([]*domain.MessageEdit, len(r.history[messageID]))
+	for i, edit := range r.history[messageID] {
+		c := *edit
+		edits[i] = &c
+	}
+	return edits, nil
+}
+
+func (r *MemoryMessageRepository) findReaction(messageID, emoji, userID string) int {
+	return slices.IndexFunc(r.reactions[messageID], func(reaction *domain.Reaction) bool {
+		return reaction.Emoji == emoji && reaction.UserID == userID
+	})
+}
+
+func (r *MemoryMessageRepository) snapshot() func() {
+	r.mu.RLock()
+	messages := cloneMap(r.messages, cloneMessage)
+	reactions := cloneMap(r.reactions, func(reactions []*domain.Reaction) []*domain.Reaction {
+		return cloneSlice(reactions)
+	})
+	history := cloneMap(r.history, func(edits []*domain.MessageEdit) []*domain.MessageEdit {
+		return cloneSlice(edits)
+	})
+	r.mu.RUnlock()
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.messages, r.reactions, r.history = messages, reactions, history
+	}
+}
+
+func cloneMessage(msg *domain.Message) *domain.Message {
+	c := *msg
+	c.ReactionCounts = maps.Clone(msg.ReactionCounts)
+	c.Attachments = slices.Clone(msg.Attachments)
+	c.MentionIDs = slices.Clone(msg.MentionIDs)
+	c.Moderation = cloneModeration(msg.Moderation)
+	return &c
+}
+
+func cloneModeration(moderation *domain.Moderation) *domain.Moderation {
+	if moderation == nil {
+		return nil
+	}
+	c := *moderation
+	c.Reasons = slices.Clone(moderation.Reasons)
+	return &c
+}
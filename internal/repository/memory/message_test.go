@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+func TestMemoryMessageRepository_List(t *testing.T) {
+	repo := NewMemoryMessageRepository([]*domain.Message{
+		{ID: "msg1", Content: "Hello", Author: "Alice", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "msg2", Content: "World", Author: "Bob", CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	})
+
+	got, err := repo.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "msg2" || got[1].ID != "msg1" {
+		t.Errorf("List() = %+v, want msg2, msg1 (newest first)", got)
+	}
+
+	// Returned values are copies and must not leak into the store.
+	got[0].Content = "changed"
+	again, _ := repo.GetByID(context.Background(), "msg2")
+	if again.Content != "World" {
+		t.Errorf("GetByID() content = %q, want %q", again.Content, "World")
+	}
+}
+
+func TestMemoryMessageRepository_GetByID(t *testing.T) {
+	repo := NewMemoryMessageRepository([]*domain.Message{{ID: "msg1", Content: "Hello"}})
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{name: "正常系: メッセージ取得成功", id: "msg1"},
+		{name: "異常系: メッセージが見つからない", id: "nonexistent", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetByID(context.Background(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetByID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.ID != tt.id {
+				t.Errorf("GetByID() got ID = %v, want %v", got.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestMemoryMessageRepository_Concurrent(t *testing.T) {
+	repo := NewMemoryMessageRepository([]*domain.Message{{ID: "msg1"}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.List(context.Background()); err != nil {
+				t.Errorf("List() error = %v", err)
+			}
+			if _, err := repo.GetByID(context.Background(), "msg1"); err != nil {
+				t.Errorf("GetByID() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
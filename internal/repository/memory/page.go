@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// after reports whether the item (createdAt, id) comes after cursor in
+// ascending creation order.
+func after(createdAt time.Time, id string, cursor repository.Cursor) bool {
+	if !createdAt.Equal(cursor.CreatedAt) {
+		return createdAt.After(cursor.CreatedAt)
+	}
+	return id > cursor.ID
+}
+
+// before reports whether the item (createdAt, id) comes after cursor in
+// descending creation order.
+func before(createdAt time.Time, id string, cursor repository.Cursor) bool {
+	if !createdAt.Equal(cursor.CreatedAt) {
+		return createdAt.Before(cursor.CreatedAt)
+	}
+	return id < cursor.ID
+}
+
+// paginate cuts items, which hold everything after the requested cursor in
+// order, down to one page.
+func paginate(items []*domain.Message, limit int) *repository.MessagePage {
+	page := &repository.MessagePage{Messages: items}
+	if len(items) > limit {
+		page.Messages = items[:limit]
+		page.HasNextPage = true
+	}
+	return page
+}
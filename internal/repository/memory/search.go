@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type MemorySearchRepository struct {
+	mu   sync.RWMutex
+	docs map[string]*domain.SearchDocument
+	// postings holds the IDs of the messages containing each token.
+	postings map[string]map[string]struct{}
+}
+
+func NewMemorySearchRepository() *MemorySearchRepository {
+	return &MemorySearchRepository{
+		docs:     make(map[string]*domain.SearchDocument),
+		postings: make(map[string]map[string]struct{}),
+	}
+}
+
+func (r *MemorySearchRepository) snapshot() func() {
+	r.mu.RLock()
+	docs := cloneMap(r.docs, func(doc *domain.SearchDocument) *domain.SearchDocument {
+		c := *doc
+		c.Tokens = slices.Clone(doc.Tokens)
+		return &c
+	})
+	postings := cloneMap(r.postings, maps.Clone[map[string]struct{}])
+	r.mu.RUnlock()
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.docs, r.postings = docs, postings
+	}
+}
+
+func (r *MemorySearchRepository) Index(ctx context.Context, doc *domain.SearchDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remove(doc.MessageID)
+	c := *doc
+	c.Tokens = slices.Clone(doc.Tokens)
+	r.docs[doc.MessageID] = &c
+	for _, token := range doc.Tokens {
+		if r.postings[token] == nil {
+			r.postings[token] = make(map[string]struct{})
+		}
+		r.postings[token][doc.MessageID] = struct{}{}
+	}
+	return nil
+}
+
+func (r *MemorySearchRepository) Delete(ctx context.Context, messageIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range messageIDs {
+		r.remove(id)
+	}
+	return nil
+}
+
+// remove drops the document of a message and its postings. The caller must
+// hold r.mu.
+func (r *MemorySearchRepository) remove(messageID string) {
+	doc, ok := r.docs[messageID]
+	if !ok {
+		return
+	}
+	for _, token := range doc.Tokens {
+		delete(r.postings[token], messageID)
+	}
+	delete(r.docs, messageID)
+}
+
+func (r *MemorySearchRepository) Search(ctx context.Context, query repository.SearchQuery, page repository.PageRequest) (*repository.SearchPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(query.Tokens) == 0 {
+		return &repository.SearchPage{}, nil
+	}
+
+	var docs []*domain.SearchDocument
+	for id := range r.postings[query.Tokens[0]] {
+		doc := r.docs[id]
+		if r.matches(doc, query) && (page.After == nil || before(doc.CreatedAt, doc.MessageID, *page.After)) {
+			c := *doc
+			c.Tokens = nil
+			docs = append(docs, &c)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return before(docs[j].CreatedAt, docs[j].MessageID, repository.Cursor{CreatedAt: docs[i].CreatedAt, ID: docs[i].MessageID})
+	})
+
+	result := &repository.SearchPage{Documents: docs}
+	if len(docs) > page.Limit {
+		result.Documents = docs[:page.Limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
+
+// matches applies every condition of query except the first token, which
+// selected doc. The caller must hold r.mu.
+func (r *MemorySearchRepository) matches(doc *domain.SearchDocument, query repository.SearchQuery) bool {
+	for _, token := range query.Tokens[1:] {
+		if _, ok := r.postings[token][doc.MessageID]; !ok {
+			return false
+		}
+	}
+	if query.Author != "" && doc.Author != query.Author {
+		return false
+	}
+	if query.From != nil && doc.CreatedAt.Before(*query.From) {
+		return false
+	}
+	if query.To != nil && !doc.CreatedAt.Before(*query.To) {
+		return false
+	}
+	return query.AllChannels || doc.ChannelID == "" || slices.Contains(query.ChannelIDs, doc.ChannelID)
+}
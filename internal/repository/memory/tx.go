@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type txKey struct{}
+
+// snapshotter is a repository whose state a transaction can restore.
+type snapshotter interface {
+	// snapshot copies the state and returns a function putting it back.
+	snapshot() (restore func())
+}
+
+// MemoryTxManager implements repository.Transactor by running transactions
+// one at a time, which makes them serializable with respect to each other
+// whatever the requested isolation. When fn fails, the repositories given to
+// NewMemoryTxManager are restored to their state before the transaction, as
+// a database would roll back; other repositories, standing in for Firestore,
+// keep their writes. Writes made outside any transaction while one runs are
+// lost with the rollback.
+type MemoryTxManager struct {
+	mu    sync.Mutex
+	repos []snapshotter
+}
+
+// NewMemoryTxManager returns a MemoryTxManager rolling back repos.
+func NewMemoryTxManager(repos ...snapshotter) *MemoryTxManager {
+	return &MemoryTxManager{repos: repos}
+}
+
+func (m *MemoryTxManager) WithinTx(ctx context.Context, opts repository.TxOptions, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	restores := make([]func(), len(m.repos))
+	for i, repo := range m.repos {
+		restores[i] = repo.snapshot()
+	}
+	err := fn(context.WithValue(ctx, txKey{}, m))
+	if err != nil {
+		for _, restore := range slices.Backward(restores) {
+			restore()
+		}
+	}
+	return err
+}
+
+// cloneMap copies m, cloning each value with clone.
+func cloneMap[K comparable, V any](m map[K]V, clone func(V) V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = clone(v)
+	}
+	return c
+}
+
+// cloneSlice copies s together with the values it points to.
+func cloneSlice[T any](s []*T) []*T {
+	c := make([]*T, len(s))
+	for i, v := range s {
+		copied := *v
+		c[i] = &copied
+	}
+	return c
+}
+
+var _ repository.Transactor = (*MemoryTxManager)(nil)
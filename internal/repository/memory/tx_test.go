@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+func TestMemoryTxManager(t *testing.T) {
+	manager := NewMemoryTxManager()
+	ctx := context.Background()
+
+	t.Run("正常系: 入れ子のWithinTxはデッドロックしない", func(t *testing.T) {
+		ran := false
+		err := manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+			return manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+				ran = true
+				return nil
+			})
+		})
+		if err != nil || !ran {
+			t.Errorf("WithinTx() error = %v, ran = %v", err, ran)
+		}
+	})
+
+	t.Run("正常系: トランザクションは1つずつ実行される", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var counter int
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+					// A read-modify-write that would race without serialization.
+					v := counter
+					counter = v + 1
+					return nil
+				})
+			}()
+		}
+		wg.Wait()
+		if counter != 50 {
+			t.Errorf("counter = %d, want 50", counter)
+		}
+	})
+}
+
+func TestMemoryTxManager_Rollback(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	users := NewMemoryUserRepository([]*domain.User{{ID: "user1", Name: "Alice", Email: "alice@example.com", CreatedAt: now}})
+	alerts := NewMemoryWeatherAlertRepository(nil)
+	manager := NewMemoryTxManager(users)
+
+	t.Run("異常系: 失敗したトランザクションの書き込みは取り消される", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		err := manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+			if err := users.Create(ctx, &domain.User{ID: "user2", Name: "Bob", Email: "bob@example.com", CreatedAt: now}); err != nil {
+				return err
+			}
+			if err := users.Update(ctx, &domain.User{ID: "user1", Name: "Alicia", Email: "alice@example.com", CreatedAt: now}); err != nil {
+				return err
+			}
+			if err := alerts.Put(ctx, &domain.WeatherAlert{ID: "alert1"}); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Fatalf("WithinTx() error = %v, want %v", err, errFailed)
+		}
+
+		if _, err := users.GetByID(ctx, "user2"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetByID(user2) error = %v, want ErrNotFound", err)
+		}
+		user, err := users.GetByID(ctx, "user1")
+		if err != nil || user.Name != "Alice" {
+			t.Errorf("GetByID(user1) = %+v, %v, want Alice", user, err)
+		}
+		// Repositories not given to the manager keep their writes, as
+		// Firestore does.
+		if _, err := alerts.GetByID(ctx, "alert1"); err != nil {
+			t.Errorf("Get(alert1) error = %v, want the alert kept", err)
+		}
+	})
+
+	t.Run("正常系: 成功したトランザクションの書き込みは残る", func(t *testing.T) {
+		err := manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+			return users.Create(ctx, &domain.User{ID: "user3", Name: "Carol", Email: "carol@example.com", CreatedAt: now})
+		})
+		if err != nil {
+			t.Fatalf("WithinTx() error = %v", err)
+		}
+		if _, err := users.GetByID(ctx, "user3"); err != nil {
+			t.Errorf("GetByID(user3) error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]*domain.User
+}
+
+func NewMemoryUserRepository(users []*domain.User) *MemoryUserRepository {
+	r := &MemoryUserRepository{users: make(map[string]*domain.User, len(users))}
+	for _, user := range users {
+		r.users[user.ID] = cloneUser(user)
+	}
+	return r
+}
+
+func (r *MemoryUserRepository) List(ctx context.Context, filter repository.UserFilter) ([]*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*domain.User, 0, len(r.users))
+	for _, user := range r.users {
+		if user.DeletedAt != nil && !filter.IncludeDeleted {
+			continue
+		}
+		users = append(users, cloneUser(user))
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.After(users[j].CreatedAt) })
+
+	return users, nil
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt != nil {
+		return nil, repository.NotFoundf("user %s not found", id)
+	}
+	return cloneUser(user), nil
+}
+
+func (r *MemoryUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []*domain.User
+	for _, user := range r.users {
+		if user.DeletedAt == nil && slices.Contains(ids, user.ID) {
+			users = append(users, cloneUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	return users, nil
+}
+
+func (r *MemoryUserRepository) GetByHandles(ctx context.Context, handles []string) ([]*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lower := make([]string, len(handles))
+	for i, handle := range handles {
+		lower[i] = strings.ToLower(handle)
+	}
+	var users []*domain.User
+	for _, user := range r.users {
+		if user.DeletedAt == nil && user.Handle != "" && slices.Contains(lower, strings.ToLower(user.Handle)) {
+			users = append(users, cloneUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	return users, nil
+}
+
+// exists reports whether a user row exists, including soft-deleted users.
+func (r *MemoryUserRepository) exists(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.users[id]
+	return ok
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; ok {
+		return repository.Conflict(fmt.Sprintf("user %s already exists", user.ID), nil)
+	}
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	user.UpdatedAt = user.CreatedAt
+	user.UpdatedBy = repository.ActorFromContext(ctx)
+	user.DeletedAt = nil
+	r.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.users[user.ID]
+	if !ok || existing.DeletedAt != nil {
+		return repository.NotFoundf("user %s not found", user.ID)
+	}
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now().UTC()
+	user.UpdatedBy = repository.ActorFromContext(ctx)
+	updated := cloneUser(user)
+	updated.CreatedAt = existing.CreatedAt
+	updated.DeletedAt = nil
+	r.users[user.ID] = updated
+	return nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, id string) (*domain.User, error) {
+	return r.setDeleted(ctx, id, true)
+}
+
+func (r *MemoryUserRepository) Restore(ctx context.Context, id string) (*domain.User, error) {
+	return r.setDeleted(ctx, id, false)
+}
+
+// setDeleted flips the soft-delete state of a user, treating a user that is
+// already in the requested state as not found.
+func (r *MemoryUserRepository) setDeleted(ctx context.Context, id string, deleted bool) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || (user.DeletedAt != nil) == deleted {
+		return nil, repository.NotFoundf("user %s not found", id)
+	}
+	now := time.Now().UTC()
+	user.DeletedAt = nil
+	if deleted {
+		user.DeletedAt = &now
+	}
+	user.UpdatedAt = now
+	user.UpdatedBy = repository.ActorFromContext(ctx)
+	return cloneUser(user), nil
+}
+
+// checkUnique enforces the unique email and handle constraints of the SQL
+// backends.
+func (r *MemoryUserRepository) checkUnique(user *domain.User) error {
+	for _, other := range r.users {
+		if other.ID == user.ID {
+			continue
+		}
+		if other.Email == user.Email {
+			return repository.Conflict(fmt.Sprintf("email %s is already in use", user.Email), nil)
+		}
+		if user.Handle != "" && strings.EqualFold(other.Handle, user.Handle) {
+			return repository.Conflict(fmt.Sprintf("handle %s is already in use", user.Handle), nil)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryUserRepository) snapshot() func() {
+	r.mu.RLock()
+	users := cloneMap(r.users, cloneUser)
+	r.mu.RUnlock()
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.users = users
+	}
+}
+
+func cloneUser(user *domain.User) *domain.User {
+	c := *user
+	c.Roles = slices.Clone(user.Roles)
+	if user.DeletedAt != nil {
+		deletedAt := *user.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	return &c
+}
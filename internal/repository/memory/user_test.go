@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+func TestMemoryUserRepository(t *testing.T) {
+	repo := NewMemoryUserRepository([]*domain.User{
+		{ID: "user1", Name: "Alice", Roles: []string{"user", "admin"}, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "user2", Name: "Bob", Roles: []string{"user"}, CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	})
+	ctx := context.Background()
+
+	t.Run("正常系: 作成日時の降順で一覧取得", func(t *testing.T) {
+		got, err := repo.List(ctx, repository.UserFilter{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(got) != 2 || got[0].ID != "user2" || got[1].ID != "user1" {
+			t.Errorf("List() = %+v, want user2, user1", got)
+		}
+	})
+
+	t.Run("正常系: ロールを含めて取得", func(t *testing.T) {
+		got, err := repo.GetByID(ctx, "user1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if !slices.Equal(got.Roles, []string{"user", "admin"}) {
+			t.Errorf("GetByID() roles = %v", got.Roles)
+		}
+
+		got.Roles[0] = "changed"
+		again, _ := repo.GetByID(ctx, "user1")
+		if again.Roles[0] != "user" {
+			t.Errorf("roles were shared with the caller: %v", again.Roles)
+		}
+	})
+
+	t.Run("異常系: ユーザーが見つからない", func(t *testing.T) {
+		if _, err := repo.GetByID(ctx, "nonexistent"); err == nil {
+			t.Error("GetByID() error = nil, want error")
+		}
+	})
+}
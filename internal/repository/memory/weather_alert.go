@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type MemoryWeatherAlertRepository struct {
+	mu     sync.RWMutex
+	alerts map[string]*domain.WeatherAlert
+}
+
+func NewMemoryWeatherAlertRepository(alerts []*domain.WeatherAlert) *MemoryWeatherAlertRepository {
+	r := &MemoryWeatherAlertRepository{alerts: make(map[string]*domain.WeatherAlert, len(alerts))}
+	for _, alert := range alerts {
+		r.alerts[alert.ID] = cloneWeatherAlert(alert)
+	}
+	return r
+}
+
+func (r *MemoryWeatherAlertRepository) GetByID(ctx context.Context, id string) (*domain.WeatherAlert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alert, ok := r.alerts[id]
+	if !ok {
+		return nil, repository.NotFoundf("weather alert %s not found", id)
+	}
+	return cloneWeatherAlert(alert), nil
+}
+
+// GetByIDs returns the alerts in the order of ids, skipping unknown IDs like
+// the Firestore implementation does.
+func (r *MemoryWeatherAlertRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.WeatherAlert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alerts := make([]*domain.WeatherAlert, 0, len(ids))
+	for _, id := range ids {
+		if alert, ok := r.alerts[id]; ok {
+			alerts = append(alerts, cloneWeatherAlert(alert))
+		}
+	}
+	return alerts, nil
+}
+
+func (r *MemoryWeatherAlertRepository) Put(ctx context.Context, alert *domain.WeatherAlert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.alerts[alert.ID] = cloneWeatherAlert(alert)
+	return nil
+}
+
+func cloneWeatherAlert(alert *domain.WeatherAlert) *domain.WeatherAlert {
+	c := *alert
+	c.RawData = maps.Clone(alert.RawData)
+	c.AffectedAreas = slices.Clone(alert.AffectedAreas)
+	c.Recommendations = slices.Clone(alert.Recommendations)
+	return &c
+}
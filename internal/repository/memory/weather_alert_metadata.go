@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type MemoryWeatherAlertMetadataRepository struct {
+	mu       sync.RWMutex
+	metadata map[string]*domain.WeatherAlertMetadata
+}
+
+func NewMemoryWeatherAlertMetadataRepository(metadata []*domain.WeatherAlertMetadata) *MemoryWeatherAlertMetadataRepository {
+	r := &MemoryWeatherAlertMetadataRepository{metadata: make(map[string]*domain.WeatherAlertMetadata, len(metadata))}
+	for _, m := range metadata {
+		r.metadata[m.ID] = cloneMetadata(m)
+	}
+	return r
+}
+
+func (r *MemoryWeatherAlertMetadataRepository) SearchIDs(ctx context.Context, filter repository.MetadataFilter) ([]string, error) {
+	metadata, err := r.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(metadata))
+	for i, m := range metadata {
+		ids[i] = m.ID
+	}
+	return ids, nil
+}
+
+// Search applies the same semantics as the PostgreSQL implementation: an exact
+// region match, issued_at >= IssuedAfter, newest first.
+func (r *MemoryWeatherAlertMetadataRepository) Search(ctx context.Context, filter repository.MetadataFilter) ([]*domain.WeatherAlertMetadata, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*domain.WeatherAlertMetadata
+	for _, m := range r.metadata {
+		if m.DeletedAt != nil && !filter.IncludeDeleted {
+			continue
+		}
+		if filter.Region != nil && m.Region != *filter.Region {
+			continue
+		}
+		if filter.IssuedAfter != nil && m.IssuedAt.Before(*filter.IssuedAfter) {
+			continue
+		}
+		result = append(result, cloneMetadata(m))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].IssuedAt.After(result[j].IssuedAt) })
+
+	return result, nil
+}
+
+func (r *MemoryWeatherAlertMetadataRepository) Create(ctx context.Context, metadata *domain.WeatherAlertMetadata) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.metadata[metadata.ID]; ok {
+		return repository.Conflict(fmt.Sprintf("weather alert metadata %s already exists", metadata.ID), nil)
+	}
+	metadata.UpdatedAt = metadata.CreatedAt
+	metadata.UpdatedBy = repository.ActorFromContext(ctx)
+	metadata.DeletedAt = nil
+	r.metadata[metadata.ID] = cloneMetadata(metadata)
+	return nil
+}
+
+func (r *MemoryWeatherAlertMetadataRepository) Delete(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error) {
+	return r.setDeleted(ctx, id, true)
+}
+
+func (r *MemoryWeatherAlertMetadataRepository) Restore(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error) {
+	return r.setDeleted(ctx, id, false)
+}
+
+func (r *MemoryWeatherAlertMetadataRepository) setDeleted(ctx context.Context, id string, deleted bool) (*domain.WeatherAlertMetadata, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.metadata[id]
+	if !ok || (m.DeletedAt != nil) == deleted {
+		return nil, repository.NotFoundf("weather alert metadata %s not found", id)
+	}
+	now := time.Now().UTC()
+	m.DeletedAt = nil
+	if deleted {
+		m.DeletedAt = &now
+	}
+	m.UpdatedAt = now
+	m.UpdatedBy = repository.ActorFromContext(ctx)
+	return cloneMetadata(m), nil
+}
+
+func (r *MemoryWeatherAlertMetadataRepository) snapshot() func() {
+	r.mu.RLock()
+	metadata := cloneMap(r.metadata, cloneMetadata)
+	r.mu.RUnlock()
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.metadata = metadata
+	}
+}
+
+func cloneMetadata(m *domain.WeatherAlertMetadata) *domain.WeatherAlertMetadata {
+	c := *m
+	if m.DeletedAt != nil {
+		deletedAt := *m.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	return &c
+}
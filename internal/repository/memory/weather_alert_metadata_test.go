@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+func TestMemoryWeatherAlertMetadataRepository_Search(t *testing.T) {
+	base := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	repo := NewMemoryWeatherAlertMetadataRepository([]*domain.WeatherAlertMetadata{
+		{ID: "tokyo-old", Region: "Tokyo", Severity: "info", IssuedAt: base.Add(-48 * time.Hour)},
+		{ID: "tokyo-new", Region: "Tokyo", Severity: "warning", IssuedAt: base},
+		{ID: "osaka", Region: "Osaka", Severity: "critical", IssuedAt: base.Add(-24 * time.Hour)},
+	})
+
+	tokyo := "Tokyo"
+	unknown := "Sapporo"
+	issuedAfter := base.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name   string
+		filter repository.MetadataFilter
+		want   []string
+	}{
+		{
+			name:   "正常系: フィルタなしで発行日時の降順",
+			filter: repository.MetadataFilter{},
+			want:   []string{"tokyo-new", "osaka", "tokyo-old"},
+		},
+		{
+			name:   "正常系: 地域フィルタ",
+			filter: repository.MetadataFilter{Region: &tokyo},
+			want:   []string{"tokyo-new", "tokyo-old"},
+		},
+		{
+			name:   "正常系: 日時フィルタは境界を含む",
+			filter: repository.MetadataFilter{IssuedAfter: &issuedAfter},
+			want:   []string{"tokyo-new", "osaka"},
+		},
+		{
+			name:   "正常系: 地域と日時の両方",
+			filter: repository.MetadataFilter{Region: &tokyo, IssuedAfter: &issuedAfter},
+			want:   []string{"tokyo-new"},
+		},
+		{
+			name:   "正常系: 該当なし",
+			filter: repository.MetadataFilter{Region: &unknown},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := repo.SearchIDs(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("SearchIDs() error = %v", err)
+			}
+			if len(ids) != len(tt.want) || (len(ids) > 0 && !slices.Equal(ids, tt.want)) {
+				t.Errorf("SearchIDs() = %v, want %v", ids, tt.want)
+			}
+
+			metadata, err := repo.Search(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(metadata) != len(tt.want) {
+				t.Errorf("Search() returned %d items, want %d", len(metadata), len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+func TestMemoryWeatherAlertRepository(t *testing.T) {
+	repo := NewMemoryWeatherAlertRepository([]*domain.WeatherAlert{
+		{ID: "alert1", Title: "Wind", RawData: map[string]interface{}{"windSpeed": 25.5}},
+		{ID: "alert2", Title: "Rain"},
+	})
+	ctx := context.Background()
+
+	t.Run("正常系: IDで取得", func(t *testing.T) {
+		got, err := repo.GetByID(ctx, "alert1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Title != "Wind" || got.RawData["windSpeed"] != 25.5 {
+			t.Errorf("GetByID() = %+v", got)
+		}
+	})
+
+	t.Run("異常系: 存在しないID", func(t *testing.T) {
+		if _, err := repo.GetByID(ctx, "nonexistent"); err == nil {
+			t.Error("GetByID() error = nil, want error")
+		}
+	})
+
+	t.Run("正常系: 複数ID取得は指定順で存在しないIDをスキップ", func(t *testing.T) {
+		got, err := repo.GetByIDs(ctx, []string{"alert2", "nonexistent", "alert1"})
+		if err != nil {
+			t.Fatalf("GetByIDs() error = %v", err)
+		}
+		if len(got) != 2 || got[0].ID != "alert2" || got[1].ID != "alert1" {
+			t.Errorf("GetByIDs() = %+v, want alert2, alert1", got)
+		}
+	})
+
+	t.Run("正常系: 空のID一覧", func(t *testing.T) {
+		got, err := repo.GetByIDs(ctx, nil)
+		if err != nil || got == nil || len(got) != 0 {
+			t.Errorf("GetByIDs() = %v, %v, want empty slice", got, err)
+		}
+	})
+}
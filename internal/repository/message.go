@@ -2,11 +2,81 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
 )
 
+// ExpiryFilter selects the top-level messages created before Before. ChannelID
+// limits them to one channel; empty selects messages in and outside channels,
+// except those of ExcludeChannelIDs.
+type ExpiryFilter struct {
+	ChannelID         string
+	ExcludeChannelIDs []string
+	Before            time.Time
+}
+
 type MessageRepository interface {
+	// List returns the top-level messages outside any channel, newest first.
+	// Replies are only returned by ListReplies.
 	List(ctx context.Context) ([]*domain.Message, error)
+	// ListByChannel returns a page of the top-level messages in a channel,
+	// newest first.
+	ListByChannel(ctx context.Context, channelID string, page PageRequest) (*MessagePage, error)
+	// Scan returns a page of every top-level message, in and outside
+	// channels, oldest first. Replies are reached with ListReplies.
+	Scan(ctx context.Context, page PageRequest) (*MessagePage, error)
+	// ListMentions returns a page of the messages and replies that mention
+	// userID, newest first.
+	ListMentions(ctx context.Context, userID string, page PageRequest) (*MessagePage, error)
+	// GetByID returns a top-level message or a reply.
 	GetByID(ctx context.Context, id string) (*domain.Message, error)
+	// GetByIDs returns the top-level messages and replies with one of ids,
+	// ordered by ID. Unknown IDs are skipped.
+	GetByIDs(ctx context.Context, ids []string) ([]*domain.Message, error)
+	// Create stores msg, failing with ErrConflict if the ID is taken. When
+	// msg is a reply, the ReplyCount of its parent is incremented in the same
+	// write; ErrNotFound is returned if the parent does not exist or is
+	// itself a reply.
+	Create(ctx context.Context, msg *domain.Message) error
+	// ListReplies returns a page of the replies to parentID, oldest first.
+	ListReplies(ctx context.Context, parentID string, page PageRequest) (*MessagePage, error)
+	// AddReaction stores reaction and increments the message's count for its
+	// emoji in the same write. It fails with ErrNotFound if the message does
+	// not exist and with ErrConflict if the user already reacted with the
+	// emoji.
+	AddReaction(ctx context.Context, reaction *domain.Reaction) error
+	// RemoveReaction deletes a reaction and decrements the matching count,
+	// failing with ErrNotFound if the user has not reacted with the emoji.
+	RemoveReaction(ctx context.Context, messageID, emoji, userID string) error
+	// UserReactions returns the emojis userID reacted to each of the messages
+	// with, keyed by message ID. Messages without such reactions are absent.
+	UserReactions(ctx context.Context, messageIDs []string, userID string) (map[string][]string, error)
+	// Edit replaces the content and mentions of a message and sets its
+	// EditedAt. A non-nil moderation replaces the moderation state as well,
+	// so that new content is never stored without the flag it earned, and
+	// gets Counted set if the message was visible before. The replaced content is added to the history in the same write, so no
+	// version is lost to concurrent edits. It fails with ErrNotFound if the
+	// message does not exist.
+	Edit(ctx context.Context, id, content string, mentionIDs []string, moderation *domain.Moderation, editorID string, editedAt time.Time) error
+	// ListFlagged returns a page of the messages and replies awaiting a
+	// moderator, oldest first.
+	ListFlagged(ctx context.Context, page PageRequest) (*MessagePage, error)
+	// SetModeration records a moderator's decision on a flagged message. It
+	// fails with ErrNotFound if the message does not exist and with
+	// ErrConflict if it is not flagged, as when another moderator decided
+	// first, so that a decision is recorded only once.
+	SetModeration(ctx context.Context, id string, moderation *domain.Moderation) error
+	// ListExpired returns a page of the top-level messages matching filter,
+	// oldest first.
+	ListExpired(ctx context.Context, filter ExpiryFilter, page PageRequest) (*MessagePage, error)
+	// Purge deletes the top-level messages with the given IDs together with
+	// their replies, reactions and edit history, and returns the deleted
+	// messages and replies so that callers can clean up what refers to them.
+	// IDs of missing messages or of replies are ignored, and of purges
+	// deleting the same thread at the same time only one returns it.
+	Purge(ctx context.Context, ids []string) ([]*domain.Message, error)
+	// History returns the earlier versions of a message's content, oldest
+	// first.
+	History(ctx context.Context, messageID string) ([]*domain.MessageEdit, error)
 }
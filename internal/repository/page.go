@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+// Cursor identifies an item in a list ordered by creation time, with the ID
+// breaking ties between items created at the same instant.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// PageRequest asks for at most Limit items that come after the item at After
+// in the list's order, or from the start of the list when After is nil.
+type PageRequest struct {
+	Limit int
+	After *Cursor
+}
+
+// MessagePage is one page of messages.
+type MessagePage struct {
+	Messages    []*domain.Message
+	HasNextPage bool
+}
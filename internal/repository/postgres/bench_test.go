@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	pgclient "github.com/kuchida1981/graphql-sampleapp/internal/postgres"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// The benchmarks compare the pgxpool-backed repositories with the same queries
+// issued through database/sql and the pgx stdlib driver. The repositories log
+// every call, so logging is silenced for both. They need a migrated database:
+//
+//	TEST_DATABASE_URL=postgres://... go test -run '^$' -bench . ./internal/repository/postgres/
+func benchDatabaseURL(b *testing.B) string {
+	b.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		b.Skip("TEST_DATABASE_URL is not set")
+	}
+	return url
+}
+
+// silenceLogs discards the log output until the benchmark ends.
+func silenceLogs(b *testing.B) {
+	b.Helper()
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(out) })
+}
+
+func newBenchPool(b *testing.B, cfg config.PostgresPoolConfig) *pgxpool.Pool {
+	b.Helper()
+	pool, err := pgclient.NewPool(context.Background(), benchDatabaseURL(b), cfg)
+	if err != nil {
+		b.Fatalf("failed to create pool: %v", err)
+	}
+	b.Cleanup(pool.Close)
+	return pool
+}
+
+func newBenchDB(b *testing.B) *sql.DB {
+	b.Helper()
+	db, err := pgclient.NewClient(context.Background(), benchDatabaseURL(b))
+	if err != nil {
+		b.Fatalf("failed to open database: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return db
+}
+
+// seedBenchUser inserts a user for the lookups and removes it afterwards.
+func seedBenchUser(b *testing.B, pool *pgxpool.Pool) string {
+	b.Helper()
+	ctx := context.Background()
+	id := "bench-user-" + time.Now().Format("150405.000000")
+	if _, err := pool.Exec(ctx,
+		"INSERT INTO users (id, name, email) VALUES ($1, $2, $3)",
+		id, "Bench", id+"@example.com"); err != nil {
+		b.Fatalf("failed to seed user: %v", err)
+	}
+	b.Cleanup(func() {
+		pool.Exec(context.Background(), "DELETE FROM users WHERE id = $1", id)
+	})
+	return id
+}
+
+func BenchmarkUserGetByID(b *testing.B) {
+	pool := newBenchPool(b, config.PostgresPoolConfig{StatementCacheCapacity: 512})
+	id := seedBenchUser(b, pool)
+	silenceLogs(b)
+
+	b.Run("database/sql", func(b *testing.B) {
+		db := newBenchDB(b)
+		query := "SELECT " + userColumns + " FROM users WHERE id = $1 AND deleted_at IS NULL"
+		b.RunParallel(func(pb *testing.PB) {
+			ctx := context.Background()
+			for pb.Next() {
+				var userID, name, email, roles, updatedBy string
+				var createdAt, updatedAt time.Time
+				var deletedAt sql.NullTime
+				if err := db.QueryRowContext(ctx, query, id).
+					Scan(&userID, &name, &email, &roles, &createdAt, &updatedAt, &updatedBy, &deletedAt); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+
+	b.Run("pgxpool", func(b *testing.B) {
+		repo := NewPostgresUserRepository(pool)
+		b.RunParallel(func(pb *testing.PB) {
+			ctx := context.Background()
+			for pb.Next() {
+				if _, err := repo.GetByID(ctx, id); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+
+	b.Run("pgxpool_no_statement_cache", func(b *testing.B) {
+		repo := NewPostgresUserRepository(newBenchPool(b, config.PostgresPoolConfig{}))
+		b.RunParallel(func(pb *testing.PB) {
+			ctx := context.Background()
+			for pb.Next() {
+				if _, err := repo.GetByID(ctx, id); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
+
+func BenchmarkWeatherAlertMetadataSearch(b *testing.B) {
+	pool := newBenchPool(b, config.PostgresPoolConfig{StatementCacheCapacity: 512})
+	silenceLogs(b)
+	const query = "SELECT " + metadataColumns + " FROM weather_alert_metadata WHERE deleted_at IS NULL ORDER BY issued_at DESC"
+
+	b.Run("database/sql", func(b *testing.B) {
+		db := newBenchDB(b)
+		b.RunParallel(func(pb *testing.PB) {
+			ctx := context.Background()
+			for pb.Next() {
+				if err := scanBenchMetadata(ctx, db, query); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+
+	b.Run("pgxpool", func(b *testing.B) {
+		repo := NewPostgresWeatherAlertMetadataRepository(pool)
+		b.RunParallel(func(pb *testing.PB) {
+			ctx := context.Background()
+			for pb.Next() {
+				if _, err := repo.Search(ctx, repository.MetadataFilter{}); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
+
+// scanBenchMetadata reads every row of query the way the repository does.
+func scanBenchMetadata(ctx context.Context, db *sql.DB, query string) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, region, severity, updatedBy string
+		var issuedAt, createdAt, updatedAt time.Time
+		var deletedAt sql.NullTime
+		if err := rows.Scan(&id, &region, &severity, &issuedAt, &createdAt, &updatedAt, &updatedBy, &deletedAt); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// channelColumns is the column list read by scanChannel.
+const channelColumns = "id, name, topic, COALESCE(created_by, ''), created_at, archived_at, " +
+	"ARRAY(SELECT user_id FROM channel_members WHERE channel_id = channels.id ORDER BY user_id)"
+
+type PostgresChannelRepository struct {
+	db DBTX
+}
+
+func NewPostgresChannelRepository(db DBTX) *PostgresChannelRepository {
+	return &PostgresChannelRepository{db: db}
+}
+
+func (r *PostgresChannelRepository) List(ctx context.Context, filter repository.ChannelFilter) ([]*domain.Channel, error) {
+	log.Printf("PostgresChannelRepository: Listing channels with filter: %+v", filter)
+
+	query := "SELECT " + channelColumns + " FROM channels WHERE ($1 OR archived_at IS NULL)"
+	args := []any{filter.IncludeArchived}
+	if filter.MemberID != "" {
+		query += " AND id IN (SELECT channel_id FROM channel_members WHERE user_id = $2)"
+		args = append(args, filter.MemberID)
+	}
+	query += " ORDER BY name"
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("PostgresChannelRepository: Failed to query channels: %v", err)
+		return nil, classifyError("failed to query channels", err)
+	}
+	defer rows.Close()
+
+	var channels []*domain.Channel
+	for rows.Next() {
+		channel, err := scanChannel(rows)
+		if err != nil {
+			log.Printf("PostgresChannelRepository: Failed to scan channel: %v", err)
+			return nil, fmt.Errorf("failed to scan channel: %w", err)
+		}
+		channels = append(channels, channel)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("PostgresChannelRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+
+	log.Printf("PostgresChannelRepository: Found %d channels", len(channels))
+	return channels, nil
+}
+
+func (r *PostgresChannelRepository) GetByID(ctx context.Context, id string) (*domain.Channel, error) {
+	log.Printf("PostgresChannelRepository: Getting channel by ID: %s", id)
+
+	query := "SELECT " + channelColumns + " FROM channels WHERE id = $1"
+	channel, err := scanChannel(conn(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.NotFoundf("channel %s not found", id)
+		}
+		log.Printf("PostgresChannelRepository: Failed to scan channel: %v", err)
+		return nil, classifyError("failed to get channel", err)
+	}
+	return channel, nil
+}
+
+func (r *PostgresChannelRepository) Create(ctx context.Context, channel *domain.Channel) error {
+	log.Printf("PostgresChannelRepository: Creating channel: %s", channel.ID)
+
+	db := conn(ctx, r.db)
+	query := "INSERT INTO channels (id, name, topic, created_by, created_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5)"
+	if _, err := db.Exec(ctx, query, channel.ID, channel.Name, channel.Topic, channel.CreatedBy, channel.CreatedAt.UTC()); err != nil {
+		log.Printf("PostgresChannelRepository: Failed to create channel: %v", err)
+		return classifyError("failed to create channel", err)
+	}
+
+	query = "INSERT INTO channel_members (channel_id, user_id) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING"
+	if _, err := db.Exec(ctx, query, channel.ID, channel.MemberIDs); err != nil {
+		log.Printf("PostgresChannelRepository: Failed to add channel members: %v", err)
+		return classifyError("failed to add channel members", err)
+	}
+	return nil
+}
+
+func (r *PostgresChannelRepository) Archive(ctx context.Context, id string) (*domain.Channel, error) {
+	log.Printf("PostgresChannelRepository: Archiving channel: %s", id)
+
+	query := "UPDATE channels SET archived_at = $2 WHERE id = $1 AND archived_at IS NULL"
+	tag, err := conn(ctx, r.db).Exec(ctx, query, id, time.Now().UTC())
+	if err != nil {
+		log.Printf("PostgresChannelRepository: Failed to archive channel: %v", err)
+		return nil, classifyError("failed to archive channel", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, repository.NotFoundf("channel %s not found", id)
+	}
+	return r.GetByID(ctx, id)
+}
+
+func scanChannel(row pgx.Row) (*domain.Channel, error) {
+	var channel domain.Channel
+	if err := row.Scan(&channel.ID, &channel.Name, &channel.Topic, &channel.CreatedBy, &channel.CreatedAt, &channel.ArchivedAt, &channel.MemberIDs); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func (r *PostgresChannelRepository) RecordMessage(ctx context.Context, channelID string) error {
+	query := "UPDATE channels SET message_count = message_count + 1 WHERE id = $1"
+	tag, err := conn(ctx, r.db).Exec(ctx, query, channelID)
+	if err != nil {
+		log.Printf("PostgresChannelRepository: Failed to count message: %v", err)
+		return classifyError("failed to count message", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.NotFoundf("channel %s not found", channelID)
+	}
+	return nil
+}
+
+func (r *PostgresChannelRepository) RemoveMessages(ctx context.Context, channelID string, n int) error {
+	log.Printf("PostgresChannelRepository: Uncounting %d message(s) of channel %s", n, channelID)
+
+	db := conn(ctx, r.db)
+	query := "UPDATE channels SET message_count = GREATEST(message_count - $2, 0) WHERE id = $1"
+	tag, err := db.Exec(ctx, query, channelID, n)
+	if err != nil {
+		log.Printf("PostgresChannelRepository: Failed to uncount messages: %v", err)
+		return classifyError("failed to uncount messages", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.NotFoundf("channel %s not found", channelID)
+	}
+
+	query = "UPDATE channel_reads SET read_count = GREATEST(read_count - $2, 0) WHERE channel_id = $1"
+	if _, err := db.Exec(ctx, query, channelID, n); err != nil {
+		log.Printf("PostgresChannelRepository: Failed to move read positions back: %v", err)
+		return classifyError("failed to move read positions back", err)
+	}
+	return nil
+}
+
+func (r *PostgresChannelRepository) MarkRead(ctx context.Context, channelID, userID string, readAt time.Time) error {
+	log.Printf("PostgresChannelRepository: Marking channel %s read for %s", channelID, userID)
+
+	query := `INSERT INTO channel_reads (channel_id, user_id, read_count, last_read_at)
+	SELECT id, $2, message_count, $3 FROM channels WHERE id = $1
+	ON CONFLICT (user_id, channel_id) DO UPDATE SET
+		read_count = GREATEST(channel_reads.read_count, EXCLUDED.read_count),
+		last_read_at = GREATEST(channel_reads.last_read_at, EXCLUDED.last_read_at)`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, channelID, userID, readAt.UTC())
+	if err != nil {
+		log.Printf("PostgresChannelRepository: Failed to mark channel read: %v", err)
+		return classifyError("failed to mark channel read", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.NotFoundf("channel %s not found", channelID)
+	}
+	return nil
+}
+
+func (r *PostgresChannelRepository) Reads(ctx context.Context, userID string, channelIDs []string) (map[string]*domain.ChannelRead, error) {
+	query := `SELECT channels.id, channel_reads.last_read_at, channels.message_count - COALESCE(channel_reads.read_count, 0)
+	FROM channels LEFT JOIN channel_reads ON channel_reads.channel_id = channels.id AND channel_reads.user_id = $1
+	WHERE channels.id = ANY($2)`
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID, channelIDs)
+	if err != nil {
+		log.Printf("PostgresChannelRepository: Failed to query read positions: %v", err)
+		return nil, classifyError("failed to query read positions", err)
+	}
+	defer rows.Close()
+
+	reads := make(map[string]*domain.ChannelRead, len(channelIDs))
+	for rows.Next() {
+		var read domain.ChannelRead
+		if err := rows.Scan(&read.ChannelID, &read.LastReadAt, &read.UnreadCount); err != nil {
+			log.Printf("PostgresChannelRepository: Failed to scan read position: %v", err)
+			return nil, fmt.Errorf("failed to scan read position: %w", err)
+		}
+		reads[read.ChannelID] = &read
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("PostgresChannelRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+	return reads, nil
+}
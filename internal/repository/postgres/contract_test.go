@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/migrate"
+	pgclient "github.com/kuchida1981/graphql-sampleapp/internal/postgres"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository/repositorytest"
+)
+
+// newContractPool connects to TEST_DATABASE_URL and applies the migrations.
+// The contract tests truncate the tables they use, so point it at a
+// disposable database.
+func newContractPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgclient.NewPool(ctx, url, config.PostgresPoolConfig{StatementCacheCapacity: 512})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrations, err := migrate.PostgresMigrations()
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+	if _, err := migrate.New(db, migrate.Postgres, migrations).Up(ctx); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+	return pool
+}
+
+// resetTable empties table and the tables referencing it.
+func resetTable(t *testing.T, pool *pgxpool.Pool, table string) {
+	t.Helper()
+	if _, err := pool.Exec(context.Background(), "TRUNCATE "+table+" CASCADE"); err != nil {
+		t.Fatalf("failed to truncate %s: %v", table, err)
+	}
+}
+
+func TestContract(t *testing.T) {
+	pool := newContractPool(t)
+	ctx := context.Background()
+
+	t.Run("MessageRepository", func(t *testing.T) {
+		repositorytest.TestMessageRepository(t, func(t *testing.T, messages []*domain.Message) repository.MessageRepository {
+			resetTable(t, pool, "messages")
+			for _, m := range messages {
+				if _, err := pool.Exec(ctx, "INSERT INTO messages (id, content, author, channel_id, created_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5)",
+					m.ID, m.Content, m.Author, m.ChannelID, m.CreatedAt.UTC()); err != nil {
+					t.Fatalf("failed to insert message: %v", err)
+				}
+			}
+			return NewPostgresMessageRepository(pool)
+		})
+	})
+	t.Run("ChannelRepository", func(t *testing.T) {
+		repositorytest.TestChannelRepository(t, func(t *testing.T, users []*domain.User) repository.ChannelRepository {
+			resetTable(t, pool, "channels")
+			insertUsers(t, pool, users)
+			return NewPostgresChannelRepository(pool)
+		})
+	})
+	t.Run("SearchRepository", func(t *testing.T) {
+		repositorytest.TestSearchRepository(t, func(t *testing.T) repository.SearchRepository {
+			resetTable(t, pool, "message_search_documents")
+			return NewPostgresSearchRepository(pool)
+		})
+	})
+	t.Run("UserRepository", func(t *testing.T) {
+		repositorytest.TestUserRepository(t, func(t *testing.T, users []*domain.User) repository.UserRepository {
+			insertUsers(t, pool, users)
+			return NewPostgresUserRepository(pool)
+		})
+	})
+	t.Run("WeatherAlertMetadataRepository", func(t *testing.T) {
+		repositorytest.TestWeatherAlertMetadataRepository(t, func(t *testing.T, metadata []*domain.WeatherAlertMetadata) repository.WeatherAlertMetadataRepository {
+			resetTable(t, pool, "weather_alert_metadata")
+			for _, m := range metadata {
+				if _, err := pool.Exec(ctx, "INSERT INTO weather_alert_metadata (id, region, severity, issued_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5)",
+					m.ID, m.Region, m.Severity, m.IssuedAt.UTC(), m.CreatedAt.UTC()); err != nil {
+					t.Fatalf("failed to insert metadata: %v", err)
+				}
+			}
+			return NewPostgresWeatherAlertMetadataRepository(pool)
+		})
+	})
+}
+
+// insertUsers replaces the contents of the users table with users.
+func insertUsers(t *testing.T, pool *pgxpool.Pool, users []*domain.User) {
+	t.Helper()
+	resetTable(t, pool, "users")
+	for _, u := range users {
+		if _, err := pool.Exec(context.Background(), "INSERT INTO users (id, name, handle, email, roles, created_at, updated_at) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $6)",
+			u.ID, u.Name, u.Handle, u.Email, u.Roles, u.CreatedAt.UTC()); err != nil {
+			t.Fatalf("failed to insert user: %v", err)
+		}
+	}
+}
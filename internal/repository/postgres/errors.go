@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// classifyError wraps err in the matching repository error kind, or with a
+// plain message when it is not one the caller can act on.
+func classifyError(message string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505", // unique_violation
+			pgErr.Code == "40001", // serialization_failure
+			pgErr.Code == "40P01": // deadlock_detected
+			return repository.Conflict(message, err)
+		case strings.HasPrefix(pgErr.Code, "22"), // data_exception
+			pgErr.Code == "23502", // not_null_violation
+			pgErr.Code == "23503", // foreign_key_violation
+			pgErr.Code == "23514": // check_violation
+			return repository.Invalid(message, err)
+		case strings.HasPrefix(pgErr.Code, "08"), // connection_exception
+			pgErr.Code == "53300", // too_many_connections
+			pgErr.Code == "57P01", // admin_shutdown
+			pgErr.Code == "57P03": // cannot_connect_now
+			return repository.Unavailable(message, err)
+		}
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) || pgconn.Timeout(err) {
+		return repository.Unavailable(message, err)
+	}
+
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// isForeignKeyViolation reports whether err was caused by a row referencing a
+// parent that does not exist.
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error
+	}{
+		{"一意制約違反はConflict", &pgconn.PgError{Code: "23505"}, repository.ErrConflict},
+		{"シリアライズ失敗はConflict", &pgconn.PgError{Code: "40001"}, repository.ErrConflict},
+		{"NOT NULL制約違反はInvalid", &pgconn.PgError{Code: "23502"}, repository.ErrInvalid},
+		{"不正な値はInvalid", &pgconn.PgError{Code: "22P02"}, repository.ErrInvalid},
+		{"接続エラーはUnavailable", &pgconn.PgError{Code: "08006"}, repository.ErrUnavailable},
+		{"接続数超過はUnavailable", &pgconn.PgError{Code: "53300"}, repository.ErrUnavailable},
+		{"構文エラーは分類しない", &pgconn.PgError{Code: "42601"}, nil},
+		{"その他のエラーは分類しない", errors.New("boom"), nil},
+	}
+
+	kinds := []error{repository.ErrNotFound, repository.ErrConflict, repository.ErrUnavailable, repository.ErrInvalid}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError("failed to query users", tt.err)
+			if !errors.Is(got, tt.err) {
+				t.Errorf("classifyError() = %v, does not wrap the cause", got)
+			}
+			for _, kind := range kinds {
+				if is := errors.Is(got, kind); is != (kind == tt.wantKind) {
+					t.Errorf("errors.Is(%v) = %v", kind, is)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,470 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// messageColumns is the column list read by scanMessage. Reaction counts are
+// aggregated from message_reactions rather than stored on the row.
+const messageColumns = "id, content, author, COALESCE(author_id, ''), COALESCE(channel_id, ''), COALESCE(parent_id, ''), reply_count, " +
+	"COALESCE((SELECT jsonb_object_agg(emoji, n) FROM (SELECT emoji, COUNT(*) AS n FROM message_reactions WHERE message_id = messages.id GROUP BY emoji) counts), '{}'), " +
+	"created_at, edited_at, attachments, mention_ids, moderation"
+
+type PostgresMessageRepository struct {
+	db DBTX
+}
+
+func NewPostgresMessageRepository(db DBTX) *PostgresMessageRepository {
+	return &PostgresMessageRepository{db: db}
+}
+
+func (r *PostgresMessageRepository) List(ctx context.Context) ([]*domain.Message, error) {
+	log.Println("PostgresMessageRepository: Listing all messages")
+
+	query := "SELECT " + messageColumns + " FROM messages WHERE parent_id IS NULL AND channel_id IS NULL ORDER BY created_at DESC"
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to query messages: %v", err)
+		return nil, classifyError("failed to query messages", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("PostgresMessageRepository: Found %d messages", len(messages))
+	return messages, nil
+}
+
+func (r *PostgresMessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	log.Printf("PostgresMessageRepository: Getting message by ID: %s", id)
+
+	query := "SELECT " + messageColumns + " FROM messages WHERE id = $1"
+	msg, err := scanMessage(conn(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("PostgresMessageRepository: Message not found: %s", id)
+			return nil, repository.NotFoundf("message %s not found", id)
+		}
+		log.Printf("PostgresMessageRepository: Failed to scan message: %v", err)
+		return nil, classifyError("failed to get message", err)
+	}
+
+	log.Printf("PostgresMessageRepository: Found message: %s", msg.ID)
+	return msg, nil
+}
+
+func (r *PostgresMessageRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Message, error) {
+	log.Printf("PostgresMessageRepository: Getting messages by IDs: %v", ids)
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query := "SELECT " + messageColumns + " FROM messages WHERE id = ANY($1) ORDER BY id"
+	rows, err := conn(ctx, r.db).Query(ctx, query, ids)
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to query messages: %v", err)
+		return nil, classifyError("failed to query messages", err)
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+func (r *PostgresMessageRepository) Create(ctx context.Context, msg *domain.Message) error {
+	log.Printf("PostgresMessageRepository: Creating message: %s", msg.ID)
+
+	attachments, err := attachmentsJSON(msg.Attachments)
+	if err != nil {
+		return err
+	}
+	moderation, err := moderationJSON(msg.Moderation)
+	if err != nil {
+		return err
+	}
+
+	if msg.ParentID == "" {
+		query := "INSERT INTO messages (id, content, author, author_id, created_at, channel_id, attachments, mention_ids, moderation) VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7, $8, $9)"
+		if _, err := conn(ctx, r.db).Exec(ctx, query, msg.ID, msg.Content, msg.Author, msg.AuthorID, msg.CreatedAt.UTC(), msg.ChannelID, attachments, mentionIDs(msg.MentionIDs), moderation); err != nil {
+			log.Printf("PostgresMessageRepository: Failed to create message: %v", err)
+			return classifyError("failed to create message", err)
+		}
+		return nil
+	}
+
+	// A single statement so that the reply and the parent's count change
+	// together without an explicit transaction. Nothing is inserted when the
+	// parent is missing or is itself a reply. The reply takes the channel of
+	// its parent.
+	query := `WITH parent AS (
+		UPDATE messages SET reply_count = reply_count + 1 WHERE id = $6 AND parent_id IS NULL RETURNING id, channel_id
+	)
+	INSERT INTO messages (id, content, author, author_id, created_at, parent_id, channel_id, attachments, mention_ids, moderation)
+	SELECT $1, $2, $3, NULLIF($4, ''), $5, id, channel_id, $7, $8, $9 FROM parent
+	RETURNING COALESCE(channel_id, '')`
+	err = conn(ctx, r.db).QueryRow(ctx, query, msg.ID, msg.Content, msg.Author, msg.AuthorID, msg.CreatedAt.UTC(), msg.ParentID, attachments, mentionIDs(msg.MentionIDs), moderation).Scan(&msg.ChannelID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.NotFoundf("message %s not found", msg.ParentID)
+	}
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to create reply: %v", err)
+		return classifyError("failed to create reply", err)
+	}
+	return nil
+}
+
+func (r *PostgresMessageRepository) ListReplies(ctx context.Context, parentID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Printf("PostgresMessageRepository: Listing replies to %s", parentID)
+
+	query := "SELECT " + messageColumns + " FROM messages WHERE parent_id = $1"
+	args := []any{parentID}
+	if page.After != nil {
+		query += " AND (created_at, id) > ($2, $3)"
+		args = append(args, page.After.CreatedAt.UTC(), page.After.ID)
+	}
+	// Fetch one extra row to learn whether another page follows.
+	args = append(args, page.Limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
+
+	return r.queryPage(ctx, query, args, page.Limit)
+}
+
+func (r *PostgresMessageRepository) Scan(ctx context.Context, page repository.PageRequest) (*repository.MessagePage, error) {
+	query := "SELECT " + messageColumns + " FROM messages WHERE parent_id IS NULL"
+	var args []any
+	if page.After != nil {
+		query += " AND (created_at, id) > ($1, $2)"
+		args = append(args, page.After.CreatedAt.UTC(), page.After.ID)
+	}
+	args = append(args, page.Limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
+
+	return r.queryPage(ctx, query, args, page.Limit)
+}
+
+// queryPage runs a query that fetches one row more than limit, so that the
+// extra row tells whether another page follows.
+func (r *PostgresMessageRepository) queryPage(ctx context.Context, query string, args []any, limit int) (*repository.MessagePage, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to query messages: %v", err)
+		return nil, classifyError("failed to query messages", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &repository.MessagePage{Messages: messages}
+	if len(messages) > limit {
+		result.Messages = messages[:limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
+
+func (r *PostgresMessageRepository) ListByChannel(ctx context.Context, channelID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Printf("PostgresMessageRepository: Listing messages in channel %s", channelID)
+
+	query := "SELECT " + messageColumns + " FROM messages WHERE channel_id = $1 AND parent_id IS NULL"
+	args := []any{channelID}
+	if page.After != nil {
+		query += " AND (created_at, id) < ($2, $3)"
+		args = append(args, page.After.CreatedAt.UTC(), page.After.ID)
+	}
+	args = append(args, page.Limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	return r.queryPage(ctx, query, args, page.Limit)
+}
+
+// ListMentions uses the GIN index on mention_ids, which serves @> but not
+// = ANY.
+func (r *PostgresMessageRepository) ListMentions(ctx context.Context, userID string, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Printf("PostgresMessageRepository: Listing messages mentioning %s", userID)
+
+	query := "SELECT " + messageColumns + " FROM messages WHERE mention_ids @> ARRAY[$1]::TEXT[]"
+	args := []any{userID}
+	if page.After != nil {
+		query += " AND (created_at, id) < ($2, $3)"
+		args = append(args, page.After.CreatedAt.UTC(), page.After.ID)
+	}
+	args = append(args, page.Limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	return r.queryPage(ctx, query, args, page.Limit)
+}
+
+func (r *PostgresMessageRepository) AddReaction(ctx context.Context, reaction *domain.Reaction) error {
+	log.Printf("PostgresMessageRepository: Adding reaction %s by %s to %s", reaction.Emoji, reaction.UserID, reaction.MessageID)
+
+	query := "INSERT INTO message_reactions (message_id, emoji, user_id, created_at) VALUES ($1, $2, $3, $4)"
+	_, err := conn(ctx, r.db).Exec(ctx, query, reaction.MessageID, reaction.Emoji, reaction.UserID, reaction.CreatedAt.UTC())
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to add reaction: %v", err)
+		if isForeignKeyViolation(err) {
+			return repository.NotFoundf("message %s not found", reaction.MessageID)
+		}
+		return classifyError("failed to add reaction", err)
+	}
+	return nil
+}
+
+func (r *PostgresMessageRepository) RemoveReaction(ctx context.Context, messageID, emoji, userID string) error {
+	log.Printf("PostgresMessageRepository: Removing reaction %s by %s from %s", emoji, userID, messageID)
+
+	query := "DELETE FROM message_reactions WHERE message_id = $1 AND emoji = $2 AND user_id = $3"
+	tag, err := conn(ctx, r.db).Exec(ctx, query, messageID, emoji, userID)
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to remove reaction: %v", err)
+		return classifyError("failed to remove reaction", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.NotFoundf("reaction %s by %s to message %s not found", emoji, userID, messageID)
+	}
+	return nil
+}
+
+func (r *PostgresMessageRepository) UserReactions(ctx context.Context, messageIDs []string, userID string) (map[string][]string, error) {
+	query := "SELECT message_id, emoji FROM message_reactions WHERE message_id = ANY($1) AND user_id = $2 ORDER BY created_at, emoji"
+	rows, err := conn(ctx, r.db).Query(ctx, query, messageIDs, userID)
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to query reactions: %v", err)
+		return nil, classifyError("failed to query reactions", err)
+	}
+	defer rows.Close()
+
+	emojis := make(map[string][]string)
+	for rows.Next() {
+		var messageID, emoji string
+		if err := rows.Scan(&messageID, &emoji); err != nil {
+			log.Printf("PostgresMessageRepository: Failed to scan reaction: %v", err)
+			return nil, fmt.Errorf("failed to scan reaction: %w", err)
+		}
+		emojis[messageID] = append(emojis[messageID], emoji)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("PostgresMessageRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+	return emojis, nil
+}
+
+func (r *PostgresMessageRepository) Edit(ctx context.Context, id, content string, mentions []string, moderation *domain.Moderation, editorID string, editedAt time.Time) error {
+	log.Printf("PostgresMessageRepository: Editing message %s by %s", id, editorID)
+
+	value, err := moderationJSON(moderation)
+	if err != nil {
+		return err
+	}
+	// The row lock makes concurrent edits wait, so each one records the
+	// content the previous edit wrote and sees whether it was counted.
+	query := `WITH previous AS (
+		SELECT id, content,
+			moderation IS NULL OR moderation->>'status' = 'approved' OR moderation->>'counted' = 'true' AS counted
+		FROM messages WHERE id = $1 FOR UPDATE
+	), updated AS (
+		UPDATE messages SET content = $2, edited_at = $4, mention_ids = $5,
+			moderation = COALESCE($6::jsonb || CASE WHEN previous.counted THEN '{"counted": true}'::jsonb ELSE '{}'::jsonb END, messages.moderation)
+		FROM previous WHERE messages.id = previous.id
+	)
+	INSERT INTO message_edits (message_id, content, editor_id, edited_at)
+	SELECT id, content, $3, $4 FROM previous`
+	tag, err := conn(ctx, r.db).Exec(ctx, query, id, content, editorID, editedAt.UTC(), mentionIDs(mentions), value)
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to edit message: %v", err)
+		return classifyError("failed to edit message", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.NotFoundf("message %s not found", id)
+	}
+	return nil
+}
+
+func (r *PostgresMessageRepository) ListFlagged(ctx context.Context, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Println("PostgresMessageRepository: Listing flagged messages")
+
+	query := "SELECT " + messageColumns + " FROM messages WHERE moderation->>'status' = 'flagged'"
+	var args []any
+	if page.After != nil {
+		query += " AND (created_at, id) > ($1, $2)"
+		args = append(args, page.After.CreatedAt.UTC(), page.After.ID)
+	}
+	args = append(args, page.Limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
+
+	return r.queryPage(ctx, query, args, page.Limit)
+}
+
+func (r *PostgresMessageRepository) ListExpired(ctx context.Context, filter repository.ExpiryFilter, page repository.PageRequest) (*repository.MessagePage, error) {
+	log.Printf("PostgresMessageRepository: Listing messages created before %s", filter.Before.Format(time.RFC3339))
+
+	query := "SELECT " + messageColumns + " FROM messages WHERE parent_id IS NULL AND created_at < $1"
+	args := []any{filter.Before.UTC()}
+	if filter.ChannelID != "" {
+		args = append(args, filter.ChannelID)
+		query += fmt.Sprintf(" AND channel_id = $%d", len(args))
+	}
+	if len(filter.ExcludeChannelIDs) > 0 {
+		args = append(args, filter.ExcludeChannelIDs)
+		query += fmt.Sprintf(" AND (channel_id IS NULL OR channel_id <> ALL($%d))", len(args))
+	}
+	if page.After != nil {
+		args = append(args, page.After.CreatedAt.UTC(), page.After.ID)
+		query += fmt.Sprintf(" AND (created_at, id) > ($%d, $%d)", len(args)-1, len(args))
+	}
+	args = append(args, page.Limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
+
+	return r.queryPage(ctx, query, args, page.Limit)
+}
+
+// Purge deletes the replies in the same statement rather than through the
+// foreign key, so that they are returned too. Reactions and edit history go
+// with the foreign key.
+func (r *PostgresMessageRepository) Purge(ctx context.Context, ids []string) ([]*domain.Message, error) {
+	log.Printf("PostgresMessageRepository: Purging %d message(s)", len(ids))
+
+	query := "DELETE FROM messages WHERE (id = ANY($1) AND parent_id IS NULL) OR parent_id = ANY($1) RETURNING " + messageColumns
+	rows, err := conn(ctx, r.db).Query(ctx, query, ids)
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to purge messages: %v", err)
+		return nil, classifyError("failed to purge messages", err)
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+func (r *PostgresMessageRepository) SetModeration(ctx context.Context, id string, moderation *domain.Moderation) error {
+	log.Printf("PostgresMessageRepository: Setting moderation of message %s", id)
+
+	value, err := moderationJSON(moderation)
+	if err != nil {
+		return err
+	}
+	// The row lock makes a concurrent decision wait and then see the message
+	// no longer flagged.
+	query := `WITH target AS (
+		SELECT id, COALESCE(moderation->>'status' = 'flagged', false) AS flagged
+		FROM messages WHERE id = $1 FOR UPDATE
+	), updated AS (
+		UPDATE messages SET moderation = $2
+		FROM target WHERE messages.id = target.id AND target.flagged
+	)
+	SELECT flagged FROM target`
+	var flagged bool
+	err = conn(ctx, r.db).QueryRow(ctx, query, id, value).Scan(&flagged)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.NotFoundf("message %s not found", id)
+	}
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to set moderation: %v", err)
+		return classifyError("failed to set moderation", err)
+	}
+	if !flagged {
+		return repository.Conflict(fmt.Sprintf("message %s is not awaiting moderation", id), nil)
+	}
+	return nil
+}
+
+func (r *PostgresMessageRepository) History(ctx context.Context, messageID string) ([]*domain.MessageEdit, error) {
+	query := "SELECT message_id, content, editor_id, edited_at FROM message_edits WHERE message_id = $1 ORDER BY edited_at, id"
+	rows, err := conn(ctx, r.db).Query(ctx, query, messageID)
+	if err != nil {
+		log.Printf("PostgresMessageRepository: Failed to query message history: %v", err)
+		return nil, classifyError("failed to query message history", err)
+	}
+	defer rows.Close()
+
+	var edits []*domain.MessageEdit
+	for rows.Next() {
+		var edit domain.MessageEdit
+		if err := rows.Scan(&edit.MessageID, &edit.Content, &edit.EditorID, &edit.EditedAt); err != nil {
+			log.Printf("PostgresMessageRepository: Failed to scan message edit: %v", err)
+			return nil, fmt.Errorf("failed to scan message edit: %w", err)
+		}
+		edits = append(edits, &edit)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("PostgresMessageRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+	return edits, nil
+}
+
+func scanMessage(row pgx.Row) (*domain.Message, error) {
+	var msg domain.Message
+	if err := row.Scan(&msg.ID, &msg.Content, &msg.Author, &msg.AuthorID, &msg.ChannelID, &msg.ParentID, &msg.ReplyCount, &msg.ReactionCounts, &msg.CreatedAt, &msg.EditedAt, &msg.Attachments, &msg.MentionIDs, &msg.Moderation); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// attachmentsJSON encodes attachments for the attachments column, which holds
+// an empty array rather than null for a message without any.
+func attachmentsJSON(attachments []domain.Attachment) (string, error) {
+	if len(attachments) == 0 {
+		return "[]", nil
+	}
+	b, err := json.Marshal(attachments)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode attachments: %w", err)
+	}
+	return string(b), nil
+}
+
+// moderationJSON encodes moderation for the moderation column, which is null
+// for a message that was never flagged.
+func moderationJSON(moderation *domain.Moderation) (any, error) {
+	if moderation == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(moderation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode moderation: %w", err)
+	}
+	return string(b), nil
+}
+
+// mentionIDs returns ids for the mention_ids column, which holds an empty
+// array rather than null for a message without mentions.
+func mentionIDs(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
+
+func scanMessages(rows pgx.Rows) ([]*domain.Message, error) {
+	var messages []*domain.Message
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			log.Printf("PostgresMessageRepository: Failed to scan message: %v", err)
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("PostgresMessageRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+	return messages, nil
+}
@@ -0,0 +1,382 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+var messageColumnNames = []string{"id", "content", "author", "author_id", "channel_id", "parent_id", "reply_count", "reaction_counts", "created_at", "edited_at", "attachments", "mention_ids", "moderation"}
+
+func TestPostgresMessageRepository_List(t *testing.T) {
+	listQuery := regexp.QuoteMeta("SELECT " + messageColumns + " FROM messages WHERE parent_id IS NULL AND channel_id IS NULL ORDER BY created_at DESC")
+
+	tests := []struct {
+		name    string
+		mockFn  func(mock pgxmock.PgxPoolIface)
+		want    []*domain.Message
+		wantErr bool
+	}{
+		{
+			name: "正常系: メッセージリスト取得成功",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows(messageColumnNames).
+					AddRow("msg2", "World", "Bob", "", "", "", 0, map[string]int{"👍": 2}, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), nil, nil, nil, nil).
+					AddRow("msg1", "Hello", "Alice", "", "", "", 0, map[string]int{}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), nil, nil, nil, nil)
+				mock.ExpectQuery(listQuery).
+					WillReturnRows(rows)
+			},
+			want: []*domain.Message{
+				{ID: "msg2", Content: "World", Author: "Bob", ReactionCounts: map[string]int{"👍": 2}, CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{ID: "msg1", Content: "Hello", Author: "Alice", ReactionCounts: map[string]int{}, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "正常系: メッセージが0件",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(listQuery).
+					WillReturnRows(pgxmock.NewRows(messageColumnNames))
+			},
+			want:    []*domain.Message{},
+			wantErr: false,
+		},
+		{
+			name: "異常系: クエリエラー",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(listQuery).
+					WillReturnError(errors.New("database connection error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("failed to create mock: %v", err)
+			}
+			defer mock.Close()
+
+			tt.mockFn(mock)
+
+			repo := NewPostgresMessageRepository(mock)
+			got, err := repo.List(context.Background())
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("List() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if len(got) != len(tt.want) {
+					t.Errorf("List() got %d messages, want %d messages", len(got), len(tt.want))
+					return
+				}
+
+				for i, msg := range got {
+					if !reflect.DeepEqual(msg, tt.want[i]) {
+						t.Errorf("List() got message[%d] = %+v, want %+v", i, msg, tt.want[i])
+					}
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgresMessageRepository_GetByID(t *testing.T) {
+	getQuery := regexp.QuoteMeta("SELECT " + messageColumns + " FROM messages WHERE id = $1")
+	editedAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		id      string
+		mockFn  func(mock pgxmock.PgxPoolIface)
+		want    *domain.Message
+		wantErr bool
+	}{
+		{
+			name: "正常系: メッセージ取得成功",
+			id:   "msg1",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows(messageColumnNames).
+					AddRow("msg1", "Hello", "Alice", "", "", "", 0, map[string]int{}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), &editedAt, []domain.Attachment{{ID: "a1", Name: "memo.txt", Size: 5}}, []string{"user2"}, nil)
+				mock.ExpectQuery(getQuery).
+					WithArgs("msg1").
+					WillReturnRows(rows)
+			},
+			want:    &domain.Message{ID: "msg1", Content: "Hello", Author: "Alice", ReactionCounts: map[string]int{}, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), EditedAt: &editedAt, Attachments: []domain.Attachment{{ID: "a1", Name: "memo.txt", Size: 5}}, MentionIDs: []string{"user2"}},
+			wantErr: false,
+		},
+		{
+			name: "異常系: メッセージが見つからない (pgx.ErrNoRows)",
+			id:   "nonexistent",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(getQuery).
+					WithArgs("nonexistent").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("failed to create mock: %v", err)
+			}
+			defer mock.Close()
+
+			tt.mockFn(mock)
+
+			repo := NewPostgresMessageRepository(mock)
+			got, err := repo.GetByID(context.Background(), tt.id)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetByID() = %+v, want %+v", got, tt.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgresMessageRepository_CreateReply(t *testing.T) {
+	insertReply := regexp.QuoteMeta("UPDATE messages SET reply_count = reply_count + 1 WHERE id = $6 AND parent_id IS NULL")
+	reply := &domain.Message{ID: "reply1", Content: "Hi", Author: "Bob", AuthorID: "user2", ParentID: "msg1", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name        string
+		rows        *pgxmock.Rows
+		wantChannel string
+		wantKind    error
+	}{
+		{name: "正常系: 親のチャンネルを引き継ぐ", rows: pgxmock.NewRows([]string{"channel_id"}).AddRow("general"), wantChannel: "general"},
+		{name: "異常系: 親メッセージが存在しないか返信", rows: pgxmock.NewRows([]string{"channel_id"}), wantKind: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("failed to create mock: %v", err)
+			}
+			defer mock.Close()
+			mock.ExpectQuery(insertReply).
+				WithArgs(reply.ID, reply.Content, reply.Author, reply.AuthorID, reply.CreatedAt, reply.ParentID, "[]", []string{}, nil).
+				WillReturnRows(tt.rows)
+
+			err = NewPostgresMessageRepository(mock).Create(context.Background(), reply)
+
+			if tt.wantKind == nil && (err != nil || reply.ChannelID != tt.wantChannel) {
+				t.Errorf("Create() error = %v, ChannelID = %q, want %q", err, reply.ChannelID, tt.wantChannel)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("Create() error = %v, want %v", err, tt.wantKind)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgresMessageRepository_ListReplies(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := &repository.Cursor{CreatedAt: base, ID: "reply1"}
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer mock.Close()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+messageColumns+" FROM messages WHERE parent_id = $1 AND (created_at, id) > ($2, $3) ORDER BY created_at, id LIMIT $4")).
+		WithArgs("msg1", base, "reply1", 3).
+		WillReturnRows(pgxmock.NewRows(messageColumnNames).
+			AddRow("reply2", "b", "Bob", "user2", "", "msg1", 0, map[string]int{}, base.Add(time.Minute), nil, nil, nil, nil).
+			AddRow("reply3", "c", "Bob", "user2", "", "msg1", 0, map[string]int{}, base.Add(2*time.Minute), nil, nil, nil, nil).
+			AddRow("reply4", "d", "Bob", "user2", "", "msg1", 0, map[string]int{}, base.Add(3*time.Minute), nil, nil, nil, nil))
+
+	got, err := NewPostgresMessageRepository(mock).ListReplies(context.Background(), "msg1", repository.PageRequest{Limit: 2, After: cursor})
+	if err != nil {
+		t.Fatalf("ListReplies() error = %v", err)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].ID != "reply2" || !got.HasNextPage {
+		t.Errorf("ListReplies() = %+v, want reply2, reply3 with a next page", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresMessageRepository_Purge(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer mock.Close()
+	mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM messages WHERE (id = ANY($1) AND parent_id IS NULL) OR parent_id = ANY($1) RETURNING " + messageColumns)).
+		WithArgs([]string{"msg1"}).
+		WillReturnRows(pgxmock.NewRows(messageColumnNames).
+			AddRow("msg1", "a", "Alice", "user1", "", "", 1, map[string]int{}, base, nil, nil, nil, nil).
+			AddRow("reply1", "b", "Bob", "user2", "", "msg1", 0, map[string]int{}, base.Add(time.Minute), nil, nil, nil, nil))
+
+	got, err := NewPostgresMessageRepository(mock).Purge(context.Background(), []string{"msg1"})
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "msg1" || got[1].ID != "reply1" {
+		t.Errorf("Purge() = %+v, want msg1 and reply1", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresMessageRepository_AddReaction(t *testing.T) {
+	insertReaction := regexp.QuoteMeta("INSERT INTO message_reactions (message_id, emoji, user_id, created_at) VALUES ($1, $2, $3, $4)")
+	reaction := &domain.Reaction{MessageID: "msg1", Emoji: "👍", UserID: "user1", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error
+	}{
+		{name: "正常系: リアクションを追加"},
+		{name: "異常系: メッセージが存在しない", err: &pgconn.PgError{Code: "23503"}, wantKind: repository.ErrNotFound},
+		{name: "異常系: 同じ絵文字で重複", err: &pgconn.PgError{Code: "23505"}, wantKind: repository.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("failed to create mock: %v", err)
+			}
+			defer mock.Close()
+			exp := mock.ExpectExec(insertReaction).WithArgs(reaction.MessageID, reaction.Emoji, reaction.UserID, reaction.CreatedAt)
+			if tt.err != nil {
+				exp.WillReturnError(tt.err)
+			} else {
+				exp.WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			}
+
+			err = NewPostgresMessageRepository(mock).AddReaction(context.Background(), reaction)
+
+			if tt.wantKind == nil && err != nil {
+				t.Errorf("AddReaction() error = %v", err)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("AddReaction() error = %v, want %v", err, tt.wantKind)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgresMessageRepository_Edit(t *testing.T) {
+	editedAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rows     int64
+		wantKind error
+	}{
+		{name: "正常系: 編集前の本文を履歴に残す", rows: 1},
+		{name: "異常系: メッセージが存在しない", rows: 0, wantKind: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("failed to create mock: %v", err)
+			}
+			defer mock.Close()
+			mock.ExpectExec("INSERT INTO message_edits").
+				WithArgs("msg1", "Edited", "user1", editedAt, []string{"user2"}, `{"status":"flagged","reasons":["spam"]}`).
+				WillReturnResult(pgxmock.NewResult("INSERT", tt.rows))
+
+			moderation := &domain.Moderation{Status: domain.ModerationFlagged, Reasons: []string{"spam"}}
+			err = NewPostgresMessageRepository(mock).Edit(context.Background(), "msg1", "Edited", []string{"user2"}, moderation, "user1", editedAt)
+
+			if tt.wantKind == nil && err != nil {
+				t.Errorf("Edit() error = %v", err)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("Edit() error = %v, want %v", err, tt.wantKind)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgresMessageRepository_SetModeration(t *testing.T) {
+	moderatedAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rows     *pgxmock.Rows
+		wantKind error
+	}{
+		{name: "正常系: 警告されたメッセージに判断を記録", rows: pgxmock.NewRows([]string{"flagged"}).AddRow(true)},
+		{name: "異常系: 判断済みのメッセージ", rows: pgxmock.NewRows([]string{"flagged"}).AddRow(false), wantKind: repository.ErrConflict},
+		{name: "異常系: メッセージが存在しない", rows: pgxmock.NewRows([]string{"flagged"}), wantKind: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("failed to create mock: %v", err)
+			}
+			defer mock.Close()
+			mock.ExpectQuery("UPDATE messages SET moderation").
+				WithArgs("msg1", `{"status":"approved","reasons":["spam"],"moderatorId":"user1","moderatedAt":"2024-01-02T00:00:00Z"}`).
+				WillReturnRows(tt.rows)
+
+			moderation := &domain.Moderation{Status: domain.ModerationApproved, Reasons: []string{"spam"}, ModeratorID: "user1", ModeratedAt: &moderatedAt}
+			err = NewPostgresMessageRepository(mock).SetModeration(context.Background(), "msg1", moderation)
+
+			if tt.wantKind == nil && err != nil {
+				t.Errorf("SetModeration() error = %v", err)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("SetModeration() error = %v, want %v", err, tt.wantKind)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
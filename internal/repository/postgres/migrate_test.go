@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/migrate"
+	pgclient "github.com/kuchida1981/graphql-sampleapp/internal/postgres"
+)
+
+// TestMigrate_FromInitScript migrates a database created by the init script
+// used before migrations, found in testdata. It recreates the public schema,
+// so point TEST_DATABASE_URL at a disposable database.
+func TestMigrate_FromInitScript(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	script, err := os.ReadFile("testdata/init-postgres.sql")
+	if err != nil {
+		t.Fatalf("failed to read init script: %v", err)
+	}
+
+	ctx := context.Background()
+	pool, err := pgclient.NewPool(ctx, url, config.PostgresPoolConfig{})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	defer pool.Close()
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+
+	for _, stmt := range []string{"DROP SCHEMA public CASCADE", "CREATE SCHEMA public", string(script)} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("failed to prepare the database: %v", err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO users (id, name, email) VALUES ('user1', 'Alice', 'alice@example.com')"); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+
+	migrations, err := migrate.PostgresMigrations()
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrate.New(db, migrate.Postgres, migrations).Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	user, err := NewPostgresUserRepository(pool).GetByID(ctx, "user1")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if len(user.Roles) != 1 || user.Roles[0] != "user" {
+		t.Errorf("GetByID() roles = %v, want [user]", user.Roles)
+	}
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type PostgresSearchRepository struct {
+	db DBTX
+}
+
+func NewPostgresSearchRepository(db DBTX) *PostgresSearchRepository {
+	return &PostgresSearchRepository{db: db}
+}
+
+func (r *PostgresSearchRepository) Index(ctx context.Context, doc *domain.SearchDocument) error {
+	log.Printf("PostgresSearchRepository: Indexing message %s with %d tokens", doc.MessageID, len(doc.Tokens))
+
+	db := conn(ctx, r.db)
+	query := `INSERT INTO message_search_documents (message_id, channel_id, author, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (message_id) DO UPDATE SET channel_id = EXCLUDED.channel_id, author = EXCLUDED.author, created_at = EXCLUDED.created_at`
+	if _, err := db.Exec(ctx, query, doc.MessageID, doc.ChannelID, doc.Author, doc.CreatedAt.UTC()); err != nil {
+		log.Printf("PostgresSearchRepository: Failed to index message: %v", err)
+		return classifyError("failed to index message", err)
+	}
+
+	if _, err := db.Exec(ctx, "DELETE FROM message_search_tokens WHERE message_id = $1", doc.MessageID); err != nil {
+		log.Printf("PostgresSearchRepository: Failed to remove tokens: %v", err)
+		return classifyError("failed to index message", err)
+	}
+	query = "INSERT INTO message_search_tokens (token, message_id) SELECT DISTINCT unnest($2::text[]), $1"
+	if _, err := db.Exec(ctx, query, doc.MessageID, doc.Tokens); err != nil {
+		log.Printf("PostgresSearchRepository: Failed to add tokens: %v", err)
+		return classifyError("failed to index message", err)
+	}
+	return nil
+}
+
+// Delete relies on the tokens of a document being removed with it by the
+// foreign key.
+func (r *PostgresSearchRepository) Delete(ctx context.Context, messageIDs []string) error {
+	log.Printf("PostgresSearchRepository: Removing %d message(s) from the index", len(messageIDs))
+
+	if _, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM message_search_documents WHERE message_id = ANY($1)", messageIDs); err != nil {
+		log.Printf("PostgresSearchRepository: Failed to remove messages: %v", err)
+		return classifyError("failed to remove messages from the index", err)
+	}
+	return nil
+}
+
+func (r *PostgresSearchRepository) Search(ctx context.Context, query repository.SearchQuery, page repository.PageRequest) (*repository.SearchPage, error) {
+	log.Printf("PostgresSearchRepository: Searching with %d tokens", len(query.Tokens))
+
+	if len(query.Tokens) == 0 {
+		return &repository.SearchPage{}, nil
+	}
+
+	where, args := searchWhere(query, page)
+	args = append(args, page.Limit+1)
+	sql := "SELECT message_id, channel_id, author, created_at FROM message_search_documents" + where +
+		fmt.Sprintf(" ORDER BY created_at DESC, message_id DESC LIMIT $%d", len(args))
+
+	rows, err := conn(ctx, r.db).Query(ctx, sql, args...)
+	if err != nil {
+		log.Printf("PostgresSearchRepository: Failed to search messages: %v", err)
+		return nil, classifyError("failed to search messages", err)
+	}
+	defer rows.Close()
+
+	var docs []*domain.SearchDocument
+	for rows.Next() {
+		var doc domain.SearchDocument
+		if err := rows.Scan(&doc.MessageID, &doc.ChannelID, &doc.Author, &doc.CreatedAt); err != nil {
+			log.Printf("PostgresSearchRepository: Failed to scan document: %v", err)
+			return nil, fmt.Errorf("failed to scan search document: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("PostgresSearchRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+
+	result := &repository.SearchPage{Documents: docs}
+	if len(docs) > page.Limit {
+		result.Documents = docs[:page.Limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
+
+// searchWhere builds the WHERE clause of Search. A document matches when it
+// has a row for every token, which the primary key keeps distinct.
+func searchWhere(query repository.SearchQuery, page repository.PageRequest) (string, []any) {
+	args := []any{query.Tokens, len(query.Tokens)}
+	conditions := []string{"message_id IN (SELECT message_id FROM message_search_tokens WHERE token = ANY($1) GROUP BY message_id HAVING COUNT(*) = $2)"}
+
+	if query.Author != "" {
+		args = append(args, query.Author)
+		conditions = append(conditions, fmt.Sprintf("author = $%d", len(args)))
+	}
+	if query.From != nil {
+		args = append(args, query.From.UTC())
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if query.To != nil {
+		args = append(args, query.To.UTC())
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if !query.AllChannels {
+		args = append(args, query.ChannelIDs)
+		conditions = append(conditions, fmt.Sprintf("(channel_id = '' OR channel_id = ANY($%d))", len(args)))
+	}
+	if page.After != nil {
+		args = append(args, page.After.CreatedAt.UTC(), page.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, message_id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// TxBeginner is implemented by *pgxpool.Pool.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+type txKey struct{}
+
+// PostgresTxManager implements repository.Transactor. Repositories in this
+// package pick up the transaction from the context through conn.
+type PostgresTxManager struct {
+	db  TxBeginner
+	cfg config.TxConfig
+}
+
+func NewPostgresTxManager(db TxBeginner, cfg config.TxConfig) *PostgresTxManager {
+	return &PostgresTxManager{db: db, cfg: cfg}
+}
+
+func (m *PostgresTxManager) WithinTx(ctx context.Context, opts repository.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := m.run(ctx, opts, fn)
+		if err == nil || attempt >= m.cfg.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		backoff := m.cfg.RetryBackoff << (attempt - 1)
+		log.Printf("PostgresTxManager: Retrying transaction after %v (attempt %d/%d): %v", backoff, attempt, m.cfg.MaxAttempts, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (m *PostgresTxManager) run(ctx context.Context, opts repository.TxOptions, fn func(ctx context.Context) error) (err error) {
+	tx, err := m.db.BeginTx(ctx, pgxTxOptions(opts))
+	if err != nil {
+		return classifyError("failed to begin transaction", err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Roll back even when ctx was cancelled so the connection is released.
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			log.Printf("PostgresTxManager: Failed to roll back transaction: %v", rbErr)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return classifyError("failed to commit transaction", err)
+	}
+	return nil
+}
+
+func pgxTxOptions(opts repository.TxOptions) pgx.TxOptions {
+	var txOpts pgx.TxOptions
+	switch opts.Isolation {
+	case repository.ReadCommitted:
+		txOpts.IsoLevel = pgx.ReadCommitted
+	case repository.RepeatableRead:
+		txOpts.IsoLevel = pgx.RepeatableRead
+	case repository.Serializable:
+		txOpts.IsoLevel = pgx.Serializable
+	}
+	if opts.ReadOnly {
+		txOpts.AccessMode = pgx.ReadOnly
+	}
+	return txOpts
+}
+
+// isRetryable reports whether err is a serialization failure or deadlock,
+// after which the whole transaction can be run again.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+}
+
+// conn returns the transaction carried by ctx, or db outside a transaction.
+func conn(ctx context.Context, db DBTX) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+var _ repository.Transactor = (*PostgresTxManager)(nil)
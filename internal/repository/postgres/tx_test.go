@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestPostgresTxManager_WithinTx(t *testing.T) {
+	// Repositories hand the transaction classified errors.
+	serializationFailure := classifyError("failed to update user", &pgconn.PgError{Code: "40001"})
+	insertUser := regexp.QuoteMeta("INSERT INTO users (id, name, handle, email, roles, created_at, updated_at, updated_by) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $6, NULLIF($7, ''))")
+	user := &domain.User{ID: "user1", Name: "Alice", Email: "alice@example.com", Roles: []string{"user"}, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name   string
+		opts   repository.TxOptions
+		mockFn func(mock pgxmock.PgxPoolIface)
+		fnErrs []error
+		// createUser makes fn write through PostgresUserRepository.
+		createUser bool
+		wantRuns   int
+		wantKind   error
+		wantErr    bool
+	}{
+		{
+			name: "正常系: リポジトリの書き込みがトランザクション内でコミットされる",
+			opts: repository.TxOptions{Isolation: repository.Serializable},
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.Serializable})
+				mock.ExpectExec(insertUser).
+					WithArgs(user.ID, user.Name, user.Handle, user.Email, user.Roles, user.CreatedAt, "").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			fnErrs:     []error{nil},
+			createUser: true,
+			wantRuns:   1,
+		},
+		{
+			name: "正常系: 読み取り専用トランザクション",
+			opts: repository.TxOptions{Isolation: repository.RepeatableRead, ReadOnly: true},
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+				mock.ExpectCommit()
+			},
+			fnErrs:   []error{nil},
+			wantRuns: 1,
+		},
+		{
+			name: "正常系: シリアライズ失敗後にリトライして成功",
+			opts: repository.TxOptions{Isolation: repository.Serializable},
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.Serializable})
+				mock.ExpectRollback()
+				mock.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.Serializable})
+				mock.ExpectCommit()
+			},
+			fnErrs:   []error{serializationFailure, nil},
+			wantRuns: 2,
+		},
+		{
+			name: "正常系: コミット時のシリアライズ失敗もリトライ",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(pgx.TxOptions{})
+				mock.ExpectCommit().WillReturnError(&pgconn.PgError{Code: "40001"})
+				mock.ExpectRollback()
+				mock.ExpectBeginTx(pgx.TxOptions{})
+				mock.ExpectCommit()
+			},
+			fnErrs:   []error{nil, nil},
+			wantRuns: 2,
+		},
+		{
+			name: "異常系: 最大試行回数を超えるとConflict",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				for range 3 {
+					mock.ExpectBeginTx(pgx.TxOptions{})
+					mock.ExpectRollback()
+				}
+			},
+			fnErrs:   []error{serializationFailure, serializationFailure, serializationFailure},
+			wantRuns: 3,
+			wantKind: repository.ErrConflict,
+			wantErr:  true,
+		},
+		{
+			name: "異常系: リトライ対象外のエラーはロールバックのみ",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(pgx.TxOptions{})
+				mock.ExpectRollback()
+			},
+			fnErrs:   []error{errors.New("validation failed")},
+			wantRuns: 1,
+			wantErr:  true,
+		},
+		{
+			name: "異常系: BEGINの失敗",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(pgx.TxOptions{}).WillReturnError(&pgconn.PgError{Code: "57P03"})
+			},
+			wantRuns: 0,
+			wantKind: repository.ErrUnavailable,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("failed to create mock: %v", err)
+			}
+			defer mock.Close()
+			tt.mockFn(mock)
+
+			manager := NewPostgresTxManager(mock, config.TxConfig{MaxAttempts: 3})
+			users := NewPostgresUserRepository(mock)
+
+			runs := 0
+			err = manager.WithinTx(context.Background(), tt.opts, func(ctx context.Context) error {
+				err := tt.fnErrs[runs]
+				runs++
+				if err == nil && tt.createUser {
+					return users.Create(ctx, user)
+				}
+				return err
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithinTx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("WithinTx() error = %v, want %v", err, tt.wantKind)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("fn ran %d times, want %d", runs, tt.wantRuns)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgresTxManager_WithinTx_Nested(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBeginTx(pgx.TxOptions{})
+	mock.ExpectCommit()
+
+	manager := NewPostgresTxManager(mock, config.TxConfig{MaxAttempts: 3})
+	err = manager.WithinTx(context.Background(), repository.TxOptions{}, func(ctx context.Context) error {
+		return manager.WithinTx(ctx, repository.TxOptions{Isolation: repository.Serializable}, func(ctx context.Context) error {
+			return nil
+		})
+	})
+	if err != nil {
+		t.Errorf("WithinTx() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -2,45 +2,57 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
 )
 
+// userColumns is the column list read by scanUser.
+const userColumns = "id, name, COALESCE(handle, ''), email, roles, created_at, updated_at, COALESCE(updated_by, ''), deleted_at"
+
 type PostgresUserRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+func NewPostgresUserRepository(db DBTX) *PostgresUserRepository {
 	return &PostgresUserRepository{db: db}
 }
 
-func (r *PostgresUserRepository) List(ctx context.Context) ([]*domain.User, error) {
-	log.Println("PostgresUserRepository: Listing all users")
+func (r *PostgresUserRepository) List(ctx context.Context, filter repository.UserFilter) ([]*domain.User, error) {
+	log.Printf("PostgresUserRepository: Listing users with filter: %+v", filter)
+
+	query := "SELECT " + userColumns + " FROM users"
+	if !filter.IncludeDeleted {
+		query += " WHERE deleted_at IS NULL"
+	}
+	query += " ORDER BY created_at DESC"
 
-	query := "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC"
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := conn(ctx, r.db).Query(ctx, query)
 	if err != nil {
 		log.Printf("PostgresUserRepository: Failed to query users: %v", err)
-		return nil, fmt.Errorf("failed to query users: %w", err)
+		return nil, classifyError("failed to query users", err)
 	}
 	defer rows.Close()
 
 	var users []*domain.User
 	for rows.Next() {
-		var user domain.User
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt); err != nil {
+		user, err := scanUser(rows)
+		if err != nil {
 			log.Printf("PostgresUserRepository: Failed to scan user: %v", err)
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	if err := rows.Err(); err != nil {
 		log.Printf("PostgresUserRepository: Row iteration error: %v", err)
-		return nil, fmt.Errorf("row iteration error: %w", err)
+		return nil, classifyError("row iteration error", err)
 	}
 
 	log.Printf("PostgresUserRepository: Found %d users", len(users))
@@ -50,19 +62,155 @@ func (r *PostgresUserRepository) List(ctx context.Context) ([]*domain.User, erro
 func (r *PostgresUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	log.Printf("PostgresUserRepository: Getting user by ID: %s", id)
 
-	query := "SELECT id, name, email, created_at FROM users WHERE id = $1"
-	row := r.db.QueryRowContext(ctx, query, id)
-
-	var user domain.User
-	if err := row.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt); err != nil {
-		if err == sql.ErrNoRows {
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1 AND deleted_at IS NULL"
+	user, err := scanUser(conn(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
 			log.Printf("PostgresUserRepository: User not found: %s", id)
-			return nil, fmt.Errorf("user not found: %s", id)
+			return nil, repository.NotFoundf("user %s not found", id)
 		}
 		log.Printf("PostgresUserRepository: Failed to scan user: %v", err)
-		return nil, fmt.Errorf("failed to scan user: %w", err)
+		return nil, classifyError("failed to get user", err)
 	}
 
 	log.Printf("PostgresUserRepository: Found user: %s", user.ID)
+	return user, nil
+}
+
+func (r *PostgresUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	log.Printf("PostgresUserRepository: Getting users by IDs: %v", ids)
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query := "SELECT " + userColumns + " FROM users WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id"
+	rows, err := conn(ctx, r.db).Query(ctx, query, ids)
+	if err != nil {
+		log.Printf("PostgresUserRepository: Failed to query users: %v", err)
+		return nil, classifyError("failed to query users", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Printf("PostgresUserRepository: Failed to scan user: %v", err)
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("PostgresUserRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+	return users, nil
+}
+
+func (r *PostgresUserRepository) GetByHandles(ctx context.Context, handles []string) ([]*domain.User, error) {
+	log.Printf("PostgresUserRepository: Getting users by handles: %v", handles)
+
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	lower := make([]string, len(handles))
+	for i, handle := range handles {
+		lower[i] = strings.ToLower(handle)
+	}
+	// LOWER(handle) matches the unique index idx_users_handle.
+	query := "SELECT " + userColumns + " FROM users WHERE LOWER(handle) = ANY($1) AND deleted_at IS NULL ORDER BY id"
+	rows, err := conn(ctx, r.db).Query(ctx, query, lower)
+	if err != nil {
+		log.Printf("PostgresUserRepository: Failed to query users: %v", err)
+		return nil, classifyError("failed to query users", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Printf("PostgresUserRepository: Failed to scan user: %v", err)
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("PostgresUserRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+	return users, nil
+}
+
+func scanUser(row pgx.Row) (*domain.User, error) {
+	var user domain.User
+	if err := row.Scan(&user.ID, &user.Name, &user.Handle, &user.Email, &user.Roles, &user.CreatedAt,
+		&user.UpdatedAt, &user.UpdatedBy, &user.DeletedAt); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
+
+func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User) error {
+	log.Printf("PostgresUserRepository: Creating user: %s", user.ID)
+
+	actor := repository.ActorFromContext(ctx)
+	query := "INSERT INTO users (id, name, handle, email, roles, created_at, updated_at, updated_by) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $6, NULLIF($7, ''))"
+	if _, err := conn(ctx, r.db).Exec(ctx, query, user.ID, user.Name, user.Handle, user.Email, user.Roles, user.CreatedAt.UTC(), actor); err != nil {
+		log.Printf("PostgresUserRepository: Failed to create user: %v", err)
+		return classifyError("failed to create user", err)
+	}
+	user.UpdatedAt = user.CreatedAt
+	user.UpdatedBy = actor
+	return nil
+}
+
+func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User) error {
+	log.Printf("PostgresUserRepository: Updating user: %s", user.ID)
+
+	now := time.Now().UTC()
+	actor := repository.ActorFromContext(ctx)
+	query := "UPDATE users SET name = $2, handle = NULLIF($3, ''), email = $4, roles = $5, updated_at = $6, updated_by = NULLIF($7, '') WHERE id = $1 AND deleted_at IS NULL"
+	tag, err := conn(ctx, r.db).Exec(ctx, query, user.ID, user.Name, user.Handle, user.Email, user.Roles, now, actor)
+	if err != nil {
+		log.Printf("PostgresUserRepository: Failed to update user: %v", err)
+		return classifyError("failed to update user", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.NotFoundf("user %s not found", user.ID)
+	}
+	user.UpdatedAt = now
+	user.UpdatedBy = actor
+	return nil
+}
+
+func (r *PostgresUserRepository) Delete(ctx context.Context, id string) (*domain.User, error) {
+	log.Printf("PostgresUserRepository: Deleting user: %s", id)
+
+	query := "UPDATE users SET deleted_at = $2, updated_at = $2, updated_by = NULLIF($3, '') WHERE id = $1 AND deleted_at IS NULL RETURNING " + userColumns
+	return r.setDeleted(ctx, query, id)
+}
+
+func (r *PostgresUserRepository) Restore(ctx context.Context, id string) (*domain.User, error) {
+	log.Printf("PostgresUserRepository: Restoring user: %s", id)
+
+	query := "UPDATE users SET deleted_at = NULL, updated_at = $2, updated_by = NULLIF($3, '') WHERE id = $1 AND deleted_at IS NOT NULL RETURNING " + userColumns
+	return r.setDeleted(ctx, query, id)
+}
+
+// setDeleted runs a Delete or Restore query, which matches no row when the
+// user does not exist or is already in the requested state.
+func (r *PostgresUserRepository) setDeleted(ctx context.Context, query, id string) (*domain.User, error) {
+	row := conn(ctx, r.db).QueryRow(ctx, query, id, time.Now().UTC(), repository.ActorFromContext(ctx))
+	user, err := scanUser(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.NotFoundf("user %s not found", id)
+	}
+	if err != nil {
+		log.Printf("PostgresUserRepository: Failed to change deleted state of user %s: %v", id, err)
+		return nil, classifyError("failed to change deleted state of user", err)
+	}
+	return user, nil
+}
@@ -2,29 +2,35 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"regexp"
+	"slices"
 	"testing"
 	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/pashagolub/pgxmock/v4"
 )
 
 func TestPostgresUserRepository_List(t *testing.T) {
+	listQuery := regexp.QuoteMeta("SELECT " + userColumns + " FROM users WHERE deleted_at IS NULL ORDER BY created_at DESC")
+
 	tests := []struct {
 		name    string
-		mockFn  func(mock sqlmock.Sqlmock)
+		mockFn  func(mock pgxmock.PgxPoolIface)
 		want    []*domain.User
 		wantErr bool
 	}{
 		{
 			name: "正常系: ユーザーリスト取得成功",
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "name", "email", "created_at"}).
-					AddRow("user1", "Alice", "alice@example.com", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)).
-					AddRow("user2", "Bob", "bob@example.com", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
-				mock.ExpectQuery("SELECT id, name, email, created_at FROM users ORDER BY created_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "name", "handle", "email", "roles", "created_at", "updated_at", "updated_by", "deleted_at"}).
+					AddRow("user1", "Alice", "alice", "alice@example.com", []string{"user", "admin"}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "", nil).
+					AddRow("user2", "Bob", "bob", "bob@example.com", []string{}, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), "", nil)
+				mock.ExpectQuery(listQuery).
 					WillReturnRows(rows)
 			},
 			want: []*domain.User{
@@ -35,9 +41,9 @@ func TestPostgresUserRepository_List(t *testing.T) {
 		},
 		{
 			name: "正常系: ユーザーが0件",
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "name", "email", "created_at"})
-				mock.ExpectQuery("SELECT id, name, email, created_at FROM users ORDER BY created_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "name", "handle", "email", "roles", "created_at", "updated_at", "updated_by", "deleted_at"})
+				mock.ExpectQuery(listQuery).
 					WillReturnRows(rows)
 			},
 			want:    []*domain.User{},
@@ -45,8 +51,8 @@ func TestPostgresUserRepository_List(t *testing.T) {
 		},
 		{
 			name: "異常系: クエリエラー",
-			mockFn: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, name, email, created_at FROM users ORDER BY created_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(listQuery).
 					WillReturnError(errors.New("database connection error"))
 			},
 			want:    nil,
@@ -54,10 +60,10 @@ func TestPostgresUserRepository_List(t *testing.T) {
 		},
 		{
 			name: "異常系: スキャンエラー",
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "name", "email", "created_at"}).
-					AddRow("user1", "Alice", "alice@example.com", "invalid-date")
-				mock.ExpectQuery("SELECT id, name, email, created_at FROM users ORDER BY created_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "name", "handle", "email", "roles", "created_at", "updated_at", "updated_by", "deleted_at"}).
+					AddRow("user1", "Alice", "alice", "alice@example.com", []string{"user", "admin"}, "invalid-date", time.Now(), "", nil)
+				mock.ExpectQuery(listQuery).
 					WillReturnRows(rows)
 			},
 			want:    nil,
@@ -67,16 +73,16 @@ func TestPostgresUserRepository_List(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			db, mock, err := sqlmock.New()
+			mock, err := pgxmock.NewPool()
 			if err != nil {
 				t.Fatalf("failed to create mock: %v", err)
 			}
-			defer db.Close()
+			defer mock.Close()
 
 			tt.mockFn(mock)
 
-			repo := NewPostgresUserRepository(db)
-			got, err := repo.List(context.Background())
+			repo := NewPostgresUserRepository(mock)
+			got, err := repo.List(context.Background(), repository.UserFilter{})
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("List() error = %v, wantErr %v", err, tt.wantErr)
@@ -105,20 +111,22 @@ func TestPostgresUserRepository_List(t *testing.T) {
 }
 
 func TestPostgresUserRepository_GetByID(t *testing.T) {
+	getQuery := regexp.QuoteMeta("SELECT " + userColumns + " FROM users WHERE id = $1 AND deleted_at IS NULL")
+
 	tests := []struct {
 		name    string
 		id      string
-		mockFn  func(mock sqlmock.Sqlmock)
+		mockFn  func(mock pgxmock.PgxPoolIface)
 		want    *domain.User
 		wantErr bool
 	}{
 		{
 			name: "正常系: ユーザー取得成功",
 			id:   "user1",
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "name", "email", "created_at"}).
-					AddRow("user1", "Alice", "alice@example.com", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
-				mock.ExpectQuery("SELECT id, name, email, created_at FROM users WHERE id = \\$1").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "name", "handle", "email", "roles", "created_at", "updated_at", "updated_by", "deleted_at"}).
+					AddRow("user1", "Alice", "alice", "alice@example.com", []string{"user", "admin"}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "", nil)
+				mock.ExpectQuery(getQuery).
 					WithArgs("user1").
 					WillReturnRows(rows)
 			},
@@ -126,17 +134,18 @@ func TestPostgresUserRepository_GetByID(t *testing.T) {
 				ID:        "user1",
 				Name:      "Alice",
 				Email:     "alice@example.com",
+				Roles:     []string{"user", "admin"},
 				CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
 			},
 			wantErr: false,
 		},
 		{
-			name: "異常系: ユーザーが見つからない (sql.ErrNoRows)",
+			name: "異常系: ユーザーが見つからない (pgx.ErrNoRows)",
 			id:   "nonexistent",
-			mockFn: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, name, email, created_at FROM users WHERE id = \\$1").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(getQuery).
 					WithArgs("nonexistent").
-					WillReturnError(sql.ErrNoRows)
+					WillReturnError(pgx.ErrNoRows)
 			},
 			want:    nil,
 			wantErr: true,
@@ -144,10 +153,10 @@ func TestPostgresUserRepository_GetByID(t *testing.T) {
 		{
 			name: "異常系: スキャンエラー",
 			id:   "user1",
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "name", "email", "created_at"}).
-					AddRow("user1", "Alice", "alice@example.com", "invalid-date")
-				mock.ExpectQuery("SELECT id, name, email, created_at FROM users WHERE id = \\$1").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "name", "handle", "email", "roles", "created_at", "updated_at", "updated_by", "deleted_at"}).
+					AddRow("user1", "Alice", "alice", "alice@example.com", []string{"user", "admin"}, "invalid-date", time.Now(), "", nil)
+				mock.ExpectQuery(getQuery).
 					WithArgs("user1").
 					WillReturnRows(rows)
 			},
@@ -158,15 +167,15 @@ func TestPostgresUserRepository_GetByID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			db, mock, err := sqlmock.New()
+			mock, err := pgxmock.NewPool()
 			if err != nil {
 				t.Fatalf("failed to create mock: %v", err)
 			}
-			defer db.Close()
+			defer mock.Close()
 
 			tt.mockFn(mock)
 
-			repo := NewPostgresUserRepository(db)
+			repo := NewPostgresUserRepository(mock)
 			got, err := repo.GetByID(context.Background(), tt.id)
 
 			if (err != nil) != tt.wantErr {
@@ -176,7 +185,8 @@ func TestPostgresUserRepository_GetByID(t *testing.T) {
 
 			if !tt.wantErr && got != nil && tt.want != nil {
 				if got.ID != tt.want.ID || got.Name != tt.want.Name ||
-					got.Email != tt.want.Email || !got.CreatedAt.Equal(tt.want.CreatedAt) {
+					got.Email != tt.want.Email || !got.CreatedAt.Equal(tt.want.CreatedAt) ||
+					!slices.Equal(got.Roles, tt.want.Roles) {
 					t.Errorf("GetByID() = %+v, want %+v", got, tt.want)
 				}
 			}
@@ -186,4 +196,131 @@ func TestPostgresUserRepository_GetByID(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestPostgresUserRepository_Update(t *testing.T) {
+	updateQuery := regexp.QuoteMeta("UPDATE users SET name = $2, handle = NULLIF($3, ''), email = $4, roles = $5, updated_at = $6, updated_by = NULLIF($7, '') WHERE id = $1 AND deleted_at IS NULL")
+	user := &domain.User{ID: "user1", Name: "Alice", Handle: "alice", Email: "alice@example.com", Roles: []string{"admin"}}
+
+	tests := []struct {
+		name     string
+		mockFn   func(mock pgxmock.PgxPoolIface)
+		wantKind error
+		wantErr  bool
+	}{
+		{
+			name: "正常系: ユーザー更新成功",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(updateQuery).
+					WithArgs(user.ID, user.Name, user.Handle, user.Email, user.Roles, pgxmock.AnyArg(), "admin1").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "異常系: ユーザーが見つからない",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(updateQuery).
+					WithArgs(user.ID, user.Name, user.Handle, user.Email, user.Roles, pgxmock.AnyArg(), "admin1").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantKind: repository.ErrNotFound,
+			wantErr:  true,
+		},
+		{
+			name: "異常系: メールアドレスの重複",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(updateQuery).
+					WithArgs(user.ID, user.Name, user.Handle, user.Email, user.Roles, pgxmock.AnyArg(), "admin1").
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			wantKind: repository.ErrConflict,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("failed to create mock: %v", err)
+			}
+			defer mock.Close()
+			tt.mockFn(mock)
+
+			repo := NewPostgresUserRepository(mock)
+			err = repo.Update(repository.WithActor(context.Background(), "admin1"), user)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("Update() error = %v, want %v", err, tt.wantKind)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgresUserRepository_Delete(t *testing.T) {
+	deleteQuery := regexp.QuoteMeta("UPDATE users SET deleted_at = $2, updated_at = $2, updated_by = NULLIF($3, '') WHERE id = $1 AND deleted_at IS NULL RETURNING " + userColumns)
+	deletedAt := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		mockFn   func(mock pgxmock.PgxPoolIface)
+		wantKind error
+		wantErr  bool
+	}{
+		{
+			name: "正常系: 論理削除成功",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "name", "handle", "email", "roles", "created_at", "updated_at", "updated_by", "deleted_at"}).
+					AddRow("user1", "Alice", "alice", "alice@example.com", []string{"user"}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), deletedAt, "admin1", &deletedAt)
+				mock.ExpectQuery(deleteQuery).
+					WithArgs("user1", pgxmock.AnyArg(), "admin1").
+					WillReturnRows(rows)
+			},
+			wantErr: false,
+		},
+		{
+			name: "異常系: ユーザーが見つからないか削除済み",
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(deleteQuery).
+					WithArgs("user1", pgxmock.AnyArg(), "admin1").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantKind: repository.ErrNotFound,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("failed to create mock: %v", err)
+			}
+			defer mock.Close()
+			tt.mockFn(mock)
+
+			repo := NewPostgresUserRepository(mock)
+			got, err := repo.Delete(repository.WithActor(context.Background(), "admin1"), "user1")
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("Delete() error = %v, want %v", err, tt.wantKind)
+			}
+			if !tt.wantErr && (got.DeletedAt == nil || !got.DeletedAt.Equal(deletedAt) || got.UpdatedBy != "admin1") {
+				t.Errorf("Delete() = %+v, want deletedAt %v by admin1", got, deletedAt)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
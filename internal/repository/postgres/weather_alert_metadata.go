@@ -2,20 +2,22 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
 	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
 )
 
 type PostgresWeatherAlertMetadataRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewPostgresWeatherAlertMetadataRepository(db *sql.DB) *PostgresWeatherAlertMetadataRepository {
+func NewPostgresWeatherAlertMetadataRepository(db DBTX) *PostgresWeatherAlertMetadataRepository {
 	return &PostgresWeatherAlertMetadataRepository{db: db}
 }
 
@@ -27,6 +29,10 @@ func (r *PostgresWeatherAlertMetadataRepository) SearchIDs(ctx context.Context,
 	var args []interface{}
 	argIndex := 1
 
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
 	if filter.Region != nil {
 		conditions = append(conditions, fmt.Sprintf("region = $%d", argIndex))
 		args = append(args, *filter.Region)
@@ -35,7 +41,9 @@ func (r *PostgresWeatherAlertMetadataRepository) SearchIDs(ctx context.Context,
 
 	if filter.IssuedAfter != nil {
 		conditions = append(conditions, fmt.Sprintf("issued_at >= $%d", argIndex))
-		args = append(args, *filter.IssuedAfter)
+		// issued_at is TIMESTAMP without time zone and pgx encodes only the wall
+		// clock, so normalize to the UTC values the table stores.
+		args = append(args, filter.IssuedAfter.UTC())
 		argIndex++
 	}
 
@@ -47,10 +55,10 @@ func (r *PostgresWeatherAlertMetadataRepository) SearchIDs(ctx context.Context,
 
 	log.Printf("PostgresWeatherAlertMetadataRepository: Executing query: %s with args: %v", query, args)
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		log.Printf("PostgresWeatherAlertMetadataRepository: Failed to query: %v", err)
-		return nil, fmt.Errorf("failed to search weather alert metadata: %w", err)
+		return nil, classifyError("failed to search weather alert metadata", err)
 	}
 	defer rows.Close()
 
@@ -66,7 +74,7 @@ func (r *PostgresWeatherAlertMetadataRepository) SearchIDs(ctx context.Context,
 
 	if err := rows.Err(); err != nil {
 		log.Printf("PostgresWeatherAlertMetadataRepository: Row iteration error: %v", err)
-		return nil, fmt.Errorf("row iteration error: %w", err)
+		return nil, classifyError("row iteration error", err)
 	}
 
 	log.Printf("PostgresWeatherAlertMetadataRepository: Found %d IDs", len(ids))
@@ -76,11 +84,15 @@ func (r *PostgresWeatherAlertMetadataRepository) SearchIDs(ctx context.Context,
 func (r *PostgresWeatherAlertMetadataRepository) Search(ctx context.Context, filter repository.MetadataFilter) ([]*domain.WeatherAlertMetadata, error) {
 	log.Printf("PostgresWeatherAlertMetadataRepository: Searching metadata with filter: %+v", filter)
 
-	query := "SELECT id, region, severity, issued_at, created_at FROM weather_alert_metadata"
+	query := "SELECT " + metadataColumns + " FROM weather_alert_metadata"
 	var conditions []string
 	var args []interface{}
 	argIndex := 1
 
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
 	if filter.Region != nil {
 		conditions = append(conditions, fmt.Sprintf("region = $%d", argIndex))
 		args = append(args, *filter.Region)
@@ -89,7 +101,7 @@ func (r *PostgresWeatherAlertMetadataRepository) Search(ctx context.Context, fil
 
 	if filter.IssuedAfter != nil {
 		conditions = append(conditions, fmt.Sprintf("issued_at >= $%d", argIndex))
-		args = append(args, *filter.IssuedAfter)
+		args = append(args, filter.IssuedAfter.UTC())
 		argIndex++
 	}
 
@@ -101,28 +113,83 @@ func (r *PostgresWeatherAlertMetadataRepository) Search(ctx context.Context, fil
 
 	log.Printf("PostgresWeatherAlertMetadataRepository: Executing query: %s with args: %v", query, args)
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
 		log.Printf("PostgresWeatherAlertMetadataRepository: Failed to query: %v", err)
-		return nil, fmt.Errorf("failed to search weather alert metadata: %w", err)
+		return nil, classifyError("failed to search weather alert metadata", err)
 	}
 	defer rows.Close()
 
 	var metadataList []*domain.WeatherAlertMetadata
 	for rows.Next() {
-		var metadata domain.WeatherAlertMetadata
-		if err := rows.Scan(&metadata.ID, &metadata.Region, &metadata.Severity, &metadata.IssuedAt, &metadata.CreatedAt); err != nil {
+		metadata, err := scanMetadata(rows)
+		if err != nil {
 			log.Printf("PostgresWeatherAlertMetadataRepository: Failed to scan metadata: %v", err)
 			return nil, fmt.Errorf("failed to scan metadata: %w", err)
 		}
-		metadataList = append(metadataList, &metadata)
+		metadataList = append(metadataList, metadata)
 	}
 
 	if err := rows.Err(); err != nil {
 		log.Printf("PostgresWeatherAlertMetadataRepository: Row iteration error: %v", err)
-		return nil, fmt.Errorf("row iteration error: %w", err)
+		return nil, classifyError("row iteration error", err)
 	}
 
 	log.Printf("PostgresWeatherAlertMetadataRepository: Found %d metadata records", len(metadataList))
 	return metadataList, nil
 }
+
+func (r *PostgresWeatherAlertMetadataRepository) Create(ctx context.Context, metadata *domain.WeatherAlertMetadata) error {
+	log.Printf("PostgresWeatherAlertMetadataRepository: Creating metadata: %s", metadata.ID)
+
+	actor := repository.ActorFromContext(ctx)
+	query := "INSERT INTO weather_alert_metadata (id, region, severity, issued_at, created_at, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $5, NULLIF($6, ''))"
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		metadata.ID, metadata.Region, metadata.Severity, metadata.IssuedAt.UTC(), metadata.CreatedAt.UTC(), actor)
+	if err != nil {
+		log.Printf("PostgresWeatherAlertMetadataRepository: Failed to create metadata: %v", err)
+		return classifyError("failed to create weather alert metadata", err)
+	}
+	metadata.UpdatedAt = metadata.CreatedAt
+	metadata.UpdatedBy = actor
+	return nil
+}
+
+func (r *PostgresWeatherAlertMetadataRepository) Delete(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error) {
+	log.Printf("PostgresWeatherAlertMetadataRepository: Deleting metadata: %s", id)
+
+	query := "UPDATE weather_alert_metadata SET deleted_at = $2, updated_at = $2, updated_by = NULLIF($3, '') WHERE id = $1 AND deleted_at IS NULL RETURNING " + metadataColumns
+	return r.setDeleted(ctx, query, id)
+}
+
+func (r *PostgresWeatherAlertMetadataRepository) Restore(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error) {
+	log.Printf("PostgresWeatherAlertMetadataRepository: Restoring metadata: %s", id)
+
+	query := "UPDATE weather_alert_metadata SET deleted_at = NULL, updated_at = $2, updated_by = NULLIF($3, '') WHERE id = $1 AND deleted_at IS NOT NULL RETURNING " + metadataColumns
+	return r.setDeleted(ctx, query, id)
+}
+
+func (r *PostgresWeatherAlertMetadataRepository) setDeleted(ctx context.Context, query, id string) (*domain.WeatherAlertMetadata, error) {
+	row := conn(ctx, r.db).QueryRow(ctx, query, id, time.Now().UTC(), repository.ActorFromContext(ctx))
+	metadata, err := scanMetadata(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.NotFoundf("weather alert metadata %s not found", id)
+	}
+	if err != nil {
+		log.Printf("PostgresWeatherAlertMetadataRepository: Failed to change deleted state of metadata %s: %v", id, err)
+		return nil, classifyError("failed to change deleted state of weather alert metadata", err)
+	}
+	return metadata, nil
+}
+
+// metadataColumns is the column list read by scanMetadata.
+const metadataColumns = "id, region, severity, issued_at, created_at, updated_at, COALESCE(updated_by, ''), deleted_at"
+
+func scanMetadata(row pgx.Row) (*domain.WeatherAlertMetadata, error) {
+	var m domain.WeatherAlertMetadata
+	if err := row.Scan(&m.ID, &m.Region, &m.Severity, &m.IssuedAt, &m.CreatedAt,
+		&m.UpdatedAt, &m.UpdatedBy, &m.DeletedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
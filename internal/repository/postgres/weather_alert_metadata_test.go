@@ -2,13 +2,12 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"testing"
 	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/pashagolub/pgxmock/v4"
 )
 
 func TestPostgresWeatherAlertMetadataRepository_Search(t *testing.T) {
@@ -19,18 +18,18 @@ func TestPostgresWeatherAlertMetadataRepository_Search(t *testing.T) {
 	tests := []struct {
 		name    string
 		filter  repository.MetadataFilter
-		mockFn  func(mock sqlmock.Sqlmock)
+		mockFn  func(mock pgxmock.PgxPoolIface)
 		want    int
 		wantErr bool
 	}{
 		{
 			name:   "正常系: フィルタなしで検索",
 			filter: repository.MetadataFilter{},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at"}).
-					AddRow("alert1", "Tokyo", "warning", now, now).
-					AddRow("alert2", "Osaka", "info", now.Add(-24*time.Hour), now.Add(-24*time.Hour))
-				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at FROM weather_alert_metadata ORDER BY issued_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at", "updated_at", "updated_by", "deleted_at"}).
+					AddRow("alert1", "Tokyo", "warning", now, now, now, "", nil).
+					AddRow("alert2", "Osaka", "info", now.Add(-24*time.Hour), now.Add(-24*time.Hour), now.Add(-24*time.Hour), "", nil)
+				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at, updated_at, COALESCE\\(updated_by, ''\\), deleted_at FROM weather_alert_metadata WHERE deleted_at IS NULL ORDER BY issued_at DESC").
 					WillReturnRows(rows)
 			},
 			want:    2,
@@ -41,10 +40,10 @@ func TestPostgresWeatherAlertMetadataRepository_Search(t *testing.T) {
 			filter: repository.MetadataFilter{
 				Region: &region,
 			},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at"}).
-					AddRow("alert1", "Tokyo", "warning", now, now)
-				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at FROM weather_alert_metadata WHERE region = \\$1 ORDER BY issued_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at", "updated_at", "updated_by", "deleted_at"}).
+					AddRow("alert1", "Tokyo", "warning", now, now, now, "", nil)
+				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at, updated_at, COALESCE\\(updated_by, ''\\), deleted_at FROM weather_alert_metadata WHERE deleted_at IS NULL AND region = \\$1 ORDER BY issued_at DESC").
 					WithArgs("Tokyo").
 					WillReturnRows(rows)
 			},
@@ -56,10 +55,10 @@ func TestPostgresWeatherAlertMetadataRepository_Search(t *testing.T) {
 			filter: repository.MetadataFilter{
 				IssuedAfter: &issuedAfter,
 			},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at"}).
-					AddRow("alert1", "Tokyo", "warning", now, now)
-				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at FROM weather_alert_metadata WHERE issued_at >= \\$1 ORDER BY issued_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at", "updated_at", "updated_by", "deleted_at"}).
+					AddRow("alert1", "Tokyo", "warning", now, now, now, "", nil)
+				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at, updated_at, COALESCE\\(updated_by, ''\\), deleted_at FROM weather_alert_metadata WHERE deleted_at IS NULL AND issued_at >= \\$1 ORDER BY issued_at DESC").
 					WithArgs(issuedAfter).
 					WillReturnRows(rows)
 			},
@@ -72,10 +71,10 @@ func TestPostgresWeatherAlertMetadataRepository_Search(t *testing.T) {
 				Region:      &region,
 				IssuedAfter: &issuedAfter,
 			},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at"}).
-					AddRow("alert1", "Tokyo", "warning", now, now)
-				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at FROM weather_alert_metadata WHERE region = \\$1 AND issued_at >= \\$2 ORDER BY issued_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at", "updated_at", "updated_by", "deleted_at"}).
+					AddRow("alert1", "Tokyo", "warning", now, now, now, "", nil)
+				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at, updated_at, COALESCE\\(updated_by, ''\\), deleted_at FROM weather_alert_metadata WHERE deleted_at IS NULL AND region = \\$1 AND issued_at >= \\$2 ORDER BY issued_at DESC").
 					WithArgs("Tokyo", issuedAfter).
 					WillReturnRows(rows)
 			},
@@ -85,8 +84,8 @@ func TestPostgresWeatherAlertMetadataRepository_Search(t *testing.T) {
 		{
 			name:   "異常系: クエリエラー",
 			filter: repository.MetadataFilter{},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at FROM weather_alert_metadata ORDER BY issued_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at, updated_at, COALESCE\\(updated_by, ''\\), deleted_at FROM weather_alert_metadata WHERE deleted_at IS NULL ORDER BY issued_at DESC").
 					WillReturnError(errors.New("database connection error"))
 			},
 			want:    0,
@@ -95,9 +94,9 @@ func TestPostgresWeatherAlertMetadataRepository_Search(t *testing.T) {
 		{
 			name:   "正常系: 結果が0件",
 			filter: repository.MetadataFilter{},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at"})
-				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at FROM weather_alert_metadata ORDER BY issued_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at", "updated_at", "updated_by", "deleted_at"})
+				mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at, updated_at, COALESCE\\(updated_by, ''\\), deleted_at FROM weather_alert_metadata WHERE deleted_at IS NULL ORDER BY issued_at DESC").
 					WillReturnRows(rows)
 			},
 			want:    0,
@@ -107,15 +106,15 @@ func TestPostgresWeatherAlertMetadataRepository_Search(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			db, mock, err := sqlmock.New()
+			mock, err := pgxmock.NewPool()
 			if err != nil {
 				t.Fatalf("failed to create mock: %v", err)
 			}
-			defer db.Close()
+			defer mock.Close()
 
 			tt.mockFn(mock)
 
-			repo := NewPostgresWeatherAlertMetadataRepository(db)
+			repo := NewPostgresWeatherAlertMetadataRepository(mock)
 			got, err := repo.Search(context.Background(), tt.filter)
 
 			if (err != nil) != tt.wantErr {
@@ -141,19 +140,19 @@ func TestPostgresWeatherAlertMetadataRepository_SearchIDs(t *testing.T) {
 	tests := []struct {
 		name    string
 		filter  repository.MetadataFilter
-		mockFn  func(mock sqlmock.Sqlmock)
+		mockFn  func(mock pgxmock.PgxPoolIface)
 		want    []string
 		wantErr bool
 	}{
 		{
 			name:   "正常系: IDリスト取得",
 			filter: repository.MetadataFilter{},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id"}).
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id"}).
 					AddRow("alert1").
 					AddRow("alert2").
 					AddRow("alert3")
-				mock.ExpectQuery("SELECT id FROM weather_alert_metadata ORDER BY issued_at DESC").
+				mock.ExpectQuery("SELECT id FROM weather_alert_metadata WHERE deleted_at IS NULL ORDER BY issued_at DESC").
 					WillReturnRows(rows)
 			},
 			want:    []string{"alert1", "alert2", "alert3"},
@@ -164,10 +163,10 @@ func TestPostgresWeatherAlertMetadataRepository_SearchIDs(t *testing.T) {
 			filter: repository.MetadataFilter{
 				Region: &region,
 			},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id"}).
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id"}).
 					AddRow("alert1")
-				mock.ExpectQuery("SELECT id FROM weather_alert_metadata WHERE region = \\$1 ORDER BY issued_at DESC").
+				mock.ExpectQuery("SELECT id FROM weather_alert_metadata WHERE deleted_at IS NULL AND region = \\$1 ORDER BY issued_at DESC").
 					WithArgs("Tokyo").
 					WillReturnRows(rows)
 			},
@@ -179,11 +178,11 @@ func TestPostgresWeatherAlertMetadataRepository_SearchIDs(t *testing.T) {
 			filter: repository.MetadataFilter{
 				IssuedAfter: &issuedAfter,
 			},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id"}).
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id"}).
 					AddRow("alert1").
 					AddRow("alert2")
-				mock.ExpectQuery("SELECT id FROM weather_alert_metadata WHERE issued_at >= \\$1 ORDER BY issued_at DESC").
+				mock.ExpectQuery("SELECT id FROM weather_alert_metadata WHERE deleted_at IS NULL AND issued_at >= \\$1 ORDER BY issued_at DESC").
 					WithArgs(issuedAfter).
 					WillReturnRows(rows)
 			},
@@ -193,8 +192,8 @@ func TestPostgresWeatherAlertMetadataRepository_SearchIDs(t *testing.T) {
 		{
 			name:   "異常系: クエリエラー",
 			filter: repository.MetadataFilter{},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery("SELECT id FROM weather_alert_metadata ORDER BY issued_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery("SELECT id FROM weather_alert_metadata WHERE deleted_at IS NULL ORDER BY issued_at DESC").
 					WillReturnError(errors.New("database connection error"))
 			},
 			want:    nil,
@@ -203,9 +202,9 @@ func TestPostgresWeatherAlertMetadataRepository_SearchIDs(t *testing.T) {
 		{
 			name:   "正常系: 結果が0件",
 			filter: repository.MetadataFilter{},
-			mockFn: func(mock sqlmock.Sqlmock) {
-				rows := sqlmock.NewRows([]string{"id"})
-				mock.ExpectQuery("SELECT id FROM weather_alert_metadata ORDER BY issued_at DESC").
+			mockFn: func(mock pgxmock.PgxPoolIface) {
+				rows := pgxmock.NewRows([]string{"id"})
+				mock.ExpectQuery("SELECT id FROM weather_alert_metadata WHERE deleted_at IS NULL ORDER BY issued_at DESC").
 					WillReturnRows(rows)
 			},
 			want:    []string{},
@@ -215,15 +214,15 @@ func TestPostgresWeatherAlertMetadataRepository_SearchIDs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			db, mock, err := sqlmock.New()
+			mock, err := pgxmock.NewPool()
 			if err != nil {
 				t.Fatalf("failed to create mock: %v", err)
 			}
-			defer db.Close()
+			defer mock.Close()
 
 			tt.mockFn(mock)
 
-			repo := NewPostgresWeatherAlertMetadataRepository(db)
+			repo := NewPostgresWeatherAlertMetadataRepository(mock)
 			got, err := repo.SearchIDs(context.Background(), tt.filter)
 
 			if (err != nil) != tt.wantErr {
@@ -252,19 +251,19 @@ func TestPostgresWeatherAlertMetadataRepository_SearchIDs(t *testing.T) {
 }
 
 func TestPostgresWeatherAlertMetadataRepository_Search_ScanError(t *testing.T) {
-	db, mock, err := sqlmock.New()
+	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatalf("failed to create mock: %v", err)
 	}
-	defer db.Close()
+	defer mock.Close()
 
 	// スキャンエラーを引き起こすために不正な型を返す
-	rows := sqlmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at"}).
-		AddRow("alert1", "Tokyo", "warning", "invalid-date", time.Now())
-	mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at FROM weather_alert_metadata ORDER BY issued_at DESC").
+	rows := pgxmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at", "updated_at", "updated_by", "deleted_at"}).
+		AddRow("alert1", "Tokyo", "warning", "invalid-date", time.Now(), time.Now(), "", nil)
+	mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at, updated_at, COALESCE\\(updated_by, ''\\), deleted_at FROM weather_alert_metadata WHERE deleted_at IS NULL ORDER BY issued_at DESC").
 		WillReturnRows(rows)
 
-	repo := NewPostgresWeatherAlertMetadataRepository(db)
+	repo := NewPostgresWeatherAlertMetadataRepository(mock)
 	_, err = repo.Search(context.Background(), repository.MetadataFilter{})
 
 	if err == nil {
@@ -277,19 +276,19 @@ func TestPostgresWeatherAlertMetadataRepository_Search_ScanError(t *testing.T) {
 }
 
 func TestPostgresWeatherAlertMetadataRepository_Search_RowsError(t *testing.T) {
-	db, mock, err := sqlmock.New()
+	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatalf("failed to create mock: %v", err)
 	}
-	defer db.Close()
+	defer mock.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at"}).
-		AddRow("alert1", "Tokyo", "warning", time.Now(), time.Now()).
-		RowError(0, sql.ErrConnDone)
-	mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at FROM weather_alert_metadata ORDER BY issued_at DESC").
+	rows := pgxmock.NewRows([]string{"id", "region", "severity", "issued_at", "created_at", "updated_at", "updated_by", "deleted_at"}).
+		AddRow("alert1", "Tokyo", "warning", time.Now(), time.Now(), time.Now(), "", nil).
+		RowError(0, errors.New("connection closed"))
+	mock.ExpectQuery("SELECT id, region, severity, issued_at, created_at, updated_at, COALESCE\\(updated_by, ''\\), deleted_at FROM weather_alert_metadata WHERE deleted_at IS NULL ORDER BY issued_at DESC").
 		WillReturnRows(rows)
 
-	repo := NewPostgresWeatherAlertMetadataRepository(db)
+	repo := NewPostgresWeatherAlertMetadataRepository(mock)
 	_, err = repo.Search(context.Background(), repository.MetadataFilter{})
 
 	if err == nil {
@@ -299,4 +298,4 @@ func TestPostgresWeatherAlertMetadataRepository_Search_RowsError(t *testing.T) {
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unfulfilled expectations: %v", err)
 	}
-}
\ No newline at end of file
+}
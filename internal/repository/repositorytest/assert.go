@@ -0,0 +1,34 @@
+package repositorytest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// assertIDs compares ID lists, treating nil and empty as equal.
+func assertIDs(t *testing.T, call string, got, want []string) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("%s = %v, want %v", call, got, want)
+	}
+}
+
+// assertNotFound checks the error of a single-item lookup for an unknown ID.
+func assertNotFound(t *testing.T, call string, err error) {
+	t.Helper()
+	assertKind(t, call, err, repository.ErrNotFound)
+}
+
+// assertKind checks that err is of the given repository error kind.
+func assertKind(t *testing.T, call string, err, kind error) {
+	t.Helper()
+	if !errors.Is(err, kind) {
+		t.Errorf("%s error = %v, want %v", call, err, kind)
+	}
+}
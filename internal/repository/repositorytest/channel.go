@@ -0,0 +1,282 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// ChannelFactory returns a ChannelRepository without channels whose backend
+// contains exactly users, which channel members must refer to.
+type ChannelFactory func(t *testing.T, users []*domain.User) repository.ChannelRepository
+
+func TestChannelRepository(t *testing.T, newRepo ChannelFactory) {
+	users := []*domain.User{
+		{ID: "user1", Name: "Alice", Email: "alice@example.com", Roles: []string{"user"}, CreatedAt: baseTime},
+		{ID: "user2", Name: "Bob", Email: "bob@example.com", Roles: []string{"user"}, CreatedAt: baseTime},
+		{ID: "user3", Name: "Charlie", Email: "charlie@example.com", Roles: []string{"user"}, CreatedAt: baseTime},
+	}
+	channels := []*domain.Channel{
+		{ID: "ch1", Name: "random", Topic: "雑談", MemberIDs: []string{"user2", "user1"}, CreatedBy: "user1", CreatedAt: baseTime},
+		{ID: "ch2", Name: "general", MemberIDs: []string{"user1"}, CreatedBy: "user1", CreatedAt: baseTime.Add(time.Hour)},
+		{ID: "ch3", Name: "dev", MemberIDs: []string{"user3"}, CreatedBy: "user3", CreatedAt: baseTime.Add(2 * time.Hour)},
+	}
+	newSeededRepo := func(t *testing.T) repository.ChannelRepository {
+		t.Helper()
+		repo := newRepo(t, users)
+		for _, c := range channels {
+			if err := repo.Create(context.Background(), c); err != nil {
+				t.Fatalf("Create(%s) error = %v", c.ID, err)
+			}
+		}
+		return repo
+	}
+
+	t.Run("GetByID: 全フィールドを取得", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		got, err := repo.GetByID(context.Background(), "ch1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Name != "random" || got.Topic != "雑談" || got.CreatedBy != "user1" ||
+			!got.CreatedAt.Equal(baseTime) || got.ArchivedAt != nil {
+			t.Errorf("GetByID() = %+v", got)
+		}
+		assertIDs(t, "GetByID() members", got.MemberIDs, []string{"user1", "user2"})
+	})
+
+	t.Run("GetByID: 存在しないID", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		_, err := repo.GetByID(context.Background(), "nonexistent")
+		assertNotFound(t, "GetByID()", err)
+	})
+
+	t.Run("List: 名前順", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		got, err := repo.List(context.Background(), repository.ChannelFilter{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", channelIDs(got), []string{"ch3", "ch2", "ch1"})
+	})
+
+	t.Run("List: メンバーで絞り込み", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		got, err := repo.List(context.Background(), repository.ChannelFilter{MemberID: "user1"})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", channelIDs(got), []string{"ch2", "ch1"})
+	})
+
+	t.Run("Create: 重複したメンバーは1人として扱う", func(t *testing.T) {
+		repo := newRepo(t, users)
+		channel := &domain.Channel{ID: "ch1", Name: "random", MemberIDs: []string{"user1", "user1"}, CreatedAt: baseTime}
+		if err := repo.Create(context.Background(), channel); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		got, err := repo.GetByID(context.Background(), "ch1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		assertIDs(t, "GetByID() members", got.MemberIDs, []string{"user1"})
+	})
+
+	t.Run("Create: 重複した名前", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		err := repo.Create(context.Background(), &domain.Channel{ID: "ch4", Name: "general", MemberIDs: []string{"user1"}, CreatedAt: baseTime})
+		assertKind(t, "Create()", err, repository.ErrConflict)
+	})
+
+	t.Run("Create: 存在しないユーザーをメンバーに指定", func(t *testing.T) {
+		repo := newRepo(t, users)
+		err := repo.Create(context.Background(), &domain.Channel{ID: "ch1", Name: "random", MemberIDs: []string{"nonexistent"}, CreatedAt: baseTime})
+		assertKind(t, "Create()", err, repository.ErrInvalid)
+	})
+
+	t.Run("Archive: アーカイブ済みは一覧から除外", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		ctx := context.Background()
+		got, err := repo.Archive(ctx, "ch2")
+		if err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		if got.ID != "ch2" || got.ArchivedAt == nil {
+			t.Errorf("Archive() = %+v, want ArchivedAt set", got)
+		}
+
+		active, err := repo.List(ctx, repository.ChannelFilter{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", channelIDs(active), []string{"ch3", "ch1"})
+		all, err := repo.List(ctx, repository.ChannelFilter{IncludeArchived: true})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List(IncludeArchived)", channelIDs(all), []string{"ch3", "ch2", "ch1"})
+	})
+
+	t.Run("Archive: アーカイブ済みまたは存在しない", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		ctx := context.Background()
+		if _, err := repo.Archive(ctx, "ch1"); err != nil {
+			t.Fatalf("Archive() error = %v", err)
+		}
+		_, err := repo.Archive(ctx, "ch1")
+		assertNotFound(t, "Archive() archived", err)
+		_, err = repo.Archive(ctx, "nonexistent")
+		assertNotFound(t, "Archive() nonexistent", err)
+	})
+
+	t.Run("MarkRead: 既読以降のメッセージを未読として数える", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		ctx := context.Background()
+		record := func(channelID string, n int) {
+			t.Helper()
+			for range n {
+				if err := repo.RecordMessage(ctx, channelID); err != nil {
+					t.Fatalf("RecordMessage(%s) error = %v", channelID, err)
+				}
+			}
+		}
+		record("ch1", 3)
+		record("ch2", 1)
+		if err := repo.MarkRead(ctx, "ch1", "user1", baseTime); err != nil {
+			t.Fatalf("MarkRead() error = %v", err)
+		}
+		record("ch1", 2)
+
+		got, err := repo.Reads(ctx, "user1", []string{"ch1", "ch2", "ch3", "nonexistent"})
+		if err != nil {
+			t.Fatalf("Reads() error = %v", err)
+		}
+		if len(got) != 3 {
+			t.Fatalf("Reads() returned %d channels, want 3", len(got))
+		}
+		if got["ch1"].UnreadCount != 2 || got["ch1"].LastReadAt == nil || !got["ch1"].LastReadAt.Equal(baseTime) {
+			t.Errorf("Reads() ch1 = %+v, want 2 unread since %v", got["ch1"], baseTime)
+		}
+		if got["ch2"].UnreadCount != 1 || got["ch2"].LastReadAt != nil {
+			t.Errorf("Reads() ch2 = %+v, want 1 unread, never read", got["ch2"])
+		}
+		if got["ch3"].UnreadCount != 0 {
+			t.Errorf("Reads() ch3 = %+v, want 0 unread", got["ch3"])
+		}
+
+		other, err := repo.Reads(ctx, "user2", []string{"ch1"})
+		if err != nil {
+			t.Fatalf("Reads() error = %v", err)
+		}
+		if other["ch1"].UnreadCount != 5 {
+			t.Errorf("Reads() user2 ch1 = %+v, want 5 unread", other["ch1"])
+		}
+	})
+
+	t.Run("MarkRead: 古い日時では既読位置が戻らない", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		ctx := context.Background()
+		if err := repo.RecordMessage(ctx, "ch1"); err != nil {
+			t.Fatalf("RecordMessage() error = %v", err)
+		}
+		if err := repo.MarkRead(ctx, "ch1", "user1", baseTime); err != nil {
+			t.Fatalf("MarkRead() error = %v", err)
+		}
+		if err := repo.MarkRead(ctx, "ch1", "user1", baseTime.Add(-time.Hour)); err != nil {
+			t.Fatalf("MarkRead() error = %v", err)
+		}
+
+		got, err := repo.Reads(ctx, "user1", []string{"ch1"})
+		if err != nil {
+			t.Fatalf("Reads() error = %v", err)
+		}
+		if got["ch1"].UnreadCount != 0 || !got["ch1"].LastReadAt.Equal(baseTime) {
+			t.Errorf("Reads() ch1 = %+v, want 0 unread since %v", got["ch1"], baseTime)
+		}
+	})
+
+	t.Run("MarkRead: 存在しないチャンネル", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		ctx := context.Background()
+		assertNotFound(t, "MarkRead()", repo.MarkRead(ctx, "nonexistent", "user1", baseTime))
+		assertNotFound(t, "RecordMessage()", repo.RecordMessage(ctx, "nonexistent"))
+		assertNotFound(t, "RemoveMessages()", repo.RemoveMessages(ctx, "nonexistent", 1))
+	})
+
+	t.Run("RemoveMessages: 削除された未読メッセージだけ未読数から減る", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		ctx := context.Background()
+		for range 5 {
+			if err := repo.RecordMessage(ctx, "ch1"); err != nil {
+				t.Fatalf("RecordMessage() error = %v", err)
+			}
+		}
+		if err := repo.MarkRead(ctx, "ch1", "user1", baseTime); err != nil {
+			t.Fatalf("MarkRead() error = %v", err)
+		}
+		if err := repo.RecordMessage(ctx, "ch1"); err != nil {
+			t.Fatalf("RecordMessage() error = %v", err)
+		}
+
+		if err := repo.RemoveMessages(ctx, "ch1", 3); err != nil {
+			t.Fatalf("RemoveMessages() error = %v", err)
+		}
+		got, err := repo.Reads(ctx, "user1", []string{"ch1"})
+		if err != nil {
+			t.Fatalf("Reads() error = %v", err)
+		}
+		if got["ch1"].UnreadCount != 1 {
+			t.Errorf("Reads() user1 ch1 = %+v, want 1 unread", got["ch1"])
+		}
+		other, err := repo.Reads(ctx, "user2", []string{"ch1"})
+		if err != nil {
+			t.Fatalf("Reads() error = %v", err)
+		}
+		if other["ch1"].UnreadCount != 3 {
+			t.Errorf("Reads() user2 ch1 = %+v, want 3 unread", other["ch1"])
+		}
+
+		if err := repo.RemoveMessages(ctx, "ch1", 10); err != nil {
+			t.Fatalf("RemoveMessages() error = %v", err)
+		}
+		got, err = repo.Reads(ctx, "user1", []string{"ch1"})
+		if err != nil {
+			t.Fatalf("Reads() error = %v", err)
+		}
+		if got["ch1"].UnreadCount != 0 {
+			t.Errorf("Reads() user1 ch1 = %+v, want 0 unread", got["ch1"])
+		}
+		if err := repo.RecordMessage(ctx, "ch1"); err != nil {
+			t.Fatalf("RecordMessage() error = %v", err)
+		}
+		got, err = repo.Reads(ctx, "user1", []string{"ch1"})
+		if err != nil {
+			t.Fatalf("Reads() error = %v", err)
+		}
+		if got["ch1"].UnreadCount != 1 {
+			t.Errorf("Reads() user1 ch1 = %+v, want 1 unread after a new message", got["ch1"])
+		}
+	})
+
+	t.Run("Reads: チャンネル指定なし", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		got, err := repo.Reads(context.Background(), "user1", nil)
+		if err != nil {
+			t.Fatalf("Reads() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("Reads() = %+v, want empty", got)
+		}
+	})
+}
+
+func channelIDs(channels []*domain.Channel) []string {
+	ids := make([]string, len(channels))
+	for i, c := range channels {
+		ids[i] = c.ID
+	}
+	return ids
+}
@@ -0,0 +1,8 @@
+// Package repositorytest holds contract test suites shared by every
+// implementation of the interfaces in package repository.
+//
+// Each suite takes a factory that returns a repository pre-populated with the
+// given fixtures. The factory is called once per subtest and must return a
+// repository that contains exactly those fixtures, so implementations backed
+// by a shared database have to clear their tables or use a fresh namespace.
+package repositorytest
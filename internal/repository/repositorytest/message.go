@@ -0,0 +1,825 @@
+package repositorytest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// MessageFactory returns a MessageRepository containing exactly messages, all
+// of which are top-level. Replies are added through Create.
+type MessageFactory func(t *testing.T, messages []*domain.Message) repository.MessageRepository
+
+// baseTime is truncated to whole seconds so that every backend stores it
+// without loss of precision.
+var baseTime = time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+func TestMessageRepository(t *testing.T, newRepo MessageFactory) {
+	messages := []*domain.Message{
+		{ID: "msg1", Content: "Hello", Author: "Alice", CreatedAt: baseTime.Add(-2 * time.Hour)},
+		{ID: "msg3", Content: "Newest", Author: "Charlie", CreatedAt: baseTime},
+		{ID: "msg2", Content: "World", Author: "Bob", CreatedAt: baseTime.Add(-1 * time.Hour)},
+	}
+
+	t.Run("List: 作成日時の降順", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		got, err := repo.List(context.Background())
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", messageIDs(got), []string{"msg3", "msg2", "msg1"})
+	})
+
+	t.Run("List: 0件", func(t *testing.T) {
+		repo := newRepo(t, nil)
+		got, err := repo.List(context.Background())
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("List() returned %d messages, want 0", len(got))
+		}
+	})
+
+	t.Run("GetByID: 全フィールドを取得", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		got, err := repo.GetByID(context.Background(), "msg2")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		want := messages[2]
+		if got.ID != want.ID || got.Content != want.Content || got.Author != want.Author || !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("GetByID() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("GetByID: 存在しないID", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		_, err := repo.GetByID(context.Background(), "nonexistent")
+		assertNotFound(t, "GetByID()", err)
+	})
+
+	t.Run("GetByIDs: IDの昇順で返信を含めて存在するメッセージのみ", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		reply := &domain.Message{ID: "reply1", Content: "Reply", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime}
+		if err := repo.Create(ctx, reply); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		got, err := repo.GetByIDs(ctx, []string{"reply1", "msg3", "nonexistent", "msg1"})
+		if err != nil {
+			t.Fatalf("GetByIDs() error = %v", err)
+		}
+		assertIDs(t, "GetByIDs()", messageIDs(got), []string{"msg1", "msg3", "reply1"})
+		if got[2].ParentID != "msg1" || got[2].Content != "Reply" {
+			t.Errorf("GetByIDs() reply = %+v, want %+v", got[2], reply)
+		}
+	})
+
+	t.Run("GetByIDs: 0件", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		got, err := repo.GetByIDs(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("GetByIDs() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("GetByIDs() = %v, want none", messageIDs(got))
+		}
+	})
+
+	t.Run("Create: トップレベルのメッセージを作成", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		msg := &domain.Message{ID: "msg4", Content: "New", Author: "Dave", AuthorID: "user4", CreatedAt: baseTime.Add(time.Hour)}
+		if err := repo.Create(context.Background(), msg); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		got, err := repo.GetByID(context.Background(), "msg4")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.AuthorID != "user4" || got.ParentID != "" || got.ReplyCount != 0 {
+			t.Errorf("GetByID() = %+v", got)
+		}
+	})
+
+	t.Run("Create: 重複したID", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		err := repo.Create(context.Background(), &domain.Message{ID: "msg1", Content: "Dup", Author: "Alice", CreatedAt: baseTime})
+		assertKind(t, "Create()", err, repository.ErrConflict)
+	})
+
+	t.Run("Create: 返信で親の返信数が増え一覧には含まれない", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		reply := &domain.Message{ID: "reply1", Content: "Hi", Author: "Bob", AuthorID: "user2", ParentID: "msg1", CreatedAt: baseTime.Add(time.Hour)}
+		if err := repo.Create(context.Background(), reply); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		parent, err := repo.GetByID(context.Background(), "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if parent.ReplyCount != 1 {
+			t.Errorf("ReplyCount = %d, want 1", parent.ReplyCount)
+		}
+		got, err := repo.GetByID(context.Background(), "reply1")
+		if err != nil {
+			t.Fatalf("GetByID() reply error = %v", err)
+		}
+		if got.ParentID != "msg1" || got.AuthorID != "user2" || !got.CreatedAt.Equal(reply.CreatedAt) {
+			t.Errorf("GetByID() reply = %+v", got)
+		}
+		list, err := repo.List(context.Background())
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", messageIDs(list), []string{"msg3", "msg2", "msg1"})
+	})
+
+	t.Run("Create: 存在しない親への返信", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		err := repo.Create(context.Background(), &domain.Message{ID: "reply1", Content: "Hi", Author: "Bob", ParentID: "nonexistent", CreatedAt: baseTime})
+		assertNotFound(t, "Create()", err)
+	})
+
+	t.Run("Create: 返信への返信", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		if err := repo.Create(ctx, &domain.Message{ID: "reply1", Content: "Hi", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		err := repo.Create(ctx, &domain.Message{ID: "reply2", Content: "Hi", Author: "Bob", ParentID: "reply1", CreatedAt: baseTime})
+		assertNotFound(t, "Create()", err)
+	})
+
+	t.Run("ListReplies: 作成日時の昇順でページング", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		// reply2 and reply3 share a timestamp so that the ID breaks the tie.
+		for _, r := range []*domain.Message{
+			{ID: "reply3", Content: "c", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime.Add(2 * time.Minute)},
+			{ID: "reply1", Content: "a", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime.Add(time.Minute)},
+			{ID: "reply2", Content: "b", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime.Add(2 * time.Minute)},
+			{ID: "other", Content: "x", Author: "Bob", ParentID: "msg2", CreatedAt: baseTime},
+		} {
+			if err := repo.Create(ctx, r); err != nil {
+				t.Fatalf("Create(%s) error = %v", r.ID, err)
+			}
+		}
+
+		first, err := repo.ListReplies(ctx, "msg1", repository.PageRequest{Limit: 2})
+		if err != nil {
+			t.Fatalf("ListReplies() error = %v", err)
+		}
+		assertIDs(t, "ListReplies() first page", messageIDs(first.Messages), []string{"reply1", "reply2"})
+		if !first.HasNextPage {
+			t.Error("ListReplies() first page HasNextPage = false, want true")
+		}
+
+		last := first.Messages[len(first.Messages)-1]
+		second, err := repo.ListReplies(ctx, "msg1", repository.PageRequest{Limit: 2, After: &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}})
+		if err != nil {
+			t.Fatalf("ListReplies() error = %v", err)
+		}
+		assertIDs(t, "ListReplies() second page", messageIDs(second.Messages), []string{"reply3"})
+		if second.HasNextPage {
+			t.Error("ListReplies() second page HasNextPage = true, want false")
+		}
+	})
+
+	t.Run("ListReplies: 返信なし", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		got, err := repo.ListReplies(context.Background(), "msg1", repository.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListReplies() error = %v", err)
+		}
+		if len(got.Messages) != 0 || got.HasNextPage {
+			t.Errorf("ListReplies() = %+v, want empty page", got)
+		}
+	})
+
+	channelMessages := append([]*domain.Message{
+		{ID: "ch-old", Content: "a", Author: "Alice", ChannelID: "ch1", CreatedAt: baseTime.Add(-time.Hour)},
+		{ID: "ch-tie2", Content: "b", Author: "Alice", ChannelID: "ch1", CreatedAt: baseTime},
+		{ID: "ch-tie1", Content: "c", Author: "Alice", ChannelID: "ch1", CreatedAt: baseTime},
+		{ID: "ch-new", Content: "d", Author: "Alice", ChannelID: "ch1", CreatedAt: baseTime.Add(time.Hour)},
+		{ID: "other", Content: "e", Author: "Alice", ChannelID: "ch2", CreatedAt: baseTime},
+	}, messages...)
+
+	t.Run("List: チャンネルのメッセージは含まない", func(t *testing.T) {
+		repo := newRepo(t, channelMessages)
+		got, err := repo.List(context.Background())
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", messageIDs(got), []string{"msg3", "msg2", "msg1"})
+	})
+
+	t.Run("ListByChannel: 作成日時の降順でページング", func(t *testing.T) {
+		repo := newRepo(t, channelMessages)
+		ctx := context.Background()
+		if err := repo.Create(ctx, &domain.Message{ID: "reply1", Content: "r", Author: "Bob", ParentID: "ch-new", CreatedAt: baseTime.Add(2 * time.Hour)}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		first, err := repo.ListByChannel(ctx, "ch1", repository.PageRequest{Limit: 2})
+		if err != nil {
+			t.Fatalf("ListByChannel() error = %v", err)
+		}
+		assertIDs(t, "ListByChannel() first page", messageIDs(first.Messages), []string{"ch-new", "ch-tie2"})
+		if !first.HasNextPage {
+			t.Error("ListByChannel() first page HasNextPage = false, want true")
+		}
+
+		last := first.Messages[len(first.Messages)-1]
+		second, err := repo.ListByChannel(ctx, "ch1", repository.PageRequest{Limit: 2, After: &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}})
+		if err != nil {
+			t.Fatalf("ListByChannel() error = %v", err)
+		}
+		assertIDs(t, "ListByChannel() second page", messageIDs(second.Messages), []string{"ch-tie1", "ch-old"})
+		if second.HasNextPage {
+			t.Error("ListByChannel() second page HasNextPage = true, want false")
+		}
+	})
+
+	t.Run("Scan: すべてのトップレベルのメッセージを作成日時の昇順で", func(t *testing.T) {
+		repo := newRepo(t, channelMessages)
+		ctx := context.Background()
+		if err := repo.Create(ctx, &domain.Message{ID: "reply1", Content: "r", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		var got []string
+		page := repository.PageRequest{Limit: 3}
+		for {
+			result, err := repo.Scan(ctx, page)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			got = append(got, messageIDs(result.Messages)...)
+			if !result.HasNextPage {
+				break
+			}
+			last := result.Messages[len(result.Messages)-1]
+			page.After = &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+		assertIDs(t, "Scan()", got, []string{"msg1", "ch-old", "msg2", "ch-tie1", "ch-tie2", "msg3", "other", "ch-new"})
+	})
+
+	t.Run("Create: 返信は親のチャンネルを引き継ぐ", func(t *testing.T) {
+		repo := newRepo(t, channelMessages)
+		ctx := context.Background()
+		reply := &domain.Message{ID: "reply1", Content: "r", Author: "Bob", ParentID: "ch-old", CreatedAt: baseTime}
+		if err := repo.Create(ctx, reply); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if reply.ChannelID != "ch1" {
+			t.Errorf("Create() ChannelID = %q, want ch1", reply.ChannelID)
+		}
+		got, err := repo.GetByID(ctx, "reply1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.ChannelID != "ch1" {
+			t.Errorf("GetByID() ChannelID = %q, want ch1", got.ChannelID)
+		}
+	})
+
+	t.Run("Create: 添付ファイルを投稿順に保存", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		attachments := []domain.Attachment{
+			{ID: "a1", Name: "週報.pdf", Size: 1024, ContentType: "application/pdf", Checksum: "abc", BlobKey: "attachments/a1"},
+			{ID: "a2", Name: "photo.png", Size: 2048, ContentType: "image/png", Checksum: "def", BlobKey: "attachments/a2"},
+		}
+		top := &domain.Message{ID: "msg4", Content: "files", Author: "Alice", CreatedAt: baseTime, Attachments: attachments}
+		reply := &domain.Message{ID: "reply1", Content: "more", Author: "Bob", ParentID: "msg4", CreatedAt: baseTime, Attachments: attachments[1:]}
+		for _, msg := range []*domain.Message{top, reply} {
+			if err := repo.Create(ctx, msg); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			got, err := repo.GetByID(ctx, msg.ID)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if !slices.Equal(got.Attachments, msg.Attachments) {
+				t.Errorf("GetByID(%s) Attachments = %+v, want %+v", msg.ID, got.Attachments, msg.Attachments)
+			}
+		}
+
+		got, err := repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if len(got.Attachments) != 0 {
+			t.Errorf("GetByID(msg1) Attachments = %+v, want none", got.Attachments)
+		}
+	})
+
+	t.Run("Edit: 編集前の本文を履歴に残す", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		if err := repo.Create(ctx, &domain.Message{ID: "reply1", Content: "r1", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		for _, id := range []string{"msg1", "reply1"} {
+			first, second := baseTime.Add(time.Hour), baseTime.Add(2*time.Hour)
+			original, err := repo.GetByID(ctx, id)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if original.EditedAt != nil {
+				t.Errorf("GetByID(%s) EditedAt = %v, want nil", id, original.EditedAt)
+			}
+			if err := repo.Edit(ctx, id, "v2", nil, nil, "user1", first); err != nil {
+				t.Fatalf("Edit() error = %v", err)
+			}
+			if err := repo.Edit(ctx, id, "v3", nil, nil, "user2", second); err != nil {
+				t.Fatalf("Edit() error = %v", err)
+			}
+
+			got, err := repo.GetByID(ctx, id)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if got.Content != "v3" || got.EditedAt == nil || !got.EditedAt.Equal(second) {
+				t.Errorf("GetByID(%s) = %+v, want content v3 edited at %v", id, got, second)
+			}
+
+			history, err := repo.History(ctx, id)
+			if err != nil {
+				t.Fatalf("History() error = %v", err)
+			}
+			if len(history) != 2 {
+				t.Fatalf("History(%s) returned %d edits, want 2", id, len(history))
+			}
+			if history[0].Content != original.Content || history[0].EditorID != "user1" || !history[0].EditedAt.Equal(first) {
+				t.Errorf("History(%s)[0] = %+v", id, history[0])
+			}
+			if history[1].Content != "v2" || history[1].EditorID != "user2" || !history[1].EditedAt.Equal(second) {
+				t.Errorf("History(%s)[1] = %+v", id, history[1])
+			}
+		}
+	})
+
+	t.Run("History: 編集されていない", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		history, err := repo.History(context.Background(), "msg2")
+		if err != nil {
+			t.Fatalf("History() error = %v", err)
+		}
+		if len(history) != 0 {
+			t.Errorf("History() = %v, want empty", history)
+		}
+	})
+
+	t.Run("Edit: 存在しないメッセージ", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		err := repo.Edit(context.Background(), "nonexistent", "v2", nil, nil, "user1", baseTime)
+		assertNotFound(t, "Edit()", err)
+	})
+
+	t.Run("ListMentions: 返信を含め作成日時の降順でページング", func(t *testing.T) {
+		repo := newRepo(t, nil)
+		ctx := context.Background()
+		for _, msg := range []*domain.Message{
+			{ID: "msg1", Content: "@alice", Author: "Bob", MentionIDs: []string{"user1"}, CreatedAt: baseTime},
+			{ID: "msg2", Content: "@bob", Author: "Alice", MentionIDs: []string{"user2"}, CreatedAt: baseTime.Add(time.Minute)},
+			{ID: "reply1", Content: "@alice @bob", Author: "Charlie", ParentID: "msg2", MentionIDs: []string{"user1", "user2"}, CreatedAt: baseTime.Add(2 * time.Minute)},
+			{ID: "msg3", Content: "@alice", Author: "Charlie", ChannelID: "ch1", MentionIDs: []string{"user1"}, CreatedAt: baseTime.Add(3 * time.Minute)},
+		} {
+			if err := repo.Create(ctx, msg); err != nil {
+				t.Fatalf("Create(%s) error = %v", msg.ID, err)
+			}
+		}
+
+		first, err := repo.ListMentions(ctx, "user1", repository.PageRequest{Limit: 2})
+		if err != nil {
+			t.Fatalf("ListMentions() error = %v", err)
+		}
+		assertIDs(t, "ListMentions() first page", messageIDs(first.Messages), []string{"msg3", "reply1"})
+		if !first.HasNextPage {
+			t.Errorf("ListMentions() first page HasNextPage = false, want true")
+		}
+		if !slices.Equal(first.Messages[1].MentionIDs, []string{"user1", "user2"}) {
+			t.Errorf("ListMentions() reply1 MentionIDs = %v", first.Messages[1].MentionIDs)
+		}
+
+		last := first.Messages[1]
+		second, err := repo.ListMentions(ctx, "user1", repository.PageRequest{Limit: 2, After: &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}})
+		if err != nil {
+			t.Fatalf("ListMentions() error = %v", err)
+		}
+		assertIDs(t, "ListMentions() second page", messageIDs(second.Messages), []string{"msg1"})
+		if second.HasNextPage {
+			t.Errorf("ListMentions() second page HasNextPage = true, want false")
+		}
+
+		none, err := repo.ListMentions(ctx, "user3", repository.PageRequest{Limit: 2})
+		if err != nil {
+			t.Fatalf("ListMentions() error = %v", err)
+		}
+		assertIDs(t, "ListMentions() user3", messageIDs(none.Messages), nil)
+	})
+
+	t.Run("Edit: メンションを置き換える", func(t *testing.T) {
+		repo := newRepo(t, nil)
+		ctx := context.Background()
+		msg := &domain.Message{ID: "msg1", Content: "@alice", Author: "Bob", MentionIDs: []string{"user1"}, CreatedAt: baseTime}
+		if err := repo.Create(ctx, msg); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := repo.Edit(ctx, "msg1", "@bob", []string{"user2"}, nil, "user2", baseTime.Add(time.Hour)); err != nil {
+			t.Fatalf("Edit() error = %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		assertIDs(t, "GetByID() MentionIDs", got.MentionIDs, []string{"user2"})
+		old, err := repo.ListMentions(ctx, "user1", repository.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListMentions() error = %v", err)
+		}
+		assertIDs(t, "ListMentions() user1", messageIDs(old.Messages), nil)
+	})
+
+	t.Run("Edit: 本文とモデレーション状態を同時に更新", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		flagged := &domain.Moderation{Status: domain.ModerationFlagged, Reasons: []string{"spam"}}
+		if err := repo.Edit(ctx, "msg1", "spam", nil, flagged, "user1", baseTime); err != nil {
+			t.Fatalf("Edit() error = %v", err)
+		}
+		if err := repo.Edit(ctx, "msg1", "spam!", nil, nil, "user1", baseTime.Add(time.Minute)); err != nil {
+			t.Fatalf("Edit() error = %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Content != "spam!" || got.Moderation == nil || got.Moderation.Status != domain.ModerationFlagged || !got.Moderation.Counted {
+			t.Errorf("GetByID() Content = %q, Moderation = %+v", got.Content, got.Moderation)
+		}
+		queue, err := repo.ListFlagged(ctx, repository.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListFlagged() error = %v", err)
+		}
+		assertIDs(t, "ListFlagged()", messageIDs(queue.Messages), []string{"msg1"})
+	})
+
+	t.Run("ListFlagged: 返信を含め作成日時の昇順でページング", func(t *testing.T) {
+		repo := newRepo(t, nil)
+		ctx := context.Background()
+		flagged := func() *domain.Moderation {
+			return &domain.Moderation{Status: domain.ModerationFlagged, Reasons: []string{"content has 3 links, more than 2"}}
+		}
+		for _, msg := range []*domain.Message{
+			{ID: "msg1", Content: "a", Author: "Alice", Moderation: flagged(), CreatedAt: baseTime},
+			{ID: "msg2", Content: "b", Author: "Alice", CreatedAt: baseTime.Add(time.Minute)},
+			{ID: "reply1", Content: "c", Author: "Bob", ParentID: "msg2", Moderation: flagged(), CreatedAt: baseTime.Add(2 * time.Minute)},
+			{ID: "msg3", Content: "d", Author: "Bob", ChannelID: "ch1", Moderation: flagged(), CreatedAt: baseTime.Add(3 * time.Minute)},
+		} {
+			if err := repo.Create(ctx, msg); err != nil {
+				t.Fatalf("Create(%s) error = %v", msg.ID, err)
+			}
+		}
+
+		first, err := repo.ListFlagged(ctx, repository.PageRequest{Limit: 2})
+		if err != nil {
+			t.Fatalf("ListFlagged() error = %v", err)
+		}
+		assertIDs(t, "ListFlagged() first page", messageIDs(first.Messages), []string{"msg1", "reply1"})
+		if !first.HasNextPage {
+			t.Errorf("ListFlagged() first page HasNextPage = false, want true")
+		}
+		if got := first.Messages[0].Moderation; got == nil || got.Status != domain.ModerationFlagged || !slices.Equal(got.Reasons, flagged().Reasons) {
+			t.Errorf("ListFlagged() msg1 Moderation = %+v", got)
+		}
+
+		last := first.Messages[1]
+		second, err := repo.ListFlagged(ctx, repository.PageRequest{Limit: 2, After: &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}})
+		if err != nil {
+			t.Fatalf("ListFlagged() error = %v", err)
+		}
+		assertIDs(t, "ListFlagged() second page", messageIDs(second.Messages), []string{"msg3"})
+		if second.HasNextPage {
+			t.Errorf("ListFlagged() second page HasNextPage = true, want false")
+		}
+	})
+
+	t.Run("SetModeration: 判断を記録するとキューから外れる", func(t *testing.T) {
+		repo := newRepo(t, nil)
+		ctx := context.Background()
+		msg := &domain.Message{ID: "msg1", Content: "a", Author: "Alice", CreatedAt: baseTime,
+			Moderation: &domain.Moderation{Status: domain.ModerationFlagged, Reasons: []string{"reason"}}}
+		if err := repo.Create(ctx, msg); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		moderatedAt := baseTime.Add(time.Hour)
+		if err := repo.SetModeration(ctx, "msg1", &domain.Moderation{
+			Status: domain.ModerationApproved, Reasons: []string{"reason"}, ModeratorID: "user1", ModeratedAt: &moderatedAt,
+		}); err != nil {
+			t.Fatalf("SetModeration() error = %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if m := got.Moderation; m == nil || m.Status != domain.ModerationApproved || m.ModeratorID != "user1" ||
+			m.ModeratedAt == nil || !m.ModeratedAt.Equal(moderatedAt) {
+			t.Errorf("GetByID() Moderation = %+v", got.Moderation)
+		}
+		queue, err := repo.ListFlagged(ctx, repository.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListFlagged() error = %v", err)
+		}
+		assertIDs(t, "ListFlagged()", messageIDs(queue.Messages), nil)
+
+		assertKind(t, "SetModeration() on a decided message", repo.SetModeration(ctx, "msg1", &domain.Moderation{
+			Status: domain.ModerationRejected, Reasons: []string{"reason"}, ModeratorID: "user2", ModeratedAt: &moderatedAt,
+		}), repository.ErrConflict)
+		got, err = repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Moderation.Status != domain.ModerationApproved || got.Moderation.ModeratorID != "user1" {
+			t.Errorf("GetByID() Moderation after conflict = %+v", got.Moderation)
+		}
+
+		assertNotFound(t, "SetModeration()", repo.SetModeration(ctx, "nonexistent", nil))
+	})
+
+	t.Run("Edit: 警告されていたメッセージを再び警告しても数えたことにしない", func(t *testing.T) {
+		repo := newRepo(t, nil)
+		ctx := context.Background()
+		flagged := &domain.Moderation{Status: domain.ModerationFlagged, Reasons: []string{"spam"}}
+		if err := repo.Create(ctx, &domain.Message{ID: "msg1", Content: "spam", Author: "Alice", CreatedAt: baseTime, Moderation: flagged}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := repo.Edit(ctx, "msg1", "spam!", nil, flagged, "user1", baseTime.Add(time.Minute)); err != nil {
+			t.Fatalf("Edit() error = %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Moderation == nil || got.Moderation.Status != domain.ModerationFlagged || got.Moderation.Counted {
+			t.Errorf("GetByID() Moderation = %+v", got.Moderation)
+		}
+	})
+
+	t.Run("ListExpired: 期限切れのトップレベルのメッセージを作成日時の昇順でページング", func(t *testing.T) {
+		repo := newRepo(t, channelMessages)
+		ctx := context.Background()
+		if err := repo.Create(ctx, &domain.Message{ID: "reply1", Content: "r", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime.Add(-90 * time.Minute)}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		first, err := repo.ListExpired(ctx, repository.ExpiryFilter{Before: baseTime}, repository.PageRequest{Limit: 2})
+		if err != nil {
+			t.Fatalf("ListExpired() error = %v", err)
+		}
+		assertIDs(t, "ListExpired() first page", messageIDs(first.Messages), []string{"msg1", "ch-old"})
+		if !first.HasNextPage {
+			t.Error("ListExpired() first page HasNextPage = false, want true")
+		}
+
+		last := first.Messages[len(first.Messages)-1]
+		second, err := repo.ListExpired(ctx, repository.ExpiryFilter{Before: baseTime}, repository.PageRequest{Limit: 2, After: &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}})
+		if err != nil {
+			t.Fatalf("ListExpired() error = %v", err)
+		}
+		assertIDs(t, "ListExpired() second page", messageIDs(second.Messages), []string{"msg2"})
+		if second.HasNextPage {
+			t.Error("ListExpired() second page HasNextPage = true, want false")
+		}
+
+		channel, err := repo.ListExpired(ctx, repository.ExpiryFilter{ChannelID: "ch1", Before: baseTime.Add(time.Hour)}, repository.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListExpired() error = %v", err)
+		}
+		assertIDs(t, "ListExpired(ch1)", messageIDs(channel.Messages), []string{"ch-old", "ch-tie1", "ch-tie2"})
+
+		rest, err := repo.ListExpired(ctx, repository.ExpiryFilter{ExcludeChannelIDs: []string{"ch1"}, Before: baseTime.Add(time.Hour)}, repository.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListExpired() error = %v", err)
+		}
+		assertIDs(t, "ListExpired(excluding ch1)", messageIDs(rest.Messages), []string{"msg1", "msg2", "msg3", "other"})
+	})
+
+	t.Run("Purge: スレッドごと削除する", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		attachments := []domain.Attachment{{ID: "a1", Name: "a.txt", Size: 1, ContentType: "text/plain", Checksum: "abc", BlobKey: "attachments/a1"}}
+		for _, msg := range []*domain.Message{
+			{ID: "reply1", Content: "r1", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime, Attachments: attachments},
+			{ID: "reply2", Content: "r2", Author: "Bob", ParentID: "msg2", CreatedAt: baseTime},
+		} {
+			if err := repo.Create(ctx, msg); err != nil {
+				t.Fatalf("Create(%s) error = %v", msg.ID, err)
+			}
+		}
+		if err := repo.AddReaction(ctx, &domain.Reaction{MessageID: "reply1", Emoji: "👍", UserID: "user1", CreatedAt: baseTime}); err != nil {
+			t.Fatalf("AddReaction() error = %v", err)
+		}
+		if err := repo.Edit(ctx, "msg1", "edited", nil, nil, "user1", baseTime); err != nil {
+			t.Fatalf("Edit() error = %v", err)
+		}
+
+		deleted, err := repo.Purge(ctx, []string{"msg1", "reply2", "nonexistent"})
+		if err != nil {
+			t.Fatalf("Purge() error = %v", err)
+		}
+		got := messageIDs(deleted)
+		slices.Sort(got)
+		assertIDs(t, "Purge()", got, []string{"msg1", "reply1"})
+		for _, msg := range deleted {
+			if msg.ID == "reply1" && !slices.Equal(msg.Attachments, attachments) {
+				t.Errorf("Purge() reply1 Attachments = %+v, want %+v", msg.Attachments, attachments)
+			}
+		}
+
+		for _, id := range []string{"msg1", "reply1"} {
+			_, err := repo.GetByID(ctx, id)
+			assertNotFound(t, "GetByID("+id+")", err)
+		}
+		for _, id := range []string{"msg2", "reply2", "msg3"} {
+			if _, err := repo.GetByID(ctx, id); err != nil {
+				t.Errorf("GetByID(%s) error = %v", id, err)
+			}
+		}
+
+		deleted, err = repo.Purge(ctx, []string{"msg1"})
+		if err != nil {
+			t.Fatalf("Purge() error = %v", err)
+		}
+		assertIDs(t, "Purge() again", messageIDs(deleted), nil)
+	})
+
+	t.Run("AddReaction: 絵文字ごとに集計", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		for _, r := range []*domain.Reaction{
+			{MessageID: "msg1", Emoji: "👍", UserID: "user1", CreatedAt: baseTime},
+			{MessageID: "msg1", Emoji: "👍", UserID: "user2", CreatedAt: baseTime},
+			{MessageID: "msg1", Emoji: "🎉", UserID: "user1", CreatedAt: baseTime.Add(time.Minute)},
+		} {
+			if err := repo.AddReaction(ctx, r); err != nil {
+				t.Fatalf("AddReaction(%s, %s) error = %v", r.Emoji, r.UserID, err)
+			}
+		}
+
+		got, err := repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		assertReactionCounts(t, got.ReactionCounts, map[string]int{"👍": 2, "🎉": 1})
+		emojis, err := repo.UserReactions(ctx, []string{"msg1"}, "user1")
+		if err != nil {
+			t.Fatalf("UserReactions() error = %v", err)
+		}
+		slices.Sort(emojis["msg1"])
+		assertIDs(t, "UserReactions()", emojis["msg1"], []string{"🎉", "👍"})
+	})
+
+	t.Run("UserReactions: 複数のメッセージと返信へのリアクションをまとめて取得", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		if err := repo.Create(ctx, &domain.Message{ID: "reply1", Content: "Hi", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		for _, r := range []*domain.Reaction{
+			{MessageID: "msg1", Emoji: "👍", UserID: "user1", CreatedAt: baseTime},
+			{MessageID: "msg2", Emoji: "👍", UserID: "user2", CreatedAt: baseTime},
+			{MessageID: "msg3", Emoji: "🎉", UserID: "user1", CreatedAt: baseTime},
+			{MessageID: "reply1", Emoji: "👀", UserID: "user1", CreatedAt: baseTime},
+		} {
+			if err := repo.AddReaction(ctx, r); err != nil {
+				t.Fatalf("AddReaction(%s, %s) error = %v", r.MessageID, r.UserID, err)
+			}
+		}
+
+		emojis, err := repo.UserReactions(ctx, []string{"msg1", "msg2", "reply1", "missing"}, "user1")
+		if err != nil {
+			t.Fatalf("UserReactions() error = %v", err)
+		}
+		if len(emojis) != 2 {
+			t.Errorf("UserReactions() = %v, want reactions to msg1 and reply1", emojis)
+		}
+		assertIDs(t, "UserReactions(msg1)", emojis["msg1"], []string{"👍"})
+		assertIDs(t, "UserReactions(reply1)", emojis["reply1"], []string{"👀"})
+	})
+
+	t.Run("AddReaction: 返信へのリアクション", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		if err := repo.Create(ctx, &domain.Message{ID: "reply1", Content: "Hi", Author: "Bob", ParentID: "msg1", CreatedAt: baseTime}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := repo.AddReaction(ctx, &domain.Reaction{MessageID: "reply1", Emoji: "👍", UserID: "user1", CreatedAt: baseTime}); err != nil {
+			t.Fatalf("AddReaction() error = %v", err)
+		}
+		got, err := repo.GetByID(ctx, "reply1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		assertReactionCounts(t, got.ReactionCounts, map[string]int{"👍": 1})
+	})
+
+	t.Run("AddReaction: 同じ絵文字で重複", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		reaction := &domain.Reaction{MessageID: "msg1", Emoji: "👍", UserID: "user1", CreatedAt: baseTime}
+		if err := repo.AddReaction(ctx, reaction); err != nil {
+			t.Fatalf("AddReaction() error = %v", err)
+		}
+		assertKind(t, "AddReaction()", repo.AddReaction(ctx, reaction), repository.ErrConflict)
+
+		got, err := repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		assertReactionCounts(t, got.ReactionCounts, map[string]int{"👍": 1})
+	})
+
+	t.Run("AddReaction: 存在しないメッセージ", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		err := repo.AddReaction(context.Background(), &domain.Reaction{MessageID: "nonexistent", Emoji: "👍", UserID: "user1", CreatedAt: baseTime})
+		assertNotFound(t, "AddReaction()", err)
+	})
+
+	t.Run("RemoveReaction: 件数が減り0件で消える", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		ctx := context.Background()
+		for _, userID := range []string{"user1", "user2"} {
+			if err := repo.AddReaction(ctx, &domain.Reaction{MessageID: "msg1", Emoji: "👍", UserID: userID, CreatedAt: baseTime}); err != nil {
+				t.Fatalf("AddReaction() error = %v", err)
+			}
+		}
+
+		if err := repo.RemoveReaction(ctx, "msg1", "👍", "user1"); err != nil {
+			t.Fatalf("RemoveReaction() error = %v", err)
+		}
+		got, err := repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		assertReactionCounts(t, got.ReactionCounts, map[string]int{"👍": 1})
+
+		if err := repo.RemoveReaction(ctx, "msg1", "👍", "user2"); err != nil {
+			t.Fatalf("RemoveReaction() error = %v", err)
+		}
+		got, err = repo.GetByID(ctx, "msg1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		assertReactionCounts(t, got.ReactionCounts, map[string]int{})
+		emojis, err := repo.UserReactions(ctx, []string{"msg1"}, "user2")
+		if err != nil {
+			t.Fatalf("UserReactions() error = %v", err)
+		}
+		if len(emojis) != 0 {
+			t.Errorf("UserReactions() = %v, want none", emojis)
+		}
+	})
+
+	t.Run("RemoveReaction: リアクションしていない", func(t *testing.T) {
+		repo := newRepo(t, messages)
+		err := repo.RemoveReaction(context.Background(), "msg1", "👍", "user1")
+		assertNotFound(t, "RemoveReaction()", err)
+	})
+}
+
+// assertReactionCounts treats a nil map and an empty map as equal, since
+// backends differ in which one they return for a message without reactions.
+func assertReactionCounts(t *testing.T, got, want map[string]int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("ReactionCounts = %v, want %v", got, want)
+		return
+	}
+	for emoji, n := range want {
+		if got[emoji] != n {
+			t.Errorf("ReactionCounts = %v, want %v", got, want)
+			return
+		}
+	}
+}
+
+func messageIDs(messages []*domain.Message) []string {
+	ids := make([]string, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+	return ids
+}
@@ -0,0 +1,125 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// SearchFactory returns an empty SearchRepository.
+type SearchFactory func(t *testing.T) repository.SearchRepository
+
+func TestSearchRepository(t *testing.T, newRepo SearchFactory) {
+	docs := []*domain.SearchDocument{
+		{MessageID: "msg1", Author: "Alice", CreatedAt: baseTime.Add(-2 * time.Hour), Tokens: []string{"hello", "world"}},
+		{MessageID: "msg2", Author: "Bob", CreatedAt: baseTime.Add(-time.Hour), Tokens: []string{"hello", "東京"}},
+		{MessageID: "msg3", Author: "Alice", CreatedAt: baseTime, Tokens: []string{"hello", "world", "東京"}},
+		{MessageID: "msg4", Author: "Alice", ChannelID: "ch1", CreatedAt: baseTime, Tokens: []string{"hello"}},
+		{MessageID: "msg5", Author: "Alice", ChannelID: "ch2", CreatedAt: baseTime, Tokens: []string{"hello"}},
+	}
+	newSeededRepo := func(t *testing.T) repository.SearchRepository {
+		t.Helper()
+		repo := newRepo(t)
+		for _, doc := range docs {
+			if err := repo.Index(context.Background(), doc); err != nil {
+				t.Fatalf("Index(%s) error = %v", doc.MessageID, err)
+			}
+		}
+		return repo
+	}
+	search := func(t *testing.T, repo repository.SearchRepository, query repository.SearchQuery) []string {
+		t.Helper()
+		page, err := repo.Search(context.Background(), query, repository.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		return searchIDs(page.Documents)
+	}
+
+	t.Run("Search: すべてのトークンを含む文書を新しい順", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		got := search(t, repo, repository.SearchQuery{Tokens: []string{"hello", "world"}, AllChannels: true})
+		assertIDs(t, "Search()", got, []string{"msg3", "msg1"})
+	})
+
+	t.Run("Search: ページング", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		ctx := context.Background()
+		query := repository.SearchQuery{Tokens: []string{"hello"}, AllChannels: true}
+
+		first, err := repo.Search(ctx, query, repository.PageRequest{Limit: 3})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		assertIDs(t, "Search() first page", searchIDs(first.Documents), []string{"msg5", "msg4", "msg3"})
+		if !first.HasNextPage {
+			t.Error("Search() first page HasNextPage = false, want true")
+		}
+		if doc := first.Documents[0]; doc.Author != "Alice" || doc.ChannelID != "ch2" || !doc.CreatedAt.Equal(baseTime) {
+			t.Errorf("Search() first document = %+v", doc)
+		}
+
+		last := first.Documents[len(first.Documents)-1]
+		second, err := repo.Search(ctx, query, repository.PageRequest{Limit: 3, After: &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.MessageID}})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		assertIDs(t, "Search() second page", searchIDs(second.Documents), []string{"msg2", "msg1"})
+		if second.HasNextPage {
+			t.Error("Search() second page HasNextPage = true, want false")
+		}
+	})
+
+	t.Run("Search: 投稿者と期間で絞り込み", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		from, to := baseTime.Add(-2*time.Hour), baseTime
+		got := search(t, repo, repository.SearchQuery{Tokens: []string{"hello"}, Author: "Alice", From: &from, To: &to, AllChannels: true})
+		assertIDs(t, "Search()", got, []string{"msg1"})
+	})
+
+	t.Run("Search: 参照できるチャンネルに限定", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		got := search(t, repo, repository.SearchQuery{Tokens: []string{"hello"}, ChannelIDs: []string{"ch1"}})
+		assertIDs(t, "Search(ch1)", got, []string{"msg4", "msg3", "msg2", "msg1"})
+		got = search(t, repo, repository.SearchQuery{Tokens: []string{"hello"}})
+		assertIDs(t, "Search()", got, []string{"msg3", "msg2", "msg1"})
+	})
+
+	t.Run("Search: トークンがなければ空", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		got := search(t, repo, repository.SearchQuery{AllChannels: true})
+		assertIDs(t, "Search()", got, nil)
+	})
+
+	t.Run("Index: 既存の文書を置き換える", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		doc := &domain.SearchDocument{MessageID: "msg1", Author: "Alice", CreatedAt: baseTime.Add(-2 * time.Hour), Tokens: []string{"goodbye"}}
+		if err := repo.Index(context.Background(), doc); err != nil {
+			t.Fatalf("Index() error = %v", err)
+		}
+
+		assertIDs(t, "Search(world)", search(t, repo, repository.SearchQuery{Tokens: []string{"world"}, AllChannels: true}), []string{"msg3"})
+		assertIDs(t, "Search(goodbye)", search(t, repo, repository.SearchQuery{Tokens: []string{"goodbye"}, AllChannels: true}), []string{"msg1"})
+	})
+
+	t.Run("Delete: 文書を削除する", func(t *testing.T) {
+		repo := newSeededRepo(t)
+		if err := repo.Delete(context.Background(), []string{"msg1", "msg3", "nonexistent"}); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+
+		assertIDs(t, "Search(hello)", search(t, repo, repository.SearchQuery{Tokens: []string{"hello"}, AllChannels: true}), []string{"msg5", "msg4", "msg2"})
+		assertIDs(t, "Search(world)", search(t, repo, repository.SearchQuery{Tokens: []string{"world"}, AllChannels: true}), nil)
+	})
+}
+
+func searchIDs(docs []*domain.SearchDocument) []string {
+	ids := make([]string, len(docs))
+	for i, d := range docs {
+		ids[i] = d.MessageID
+	}
+	return ids
+}
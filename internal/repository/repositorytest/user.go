@@ -0,0 +1,318 @@
+package repositorytest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// UserFactory returns a UserRepository containing exactly users.
+type UserFactory func(t *testing.T, users []*domain.User) repository.UserRepository
+
+func TestUserRepository(t *testing.T, newRepo UserFactory) {
+	users := []*domain.User{
+		{ID: "user1", Name: "Alice", Handle: "alice", Email: "alice@example.com", Roles: []string{domain.RoleUser, domain.RoleAdmin}, CreatedAt: baseTime.Add(-48 * time.Hour)},
+		{ID: "user3", Name: "Charlie", Handle: "Charlie", Email: "charlie@example.com", Roles: []string{domain.RoleUser}, CreatedAt: baseTime},
+		{ID: "user2", Name: "Bob", Handle: "bob", Email: "bob@example.com", Roles: []string{domain.RoleUser}, CreatedAt: baseTime.Add(-24 * time.Hour)},
+	}
+
+	t.Run("List: 作成日時の降順", func(t *testing.T) {
+		repo := newRepo(t, users)
+		got, err := repo.List(context.Background(), repository.UserFilter{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", userIDs(got), []string{"user3", "user2", "user1"})
+	})
+
+	t.Run("List: 0件", func(t *testing.T) {
+		repo := newRepo(t, nil)
+		got, err := repo.List(context.Background(), repository.UserFilter{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("List() returned %d users, want 0", len(got))
+		}
+	})
+
+	t.Run("GetByID: ロールを含む全フィールドを取得", func(t *testing.T) {
+		repo := newRepo(t, users)
+		got, err := repo.GetByID(context.Background(), "user1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		want := users[0]
+		if got.ID != want.ID || got.Name != want.Name || got.Email != want.Email ||
+			!got.CreatedAt.Equal(want.CreatedAt) || !slices.Equal(got.Roles, want.Roles) {
+			t.Errorf("GetByID() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("GetByID: 存在しないID", func(t *testing.T) {
+		repo := newRepo(t, users)
+		_, err := repo.GetByID(context.Background(), "nonexistent")
+		assertNotFound(t, "GetByID()", err)
+	})
+
+	t.Run("GetByIDs: IDの昇順で存在するユーザーのみ", func(t *testing.T) {
+		repo := newRepo(t, users)
+		ctx := context.Background()
+		if _, err := repo.Delete(ctx, "user2"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		got, err := repo.GetByIDs(ctx, []string{"user3", "nonexistent", "user2", "user1"})
+		if err != nil {
+			t.Fatalf("GetByIDs() error = %v", err)
+		}
+		assertIDs(t, "GetByIDs()", userIDs(got), []string{"user1", "user3"})
+	})
+
+	t.Run("GetByIDs: 0件", func(t *testing.T) {
+		repo := newRepo(t, users)
+		got, err := repo.GetByIDs(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("GetByIDs() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("GetByIDs() = %v, want none", userIDs(got))
+		}
+	})
+
+	t.Run("GetByHandles: 大文字小文字を区別せず一致", func(t *testing.T) {
+		repo := newRepo(t, users)
+		got, err := repo.GetByHandles(context.Background(), []string{"ALICE", "charlie", "nobody"})
+		if err != nil {
+			t.Fatalf("GetByHandles() error = %v", err)
+		}
+		assertIDs(t, "GetByHandles()", userIDs(got), []string{"user1", "user3"})
+	})
+
+	t.Run("GetByHandles: 削除済みユーザーは除外", func(t *testing.T) {
+		repo := newRepo(t, users)
+		ctx := context.Background()
+		if _, err := repo.Delete(ctx, "user2"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		got, err := repo.GetByHandles(ctx, []string{"bob"})
+		if err != nil {
+			t.Fatalf("GetByHandles() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("GetByHandles() = %v, want none", userIDs(got))
+		}
+	})
+
+	t.Run("Create: 作成したユーザーを取得", func(t *testing.T) {
+		repo := newRepo(t, users)
+		user := &domain.User{ID: "user4", Name: "Dave", Handle: "dave", Email: "dave@example.com", Roles: []string{domain.RoleUser}, CreatedAt: baseTime.Add(time.Hour)}
+		if err := repo.Create(context.Background(), user); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		got, err := repo.GetByID(context.Background(), "user4")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Name != user.Name || got.Handle != user.Handle || got.Email != user.Email || !got.CreatedAt.Equal(user.CreatedAt) || !slices.Equal(got.Roles, user.Roles) {
+			t.Errorf("GetByID() = %+v, want %+v", got, user)
+		}
+	})
+
+	t.Run("Create: IDの重複", func(t *testing.T) {
+		repo := newRepo(t, users)
+		err := repo.Create(context.Background(), &domain.User{ID: "user1", Name: "Alice", Email: "other@example.com", CreatedAt: baseTime})
+		assertKind(t, "Create()", err, repository.ErrConflict)
+	})
+
+	t.Run("Create: メールアドレスの重複", func(t *testing.T) {
+		repo := newRepo(t, users)
+		err := repo.Create(context.Background(), &domain.User{ID: "user4", Name: "Alice", Email: "alice@example.com", CreatedAt: baseTime})
+		assertKind(t, "Create()", err, repository.ErrConflict)
+	})
+
+	t.Run("Update: 名前・ハンドル・メール・ロールを更新し作成日時は保持", func(t *testing.T) {
+		repo := newRepo(t, users)
+		update := &domain.User{ID: "user2", Name: "Robert", Handle: "robert", Email: "robert@example.com", Roles: []string{domain.RoleAdmin}}
+		if err := repo.Update(context.Background(), update); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		got, err := repo.GetByID(context.Background(), "user2")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Name != update.Name || got.Handle != update.Handle || got.Email != update.Email || !slices.Equal(got.Roles, update.Roles) ||
+			!got.CreatedAt.Equal(users[2].CreatedAt) {
+			t.Errorf("GetByID() = %+v, want %+v with the original createdAt", got, update)
+		}
+	})
+
+	t.Run("Create: 大文字小文字だけが異なるハンドルの重複", func(t *testing.T) {
+		repo := newRepo(t, users)
+		err := repo.Create(context.Background(), &domain.User{ID: "user4", Name: "Alice", Handle: "Alice", Email: "other@example.com", CreatedAt: baseTime})
+		assertKind(t, "Create()", err, repository.ErrConflict)
+	})
+
+	t.Run("Create: 削除済みユーザーのハンドルは使えない", func(t *testing.T) {
+		repo := newRepo(t, users)
+		ctx := context.Background()
+		if _, err := repo.Delete(ctx, "user2"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		err := repo.Create(ctx, &domain.User{ID: "user4", Name: "Bob", Handle: "bob", Email: "other@example.com", CreatedAt: baseTime})
+		assertKind(t, "Create()", err, repository.ErrConflict)
+	})
+
+	t.Run("Create: ハンドルのないユーザーは複数作成できる", func(t *testing.T) {
+		repo := newRepo(t, users)
+		ctx := context.Background()
+		for _, id := range []string{"user4", "user5"} {
+			if err := repo.Create(ctx, &domain.User{ID: id, Name: id, Email: id + "@example.com", CreatedAt: baseTime}); err != nil {
+				t.Fatalf("Create(%s) error = %v", id, err)
+			}
+		}
+	})
+
+	t.Run("Update: 存在しないID", func(t *testing.T) {
+		repo := newRepo(t, users)
+		err := repo.Update(context.Background(), &domain.User{ID: "nonexistent", Name: "Nobody", Email: "nobody@example.com"})
+		assertNotFound(t, "Update()", err)
+	})
+
+	t.Run("Update: 他のユーザーとメールアドレスが重複", func(t *testing.T) {
+		repo := newRepo(t, users)
+		err := repo.Update(context.Background(), &domain.User{ID: "user2", Name: "Bob", Handle: "bob", Email: "alice@example.com"})
+		assertKind(t, "Update()", err, repository.ErrConflict)
+	})
+
+	t.Run("Update: 他のユーザーとハンドルが重複", func(t *testing.T) {
+		repo := newRepo(t, users)
+		err := repo.Update(context.Background(), &domain.User{ID: "user2", Name: "Bob", Handle: "ALICE", Email: "bob@example.com"})
+		assertKind(t, "Update()", err, repository.ErrConflict)
+	})
+
+	t.Run("Create: 更新日時と更新者を記録", func(t *testing.T) {
+		repo := newRepo(t, users)
+		ctx := repository.WithActor(context.Background(), "user1")
+		user := &domain.User{ID: "user4", Name: "Dave", Email: "dave@example.com", Roles: []string{domain.RoleUser}, CreatedAt: baseTime}
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		got, err := repo.GetByID(context.Background(), "user4")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if !got.UpdatedAt.Equal(baseTime) || got.UpdatedBy != "user1" || got.DeletedAt != nil {
+			t.Errorf("GetByID() = %+v, want updatedAt %v by user1", got, baseTime)
+		}
+		if !user.UpdatedAt.Equal(got.UpdatedAt) || user.UpdatedBy != got.UpdatedBy {
+			t.Errorf("Create() set updatedAt=%v updatedBy=%q, want the stored values", user.UpdatedAt, user.UpdatedBy)
+		}
+	})
+
+	t.Run("Update: 更新日時と更新者を記録", func(t *testing.T) {
+		repo := newRepo(t, users)
+		ctx := repository.WithActor(context.Background(), "user1")
+		update := &domain.User{ID: "user2", Name: "Robert", Email: "bob@example.com", Roles: []string{domain.RoleUser}}
+		if err := repo.Update(ctx, update); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		got, err := repo.GetByID(context.Background(), "user2")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.UpdatedBy != "user1" || !got.UpdatedAt.After(users[2].CreatedAt) {
+			t.Errorf("GetByID() = %+v, want a later updatedAt by user1", got)
+		}
+	})
+
+	t.Run("Delete: 削除したユーザーは既定で除外", func(t *testing.T) {
+		repo := newRepo(t, users)
+		ctx := repository.WithActor(context.Background(), "user1")
+		deleted, err := repo.Delete(ctx, "user2")
+		if err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if deleted.DeletedAt == nil || deleted.UpdatedBy != "user1" || !deleted.UpdatedAt.Equal(*deleted.DeletedAt) {
+			t.Errorf("Delete() = %+v, want deletedAt and updatedBy set", deleted)
+		}
+
+		_, err = repo.GetByID(context.Background(), "user2")
+		assertNotFound(t, "GetByID()", err)
+
+		got, err := repo.List(context.Background(), repository.UserFilter{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", userIDs(got), []string{"user3", "user1"})
+
+		got, err = repo.List(context.Background(), repository.UserFilter{IncludeDeleted: true})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List(IncludeDeleted)", userIDs(got), []string{"user3", "user2", "user1"})
+		if got[1].DeletedAt == nil {
+			t.Errorf("List(IncludeDeleted)[1].DeletedAt = nil, want set")
+		}
+	})
+
+	t.Run("Delete: 存在しないIDと削除済みのユーザー", func(t *testing.T) {
+		repo := newRepo(t, users)
+		_, err := repo.Delete(context.Background(), "nonexistent")
+		assertNotFound(t, "Delete()", err)
+
+		if _, err := repo.Delete(context.Background(), "user2"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		_, err = repo.Delete(context.Background(), "user2")
+		assertNotFound(t, "Delete() twice", err)
+	})
+
+	t.Run("Delete: 削除済みのユーザーは更新できずメールアドレスは予約されたまま", func(t *testing.T) {
+		repo := newRepo(t, users)
+		if _, err := repo.Delete(context.Background(), "user2"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		err := repo.Update(context.Background(), &domain.User{ID: "user2", Name: "Robert", Email: "bob@example.com"})
+		assertNotFound(t, "Update()", err)
+
+		err = repo.Create(context.Background(), &domain.User{ID: "user4", Name: "Bob", Email: "bob@example.com", CreatedAt: baseTime})
+		assertKind(t, "Create()", err, repository.ErrConflict)
+	})
+
+	t.Run("Restore: 削除を取り消す", func(t *testing.T) {
+		repo := newRepo(t, users)
+		if _, err := repo.Delete(context.Background(), "user2"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		restored, err := repo.Restore(repository.WithActor(context.Background(), "user1"), "user2")
+		if err != nil {
+			t.Fatalf("Restore() error = %v", err)
+		}
+		if restored.DeletedAt != nil || restored.UpdatedBy != "user1" || restored.Name != "Bob" {
+			t.Errorf("Restore() = %+v, want an active user updated by user1", restored)
+		}
+		if _, err := repo.GetByID(context.Background(), "user2"); err != nil {
+			t.Errorf("GetByID() error = %v", err)
+		}
+	})
+
+	t.Run("Restore: 削除されていないユーザー", func(t *testing.T) {
+		repo := newRepo(t, users)
+		_, err := repo.Restore(context.Background(), "user2")
+		assertNotFound(t, "Restore()", err)
+		_, err = repo.Restore(context.Background(), "nonexistent")
+		assertNotFound(t, "Restore() unknown ID", err)
+	})
+}
+
+func userIDs(users []*domain.User) []string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
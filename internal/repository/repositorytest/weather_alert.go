@@ -0,0 +1,96 @@
+package repositorytest
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// WeatherAlertFactory returns a WeatherAlertRepository containing exactly alerts.
+type WeatherAlertFactory func(t *testing.T, alerts []*domain.WeatherAlert) repository.WeatherAlertRepository
+
+func TestWeatherAlertRepository(t *testing.T, newRepo WeatherAlertFactory) {
+	alerts := []*domain.WeatherAlert{
+		{
+			ID:              "alert1",
+			Title:           "Strong Wind Warning",
+			Description:     "Strong winds expected",
+			RawData:         map[string]interface{}{"windSpeed": map[string]interface{}{"value": 25.5, "unit": "m/s"}},
+			AffectedAreas:   []string{"Chiyoda", "Minato"},
+			Recommendations: []string{"Stay indoors"},
+		},
+		{ID: "alert2", Title: "Heavy Rain Warning", AffectedAreas: []string{"Kita"}},
+		{ID: "alert3", Title: "Fog Warning"},
+	}
+
+	t.Run("GetByID: 全フィールドを取得", func(t *testing.T) {
+		repo := newRepo(t, alerts)
+		got, err := repo.GetByID(context.Background(), "alert1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		want := alerts[0]
+		if got.ID != want.ID || got.Title != want.Title || got.Description != want.Description ||
+			!slices.Equal(got.AffectedAreas, want.AffectedAreas) || !slices.Equal(got.Recommendations, want.Recommendations) {
+			t.Errorf("GetByID() = %+v, want %+v", got, want)
+		}
+		windSpeed, ok := got.RawData["windSpeed"].(map[string]interface{})
+		if !ok || windSpeed["value"] != 25.5 || windSpeed["unit"] != "m/s" {
+			t.Errorf("GetByID() rawData = %v, want %v", got.RawData, want.RawData)
+		}
+	})
+
+	t.Run("GetByID: 存在しないID", func(t *testing.T) {
+		repo := newRepo(t, alerts)
+		_, err := repo.GetByID(context.Background(), "nonexistent")
+		assertNotFound(t, "GetByID()", err)
+	})
+
+	t.Run("GetByIDs: 指定順で存在しないIDはスキップ", func(t *testing.T) {
+		repo := newRepo(t, alerts)
+		got, err := repo.GetByIDs(context.Background(), []string{"alert3", "nonexistent", "alert1"})
+		if err != nil {
+			t.Fatalf("GetByIDs() error = %v", err)
+		}
+		ids := make([]string, len(got))
+		for i, a := range got {
+			ids[i] = a.ID
+		}
+		assertIDs(t, "GetByIDs()", ids, []string{"alert3", "alert1"})
+	})
+
+	t.Run("GetByIDs: 空のID一覧", func(t *testing.T) {
+		repo := newRepo(t, alerts)
+		got, err := repo.GetByIDs(context.Background(), []string{})
+		if err != nil {
+			t.Fatalf("GetByIDs() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("GetByIDs() returned %d alerts, want 0", len(got))
+		}
+	})
+
+	t.Run("Put: 新規作成と上書き", func(t *testing.T) {
+		repo := newRepo(t, alerts)
+		alert := &domain.WeatherAlert{ID: "alert4", Title: "Snow Warning", AffectedAreas: []string{"Sapporo"}, RawData: map[string]interface{}{"depth": "30cm"}}
+		if err := repo.Put(context.Background(), alert); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		// Put is idempotent, so writing the same alert again must succeed.
+		alert.Title = "Heavy Snow Warning"
+		if err := repo.Put(context.Background(), alert); err != nil {
+			t.Fatalf("Put() again error = %v", err)
+		}
+
+		got, err := repo.GetByID(context.Background(), "alert4")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Title != "Heavy Snow Warning" || !slices.Equal(got.AffectedAreas, alert.AffectedAreas) || got.RawData["depth"] != "30cm" {
+			t.Errorf("GetByID() = %+v, want %+v", got, alert)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// WeatherAlertMetadataFactory returns a WeatherAlertMetadataRepository
+// containing exactly metadata.
+type WeatherAlertMetadataFactory func(t *testing.T, metadata []*domain.WeatherAlertMetadata) repository.WeatherAlertMetadataRepository
+
+func TestWeatherAlertMetadataRepository(t *testing.T, newRepo WeatherAlertMetadataFactory) {
+	metadata := []*domain.WeatherAlertMetadata{
+		{ID: "tokyo-old", Region: "Tokyo", Severity: domain.SeverityInfo, IssuedAt: baseTime.Add(-48 * time.Hour), CreatedAt: baseTime.Add(-48 * time.Hour)},
+		{ID: "tokyo-new", Region: "Tokyo", Severity: domain.SeverityWarning, IssuedAt: baseTime, CreatedAt: baseTime},
+		{ID: "osaka", Region: "Osaka", Severity: domain.SeverityCritical, IssuedAt: baseTime.Add(-24 * time.Hour), CreatedAt: baseTime.Add(-24 * time.Hour)},
+	}
+
+	tokyo := "Tokyo"
+	osaka := "Osaka"
+	unknown := "Sapporo"
+	boundary := baseTime.Add(-24 * time.Hour)
+	boundaryJST := boundary.In(time.FixedZone("JST", 9*60*60))
+	future := baseTime.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		filter repository.MetadataFilter
+		want   []string
+	}{
+		{
+			name:   "フィルタなしで発行日時の降順",
+			filter: repository.MetadataFilter{},
+			want:   []string{"tokyo-new", "osaka", "tokyo-old"},
+		},
+		{
+			name:   "地域の完全一致",
+			filter: repository.MetadataFilter{Region: &tokyo},
+			want:   []string{"tokyo-new", "tokyo-old"},
+		},
+		{
+			name:   "IssuedAfterは境界を含む",
+			filter: repository.MetadataFilter{IssuedAfter: &boundary},
+			want:   []string{"tokyo-new", "osaka"},
+		},
+		{
+			name:   "IssuedAfterはタイムゾーンに依存しない",
+			filter: repository.MetadataFilter{IssuedAfter: &boundaryJST},
+			want:   []string{"tokyo-new", "osaka"},
+		},
+		{
+			name:   "地域と日時のAND",
+			filter: repository.MetadataFilter{Region: &tokyo, IssuedAfter: &boundary},
+			want:   []string{"tokyo-new"},
+		},
+		{
+			name:   "該当する地域なし",
+			filter: repository.MetadataFilter{Region: &unknown},
+			want:   nil,
+		},
+		{
+			name:   "未来の日時",
+			filter: repository.MetadataFilter{IssuedAfter: &future},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("SearchIDs: "+tt.name, func(t *testing.T) {
+			repo := newRepo(t, metadata)
+			got, err := repo.SearchIDs(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("SearchIDs() error = %v", err)
+			}
+			assertIDs(t, "SearchIDs()", got, tt.want)
+		})
+
+		t.Run("Search: "+tt.name, func(t *testing.T) {
+			repo := newRepo(t, metadata)
+			got, err := repo.Search(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			ids := make([]string, len(got))
+			for i, m := range got {
+				ids[i] = m.ID
+			}
+			assertIDs(t, "Search()", ids, tt.want)
+		})
+	}
+
+	t.Run("Search: 全フィールドを取得", func(t *testing.T) {
+		repo := newRepo(t, metadata)
+		got, err := repo.Search(context.Background(), repository.MetadataFilter{Region: &tokyo, IssuedAfter: &boundary})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("Search() returned %d items, want 1", len(got))
+		}
+		want := metadata[1]
+		if got[0].Region != want.Region || got[0].Severity != want.Severity ||
+			!got[0].IssuedAt.Equal(want.IssuedAt) || !got[0].CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("Search() = %+v, want %+v", got[0], want)
+		}
+	})
+
+	t.Run("Search: 0件", func(t *testing.T) {
+		repo := newRepo(t, nil)
+		got, err := repo.Search(context.Background(), repository.MetadataFilter{})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("Search() returned %d items, want 0", len(got))
+		}
+	})
+
+	t.Run("Create: 作成したメタデータを検索", func(t *testing.T) {
+		repo := newRepo(t, metadata)
+		created := &domain.WeatherAlertMetadata{ID: "tokyo-newest", Region: "Tokyo", Severity: domain.SeverityCritical, IssuedAt: future, CreatedAt: future}
+		if err := repo.Create(context.Background(), created); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		got, err := repo.Search(context.Background(), repository.MetadataFilter{Region: &tokyo})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		ids := make([]string, len(got))
+		for i, m := range got {
+			ids[i] = m.ID
+		}
+		assertIDs(t, "Search()", ids, []string{"tokyo-newest", "tokyo-new", "tokyo-old"})
+		if !got[0].IssuedAt.Equal(future) || got[0].Severity != domain.SeverityCritical {
+			t.Errorf("Search()[0] = %+v, want %+v", got[0], created)
+		}
+	})
+
+	t.Run("Create: IDの重複", func(t *testing.T) {
+		repo := newRepo(t, metadata)
+		err := repo.Create(context.Background(), &domain.WeatherAlertMetadata{ID: "osaka", Region: "Osaka", Severity: domain.SeverityInfo, IssuedAt: baseTime, CreatedAt: baseTime})
+		assertKind(t, "Create()", err, repository.ErrConflict)
+	})
+
+	t.Run("Create: 更新日時と更新者を記録", func(t *testing.T) {
+		repo := newRepo(t, nil)
+		ctx := repository.WithActor(context.Background(), "admin1")
+		created := &domain.WeatherAlertMetadata{ID: "tokyo", Region: "Tokyo", Severity: domain.SeverityInfo, IssuedAt: baseTime, CreatedAt: baseTime}
+		if err := repo.Create(ctx, created); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		got, err := repo.Search(context.Background(), repository.MetadataFilter{})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		if len(got) != 1 || !got[0].UpdatedAt.Equal(baseTime) || got[0].UpdatedBy != "admin1" || got[0].DeletedAt != nil {
+			t.Errorf("Search() = %+v, want updatedAt %v by admin1", got, baseTime)
+		}
+	})
+
+	t.Run("Delete: 削除したメタデータは既定で除外", func(t *testing.T) {
+		repo := newRepo(t, metadata)
+		deleted, err := repo.Delete(repository.WithActor(context.Background(), "admin1"), "osaka")
+		if err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if deleted.DeletedAt == nil || deleted.UpdatedBy != "admin1" || deleted.Region != "Osaka" {
+			t.Errorf("Delete() = %+v, want deletedAt and updatedBy set", deleted)
+		}
+
+		ids, err := repo.SearchIDs(context.Background(), repository.MetadataFilter{})
+		if err != nil {
+			t.Fatalf("SearchIDs() error = %v", err)
+		}
+		assertIDs(t, "SearchIDs()", ids, []string{"tokyo-new", "tokyo-old"})
+
+		ids, err = repo.SearchIDs(context.Background(), repository.MetadataFilter{IncludeDeleted: true, IssuedAfter: &boundary})
+		if err != nil {
+			t.Fatalf("SearchIDs() error = %v", err)
+		}
+		assertIDs(t, "SearchIDs(IncludeDeleted)", ids, []string{"tokyo-new", "osaka"})
+
+		got, err := repo.Search(context.Background(), repository.MetadataFilter{IncludeDeleted: true, Region: &osaka})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		if len(got) != 1 || got[0].DeletedAt == nil {
+			t.Errorf("Search(IncludeDeleted) = %+v, want the deleted metadata", got)
+		}
+	})
+
+	t.Run("Delete: 存在しないIDと削除済みのメタデータ", func(t *testing.T) {
+		repo := newRepo(t, metadata)
+		_, err := repo.Delete(context.Background(), "nonexistent")
+		assertNotFound(t, "Delete()", err)
+
+		if _, err := repo.Delete(context.Background(), "osaka"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		_, err = repo.Delete(context.Background(), "osaka")
+		assertNotFound(t, "Delete() twice", err)
+	})
+
+	t.Run("Restore: 削除を取り消す", func(t *testing.T) {
+		repo := newRepo(t, metadata)
+		if _, err := repo.Delete(context.Background(), "osaka"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		restored, err := repo.Restore(context.Background(), "osaka")
+		if err != nil {
+			t.Fatalf("Restore() error = %v", err)
+		}
+		if restored.DeletedAt != nil {
+			t.Errorf("Restore() = %+v, want deletedAt cleared", restored)
+		}
+		ids, err := repo.SearchIDs(context.Background(), repository.MetadataFilter{})
+		if err != nil {
+			t.Fatalf("SearchIDs() error = %v", err)
+		}
+		assertIDs(t, "SearchIDs()", ids, []string{"tokyo-new", "osaka", "tokyo-old"})
+	})
+
+	t.Run("Restore: 削除されていないメタデータ", func(t *testing.T) {
+		repo := newRepo(t, metadata)
+		_, err := repo.Restore(context.Background(), "osaka")
+		assertNotFound(t, "Restore()", err)
+	})
+}
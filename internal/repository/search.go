@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+)
+
+type SearchQuery struct {
+	// Tokens must all be present in a document for it to match.
+	Tokens []string
+	// Author limits the result to messages by the given author name.
+	Author string
+	// From and To limit the result to messages created at or after From
+	// and before To.
+	From *time.Time
+	To   *time.Time
+	// AllChannels matches messages in every channel. Otherwise only
+	// messages outside channels and in ChannelIDs match.
+	AllChannels bool
+	ChannelIDs  []string
+}
+
+// SearchPage is one page of matching documents. Their Tokens are not set.
+type SearchPage struct {
+	Documents   []*domain.SearchDocument
+	HasNextPage bool
+}
+
+// SearchRepository is an inverted index over message content. It is a
+// projection maintained next to the message store, which may not support
+// text search itself.
+type SearchRepository interface {
+	// Index adds doc to the index, replacing an earlier document for the
+	// same message. Call it within a transaction so that a failure does not
+	// leave the document half replaced.
+	Index(ctx context.Context, doc *domain.SearchDocument) error
+	// Delete removes the documents of the given messages. IDs without a
+	// document are ignored.
+	Delete(ctx context.Context, messageIDs []string) error
+	// Search returns a page of the documents matching query, newest first.
+	Search(ctx context.Context, query SearchQuery, page PageRequest) (*SearchPage, error)
+}
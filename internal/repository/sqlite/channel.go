@@ -0,0 +1,224 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// channelColumns is the column list read by scanChannel. Members are read as
+// a JSON array.
+const channelColumns = "id, name, topic, COALESCE(created_by, ''), created_at, archived_at, " +
+	"(SELECT json_group_array(user_id) FROM (SELECT user_id FROM channel_members WHERE channel_id = channels.id ORDER BY user_id))"
+
+type SQLiteChannelRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteChannelRepository(db *sql.DB) *SQLiteChannelRepository {
+	return &SQLiteChannelRepository{db: db}
+}
+
+func (r *SQLiteChannelRepository) List(ctx context.Context, filter repository.ChannelFilter) ([]*domain.Channel, error) {
+	log.Printf("SQLiteChannelRepository: Listing channels with filter: %+v", filter)
+
+	query := "SELECT " + channelColumns + " FROM channels WHERE ($1 OR archived_at IS NULL)"
+	args := []any{filter.IncludeArchived}
+	if filter.MemberID != "" {
+		query += " AND id IN (SELECT channel_id FROM channel_members WHERE user_id = $2)"
+		args = append(args, filter.MemberID)
+	}
+	query += " ORDER BY name"
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("SQLiteChannelRepository: Failed to query channels: %v", err)
+		return nil, classifyError("failed to query channels", err)
+	}
+	defer rows.Close()
+
+	var channels []*domain.Channel
+	for rows.Next() {
+		channel, err := scanChannel(rows)
+		if err != nil {
+			log.Printf("SQLiteChannelRepository: Failed to scan channel: %v", err)
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("SQLiteChannelRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+
+	log.Printf("SQLiteChannelRepository: Found %d channels", len(channels))
+	return channels, nil
+}
+
+func (r *SQLiteChannelRepository) GetByID(ctx context.Context, id string) (*domain.Channel, error) {
+	log.Printf("SQLiteChannelRepository: Getting channel by ID: %s", id)
+
+	query := "SELECT " + channelColumns + " FROM channels WHERE id = $1"
+	channel, err := scanChannel(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.NotFoundf("channel %s not found", id)
+		}
+		log.Printf("SQLiteChannelRepository: Failed to scan channel: %v", err)
+		return nil, err
+	}
+	return channel, nil
+}
+
+func (r *SQLiteChannelRepository) Create(ctx context.Context, channel *domain.Channel) error {
+	log.Printf("SQLiteChannelRepository: Creating channel: %s", channel.ID)
+
+	db := conn(ctx, r.db)
+	query := "INSERT INTO channels (id, name, topic, created_by, created_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5)"
+	if _, err := db.ExecContext(ctx, query, channel.ID, channel.Name, channel.Topic, channel.CreatedBy, channel.CreatedAt.UTC()); err != nil {
+		log.Printf("SQLiteChannelRepository: Failed to create channel: %v", err)
+		return classifyError("failed to create channel", err)
+	}
+
+	for _, userID := range channel.MemberIDs {
+		query := "INSERT OR IGNORE INTO channel_members (channel_id, user_id) VALUES ($1, $2)"
+		if _, err := db.ExecContext(ctx, query, channel.ID, userID); err != nil {
+			log.Printf("SQLiteChannelRepository: Failed to add channel member %s: %v", userID, err)
+			return classifyError("failed to add channel members", err)
+		}
+	}
+	return nil
+}
+
+func (r *SQLiteChannelRepository) Archive(ctx context.Context, id string) (*domain.Channel, error) {
+	log.Printf("SQLiteChannelRepository: Archiving channel: %s", id)
+
+	query := "UPDATE channels SET archived_at = $2 WHERE id = $1 AND archived_at IS NULL"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, time.Now().UTC())
+	if err != nil {
+		log.Printf("SQLiteChannelRepository: Failed to archive channel: %v", err)
+		return nil, classifyError("failed to archive channel", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return nil, repository.NotFoundf("channel %s not found", id)
+	}
+	return r.GetByID(ctx, id)
+}
+
+func scanChannel(row scanner) (*domain.Channel, error) {
+	var channel domain.Channel
+	var archivedAt sql.NullTime
+	var members string
+	if err := row.Scan(&channel.ID, &channel.Name, &channel.Topic, &channel.CreatedBy, &channel.CreatedAt, &archivedAt, &members); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, classifyError("failed to scan channel", err)
+	}
+	if err := json.Unmarshal([]byte(members), &channel.MemberIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode members of channel %s: %w", channel.ID, err)
+	}
+	if archivedAt.Valid {
+		channel.ArchivedAt = &archivedAt.Time
+	}
+	return &channel, nil
+}
+
+func (r *SQLiteChannelRepository) RecordMessage(ctx context.Context, channelID string) error {
+	query := "UPDATE channels SET message_count = message_count + 1 WHERE id = $1"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, channelID)
+	if err != nil {
+		log.Printf("SQLiteChannelRepository: Failed to count message: %v", err)
+		return classifyError("failed to count message", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return repository.NotFoundf("channel %s not found", channelID)
+	}
+	return nil
+}
+
+func (r *SQLiteChannelRepository) RemoveMessages(ctx context.Context, channelID string, n int) error {
+	log.Printf("SQLiteChannelRepository: Uncounting %d message(s) of channel %s", n, channelID)
+
+	db := conn(ctx, r.db)
+	query := "UPDATE channels SET message_count = MAX(message_count - $2, 0) WHERE id = $1"
+	result, err := db.ExecContext(ctx, query, channelID, n)
+	if err != nil {
+		log.Printf("SQLiteChannelRepository: Failed to uncount messages: %v", err)
+		return classifyError("failed to uncount messages", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return repository.NotFoundf("channel %s not found", channelID)
+	}
+
+	query = "UPDATE channel_reads SET read_count = MAX(read_count - $2, 0) WHERE channel_id = $1"
+	if _, err := db.ExecContext(ctx, query, channelID, n); err != nil {
+		log.Printf("SQLiteChannelRepository: Failed to move read positions back: %v", err)
+		return classifyError("failed to move read positions back", err)
+	}
+	return nil
+}
+
+func (r *SQLiteChannelRepository) MarkRead(ctx context.Context, channelID, userID string, readAt time.Time) error {
+	log.Printf("SQLiteChannelRepository: Marking channel %s read for %s", channelID, userID)
+
+	query := `INSERT INTO channel_reads (channel_id, user_id, read_count, last_read_at)
+	SELECT id, $2, message_count, $3 FROM channels WHERE id = $1
+	ON CONFLICT (user_id, channel_id) DO UPDATE SET
+		read_count = MAX(channel_reads.read_count, excluded.read_count),
+		last_read_at = MAX(channel_reads.last_read_at, excluded.last_read_at)`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, channelID, userID, readAt.UTC())
+	if err != nil {
+		log.Printf("SQLiteChannelRepository: Failed to mark channel read: %v", err)
+		return classifyError("failed to mark channel read", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return repository.NotFoundf("channel %s not found", channelID)
+	}
+	return nil
+}
+
+func (r *SQLiteChannelRepository) Reads(ctx context.Context, userID string, channelIDs []string) (map[string]*domain.ChannelRead, error) {
+	reads := make(map[string]*domain.ChannelRead, len(channelIDs))
+	if len(channelIDs) == 0 {
+		return reads, nil
+	}
+
+	args := []any{userID}
+	query := `SELECT channels.id, channel_reads.last_read_at, channels.message_count - COALESCE(channel_reads.read_count, 0)
+	FROM channels LEFT JOIN channel_reads ON channel_reads.channel_id = channels.id AND channel_reads.user_id = $1
+	WHERE channels.id IN (` + placeholders(&args, channelIDs) + ")"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("SQLiteChannelRepository: Failed to query read positions: %v", err)
+		return nil, classifyError("failed to query read positions", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var read domain.ChannelRead
+		var lastReadAt sql.NullTime
+		if err := rows.Scan(&read.ChannelID, &lastReadAt, &read.UnreadCount); err != nil {
+			log.Printf("SQLiteChannelRepository: Failed to scan read position: %v", err)
+			return nil, classifyError("failed to scan read position", err)
+		}
+		if lastReadAt.Valid {
+			read.LastReadAt = &lastReadAt.Time
+		}
+		reads[read.ChannelID] = &read
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("SQLiteChannelRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+	return reads, nil
+}
@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository/repositorytest"
+)
+
+func TestContract(t *testing.T) {
+	t.Run("ChannelRepository", func(t *testing.T) {
+		repositorytest.TestChannelRepository(t, func(t *testing.T, users []*domain.User) repository.ChannelRepository {
+			db := newTestDB(t)
+			insertUsers(t, db, users)
+			return NewSQLiteChannelRepository(db)
+		})
+	})
+	t.Run("SearchRepository", func(t *testing.T) {
+		repositorytest.TestSearchRepository(t, func(t *testing.T) repository.SearchRepository {
+			return NewSQLiteSearchRepository(newTestDB(t))
+		})
+	})
+	t.Run("UserRepository", func(t *testing.T) {
+		repositorytest.TestUserRepository(t, func(t *testing.T, users []*domain.User) repository.UserRepository {
+			db := newTestDB(t)
+			insertUsers(t, db, users)
+			return NewSQLiteUserRepository(db)
+		})
+	})
+	t.Run("WeatherAlertMetadataRepository", func(t *testing.T) {
+		repositorytest.TestWeatherAlertMetadataRepository(t, func(t *testing.T, metadata []*domain.WeatherAlertMetadata) repository.WeatherAlertMetadataRepository {
+			db := newTestDB(t)
+			for _, m := range metadata {
+				mustExec(t, db, "INSERT INTO weather_alert_metadata (id, region, severity, issued_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $5)",
+					m.ID, m.Region, m.Severity, m.IssuedAt.UTC(), m.CreatedAt.UTC())
+			}
+			return NewSQLiteWeatherAlertMetadataRepository(db)
+		})
+	})
+}
+
+func insertUsers(t *testing.T, db *sql.DB, users []*domain.User) {
+	t.Helper()
+	for _, u := range users {
+		roles, err := json.Marshal(u.Roles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustExec(t, db, "INSERT INTO users (id, name, handle, email, roles, created_at, updated_at) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $6)",
+			u.ID, u.Name, u.Handle, u.Email, string(roles), u.CreatedAt.UTC())
+	}
+}
@@ -0,0 +1,30 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// classifyError wraps err in the matching repository error kind, or with a
+// plain message when it is not one the caller can act on.
+func classifyError(message string, err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	// Extended result codes keep the primary code in the low byte.
+	switch code := sqliteErr.Code(); {
+	case code == sqlite3.SQLITE_CONSTRAINT_UNIQUE, code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return repository.Conflict(message, err)
+	case code&0xff == sqlite3.SQLITE_CONSTRAINT:
+		return repository.Invalid(message, err)
+	case code&0xff == sqlite3.SQLITE_BUSY, code&0xff == sqlite3.SQLITE_LOCKED:
+		return repository.Unavailable(message, err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
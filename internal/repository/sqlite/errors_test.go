@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+func TestClassifyError(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mustExec(t, db, "INSERT INTO users (id, name, email, created_at) VALUES ($1, $2, $3, $4)",
+		"user1", "Alice", "alice@example.com", createdAt)
+
+	tests := []struct {
+		name     string
+		query    string
+		args     []any
+		wantKind error
+	}{
+		{
+			name:     "主キー重複はConflict",
+			query:    "INSERT INTO users (id, name, email, created_at) VALUES ($1, $2, $3, $4)",
+			args:     []any{"user1", "Alice", "alice2@example.com", createdAt},
+			wantKind: repository.ErrConflict,
+		},
+		{
+			name:     "NOT NULL制約違反はInvalid",
+			query:    "INSERT INTO users (id, name, email, created_at) VALUES ($1, NULL, $2, $3)",
+			args:     []any{"user2", "bob@example.com", createdAt},
+			wantKind: repository.ErrInvalid,
+		},
+		{
+			name:     "構文エラーは分類しない",
+			query:    "INSERT INTO",
+			wantKind: nil,
+		},
+	}
+
+	kinds := []error{repository.ErrNotFound, repository.ErrConflict, repository.ErrUnavailable, repository.ErrInvalid}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.ExecContext(ctx, tt.query, tt.args...)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			got := classifyError("failed to insert user", err)
+			for _, kind := range kinds {
+				if is := errors.Is(got, kind); is != (kind == tt.wantKind) {
+					t.Errorf("errors.Is(%v) = %v, err = %v", kind, is, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type SQLiteSearchRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteSearchRepository(db *sql.DB) *SQLiteSearchRepository {
+	return &SQLiteSearchRepository{db: db}
+}
+
+func (r *SQLiteSearchRepository) Index(ctx context.Context, doc *domain.SearchDocument) error {
+	log.Printf("SQLiteSearchRepository: Indexing message %s with %d tokens", doc.MessageID, len(doc.Tokens))
+
+	db := conn(ctx, r.db)
+	query := `INSERT INTO message_search_documents (message_id, channel_id, author, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (message_id) DO UPDATE SET channel_id = excluded.channel_id, author = excluded.author, created_at = excluded.created_at`
+	if _, err := db.ExecContext(ctx, query, doc.MessageID, doc.ChannelID, doc.Author, doc.CreatedAt.UTC()); err != nil {
+		log.Printf("SQLiteSearchRepository: Failed to index message: %v", err)
+		return classifyError("failed to index message", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "DELETE FROM message_search_tokens WHERE message_id = $1", doc.MessageID); err != nil {
+		log.Printf("SQLiteSearchRepository: Failed to remove tokens: %v", err)
+		return classifyError("failed to index message", err)
+	}
+	for _, token := range doc.Tokens {
+		query := "INSERT OR IGNORE INTO message_search_tokens (token, message_id) VALUES ($1, $2)"
+		if _, err := db.ExecContext(ctx, query, token, doc.MessageID); err != nil {
+			log.Printf("SQLiteSearchRepository: Failed to add token: %v", err)
+			return classifyError("failed to index message", err)
+		}
+	}
+	return nil
+}
+
+// Delete relies on the tokens of a document being removed with it by the
+// foreign key.
+func (r *SQLiteSearchRepository) Delete(ctx context.Context, messageIDs []string) error {
+	log.Printf("SQLiteSearchRepository: Removing %d message(s) from the index", len(messageIDs))
+
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	var args []any
+	query := "DELETE FROM message_search_documents WHERE message_id IN (" + placeholders(&args, messageIDs) + ")"
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		log.Printf("SQLiteSearchRepository: Failed to remove messages: %v", err)
+		return classifyError("failed to remove messages from the index", err)
+	}
+	return nil
+}
+
+func (r *SQLiteSearchRepository) Search(ctx context.Context, query repository.SearchQuery, page repository.PageRequest) (*repository.SearchPage, error) {
+	log.Printf("SQLiteSearchRepository: Searching with %d tokens", len(query.Tokens))
+
+	if len(query.Tokens) == 0 {
+		return &repository.SearchPage{}, nil
+	}
+
+	where, args := searchWhere(query, page)
+	args = append(args, page.Limit+1)
+	stmt := "SELECT message_id, channel_id, author, created_at FROM message_search_documents" + where +
+		fmt.Sprintf(" ORDER BY created_at DESC, message_id DESC LIMIT $%d", len(args))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, stmt, args...)
+	if err != nil {
+		log.Printf("SQLiteSearchRepository: Failed to search messages: %v", err)
+		return nil, classifyError("failed to search messages", err)
+	}
+	defer rows.Close()
+
+	var docs []*domain.SearchDocument
+	for rows.Next() {
+		var doc domain.SearchDocument
+		if err := rows.Scan(&doc.MessageID, &doc.ChannelID, &doc.Author, &doc.CreatedAt); err != nil {
+			log.Printf("SQLiteSearchRepository: Failed to scan document: %v", err)
+			return nil, fmt.Errorf("failed to scan search document: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("SQLiteSearchRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+
+	result := &repository.SearchPage{Documents: docs}
+	if len(docs) > page.Limit {
+		result.Documents = docs[:page.Limit]
+		result.HasNextPage = true
+	}
+	return result, nil
+}
+
+// searchWhere builds the WHERE clause of Search. A document matches when it
+// has a row for every token, which the primary key keeps distinct.
+func searchWhere(query repository.SearchQuery, page repository.PageRequest) (string, []any) {
+	var args []any
+	tokens := placeholders(&args, query.Tokens)
+	args = append(args, len(query.Tokens))
+	conditions := []string{fmt.Sprintf("message_id IN (SELECT message_id FROM message_search_tokens WHERE token IN (%s) GROUP BY message_id HAVING COUNT(*) = $%d)", tokens, len(args))}
+
+	if query.Author != "" {
+		args = append(args, query.Author)
+		conditions = append(conditions, fmt.Sprintf("author = $%d", len(args)))
+	}
+	if query.From != nil {
+		args = append(args, query.From.UTC())
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if query.To != nil {
+		args = append(args, query.To.UTC())
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if !query.AllChannels {
+		condition := "channel_id = ''"
+		if len(query.ChannelIDs) > 0 {
+			condition = fmt.Sprintf("(channel_id = '' OR channel_id IN (%s))", placeholders(&args, query.ChannelIDs))
+		}
+		conditions = append(conditions, condition)
+	}
+	if page.After != nil {
+		args = append(args, page.After.CreatedAt.UTC(), page.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, message_id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// placeholders appends values to args and returns their comma-separated
+// placeholders, since SQLite has no array parameters.
+func placeholders(args *[]any, values []string) string {
+	list := make([]string, len(values))
+	for i, v := range values {
+		*args = append(*args, v)
+		list[i] = fmt.Sprintf("$%d", len(*args))
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type txKey struct{}
+
+// SQLiteTxManager implements repository.Transactor. SQLite transactions are
+// always serializable, so the requested isolation level is ignored, and
+// because NewClient begins write transactions with BEGIN IMMEDIATE they wait
+// for the write lock up front instead of failing with SQLITE_BUSY later.
+type SQLiteTxManager struct {
+	db *sql.DB
+}
+
+func NewSQLiteTxManager(db *sql.DB) *SQLiteTxManager {
+	return &SQLiteTxManager{db: db}
+}
+
+func (m *SQLiteTxManager) WithinTx(ctx context.Context, opts repository.TxOptions, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return classifyError("failed to begin transaction", err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
+			log.Printf("SQLiteTxManager: Failed to roll back transaction: %v", rbErr)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return classifyError("failed to commit transaction", err)
+	}
+	return nil
+}
+
+// conn returns the transaction carried by ctx, or db outside a transaction.
+func conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+var _ repository.Transactor = (*SQLiteTxManager)(nil)
@@ -0,0 +1,121 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+func TestSQLiteTxManager_WithinTx(t *testing.T) {
+	ctx := context.Background()
+	newUser := func(id string) *domain.User {
+		return &domain.User{ID: id, Name: id, Email: id + "@example.com", Roles: []string{"user"}, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	}
+
+	t.Run("正常系: コミットした書き込みが見える", func(t *testing.T) {
+		db := newTestDB(t)
+		manager := NewSQLiteTxManager(db)
+		users := NewSQLiteUserRepository(db)
+
+		err := manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+			if err := users.Create(ctx, newUser("user1")); err != nil {
+				return err
+			}
+			// Reads inside the transaction see its own writes.
+			_, err := users.GetByID(ctx, "user1")
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WithinTx() error = %v", err)
+		}
+		if _, err := users.GetByID(ctx, "user1"); err != nil {
+			t.Errorf("GetByID() after commit error = %v", err)
+		}
+	})
+
+	t.Run("異常系: エラー時はロールバックされる", func(t *testing.T) {
+		db := newTestDB(t)
+		manager := NewSQLiteTxManager(db)
+		users := NewSQLiteUserRepository(db)
+		metadata := NewSQLiteWeatherAlertMetadataRepository(db)
+		wantErr := errors.New("firestore write failed")
+
+		err := manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+			if err := users.Create(ctx, newUser("user1")); err != nil {
+				return err
+			}
+			if err := metadata.Create(ctx, &domain.WeatherAlertMetadata{ID: "alert1", Region: "Tokyo", Severity: "info"}); err != nil {
+				return err
+			}
+			return wantErr
+		})
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("WithinTx() error = %v, want %v", err, wantErr)
+		}
+		if _, err := users.GetByID(ctx, "user1"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetByID() after rollback error = %v, want ErrNotFound", err)
+		}
+		ids, err := metadata.SearchIDs(ctx, repository.MetadataFilter{})
+		if err != nil || len(ids) != 0 {
+			t.Errorf("SearchIDs() after rollback = %v, %v, want none", ids, err)
+		}
+	})
+
+	t.Run("正常系: 入れ子のWithinTxは外側のトランザクションに参加する", func(t *testing.T) {
+		db := newTestDB(t)
+		manager := NewSQLiteTxManager(db)
+		users := NewSQLiteUserRepository(db)
+
+		err := manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+			if err := manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+				return users.Create(ctx, newUser("user1"))
+			}); err != nil {
+				return err
+			}
+			return errors.New("abort")
+		})
+		if err == nil {
+			t.Fatal("WithinTx() error = nil, want abort")
+		}
+		if _, err := users.GetByID(ctx, "user1"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetByID() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("正常系: 並行する読み取り後の書き込みがBUSYにならない", func(t *testing.T) {
+		db := newTestDB(t)
+		manager := NewSQLiteTxManager(db)
+		users := NewSQLiteUserRepository(db)
+		if err := users.Create(ctx, newUser("user0")); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 4)
+		for _, id := range []string{"user1", "user2", "user3", "user4"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- manager.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+					if _, err := users.List(ctx, repository.UserFilter{}); err != nil {
+						return err
+					}
+					return users.Create(ctx, newUser(id))
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Errorf("WithinTx() error = %v", err)
+			}
+		}
+	})
+}
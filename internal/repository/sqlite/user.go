@@ -0,0 +1,257 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type SQLiteUserRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{db: db}
+}
+
+// userColumns is the column list read by scanUser.
+const userColumns = "id, name, COALESCE(handle, ''), email, roles, created_at, updated_at, COALESCE(updated_by, ''), deleted_at"
+
+func (r *SQLiteUserRepository) List(ctx context.Context, filter repository.UserFilter) ([]*domain.User, error) {
+	log.Printf("SQLiteUserRepository: Listing users with filter: %+v", filter)
+
+	query := "SELECT " + userColumns + " FROM users"
+	if !filter.IncludeDeleted {
+		query += " WHERE deleted_at IS NULL"
+	}
+	query += " ORDER BY created_at DESC"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		log.Printf("SQLiteUserRepository: Failed to query users: %v", err)
+		return nil, classifyError("failed to query users", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Printf("SQLiteUserRepository: Failed to scan user: %v", err)
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("SQLiteUserRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+
+	log.Printf("SQLiteUserRepository: Found %d users", len(users))
+	return users, nil
+}
+
+func (r *SQLiteUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	log.Printf("SQLiteUserRepository: Getting user by ID: %s", id)
+
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1 AND deleted_at IS NULL"
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("SQLiteUserRepository: User not found: %s", id)
+			return nil, repository.NotFoundf("user %s not found", id)
+		}
+		log.Printf("SQLiteUserRepository: Failed to scan user: %v", err)
+		return nil, err
+	}
+
+	log.Printf("SQLiteUserRepository: Found user: %s", user.ID)
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	log.Printf("SQLiteUserRepository: Getting users by IDs: %v", ids)
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var args []any
+	query := "SELECT " + userColumns + " FROM users WHERE id IN (" + placeholders(&args, ids) + ") AND deleted_at IS NULL ORDER BY id"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("SQLiteUserRepository: Failed to query users: %v", err)
+		return nil, classifyError("failed to query users", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Printf("SQLiteUserRepository: Failed to scan user: %v", err)
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("SQLiteUserRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+	return users, nil
+}
+
+// GetByHandles compares handles with LOWER, which SQLite only applies to
+// ASCII letters, so other scripts match case-sensitively.
+func (r *SQLiteUserRepository) GetByHandles(ctx context.Context, handles []string) ([]*domain.User, error) {
+	log.Printf("SQLiteUserRepository: Getting users by handles: %v", handles)
+
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	lower := make([]string, len(handles))
+	for i, handle := range handles {
+		lower[i] = strings.ToLower(handle)
+	}
+	var args []any
+	query := "SELECT " + userColumns + " FROM users WHERE LOWER(handle) IN (" + placeholders(&args, lower) + ") AND deleted_at IS NULL ORDER BY id"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("SQLiteUserRepository: Failed to query users: %v", err)
+		return nil, classifyError("failed to query users", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Printf("SQLiteUserRepository: Failed to scan user: %v", err)
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("SQLiteUserRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+	return users, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanUser reads the userColumns of a users row, decoding the roles column
+// from its JSON array.
+func scanUser(row scanner) (*domain.User, error) {
+	var user domain.User
+	var roles string
+	var deletedAt sql.NullTime
+	if err := row.Scan(&user.ID, &user.Name, &user.Handle, &user.Email, &roles, &user.CreatedAt, &user.UpdatedAt, &user.UpdatedBy, &deletedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, classifyError("failed to scan user", err)
+	}
+	if err := json.Unmarshal([]byte(roles), &user.Roles); err != nil {
+		return nil, fmt.Errorf("failed to decode roles of user %s: %w", user.ID, err)
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	return &user, nil
+}
+
+func (r *SQLiteUserRepository) Create(ctx context.Context, user *domain.User) error {
+	log.Printf("SQLiteUserRepository: Creating user: %s", user.ID)
+
+	roles, err := encodeRoles(user.Roles)
+	if err != nil {
+		return err
+	}
+
+	actor := repository.ActorFromContext(ctx)
+	query := "INSERT INTO users (id, name, handle, email, roles, created_at, updated_at, updated_by) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $6, NULLIF($7, ''))"
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, user.ID, user.Name, user.Handle, user.Email, roles, user.CreatedAt.UTC(), actor); err != nil {
+		log.Printf("SQLiteUserRepository: Failed to create user: %v", err)
+		return classifyError("failed to create user", err)
+	}
+	user.UpdatedAt = user.CreatedAt
+	user.UpdatedBy = actor
+	return nil
+}
+
+func (r *SQLiteUserRepository) Update(ctx context.Context, user *domain.User) error {
+	log.Printf("SQLiteUserRepository: Updating user: %s", user.ID)
+
+	roles, err := encodeRoles(user.Roles)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	actor := repository.ActorFromContext(ctx)
+	query := "UPDATE users SET name = $2, handle = NULLIF($3, ''), email = $4, roles = $5, updated_at = $6, updated_by = NULLIF($7, '') WHERE id = $1 AND deleted_at IS NULL"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, user.ID, user.Name, user.Handle, user.Email, roles, now, actor)
+	if err != nil {
+		log.Printf("SQLiteUserRepository: Failed to update user: %v", err)
+		return classifyError("failed to update user", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return repository.NotFoundf("user %s not found", user.ID)
+	}
+	user.UpdatedAt = now
+	user.UpdatedBy = actor
+	return nil
+}
+
+func (r *SQLiteUserRepository) Delete(ctx context.Context, id string) (*domain.User, error) {
+	log.Printf("SQLiteUserRepository: Deleting user: %s", id)
+
+	query := "UPDATE users SET deleted_at = $2, updated_at = $2, updated_by = NULLIF($3, '') WHERE id = $1 AND deleted_at IS NULL RETURNING " + userColumns
+	return r.setDeleted(ctx, query, id)
+}
+
+func (r *SQLiteUserRepository) Restore(ctx context.Context, id string) (*domain.User, error) {
+	log.Printf("SQLiteUserRepository: Restoring user: %s", id)
+
+	query := "UPDATE users SET deleted_at = NULL, updated_at = $2, updated_by = NULLIF($3, '') WHERE id = $1 AND deleted_at IS NOT NULL RETURNING " + userColumns
+	return r.setDeleted(ctx, query, id)
+}
+
+// setDeleted runs a Delete or Restore query, which matches no row when the
+// user does not exist or is already in the requested state.
+func (r *SQLiteUserRepository) setDeleted(ctx context.Context, query, id string) (*domain.User, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id, time.Now().UTC(), repository.ActorFromContext(ctx))
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.NotFoundf("user %s not found", id)
+	}
+	if err != nil {
+		log.Printf("SQLiteUserRepository: Failed to change deleted state of user %s: %v", id, err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// encodeRoles stores roles as the JSON array expected by the roles column.
+func encodeRoles(roles []string) (string, error) {
+	if roles == nil {
+		roles = []string{}
+	}
+	b, err := json.Marshal(roles)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode roles: %w", err)
+	}
+	return string(b), nil
+}
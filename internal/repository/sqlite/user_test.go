@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+func TestSQLiteUserRepository(t *testing.T) {
+	db := newTestDB(t)
+	mustExec(t, db, "INSERT INTO users (id, name, email, roles, created_at) VALUES ($1, $2, $3, $4, $5)",
+		"user1", "Alice", "alice@example.com", `["user","admin"]`, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	mustExec(t, db, "INSERT INTO users (id, name, email, created_at) VALUES ($1, $2, $3, $4)",
+		"user2", "Bob", "bob@example.com", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	repo := NewSQLiteUserRepository(db)
+	ctx := context.Background()
+
+	t.Run("正常系: 作成日時の降順で一覧取得", func(t *testing.T) {
+		got, err := repo.List(ctx, repository.UserFilter{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(got) != 2 || got[0].ID != "user2" || got[1].ID != "user1" {
+			t.Errorf("List() = %+v, want user2, user1", got)
+		}
+	})
+
+	t.Run("正常系: ユーザー取得成功", func(t *testing.T) {
+		got, err := repo.GetByID(ctx, "user1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Name != "Alice" || got.Email != "alice@example.com" ||
+			!got.CreatedAt.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) ||
+			!slices.Equal(got.Roles, []string{"user", "admin"}) {
+			t.Errorf("GetByID() = %+v", got)
+		}
+	})
+
+	t.Run("正常系: ロールのデフォルトはuser", func(t *testing.T) {
+		got, err := repo.GetByID(ctx, "user2")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if !slices.Equal(got.Roles, []string{"user"}) {
+			t.Errorf("GetByID() roles = %v, want [user]", got.Roles)
+		}
+	})
+
+	t.Run("異常系: ユーザーが見つからない", func(t *testing.T) {
+		if _, err := repo.GetByID(ctx, "nonexistent"); err == nil {
+			t.Error("GetByID() error = nil, want error")
+		}
+	})
+
+	t.Run("異常系: ロールが不正なJSON", func(t *testing.T) {
+		mustExec(t, db, "INSERT INTO users (id, name, email, roles) VALUES ($1, $2, $3, $4)",
+			"broken", "Broken", "broken@example.com", "user,admin")
+		if _, err := repo.GetByID(ctx, "broken"); err == nil {
+			t.Error("GetByID() error = nil, want error")
+		}
+	})
+}
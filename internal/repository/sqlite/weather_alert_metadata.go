@@ -0,0 +1,168 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+type SQLiteWeatherAlertMetadataRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteWeatherAlertMetadataRepository(db *sql.DB) *SQLiteWeatherAlertMetadataRepository {
+	return &SQLiteWeatherAlertMetadataRepository{db: db}
+}
+
+func (r *SQLiteWeatherAlertMetadataRepository) SearchIDs(ctx context.Context, filter repository.MetadataFilter) ([]string, error) {
+	log.Printf("SQLiteWeatherAlertMetadataRepository: Searching IDs with filter: %+v", filter)
+
+	where, args := metadataWhere(filter)
+	query := "SELECT id FROM weather_alert_metadata" + where + " ORDER BY issued_at DESC"
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("SQLiteWeatherAlertMetadataRepository: Failed to query: %v", err)
+		return nil, classifyError("failed to search weather alert metadata", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("SQLiteWeatherAlertMetadataRepository: Failed to scan ID: %v", err)
+			return nil, fmt.Errorf("failed to scan ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("SQLiteWeatherAlertMetadataRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+
+	log.Printf("SQLiteWeatherAlertMetadataRepository: Found %d IDs", len(ids))
+	return ids, nil
+}
+
+func (r *SQLiteWeatherAlertMetadataRepository) Search(ctx context.Context, filter repository.MetadataFilter) ([]*domain.WeatherAlertMetadata, error) {
+	log.Printf("SQLiteWeatherAlertMetadataRepository: Searching metadata with filter: %+v", filter)
+
+	where, args := metadataWhere(filter)
+	query := "SELECT " + metadataColumns + " FROM weather_alert_metadata" + where + " ORDER BY issued_at DESC"
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("SQLiteWeatherAlertMetadataRepository: Failed to query: %v", err)
+		return nil, classifyError("failed to search weather alert metadata", err)
+	}
+	defer rows.Close()
+
+	var results []*domain.WeatherAlertMetadata
+	for rows.Next() {
+		m, err := scanMetadata(rows)
+		if err != nil {
+			log.Printf("SQLiteWeatherAlertMetadataRepository: Failed to scan metadata: %v", err)
+			return nil, fmt.Errorf("failed to scan metadata: %w", err)
+		}
+		results = append(results, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("SQLiteWeatherAlertMetadataRepository: Row iteration error: %v", err)
+		return nil, classifyError("row iteration error", err)
+	}
+
+	log.Printf("SQLiteWeatherAlertMetadataRepository: Found %d metadata records", len(results))
+	return results, nil
+}
+
+// metadataWhere builds the WHERE clause for filter. Timestamps are compared
+// as UTC text, matching how they are stored.
+func metadataWhere(filter repository.MetadataFilter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if filter.Region != nil {
+		args = append(args, *filter.Region)
+		conditions = append(conditions, fmt.Sprintf("region = $%d", len(args)))
+	}
+	if filter.IssuedAfter != nil {
+		args = append(args, filter.IssuedAfter.UTC())
+		conditions = append(conditions, fmt.Sprintf("issued_at >= $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *SQLiteWeatherAlertMetadataRepository) Create(ctx context.Context, metadata *domain.WeatherAlertMetadata) error {
+	log.Printf("SQLiteWeatherAlertMetadataRepository: Creating metadata: %s", metadata.ID)
+
+	actor := repository.ActorFromContext(ctx)
+	query := "INSERT INTO weather_alert_metadata (id, region, severity, issued_at, created_at, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $5, NULLIF($6, ''))"
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		metadata.ID, metadata.Region, metadata.Severity, metadata.IssuedAt.UTC(), metadata.CreatedAt.UTC(), actor)
+	if err != nil {
+		log.Printf("SQLiteWeatherAlertMetadataRepository: Failed to create metadata: %v", err)
+		return classifyError("failed to create weather alert metadata", err)
+	}
+	metadata.UpdatedAt = metadata.CreatedAt
+	metadata.UpdatedBy = actor
+	return nil
+}
+
+func (r *SQLiteWeatherAlertMetadataRepository) Delete(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error) {
+	log.Printf("SQLiteWeatherAlertMetadataRepository: Deleting metadata: %s", id)
+
+	query := "UPDATE weather_alert_metadata SET deleted_at = $2, updated_at = $2, updated_by = NULLIF($3, '') WHERE id = $1 AND deleted_at IS NULL RETURNING " + metadataColumns
+	return r.setDeleted(ctx, query, id)
+}
+
+func (r *SQLiteWeatherAlertMetadataRepository) Restore(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error) {
+	log.Printf("SQLiteWeatherAlertMetadataRepository: Restoring metadata: %s", id)
+
+	query := "UPDATE weather_alert_metadata SET deleted_at = NULL, updated_at = $2, updated_by = NULLIF($3, '') WHERE id = $1 AND deleted_at IS NOT NULL RETURNING " + metadataColumns
+	return r.setDeleted(ctx, query, id)
+}
+
+func (r *SQLiteWeatherAlertMetadataRepository) setDeleted(ctx context.Context, query, id string) (*domain.WeatherAlertMetadata, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id, time.Now().UTC(), repository.ActorFromContext(ctx))
+	m, err := scanMetadata(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.NotFoundf("weather alert metadata %s not found", id)
+	}
+	if err != nil {
+		log.Printf("SQLiteWeatherAlertMetadataRepository: Failed to change deleted state of metadata %s: %v", id, err)
+		return nil, classifyError("failed to change deleted state of weather alert metadata", err)
+	}
+	return m, nil
+}
+
+// metadataColumns is the column list read by scanMetadata.
+const metadataColumns = "id, region, severity, issued_at, created_at, updated_at, COALESCE(updated_by, ''), deleted_at"
+
+func scanMetadata(row scanner) (*domain.WeatherAlertMetadata, error) {
+	var m domain.WeatherAlertMetadata
+	var deletedAt sql.NullTime
+	if err := row.Scan(&m.ID, &m.Region, &m.Severity, &m.IssuedAt, &m.CreatedAt, &m.UpdatedAt, &m.UpdatedBy, &deletedAt); err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		m.DeletedAt = &deletedAt.Time
+	}
+	return &m, nil
+}
@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+func TestSQLiteWeatherAlertMetadataRepository_Search(t *testing.T) {
+	db := newTestDB(t)
+	base := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	for _, m := range []struct {
+		id, region, severity string
+		issuedAt             time.Time
+	}{
+		{"tokyo-old", "Tokyo", "info", base.Add(-48 * time.Hour)},
+		{"tokyo-new", "Tokyo", "warning", base},
+		{"osaka", "Osaka", "critical", base.Add(-24 * time.Hour)},
+	} {
+		mustExec(t, db, "INSERT INTO weather_alert_metadata (id, region, severity, issued_at, created_at) VALUES ($1, $2, $3, $4, $5)",
+			m.id, m.region, m.severity, m.issuedAt, m.issuedAt)
+	}
+
+	repo := NewSQLiteWeatherAlertMetadataRepository(db)
+	tokyo := "Tokyo"
+	issuedAfter := base.Add(-24 * time.Hour)
+	// The same instant in another time zone must match the same rows.
+	issuedAfterJST := issuedAfter.In(time.FixedZone("JST", 9*60*60))
+
+	tests := []struct {
+		name   string
+		filter repository.MetadataFilter
+		want   []string
+	}{
+		{
+			name:   "正常系: フィルタなしで発行日時の降順",
+			filter: repository.MetadataFilter{},
+			want:   []string{"tokyo-new", "osaka", "tokyo-old"},
+		},
+		{
+			name:   "正常系: 地域フィルタ",
+			filter: repository.MetadataFilter{Region: &tokyo},
+			want:   []string{"tokyo-new", "tokyo-old"},
+		},
+		{
+			name:   "正常系: 日時フィルタは境界を含む",
+			filter: repository.MetadataFilter{IssuedAfter: &issuedAfter},
+			want:   []string{"tokyo-new", "osaka"},
+		},
+		{
+			name:   "正常系: UTC以外のタイムゾーンの日時フィルタ",
+			filter: repository.MetadataFilter{IssuedAfter: &issuedAfterJST},
+			want:   []string{"tokyo-new", "osaka"},
+		},
+		{
+			name:   "正常系: 地域と日時の両方",
+			filter: repository.MetadataFilter{Region: &tokyo, IssuedAfter: &issuedAfter},
+			want:   []string{"tokyo-new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := repo.SearchIDs(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("SearchIDs() error = %v", err)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("SearchIDs() = %v, want %v", ids, tt.want)
+			}
+
+			metadata, err := repo.Search(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(metadata) != len(tt.want) {
+				t.Fatalf("Search() returned %d items, want %d", len(metadata), len(tt.want))
+			}
+			for i, m := range metadata {
+				if m.ID != tt.want[i] || m.IssuedAt.IsZero() || m.Region == "" {
+					t.Errorf("Search()[%d] = %+v, want ID %s", i, m, tt.want[i])
+				}
+			}
+		})
+	}
+}
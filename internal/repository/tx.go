@@ -0,0 +1,46 @@
+package repository
+
+import "context"
+
+// IsolationLevel selects the isolation of a transaction. Backends that do
+// not support a level use the closest stronger one.
+type IsolationLevel int
+
+const (
+	// IsolationDefault uses the backend's default level.
+	IsolationDefault IsolationLevel = iota
+	ReadCommitted
+	RepeatableRead
+	Serializable
+)
+
+func (l IsolationLevel) String() string {
+	switch l {
+	case ReadCommitted:
+		return "read committed"
+	case RepeatableRead:
+		return "repeatable read"
+	case Serializable:
+		return "serializable"
+	}
+	return "default"
+}
+
+// TxOptions configures a transaction started by a Transactor.
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool
+}
+
+// Transactor runs a unit of work in a single transaction.
+//
+// Repository calls made with the context passed to fn use the transaction;
+// calls made with any other context do not. fn must not keep the context
+// after it returns. The transaction commits when fn returns nil and rolls
+// back otherwise. On a serialization failure or deadlock fn may be run again,
+// so any side effect outside the transaction must be idempotent. Calling
+// WithinTx with a context that already carries a transaction joins it and
+// ignores opts.
+type Transactor interface {
+	WithinTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error
+}
@@ -6,7 +6,33 @@ import (
 	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
 )
 
+type UserFilter struct {
+	// IncludeDeleted also returns soft-deleted users.
+	IncludeDeleted bool
+}
+
+// UserRepository stores users. Soft-deleted users are hidden from every
+// method except List with IncludeDeleted and Restore.
 type UserRepository interface {
-	List(ctx context.Context) ([]*domain.User, error)
+	List(ctx context.Context, filter UserFilter) ([]*domain.User, error)
 	GetByID(ctx context.Context, id string) (*domain.User, error)
+	// GetByIDs returns the users with one of ids, ordered by ID. Unknown IDs
+	// are skipped.
+	GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error)
+	// GetByHandles returns the users whose handle matches one of handles,
+	// ignoring case, ordered by ID. Handles matching nobody are skipped.
+	GetByHandles(ctx context.Context, handles []string) ([]*domain.User, error)
+	// Create inserts user, failing with ErrConflict if its ID, handle or
+	// email is already taken; handles are compared ignoring case. Deleted
+	// users keep their handle and email reserved. UpdatedAt and UpdatedBy of
+	// user are set to the values stored.
+	Create(ctx context.Context, user *domain.User) error
+	// Update overwrites the name, handle, email and roles of an existing user
+	// and sets UpdatedAt and UpdatedBy of user to the values stored.
+	Update(ctx context.Context, user *domain.User) error
+	// Delete soft-deletes the user and returns it as stored.
+	Delete(ctx context.Context, id string) (*domain.User, error)
+	// Restore undoes Delete, failing with ErrNotFound if the user is not
+	// deleted.
+	Restore(ctx context.Context, id string) (*domain.User, error)
 }
@@ -9,4 +9,7 @@ import (
 type WeatherAlertRepository interface {
 	GetByID(ctx context.Context, id string) (*domain.WeatherAlert, error)
 	GetByIDs(ctx context.Context, ids []string) ([]*domain.WeatherAlert, error)
+	// Put creates or replaces alert. It is idempotent, so it is safe to call
+	// from a transaction that may be retried.
+	Put(ctx context.Context, alert *domain.WeatherAlert) error
 }
@@ -10,9 +10,19 @@ import (
 type MetadataFilter struct {
 	Region      *string
 	IssuedAfter *time.Time
+	// IncludeDeleted also matches soft-deleted alerts.
+	IncludeDeleted bool
 }
 
 type WeatherAlertMetadataRepository interface {
 	SearchIDs(ctx context.Context, filter MetadataFilter) ([]string, error)
 	Search(ctx context.Context, filter MetadataFilter) ([]*domain.WeatherAlertMetadata, error)
+	// Create inserts metadata, failing with ErrConflict if the ID is taken.
+	// UpdatedAt and UpdatedBy of metadata are set to the values stored.
+	Create(ctx context.Context, metadata *domain.WeatherAlertMetadata) error
+	// Delete soft-deletes the metadata and returns it as stored.
+	Delete(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error)
+	// Restore undoes Delete, failing with ErrNotFound if the metadata is not
+	// deleted.
+	Restore(ctx context.Context, id string) (*domain.WeatherAlertMetadata, error)
 }
@@ -0,0 +1,201 @@
+// Package retention deletes the messages kept longer than their retention
+// period.
+package retention
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/attachment"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// Observer is told the outcome of every purge. *metrics.Metrics implements it.
+type Observer interface {
+	ObservePurge(dryRun bool, messages int, err error)
+}
+
+// Purger deletes the threads whose top-level message is older than the
+// retention of its channel, together with their search documents and
+// attachments, and uncounts them from the unread counts of the channel. A
+// thread expires as a whole, so a recent reply does not keep an old thread,
+// nor does an old reply go before its thread.
+type Purger struct {
+	messages    repository.MessageRepository
+	channels    repository.ChannelRepository
+	search      repository.SearchRepository
+	tx          repository.Transactor
+	attachments *attachment.Store
+	cfg         config.RetentionConfig
+	observer    Observer
+}
+
+// NewPurger returns a Purger applying the retention in cfg. attachments and
+// observer may be nil.
+func NewPurger(messages repository.MessageRepository, channels repository.ChannelRepository, search repository.SearchRepository, tx repository.Transactor,
+	attachments *attachment.Store, cfg config.RetentionConfig, observer Observer) *Purger {
+	return &Purger{
+		messages:    messages,
+		channels:    channels,
+		search:      search,
+		tx:          tx,
+		attachments: attachments,
+		cfg:         cfg,
+		observer:    observer,
+	}
+}
+
+// Enabled reports whether any message ever expires.
+func (p *Purger) Enabled() bool {
+	if p.cfg.Default > 0 {
+		return true
+	}
+	for _, d := range p.cfg.Channels {
+		if d > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// scopes returns the filters of the messages expired at now: one per channel
+// with its own retention, followed by one for every other message.
+func (p *Purger) scopes(now time.Time) []repository.ExpiryFilter {
+	var scopes []repository.ExpiryFilter
+	ids := make([]string, 0, len(p.cfg.Channels))
+	for id := range p.cfg.Channels {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		if d := p.cfg.Channels[id]; d > 0 {
+			scopes = append(scopes, repository.ExpiryFilter{ChannelID: id, Before: now.Add(-d)})
+		}
+	}
+	if p.cfg.Default > 0 {
+		scopes = append(scopes, repository.ExpiryFilter{ExcludeChannelIDs: ids, Before: now.Add(-p.cfg.Default)})
+	}
+	return scopes
+}
+
+// Run purges the threads expired at now and returns the number of messages
+// and replies deleted, or that would be deleted in a dry run. Purges running
+// at the same time, such as on several server instances, may read the same
+// threads, but only the one that deletes a thread counts and cleans it up.
+func (p *Purger) Run(ctx context.Context, now time.Time) (int, error) {
+	total := 0
+	var err error
+	for _, filter := range p.scopes(now) {
+		var n int
+		n, err = p.purge(ctx, filter)
+		total += n
+		if err != nil {
+			break
+		}
+	}
+	if p.observer != nil {
+		p.observer.ObservePurge(p.cfg.DryRun, total, err)
+	}
+	return total, err
+}
+
+// purge deletes the threads matching filter a page of BatchSize threads at a
+// time. The page cursor stays valid after the threads before it are deleted.
+func (p *Purger) purge(ctx context.Context, filter repository.ExpiryFilter) (int, error) {
+	total := 0
+	page := repository.PageRequest{Limit: p.cfg.BatchSize}
+	for {
+		result, err := p.messages.ListExpired(ctx, filter, page)
+		if err != nil {
+			return total, err
+		}
+
+		var ids []string
+		for _, msg := range result.Messages {
+			ids = append(ids, msg.ID)
+			if p.cfg.DryRun {
+				total += 1 + msg.ReplyCount
+			}
+		}
+		if !p.cfg.DryRun && len(ids) > 0 {
+			deleted, err := p.messages.Purge(ctx, ids)
+			if err != nil {
+				return total, err
+			}
+			total += len(deleted)
+			p.cleanUp(ctx, deleted)
+		}
+
+		if !result.HasNextPage {
+			return total, nil
+		}
+		last := result.Messages[len(result.Messages)-1]
+		page.After = &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// cleanUp removes the search documents and attachments of deleted messages
+// and uncounts them from their channels. Failures are only logged: the
+// messages are gone, so a later purge would not find them again, and search
+// already skips documents of missing messages.
+func (p *Purger) cleanUp(ctx context.Context, deleted []*domain.Message) {
+	ids := make([]string, len(deleted))
+	counts := make(map[string]int)
+	for i, msg := range deleted {
+		ids[i] = msg.ID
+		if msg.Counted() {
+			counts[msg.ChannelID]++
+		}
+	}
+	for _, channelID := range slices.Sorted(maps.Keys(counts)) {
+		err := p.tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+			return p.channels.RemoveMessages(ctx, channelID, counts[channelID])
+		})
+		if err != nil {
+			log.Printf("Retention: failed to uncount %d message(s) of channel %s: %v", counts[channelID], channelID, err)
+		}
+	}
+	if err := p.search.Delete(ctx, ids); err != nil {
+		log.Printf("Retention: failed to remove %d message(s) from the search index: %v", len(ids), err)
+	}
+
+	if p.attachments == nil {
+		return
+	}
+	for _, msg := range deleted {
+		for _, a := range msg.Attachments {
+			if err := p.attachments.Delete(ctx, a); err != nil {
+				log.Printf("Retention: failed to delete attachment %s: %v", a.ID, err)
+			}
+		}
+	}
+}
+
+// Schedule runs a purge now and then every interval until ctx is done. A
+// failed purge is logged and tried again at the next interval.
+func (p *Purger) Schedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := p.Run(ctx, time.Now().UTC())
+		switch {
+		case err != nil:
+			log.Printf("Retention: purge failed after %d message(s): %v", n, err)
+		case p.cfg.DryRun:
+			log.Printf("Retention: dry run found %d expired message(s)", n)
+		case n > 0:
+			log.Printf("Retention: purged %d message(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
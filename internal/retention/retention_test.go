@@ -0,0 +1,136 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/attachment"
+	"github.com/kuchida1981/graphql-sampleapp/internal/blob"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository/memory"
+	"github.com/kuchida1981/graphql-sampleapp/internal/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type purgeRecord struct {
+	dryRun   bool
+	messages int
+	err      error
+}
+
+type recordingObserver struct {
+	purges []purgeRecord
+}
+
+func (o *recordingObserver) ObservePurge(dryRun bool, messages int, err error) {
+	o.purges = append(o.purges, purgeRecord{dryRun: dryRun, messages: messages, err: err})
+}
+
+func TestPurger_Run(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	attachments := attachment.NewStore(blobs, 1<<10)
+	file, err := attachments.Save(ctx, "memo.txt", "text/plain", strings.NewReader("hello"))
+	require.NoError(t, err)
+
+	messages := memory.NewMemoryMessageRepository([]*domain.Message{
+		{ID: "old", Content: "old", Author: "Alice", CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "recent", Content: "recent", Author: "Alice", CreatedAt: now.Add(-time.Hour)},
+		{ID: "short-old", Content: "old", Author: "Alice", ChannelID: "short", CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "short-recent", Content: "recent", Author: "Alice", ChannelID: "short", CreatedAt: now.Add(-time.Hour)},
+		{ID: "exempt-old", Content: "old", Author: "Alice", ChannelID: "exempt", CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "other-old", Content: "old", Author: "Alice", ChannelID: "other", CreatedAt: now.Add(-48 * time.Hour)},
+		// Counted when posted, then flagged on an edit and rejected.
+		{ID: "other-rejected", Content: "spam", Author: "Alice", ChannelID: "other", CreatedAt: now.Add(-47 * time.Hour),
+			Moderation: &domain.Moderation{Status: domain.ModerationRejected, Counted: true}},
+	})
+	require.NoError(t, messages.Create(ctx, &domain.Message{
+		ID: "old-reply", Content: "old reply", Author: "Bob", ParentID: "old", CreatedAt: now.Add(-time.Minute),
+		Attachments: []domain.Attachment{*file},
+	}))
+	index := memory.NewMemorySearchRepository()
+	tx := memory.NewMemoryTxManager()
+	_, err = search.Reindex(ctx, messages, index, tx)
+	require.NoError(t, err)
+
+	channels := memory.NewMemoryChannelRepository(memory.NewMemoryUserRepository([]*domain.User{
+		{ID: "alice", Name: "Alice", CreatedAt: now},
+	}))
+	for _, id := range []string{"short", "exempt", "other"} {
+		require.NoError(t, channels.Create(ctx, &domain.Channel{ID: id, Name: id, MemberIDs: []string{"alice"}, CreatedAt: now}))
+	}
+	for _, id := range []string{"short", "short", "exempt", "other", "other"} {
+		require.NoError(t, channels.RecordMessage(ctx, id))
+	}
+
+	cfg := config.RetentionConfig{
+		Default:   24 * time.Hour,
+		Channels:  map[string]time.Duration{"short": 2 * time.Hour, "exempt": 0},
+		BatchSize: 1,
+	}
+	remaining := func(t *testing.T) []string {
+		t.Helper()
+		var ids []string
+		for _, id := range []string{"old", "old-reply", "recent", "short-old", "short-recent", "exempt-old", "other-old", "other-rejected"} {
+			_, err := messages.GetByID(ctx, id)
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	t.Run("正常系: ドライランは削除せずに件数を数える", func(t *testing.T) {
+		observer := &recordingObserver{}
+		dryRun := cfg
+		dryRun.DryRun = true
+		n, err := NewPurger(messages, channels, index, tx, attachments, dryRun, observer).Run(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, n)
+		assert.Len(t, remaining(t), 8)
+		assert.Equal(t, []purgeRecord{{dryRun: true, messages: 5}}, observer.purges)
+	})
+
+	t.Run("正常系: チャンネルごとの保持期間でスレッドごと削除", func(t *testing.T) {
+		observer := &recordingObserver{}
+		purger := NewPurger(messages, channels, index, tx, attachments, cfg, observer)
+		n, err := purger.Run(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, n)
+		assert.Equal(t, []string{"recent", "short-recent", "exempt-old"}, remaining(t))
+
+		page, err := index.Search(ctx, repository.SearchQuery{Tokens: []string{"old"}, AllChannels: true}, repository.PageRequest{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, page.Documents, 1)
+		assert.Equal(t, "exempt-old", page.Documents[0].MessageID)
+		_, err = blobs.Open(ctx, file.BlobKey)
+		assert.Error(t, err)
+
+		reads, err := channels.Reads(ctx, "alice", []string{"short", "exempt", "other"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, reads["short"].UnreadCount)
+		assert.Equal(t, 1, reads["exempt"].UnreadCount)
+		assert.Equal(t, 0, reads["other"].UnreadCount)
+
+		n, err = purger.Run(ctx, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+		assert.Equal(t, []purgeRecord{{messages: 5}, {messages: 0}}, observer.purges)
+	})
+
+	t.Run("正常系: 保持期間がなければ無効", func(t *testing.T) {
+		assert.True(t, NewPurger(messages, channels, index, tx, nil, cfg, nil).Enabled())
+		assert.False(t, NewPurger(messages, channels, index, tx, nil, config.RetentionConfig{Channels: map[string]time.Duration{"exempt": 0}}, nil).Enabled())
+	})
+}
@@ -0,0 +1,73 @@
+package search
+
+import "slices"
+
+// Segment is a part of highlighted text. Concatenating the segments of
+// Highlight yields the original text.
+type Segment struct {
+	Text    string
+	Matched bool
+}
+
+// Highlight splits text into segments, marking the parts that match a term of
+// query. Words only match whole words of text; Japanese is matched by the
+// same bigrams QueryTokens looks up, so adjacent matches merge into one
+// segment.
+func Highlight(text, query string) []Segment {
+	runes := []rune(text)
+	folded := make([]rune, len(runes))
+	for i, r := range runes {
+		folded[i] = fold(r)
+	}
+	wordStart := make(map[int]int)
+	for _, r := range runs(text) {
+		if !r.cjk {
+			wordStart[r.start] = len(r.text)
+		}
+	}
+
+	matched := make([]bool, len(runes))
+	for _, q := range runs(query) {
+		if !q.cjk {
+			// Compare whole words only, so that "run" does not light up
+			// inside "running", which the index would not have matched.
+			term := truncate(q.text)
+			for start, length := range wordStart {
+				if truncate(folded[start:start+length]) == term {
+					markRange(matched, start, length)
+				}
+			}
+			continue
+		}
+		size := min(2, len(q.text))
+		for i := 0; i+size <= len(q.text); i++ {
+			markAll(matched, folded, q.text[i:i+size])
+		}
+	}
+
+	var segments []Segment
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && matched[end] == matched[start] {
+			end++
+		}
+		segments = append(segments, Segment{Text: string(runes[start:end]), Matched: matched[start]})
+		start = end
+	}
+	return segments
+}
+
+// markAll marks every occurrence of term in text.
+func markAll(matched []bool, text, term []rune) {
+	for i := 0; i+len(term) <= len(text); i++ {
+		if slices.Equal(text[i:i+len(term)], term) {
+			markRange(matched, i, len(term))
+		}
+	}
+}
+
+func markRange(matched []bool, start, length int) {
+	for i := start; i < start+length; i++ {
+		matched[i] = true
+	}
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/domain"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+)
+
+// reindexBatchSize is the number of messages read per page by Reindex.
+const reindexBatchSize = 100
+
+// NewDocument returns the search document of msg.
+func NewDocument(msg *domain.Message) *domain.SearchDocument {
+	return &domain.SearchDocument{
+		MessageID: msg.ID,
+		ChannelID: msg.ChannelID,
+		Author:    msg.Author,
+		CreatedAt: msg.CreatedAt,
+		Tokens:    Tokenize(msg.Content),
+	}
+}
+
+// Reindex adds every message and reply to index, replacing the documents
+// already there, and returns the number of messages indexed. Each document is
+// written in its own transaction.
+func Reindex(ctx context.Context, messages repository.MessageRepository, index repository.SearchRepository, tx repository.Transactor) (int, error) {
+	indexed := 0
+	put := func(msg *domain.Message) error {
+		err := tx.WithinTx(ctx, repository.TxOptions{}, func(ctx context.Context) error {
+			return index.Index(ctx, NewDocument(msg))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to index message %s: %w", msg.ID, err)
+		}
+		indexed++
+		return nil
+	}
+
+	err := eachPage(func(page repository.PageRequest) (*repository.MessagePage, error) {
+		return messages.Scan(ctx, page)
+	}, func(msg *domain.Message) error {
+		if err := put(msg); err != nil {
+			return err
+		}
+		if msg.ReplyCount == 0 {
+			return nil
+		}
+		return eachPage(func(page repository.PageRequest) (*repository.MessagePage, error) {
+			return messages.ListReplies(ctx, msg.ID, page)
+		}, put)
+	})
+	return indexed, err
+}
+
+// eachPage calls fn for every message of the pages returned by list.
+func eachPage(list func(repository.PageRequest) (*repository.MessagePage, error), fn func(*domain.Message) error) error {
+	page := repository.PageRequest{Limit: reindexBatchSize}
+	for {
+		result, err := list(page)
+		if err != nil {
+			return err
+		}
+		for _, msg := range result.Messages {
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+		if !result.HasNextPage {
+			return nil
+		}
+		last := result.Messages[len(result.Messages)-1]
+		page.After = &repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
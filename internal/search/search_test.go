@@ -0,0 +1,92 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "正常系: 英単語は小文字の単語単位", text: "Hello, World! hello", want: []string{"hello", "world"}},
+		{name: "正常系: 日本語は1文字と2文字", text: "東京タワー", want: []string{"タ", "タワ", "ワ", "ワー", "ー", "京", "京タ", "東", "東京"}},
+		{name: "正常系: 英数字と日本語の混在", text: "Go言語 1.24", want: []string{"1", "24", "go", "言", "言語", "語"}},
+		{name: "正常系: 全角英数字と半角カナを正規化", text: "ＧｏＬａｎｇ ｶﾀ", want: []string{"golang", "カ", "カタ", "タ"}},
+		{name: "正常系: 記号のみ", text: "!?", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Tokenize(tt.text))
+		})
+	}
+}
+
+func TestQueryTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "正常系: 日本語はバイグラム", query: "東京タワー", want: []string{"タワ", "ワー", "京タ", "東京"}},
+		{name: "正常系: 1文字の日本語", query: "京", want: []string{"京"}},
+		{name: "正常系: 英単語", query: "GraphQL server", want: []string{"graphql", "server"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, QueryTokens(tt.query))
+		})
+	}
+
+	t.Run("正常系: 検索語のトークンは索引に含まれる", func(t *testing.T) {
+		index := Tokenize("来週、東京タワーでGraphQLの勉強会")
+		for _, token := range QueryTokens("東京タワー graphql") {
+			assert.Contains(t, index, token)
+		}
+	})
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  []Segment
+	}{
+		{
+			name:  "正常系: 英単語は単語単位で一致",
+			text:  "Running to run",
+			query: "RUN",
+			want:  []Segment{{Text: "Running to "}, {Text: "run", Matched: true}},
+		},
+		{
+			name:  "正常系: 日本語の連続した一致はまとめる",
+			text:  "明日は東京タワーへ",
+			query: "東京タワー",
+			want:  []Segment{{Text: "明日は"}, {Text: "東京タワー", Matched: true}, {Text: "へ"}},
+		},
+		{
+			name:  "正常系: 全角で書かれた単語",
+			text:  "ＧｏＬａｎｇ入門",
+			query: "golang",
+			want:  []Segment{{Text: "ＧｏＬａｎｇ", Matched: true}, {Text: "入門"}},
+		},
+		{
+			name:  "正常系: 一致なし",
+			text:  "hello",
+			query: "world",
+			want:  []Segment{{Text: "hello"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Highlight(tt.text, tt.query))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"slices"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// maxTokenLength bounds the length of a token in runes, so that a long word
+// fits in the index. Index and query tokens are truncated alike.
+const maxTokenLength = 64
+
+// Tokenize returns the distinct terms under which text is indexed.
+//
+// Text is folded first: letters are lowercased and full-width ASCII and
+// half-width katakana are mapped to their usual width. Words of letters and
+// digits, as in English, become one token each. Japanese is written without
+// spaces, so runs of kanji, hiragana and katakana are split into overlapping
+// pairs of characters (bigrams) instead, plus single characters so that a
+// one-character query matches as well.
+func Tokenize(text string) []string {
+	var tokens []string
+	for _, run := range runs(text) {
+		if !run.cjk {
+			tokens = append(tokens, truncate(run.text))
+			continue
+		}
+		for i := range run.text {
+			tokens = append(tokens, string(run.text[i]))
+			if i+1 < len(run.text) {
+				tokens = append(tokens, string(run.text[i:i+2]))
+			}
+		}
+	}
+	return distinct(tokens)
+}
+
+// QueryTokens returns the distinct terms a document must contain to match
+// query. Japanese runs of two or more characters are looked up by their
+// bigrams, so that a match does not depend on how the text would be split
+// into words.
+func QueryTokens(query string) []string {
+	var tokens []string
+	for _, run := range runs(query) {
+		if !run.cjk || len(run.text) == 1 {
+			tokens = append(tokens, truncate(run.text))
+			continue
+		}
+		for i := 0; i+1 < len(run.text); i++ {
+			tokens = append(tokens, string(run.text[i:i+2]))
+		}
+	}
+	return distinct(tokens)
+}
+
+// run is a maximal sequence of folded word or Japanese characters. start is
+// the index of its first rune in the text.
+type run struct {
+	text  []rune
+	start int
+	cjk   bool
+}
+
+func runs(text string) []run {
+	var result []run
+	var current *run
+	for i, r := range []rune(text) {
+		r = fold(r)
+		cjk := isCJK(r)
+		if !cjk && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			current = nil
+			continue
+		}
+		if current == nil || current.cjk != cjk {
+			result = append(result, run{start: i, cjk: cjk})
+			current = &result[len(result)-1]
+		}
+		current.text = append(current.text, r)
+	}
+	return result
+}
+
+// fold maps r to the form it is indexed under.
+func fold(r rune) rune {
+	if folded := width.LookupRune(r).Folded(); folded != 0 {
+		r = folded
+	}
+	return unicode.ToLower(r)
+}
+
+func isCJK(r rune) bool {
+	// The prolonged sound mark is shared by hiragana and katakana and belongs
+	// to neither script.
+	return r == 'ー' || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+func truncate(text []rune) string {
+	if len(text) > maxTokenLength {
+		text = text[:maxTokenLength]
+	}
+	return string(text)
+}
+
+func distinct(tokens []string) []string {
+	slices.Sort(tokens)
+	return slices.Compact(tokens)
+}
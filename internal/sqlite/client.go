@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// pragmas are applied to every connection. WAL lets readers proceed while a
+// write is in progress, and busy_timeout makes writers wait for the lock
+// instead of failing immediately.
+var pragmas = []string{
+	"busy_timeout(5000)",
+	"journal_mode(WAL)",
+	"foreign_keys(1)",
+}
+
+// NewClient opens the SQLite database at path, creating the file if needed.
+// The driver is pure Go, so CGO_ENABLED=0 builds keep working.
+func NewClient(ctx context.Context, path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("SQLITE_PATH is not set")
+	}
+
+	query := url.Values{}
+	for _, p := range pragmas {
+		query.Add("_pragma", p)
+	}
+	// Write transactions take the write lock when they begin, so a
+	// read-then-write transaction waits on busy_timeout rather than failing
+	// with SQLITE_BUSY when it upgrades its lock.
+	query.Set("_txlock", "immediate")
+	dsn := "file:" + path + "?" + query.Encode()
+
+	log.Printf("Opening SQLite database: %s", path)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	log.Println("Successfully opened SQLite database")
+	return db, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/migrate"
+	"github.com/kuchida1981/graphql-sampleapp/internal/postgres"
+	"github.com/kuchida1981/graphql-sampleapp/internal/sqlite"
+)
+
+const migrateUsage = `usage: graphql-server migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [n]    revert the last n applied migrations (default 1)
+  status      list migrations and when they were applied`
+
+// runMigrate implements the "migrate" subcommand.
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), migrateUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing migrate command")
+	}
+
+	var db *sql.DB
+	var err error
+	newMigrator := newPostgresMigrator
+	if cfg.SQLBackend == config.BackendSQLite {
+		newMigrator = newSQLiteMigrator
+		db, err = sqlite.NewClient(ctx, cfg.SQLitePath)
+		if err != nil {
+			return fmt.Errorf("failed to open SQLite database: %w", err)
+		}
+	} else {
+		db, err = postgres.NewClient(ctx, cfg.DatabaseURL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+		}
+	}
+	defer db.Close()
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Migrate: applied %d migration(s)", len(applied))
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			steps, err = strconv.Atoi(fs.Arg(1))
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %s", fs.Arg(1))
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("Migrate: reverted %d migration(s)", len(reverted))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied at " + s.AppliedAt.Format("2006-01-02T15:04:05Z07:00")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate command: %s", fs.Arg(0))
+	}
+
+	return nil
+}
+
+func newPostgresMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	migrations, err := migrate.PostgresMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return migrate.New(db, migrate.Postgres, migrations), nil
+}
+
+func newSQLiteMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	migrations, err := migrate.SQLiteMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return migrate.New(db, migrate.SQLite, migrations), nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/attachment"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/metrics"
+	"github.com/kuchida1981/graphql-sampleapp/internal/retention"
+)
+
+const purgeUsage = `usage: graphql-server purge [-dry-run]
+
+Deletes the messages older than RETENTION_DEFAULT or RETENTION_CHANNELS.
+
+flags:
+  -dry-run    count the expired messages without deleting them`
+
+// runPurge implements the "purge" subcommand, which runs the retention purge
+// once.
+func runPurge(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), purgeUsage) }
+	dryRun := fs.Bool("dry-run", cfg.Retention.DryRun, "count the expired messages without deleting them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	appMetrics := metrics.New()
+	repos, err := newRepositories(ctx, cfg, appMetrics)
+	if err != nil {
+		return err
+	}
+	defer repos.close()
+
+	blobs, closeBlobs, err := newBlobStore(ctx, cfg.Attachments, appMetrics)
+	if err != nil {
+		return err
+	}
+	defer closeBlobs()
+
+	retentionCfg := cfg.Retention
+	retentionCfg.DryRun = *dryRun
+	purger := retention.NewPurger(repos.messages, repos.channels, repos.search, repos.tx, attachment.NewStore(blobs, cfg.Attachments.MaxSize), retentionCfg, nil)
+	if !purger.Enabled() {
+		log.Println("Purge: no retention configured, nothing to do")
+		return nil
+	}
+
+	n, err := purger.Run(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed after %d message(s): %w", n, err)
+	}
+	if *dryRun {
+		log.Printf("Purge: found %d expired message(s), none deleted", n)
+	} else {
+		log.Printf("Purge: deleted %d message(s)", n)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/metrics"
+	"github.com/kuchida1981/graphql-sampleapp/internal/search"
+)
+
+// runReindex implements the "reindex" subcommand, which rebuilds the message
+// search index from the stored messages.
+func runReindex(ctx context.Context, cfg *config.Config) error {
+	repos, err := newRepositories(ctx, cfg, metrics.New())
+	if err != nil {
+		return err
+	}
+	defer repos.close()
+
+	n, err := search.Reindex(ctx, repos.messages, repos.search, repos.tx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Reindex: indexed %d message(s)", n)
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	firestoreClient "github.com/kuchida1981/graphql-sampleapp/internal/firestore"
+	"github.com/kuchida1981/graphql-sampleapp/internal/metrics"
+	"github.com/kuchida1981/graphql-sampleapp/internal/migrate"
+	"github.com/kuchida1981/graphql-sampleapp/internal/postgres"
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository"
+	firestoreRepo "github.com/kuchida1981/graphql-sampleapp/internal/repository/firestore"
+	memoryRepo "github.com/kuchida1981/graphql-sampleapp/internal/repository/memory"
+	postgresRepo "github.com/kuchida1981/graphql-sampleapp/internal/repository/postgres"
+	sqliteRepo "github.com/kuchida1981/graphql-sampleapp/internal/repository/sqlite"
+	"github.com/kuchida1981/graphql-sampleapp/internal/search"
+	"github.com/kuchida1981/graphql-sampleapp/internal/sqlite"
+)
+
+// repositories holds the instrumented repositories for the selected storage
+// backend. Channels and the message search index are stored next to the
+// users they reference. tx runs transactions on the SQL backend, which the
+// Firestore repositories do not take part in. close releases the underlying
+// clients.
+type repositories struct {
+	messages             repository.MessageRepository
+	channels             repository.ChannelRepository
+	search               repository.SearchRepository
+	users                repository.UserRepository
+	weatherAlerts        repository.WeatherAlertRepository
+	weatherAlertMetadata repository.WeatherAlertMetadataRepository
+	tx                   repository.Transactor
+	close                func()
+}
+
+func newRepositories(ctx context.Context, cfg *config.Config, appMetrics *metrics.Metrics) (*repositories, error) {
+	if cfg.StorageBackend == config.BackendMemory {
+		return newMemoryRepositories(ctx, cfg, appMetrics)
+	}
+	return newExternalRepositories(ctx, cfg, appMetrics)
+}
+
+// loadMemoryFixtures returns the seed data for in-memory repositories, which
+// is empty unless MEMORY_SEED_FILE is set.
+func loadMemoryFixtures(cfg *config.Config) (*memoryRepo.Fixtures, error) {
+	if cfg.MemorySeedFile == "" {
+		return &memoryRepo.Fixtures{}, nil
+	}
+	fixtures, err := memoryRepo.LoadFixtures(cfg.MemorySeedFile)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Loaded fixtures from %s", cfg.MemorySeedFile)
+	return fixtures, nil
+}
+
+func newMemoryRepositories(ctx context.Context, cfg *config.Config, appMetrics *metrics.Metrics) (*repositories, error) {
+	fixtures, err := loadMemoryFixtures(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log.Println("Using in-memory storage; data is lost on restart")
+
+	users := memoryRepo.NewMemoryUserRepository(fixtures.Users)
+	messages := memoryRepo.NewMemoryMessageRepository(fixtures.Messages)
+	channels := memoryRepo.NewMemoryChannelRepository(users)
+	search := memoryRepo.NewMemorySearchRepository()
+	metadata := memoryRepo.NewMemoryWeatherAlertMetadataRepository(fixtures.WeatherAlertMetadata)
+	repos := &repositories{
+		messages: metrics.InstrumentMessageRepository(appMetrics, "memory", messages),
+		channels: metrics.InstrumentChannelRepository(appMetrics, "memory", channels),
+		search:   metrics.InstrumentSearchRepository(appMetrics, "memory", search),
+		users:    metrics.InstrumentUserRepository(appMetrics, "memory", users),
+		weatherAlerts: metrics.InstrumentWeatherAlertRepository(appMetrics, "memory",
+			memoryRepo.NewMemoryWeatherAlertRepository(fixtures.WeatherAlerts)),
+		weatherAlertMetadata: metrics.InstrumentWeatherAlertMetadataRepository(appMetrics, "memory", metadata),
+		// Weather alerts stand in for Firestore, which does not join
+		// transactions, so only the other repositories are rolled back.
+		tx:    memoryRepo.NewMemoryTxManager(users, messages, channels, search, metadata),
+		close: func() {},
+	}
+	if err := indexFixtures(ctx, repos); err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+// indexFixtures builds the search index for messages loaded from fixtures,
+// which are not written through the resolvers that keep it up to date.
+func indexFixtures(ctx context.Context, repos *repositories) error {
+	n, err := search.Reindex(ctx, repos.messages, repos.search, repos.tx)
+	if err != nil {
+		return fmt.Errorf("failed to index messages: %w", err)
+	}
+	if n > 0 {
+		log.Printf("Indexed %d message(s) for search", n)
+	}
+	return nil
+}
+
+func newExternalRepositories(ctx context.Context, cfg *config.Config, appMetrics *metrics.Metrics) (repos *repositories, err error) {
+	var closers []func()
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+	defer func() {
+		if err != nil {
+			closeAll()
+		}
+	}()
+
+	firestoreConn, err := firestoreClient.NewClient(ctx, cfg.ProjectID, cfg.Firestore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Firestore client: %w", err)
+	}
+	closers = append(closers, func() { firestoreConn.Close() })
+
+	firestoreOpts := []firestoreRepo.Option{
+		firestoreRepo.WithCollectionPrefix(cfg.Firestore.CollectionPrefix),
+		firestoreRepo.WithRetry(cfg.Firestore.Retry),
+	}
+
+	repos = &repositories{
+		weatherAlerts: metrics.InstrumentWeatherAlertRepository(appMetrics, "firestore",
+			firestoreRepo.NewFirestoreWeatherAlertRepository(firestoreConn, firestoreOpts...)),
+		close: closeAll,
+	}
+
+	var pgPool *pgxpool.Pool
+	switch cfg.SQLBackend {
+	case config.BackendSQLite:
+		db, err := sqlite.NewClient(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+		}
+		closers = append(closers, func() { db.Close() })
+
+		if cfg.MigrateOnStart {
+			if err := migrateUp(ctx, newSQLiteMigrator, db); err != nil {
+				return nil, err
+			}
+		}
+
+		repos.users = metrics.InstrumentUserRepository(appMetrics, "sqlite",
+			sqliteRepo.NewSQLiteUserRepository(db))
+		repos.channels = metrics.InstrumentChannelRepository(appMetrics, "sqlite",
+			sqliteRepo.NewSQLiteChannelRepository(db))
+		repos.search = metrics.InstrumentSearchRepository(appMetrics, "sqlite",
+			sqliteRepo.NewSQLiteSearchRepository(db))
+		repos.weatherAlertMetadata = metrics.InstrumentWeatherAlertMetadataRepository(appMetrics, "sqlite",
+			sqliteRepo.NewSQLiteWeatherAlertMetadataRepository(db))
+		repos.tx = sqliteRepo.NewSQLiteTxManager(db)
+	default:
+		pgPool, err = postgres.NewPool(ctx, cfg.DatabaseURL, cfg.Postgres)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize PostgreSQL pool: %w", err)
+		}
+		closers = append(closers, pgPool.Close)
+
+		if cfg.MigrateOnStart {
+			migrationDB := stdlib.OpenDBFromPool(pgPool)
+			defer migrationDB.Close()
+			if err := migrateUp(ctx, newPostgresMigrator, migrationDB); err != nil {
+				return nil, err
+			}
+		}
+
+		appMetrics.RegisterPoolStats(pgPool, "postgres")
+
+		repos.users = metrics.InstrumentUserRepository(appMetrics, "postgres",
+			postgresRepo.NewPostgresUserRepository(pgPool))
+		repos.channels = metrics.InstrumentChannelRepository(appMetrics, "postgres",
+			postgresRepo.NewPostgresChannelRepository(pgPool))
+		repos.search = metrics.InstrumentSearchRepository(appMetrics, "postgres",
+			postgresRepo.NewPostgresSearchRepository(pgPool))
+		repos.weatherAlertMetadata = metrics.InstrumentWeatherAlertMetadataRepository(appMetrics, "postgres",
+			postgresRepo.NewPostgresWeatherAlertMetadataRepository(pgPool))
+		repos.tx = postgresRepo.NewPostgresTxManager(pgPool, cfg.Tx)
+	}
+
+	switch cfg.MessageBackend {
+	case config.BackendPostgres:
+		// config.Load guarantees SQL_BACKEND=postgres in this case.
+		repos.messages = metrics.InstrumentMessageRepository(appMetrics, "postgres",
+			postgresRepo.NewPostgresMessageRepository(pgPool))
+	case config.BackendMemory:
+		fixtures, err := loadMemoryFixtures(cfg)
+		if err != nil {
+			return nil, err
+		}
+		repos.messages = metrics.InstrumentMessageRepository(appMetrics, "memory",
+			memoryRepo.NewMemoryMessageRepository(fixtures.Messages))
+		if err := indexFixtures(ctx, repos); err != nil {
+			return nil, err
+		}
+	default:
+		repos.messages = metrics.InstrumentMessageRepository(appMetrics, "firestore",
+			firestoreRepo.NewFirestoreMessageRepository(firestoreConn, firestoreOpts...))
+	}
+	log.Printf("Storing users, channels and weather alert metadata in %s, messages in %s", cfg.SQLBackend, cfg.MessageBackend)
+
+	return repos, nil
+}
+
+func migrateUp(ctx context.Context, newMigrator func(*sql.DB) (*migrate.Migrator, error), db *sql.DB) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize migrations: %w", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
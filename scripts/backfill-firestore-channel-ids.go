@@ -0,0 +1,76 @@
+//go:build ignore
+
+// Command backfill-firestore-channel-ids writes an empty channelId to the
+// top-level messages stored before channels existed. The messages query
+// selects messages outside channels by channelId, so it cannot find the
+// messages that lack the field.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	firestoreClient "github.com/kuchida1981/graphql-sampleapp/internal/firestore"
+	"google.golang.org/api/iterator"
+)
+
+func main() {
+	ctx := context.Background()
+
+	projectID := os.Getenv("GCP_PROJECT_ID")
+	if projectID == "" {
+		projectID = "demo-project"
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	client, err := firestoreClient.NewClient(ctx, projectID, cfg.Firestore)
+	if err != nil {
+		log.Fatalf("Failed to initialize Firestore client: %v", err)
+	}
+	defer client.Close()
+
+	// Only the channelId field is read, so documents lacking it come back
+	// empty.
+	iter := client.Collection(cfg.Firestore.CollectionPrefix + "messages").Select("channelId").Documents(ctx)
+	defer iter.Stop()
+
+	writer := client.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			log.Fatalf("Failed to read messages: %v", err)
+		}
+		if _, ok := doc.Data()["channelId"]; ok {
+			continue
+		}
+		job, err := writer.Update(doc.Ref, []firestore.Update{{Path: "channelId", Value: ""}})
+		if err != nil {
+			log.Fatalf("Failed to queue message %s: %v", doc.Ref.ID, err)
+		}
+		jobs = append(jobs, job)
+	}
+	writer.End()
+
+	failed := 0
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			log.Printf("Failed to backfill a message: %v", err)
+			failed++
+		}
+	}
+	log.Printf("Backfilled channelId on %d of %d message(s)", len(jobs)-failed, len(jobs))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -8,13 +8,17 @@ import (
 	"os"
 	"time"
 
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
 	firestoreClient "github.com/kuchida1981/graphql-sampleapp/internal/firestore"
 )
 
 type Message struct {
-	ID        string    `firestore:"id"`
-	Content   string    `firestore:"content"`
-	Author    string    `firestore:"author"`
+	ID      string `firestore:"id"`
+	Content string `firestore:"content"`
+	Author  string `firestore:"author"`
+	// ChannelID is always written, empty outside channels, so that the
+	// messages query selects the message.
+	ChannelID string    `firestore:"channelId"`
 	CreatedAt time.Time `firestore:"createdAt"`
 }
 
@@ -26,7 +30,12 @@ func main() {
 		projectID = "demo-project"
 	}
 
-	client, err := firestoreClient.NewClient(ctx, projectID)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	client, err := firestoreClient.NewClient(ctx, projectID, cfg.Firestore)
 	if err != nil {
 		log.Fatalf("Failed to initialize Firestore client: %v", err)
 	}
@@ -54,7 +63,7 @@ func main() {
 	}
 
 	for _, msg := range messages {
-		_, err := client.Collection("messages").Doc(msg.ID).Set(ctx, msg)
+		_, err := client.Collection(cfg.Firestore.CollectionPrefix+"messages").Doc(msg.ID).Set(ctx, msg)
 		if err != nil {
 			log.Fatalf("Failed to create message %s: %v", msg.ID, err)
 		}
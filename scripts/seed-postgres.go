@@ -29,32 +29,65 @@ func main() {
 	users := []struct {
 		id        string
 		name      string
+		handle    string
 		email     string
+		roles     string
 		createdAt time.Time
 	}{
-		{"user1", "Alice Smith", "alice@example.com", time.Now().Add(-48 * time.Hour)},
-		{"user2", "Bob Johnson", "bob@example.com", time.Now().Add(-24 * time.Hour)},
-		{"user3", "Charlie Brown", "charlie@example.com", time.Now().Add(-12 * time.Hour)},
-		{"user4", "Diana Prince", "diana@example.com", time.Now().Add(-6 * time.Hour)},
-		{"user5", "Eve Adams", "eve@example.com", time.Now()},
+		{"user1", "Alice Smith", "alice", "alice@example.com", "{user,admin}", time.Now().Add(-48 * time.Hour)},
+		{"user2", "Bob Johnson", "bob", "bob@example.com", "{user}", time.Now().Add(-24 * time.Hour)},
+		{"user3", "Charlie Brown", "charlie", "charlie@example.com", "{user}", time.Now().Add(-12 * time.Hour)},
+		{"user4", "Diana Prince", "diana", "diana@example.com", "{user}", time.Now().Add(-6 * time.Hour)},
+		{"user5", "Eve Adams", "eve", "eve@example.com", "{user}", time.Now()},
 	}
 
 	query := `
-		INSERT INTO users (id, name, email, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (id, name, handle, email, roles, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (id) DO UPDATE
 		SET name = EXCLUDED.name,
+		    handle = EXCLUDED.handle,
 		    email = EXCLUDED.email,
+		    roles = EXCLUDED.roles,
 		    created_at = EXCLUDED.created_at
 	`
 
 	for _, user := range users {
-		_, err := db.ExecContext(ctx, query, user.id, user.name, user.email, user.createdAt)
+		_, err := db.ExecContext(ctx, query, user.id, user.name, user.handle, user.email, user.roles, user.createdAt)
 		if err != nil {
 			log.Fatalf("Failed to insert user %s: %v", user.id, err)
 		}
 		log.Printf("Seeded user: %s (%s)", user.name, user.email)
 	}
 
-	log.Println("Successfully seeded PostgreSQL database with sample users")
+	// Messages are only read from PostgreSQL when MESSAGE_BACKEND=postgres.
+	messages := []struct {
+		id        string
+		content   string
+		author    string
+		createdAt time.Time
+	}{
+		{"msg1", "Hello, PostgreSQL! This is the first message.", "Alice", time.Now().Add(-2 * time.Hour)},
+		{"msg2", "GraphQL and PostgreSQL integration is working!", "Bob", time.Now().Add(-1 * time.Hour)},
+		{"msg3", "Docker Compose makes local development easy.", "Charlie", time.Now()},
+	}
+
+	messageQuery := `
+		INSERT INTO messages (id, content, author, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE
+		SET content = EXCLUDED.content,
+		    author = EXCLUDED.author,
+		    created_at = EXCLUDED.created_at
+	`
+
+	for _, msg := range messages {
+		_, err := db.ExecContext(ctx, messageQuery, msg.id, msg.content, msg.author, msg.createdAt)
+		if err != nil {
+			log.Fatalf("Failed to insert message %s: %v", msg.id, err)
+		}
+		log.Printf("Seeded message: %s", msg.id)
+	}
+
+	log.Println("Successfully seeded PostgreSQL database with sample users and messages")
 }
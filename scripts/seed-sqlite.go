@@ -0,0 +1,81 @@
+//go:build ignore
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/kuchida1981/graphql-sampleapp/internal/repository/memory"
+	"github.com/kuchida1981/graphql-sampleapp/internal/sqlite"
+)
+
+// Seeds users and weather alert metadata from fixtures/dev.json into the
+// SQLite database. Run "go run . migrate up" with SQL_BACKEND=sqlite first.
+func main() {
+	ctx := context.Background()
+
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = "graphql-sampleapp.db"
+	}
+
+	fixturesPath := os.Getenv("MEMORY_SEED_FILE")
+	if fixturesPath == "" {
+		fixturesPath = "fixtures/dev.json"
+	}
+
+	fixtures, err := memory.LoadFixtures(fixturesPath)
+	if err != nil {
+		log.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	db, err := sqlite.NewClient(ctx, path)
+	if err != nil {
+		log.Fatalf("Failed to open SQLite database: %v", err)
+	}
+	defer db.Close()
+
+	userQuery := `
+		INSERT INTO users (id, name, handle, email, roles, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE
+		SET name = excluded.name,
+		    handle = excluded.handle,
+		    email = excluded.email,
+		    roles = excluded.roles,
+		    created_at = excluded.created_at
+	`
+
+	for _, user := range fixtures.Users {
+		roles, err := json.Marshal(user.Roles)
+		if err != nil {
+			log.Fatalf("Failed to encode roles of user %s: %v", user.ID, err)
+		}
+		if _, err := db.ExecContext(ctx, userQuery, user.ID, user.Name, user.Handle, user.Email, string(roles), user.CreatedAt.UTC()); err != nil {
+			log.Fatalf("Failed to insert user %s: %v", user.ID, err)
+		}
+		log.Printf("Seeded user: %s (%s)", user.Name, user.Email)
+	}
+
+	metadataQuery := `
+		INSERT INTO weather_alert_metadata (id, region, severity, issued_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE
+		SET region = excluded.region,
+		    severity = excluded.severity,
+		    issued_at = excluded.issued_at,
+		    created_at = excluded.created_at
+	`
+
+	for _, m := range fixtures.WeatherAlertMetadata {
+		if _, err := db.ExecContext(ctx, metadataQuery, m.ID, m.Region, m.Severity, m.IssuedAt.UTC(), m.CreatedAt.UTC()); err != nil {
+			log.Fatalf("Failed to insert metadata %s: %v", m.ID, err)
+		}
+		log.Printf("Seeded metadata: %s", m.ID)
+	}
+
+	log.Println("Successfully seeded SQLite database")
+}
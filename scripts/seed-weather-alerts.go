@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
 	"github.com/kuchida1981/graphql-sampleapp/internal/firestore"
 	"github.com/kuchida1981/graphql-sampleapp/internal/postgres"
 )
@@ -32,7 +33,12 @@ func main() {
 		projectID = "demo-project"
 	}
 
-	firestoreClient, err := firestore.NewClient(ctx, projectID)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	firestoreClient, err := firestore.NewClient(ctx, projectID, cfg.Firestore)
 	if err != nil {
 		log.Fatalf("Failed to connect to Firestore: %v", err)
 	}
@@ -217,7 +223,7 @@ func main() {
 	}
 
 	for _, alert := range firestoreAlerts {
-		_, err := firestoreClient.Collection("weatherAlerts").Doc(alert.id).Set(ctx, map[string]interface{}{
+		_, err := firestoreClient.Collection(cfg.Firestore.CollectionPrefix+"weatherAlerts").Doc(alert.id).Set(ctx, map[string]interface{}{
 			"id":              alert.id,
 			"title":           alert.title,
 			"description":     alert.description,
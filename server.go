@@ -12,64 +12,126 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/kuchida1981/graphql-sampleapp/graph"
-	firestoreClient "github.com/kuchida1981/graphql-sampleapp/internal/firestore"
-	"github.com/kuchida1981/graphql-sampleapp/internal/postgres"
-	firestoreRepo "github.com/kuchida1981/graphql-sampleapp/internal/repository/firestore"
-	postgresRepo "github.com/kuchida1981/graphql-sampleapp/internal/repository/postgres"
+	"github.com/kuchida1981/graphql-sampleapp/internal/attachment"
+	"github.com/kuchida1981/graphql-sampleapp/internal/auth"
+	"github.com/kuchida1981/graphql-sampleapp/internal/config"
+	"github.com/kuchida1981/graphql-sampleapp/internal/metrics"
+	"github.com/kuchida1981/graphql-sampleapp/internal/moderation"
+	"github.com/kuchida1981/graphql-sampleapp/internal/querylimit"
+	"github.com/kuchida1981/graphql-sampleapp/internal/ratelimit"
+	"github.com/kuchida1981/graphql-sampleapp/internal/retention"
 	"github.com/vektah/gqlparser/v2/ast"
 )
 
-const defaultPort = "8080"
-
 func main() {
 	ctx := context.Background()
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	projectID := os.Getenv("GCP_PROJECT_ID")
-	if projectID == "" {
-		projectID = "demo-project"
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(ctx, cfg, os.Args[2:]); err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+		case "purge":
+			if err := runPurge(ctx, cfg, os.Args[2:]); err != nil {
+				log.Fatalf("Purge failed: %v", err)
+			}
+		case "reindex":
+			if err := runReindex(ctx, cfg); err != nil {
+				log.Fatalf("Reindex failed: %v", err)
+			}
+		default:
+			log.Fatalf("Unknown command: %s", os.Args[1])
+		}
+		return
 	}
 
-	firestoreConn, err := firestoreClient.NewClient(ctx, projectID)
+	appMetrics := metrics.New()
+
+	repos, err := newRepositories(ctx, cfg, appMetrics)
 	if err != nil {
-		log.Fatalf("Failed to initialize Firestore client: %v", err)
+		log.Fatalf("Failed to initialize repositories: %v", err)
 	}
-	defer firestoreConn.Close()
+	defer repos.close()
 
-	databaseURL := os.Getenv("DATABASE_URL")
-	pgConn, err := postgres.NewClient(ctx, databaseURL)
+	blobs, closeBlobs, err := newBlobStore(ctx, cfg.Attachments, appMetrics)
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+	defer closeBlobs()
+	key, err := signingKey(cfg.Attachments)
 	if err != nil {
-		log.Fatalf("Failed to initialize PostgreSQL client: %v", err)
+		log.Fatalf("Failed to initialize attachment URLs: %v", err)
 	}
-	defer pgConn.Close()
+	attachmentURLs := attachment.NewSigner(key, cfg.Attachments.URLTTL)
+
+	attachments := attachment.NewStore(blobs, cfg.Attachments.MaxSize)
 
-	messageRepo := firestoreRepo.NewFirestoreMessageRepository(firestoreConn)
-	userRepo := postgresRepo.NewPostgresUserRepository(pgConn)
-	weatherAlertMetadataRepo := postgresRepo.NewPostgresWeatherAlertMetadataRepository(pgConn)
-	weatherAlertRepo := firestoreRepo.NewFirestoreWeatherAlertRepository(firestoreConn)
+	resolver := graph.NewResolver(repos.messages, repos.channels, repos.search, repos.users, repos.weatherAlertMetadata, repos.weatherAlerts, repos.tx,
+		attachments, attachmentURLs, moderation.FromConfig(cfg.Moderation))
+
+	purger := retention.NewPurger(repos.messages, repos.channels, repos.search, repos.tx, attachments, cfg.Retention, appMetrics)
+	if purger.Enabled() && cfg.Retention.Interval > 0 {
+		go purger.Schedule(ctx, cfg.Retention.Interval)
+	}
 
-	resolver := graph.NewResolver(messageRepo, userRepo, weatherAlertMetadataRepo, weatherAlertRepo)
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers:  resolver,
+		Directives: graph.NewDirectiveRoot(),
+		Complexity: graph.NewComplexityRoot(),
+	}))
 
-	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	srv.SetErrorPresenter(graph.ErrorPresenter)
 
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{
+		// Leave room for the operations and map fields besides the files.
+		MaxUploadSize: cfg.Attachments.MaxSize*graph.MaxAttachments + 1<<20,
+	})
 
-	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.SetQueryCache(metrics.InstrumentCache(appMetrics, metrics.CacheQuery, lru.New[*ast.QueryDocument](1000)))
 
 	srv.Use(extension.Introspection{})
 	srv.Use(extension.AutomaticPersistedQuery{
-		Cache: lru.New[string](100),
+		Cache: metrics.InstrumentCache(appMetrics, metrics.CacheAPQ, lru.New[string](100)),
 	})
+	srv.Use(appMetrics.Extension(cfg.Metrics.Operations))
+	if cfg.QueryLimits.MaxDepth > 0 {
+		srv.Use(querylimit.DepthLimit{Max: cfg.QueryLimits.MaxDepth})
+	}
+	if cfg.QueryLimits.MaxComplexity > 0 {
+		srv.Use(extension.FixedComplexityLimit(cfg.QueryLimits.MaxComplexity))
+	}
+	if cfg.RateLimit.Enabled {
+		srv.Use(ratelimit.Extension{Limiter: ratelimit.NewLimiter(cfg.RateLimit)})
+	}
+
+	var queryHandler http.Handler = srv
+	if cfg.RateLimit.Enabled {
+		queryHandler = ratelimit.Middleware(cfg.RateLimit)(queryHandler)
+	}
+	if cfg.Auth.Enabled() {
+		verifier, err := auth.NewVerifier(ctx, cfg.Auth)
+		if err != nil {
+			log.Fatalf("Failed to initialize JWT verifier: %v", err)
+		}
+		queryHandler = auth.Middleware(verifier, repos.users)(queryHandler)
+	} else {
+		log.Println("Auth: no JWKS configured, all requests are anonymous")
+	}
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	http.Handle("/query", srv)
+	http.Handle("/query", queryHandler)
+	http.Handle("/metrics", appMetrics.Handler())
+	http.Handle(attachment.PathPrefix, attachment.NewHandler(attachmentURLs, repos.messages, blobs))
 
-	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	log.Printf("connect to http://localhost:%s/ for GraphQL playground", cfg.Port)
+	log.Fatal(http.ListenAndServe(":"+cfg.Port, nil))
 }